@@ -0,0 +1,37 @@
+// Command backfill-search creates the full-text search index
+// SearchRanked depends on and backfills it for rows written before the
+// index existed. It's idempotent, so it's safe to run again after a
+// fresh MigrateSearchIndex call at server startup.
+package main
+
+import (
+	"log"
+
+	"github.com/example/calorie-tracker/internal/database"
+	"github.com/example/calorie-tracker/internal/metrics"
+	"github.com/example/calorie-tracker/internal/repository"
+)
+
+func main() {
+	cfg := database.DefaultConfig()
+	if err := database.Connect(cfg); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	if err := database.Migrate(); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	foodRepo := repository.NewFoodRepository(metrics.NewNoopRecorder(), nil)
+
+	if err := foodRepo.MigrateSearchIndex(); err != nil {
+		log.Fatalf("Failed to migrate search index: %v", err)
+	}
+
+	if err := foodRepo.BackfillSearchIndex(); err != nil {
+		log.Fatalf("Failed to backfill search index: %v", err)
+	}
+
+	log.Println("Search index backfill complete")
+}
@@ -10,11 +10,16 @@ import (
 	"time"
 
 	"github.com/example/calorie-tracker/internal/database"
+	"github.com/example/calorie-tracker/internal/eventqueue"
 	"github.com/example/calorie-tracker/internal/handlers"
+	"github.com/example/calorie-tracker/internal/metrics"
 	"github.com/example/calorie-tracker/internal/middleware"
+	"github.com/example/calorie-tracker/internal/notifications"
 	"github.com/example/calorie-tracker/internal/repository"
 	"github.com/example/calorie-tracker/internal/services"
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
@@ -30,21 +35,60 @@ func main() {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
+	// Initialize metrics
+	recorder, err := metrics.FromEnv()
+	if err != nil {
+		log.Printf("Failed to initialize metrics backend, falling back to noop: %v", err)
+		recorder = metrics.NewNoopRecorder()
+	}
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository()
-	foodRepo := repository.NewFoodRepository()
+	foodRepo := repository.NewFoodRepository(recorder, barcodeProviderFromEnv())
+	prefRepo := repository.NewNotificationPreferenceRepository()
+
+	// Create the full-text search index SearchRanked depends on. This is
+	// separate from database.Migrate because AutoMigrate can't express a
+	// generated tsvector column or an FTS5 virtual table; cmd/backfill-search
+	// handles populating it for rows that predate this index.
+	if err := foodRepo.MigrateSearchIndex(); err != nil {
+		log.Fatalf("Failed to migrate search index: %v", err)
+	}
 
 	// Initialize services
 	authService := services.NewAuthService(userRepo)
-	trackingService := services.NewTrackingService()
+	prefService := notifications.NewPreferenceService(prefRepo)
+	trackingService := services.NewTrackingService(notifications.NewGatedDispatcher(notificationDispatcherFromEnv(), prefService), recorder, foodRepo)
+
+	// Replace the in-memory EventQueue NewTrackingService starts by
+	// default with one backed by eventQueueStoreFromEnv, so daily-log
+	// recalculation jobs survive a restart when EVENTQUEUE_REDIS_ADDR is
+	// configured.
+	eventStore, err := eventQueueStoreFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to initialize event queue store: %v", err)
+	}
+	eventQueue := eventqueue.NewEventQueue(eventStore, trackingService.HandleJob, eventqueue.Config{})
+	if err := eventQueue.Start(context.Background()); err != nil {
+		log.Fatalf("Failed to start event queue: %v", err)
+	}
+	defer eventQueue.Stop()
+	trackingService.UseEventQueue(eventQueue)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
 	foodHandler := handlers.NewFoodHandler(foodRepo)
 	trackingHandler := handlers.NewTrackingHandler(trackingService)
+	notificationHandler := handlers.NewNotificationHandler(prefService)
 
 	// Setup router
-	router := setupRouter(authService, authHandler, foodHandler, trackingHandler)
+	router := setupRouter(authService, authHandler, foodHandler, trackingHandler, notificationHandler)
+
+	// Prometheus scrapes /metrics directly; StatsD pushes out-of-band, so
+	// there's nothing to serve in that case.
+	if promRecorder, ok := recorder.(*metrics.PrometheusRecorder); ok {
+		router.GET("/metrics", gin.WrapH(promRecorder.Handler()))
+	}
 
 	// Start server with graceful shutdown
 	port := os.Getenv("PORT")
@@ -86,11 +130,58 @@ func main() {
 	log.Println("Server exited")
 }
 
+// notificationDispatcherFromEnv builds a notifications.GRPCDispatcher
+// connected to NOTIFICATION_SERVICE_ADDR, or a notifications.NoopDispatcher
+// if that env var isn't set, so deployments without a notification-service
+// still start up.
+func notificationDispatcherFromEnv() notifications.NotificationDispatcher {
+	addr := os.Getenv("NOTIFICATION_SERVICE_ADDR")
+	if addr == "" {
+		return notifications.NewNoopDispatcher()
+	}
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("Failed to connect to notification-service at %s, notifications disabled: %v", addr, err)
+		return notifications.NewNoopDispatcher()
+	}
+
+	return notifications.NewGRPCDispatcher(notifications.NewGRPCClient(conn))
+}
+
+// barcodeProviderFromEnv builds a repository.OFFProvider configured from
+// OFF_API_BASE_URL and OFF_API_KEY, falling back to repository.DefaultOFFConfig
+// defaults (the public Open Food Facts API, no auth) when they're unset.
+func barcodeProviderFromEnv() repository.BarcodeProvider {
+	cfg := repository.DefaultOFFConfig()
+	if baseURL := os.Getenv("OFF_API_BASE_URL"); baseURL != "" {
+		cfg.BaseURL = baseURL
+	}
+	cfg.APIKey = os.Getenv("OFF_API_KEY")
+	return repository.NewOFFProvider(cfg)
+}
+
+// eventQueueStoreFromEnv selects an eventqueue.Store based on
+// EVENTQUEUE_REDIS_ADDR: a RedisStore dialed to it if set, otherwise a
+// MemoryStore, so deployments without Redis still start up.
+func eventQueueStoreFromEnv() (eventqueue.Store, error) {
+	addr := os.Getenv("EVENTQUEUE_REDIS_ADDR")
+	if addr == "" {
+		return eventqueue.NewMemoryStore(), nil
+	}
+	return eventqueue.NewRedisStore(addr)
+}
+
+// setupRouter builds the gin.Engine and its nested RouterGroups. The
+// resulting route -> middleware-chain -> handler resolution is captured in
+// api/routes.json for route-extraction fixture coverage.
+//routes:expect artifact=api/routes.json
 func setupRouter(
 	authService *services.AuthService,
 	authHandler *handlers.AuthHandler,
 	foodHandler *handlers.FoodHandler,
 	trackingHandler *handlers.TrackingHandler,
+	notificationHandler *handlers.NotificationHandler,
 ) *gin.Engine {
 	router := gin.New()
 
@@ -161,6 +252,14 @@ func setupRouter(
 			tracking.GET("/daily", trackingHandler.GetDailyLog)
 			tracking.GET("/weekly", trackingHandler.GetWeeklyProgress)
 		}
+
+		// Notification preference routes (all protected)
+		notificationsGroup := v1.Group("/notifications")
+		notificationsGroup.Use(middleware.AuthMiddleware(authService))
+		{
+			notificationsGroup.GET("/preferences", notificationHandler.GetPreferences)
+			notificationsGroup.PUT("/preferences", notificationHandler.UpdatePreferences)
+		}
 	}
 
 	return router
@@ -85,6 +85,10 @@ func Migrate() error {
 		&models.FoodEntry{},
 		&models.Meal{},
 		&models.DailyLog{},
+		&models.NotificationPreference{},
+		&models.Recipe{},
+		&models.RecipeIngredient{},
+		&models.RecipeShare{},
 	)
 }
 
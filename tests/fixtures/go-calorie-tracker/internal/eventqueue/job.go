@@ -0,0 +1,33 @@
+package eventqueue
+
+import "time"
+
+// JobType identifies which handler a Job's Payload decodes into.
+type JobType string
+
+const (
+	JobRecalcDailyLog JobType = "recalc_daily_log"
+	JobUpdateStats    JobType = "update_stats"
+)
+
+// Job is a durable unit of work. Payload is JSON-encoded and decoded
+// against RecalcDailyLog or UpdateStats depending on Type.
+type Job struct {
+	ID        string
+	Type      JobType
+	Payload   []byte
+	CreatedAt int64
+}
+
+// RecalcDailyLog is the JobRecalcDailyLog payload: get or create and
+// recompute the DailyLog totals for UserID on Date.
+type RecalcDailyLog struct {
+	UserID uint      `json:"user_id"`
+	Date   time.Time `json:"date"`
+}
+
+// UpdateStats is the JobUpdateStats payload. It carries no fields yet --
+// the job type exists so a future aggregate-stats job (streaks, weekly
+// averages, etc.) has a durable queue to land on without another
+// migration.
+type UpdateStats struct{}
@@ -0,0 +1,263 @@
+// Package eventqueue is a durable, at-least-once queue for tracking-side
+// background work (recalculating a DailyLog, updating aggregate stats),
+// modeled on the notification-service's queue.NotificationQueue: a
+// priority heap of scheduled jobs backed by a Store, processed by a
+// worker pool with exponential backoff and a dead-letter hook, instead
+// of TrackingService firing off untracked goroutines.
+package eventqueue
+
+import (
+	"container/heap"
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ScheduledJob wraps a Job with its scheduled time.
+type ScheduledJob struct {
+	Job         *Job
+	ScheduledAt time.Time
+
+	// Attempts counts how many times the handler has been invoked for
+	// this job so far.
+	Attempts int
+
+	// MaxAttempts is the RetryPolicy.MaxAttempts in effect when this job
+	// was scheduled, carried alongside it so a later change to
+	// EventQueue's policy doesn't affect jobs already queued.
+	MaxAttempts int
+
+	index int
+}
+
+// PriorityQueue implements heap.Interface for scheduled jobs.
+type PriorityQueue []*ScheduledJob
+
+func (pq PriorityQueue) Len() int { return len(pq) }
+
+func (pq PriorityQueue) Less(i, j int) bool {
+	return pq[i].ScheduledAt.Before(pq[j].ScheduledAt)
+}
+
+func (pq PriorityQueue) Swap(i, j int) {
+	pq[i], pq[j] = pq[j], pq[i]
+	pq[i].index = i
+	pq[j].index = j
+}
+
+func (pq *PriorityQueue) Push(x interface{}) {
+	n := len(*pq)
+	item := x.(*ScheduledJob)
+	item.index = n
+	*pq = append(*pq, item)
+}
+
+func (pq *PriorityQueue) Pop() interface{} {
+	old := *pq
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*pq = old[0 : n-1]
+	return item
+}
+
+// EventQueue manages scheduled tracking-event jobs.
+type EventQueue struct {
+	queue       PriorityQueue
+	mu          sync.Mutex
+	store       Store
+	handler     func(*Job) error
+	retryPolicy RetryPolicy
+	deadLetter  DeadLetterHandler
+	metrics     queueMetrics
+	stopChan    chan struct{}
+	wg          sync.WaitGroup
+}
+
+// Config configures an EventQueue's retry and dead-letter behavior. The
+// zero value is usable: RetryPolicy defaults to DefaultRetryPolicy, and a
+// nil DeadLetterHandler simply drops jobs once they exhaust their
+// retries.
+type Config struct {
+	RetryPolicy       RetryPolicy
+	DeadLetterHandler DeadLetterHandler
+}
+
+// NewEventQueue creates a new EventQueue backed by store. Start hydrates
+// the heap from store.LoadPending before it begins processing, so jobs
+// scheduled before a restart aren't lost.
+func NewEventQueue(store Store, handler func(*Job) error, cfg Config) *EventQueue {
+	if cfg.RetryPolicy.MaxAttempts == 0 {
+		cfg.RetryPolicy = DefaultRetryPolicy()
+	}
+
+	q := &EventQueue{
+		queue:       make(PriorityQueue, 0),
+		store:       store,
+		handler:     handler,
+		retryPolicy: cfg.RetryPolicy,
+		deadLetter:  cfg.DeadLetterHandler,
+		stopChan:    make(chan struct{}),
+	}
+	heap.Init(&q.queue)
+	return q
+}
+
+// Start hydrates the heap from the store's pending jobs and starts the
+// queue processor.
+func (q *EventQueue) Start(ctx context.Context) error {
+	pending, err := q.store.LoadPending(ctx)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	for _, item := range pending {
+		heap.Push(&q.queue, item)
+	}
+	q.mu.Unlock()
+
+	q.wg.Add(1)
+	go q.processLoop(ctx)
+	return nil
+}
+
+// Stop stops the queue processor.
+func (q *EventQueue) Stop() {
+	close(q.stopChan)
+	q.wg.Wait()
+}
+
+// EnqueueTx schedules job for immediate processing, persisting it via tx
+// (see Store.EnqueueTx) before adding it to the in-memory heap.
+func (q *EventQueue) EnqueueTx(ctx context.Context, tx *gorm.DB, job *Job) error {
+	item := &ScheduledJob{
+		Job:         job,
+		ScheduledAt: time.Now(),
+		MaxAttempts: q.retryPolicy.MaxAttempts,
+	}
+
+	if err := q.store.EnqueueTx(ctx, tx, item); err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	heap.Push(&q.queue, item)
+
+	return nil
+}
+
+// processLoop continuously processes scheduled jobs.
+func (q *EventQueue) processLoop(ctx context.Context) {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.stopChan:
+			return
+		case <-ticker.C:
+			q.processReady()
+		}
+	}
+}
+
+// processReady processes all jobs that are ready to run.
+func (q *EventQueue) processReady() {
+	now := time.Now()
+
+	for {
+		q.mu.Lock()
+		if q.queue.Len() == 0 {
+			q.mu.Unlock()
+			return
+		}
+
+		next := q.queue[0]
+		if next.ScheduledAt.After(now) {
+			q.mu.Unlock()
+			return
+		}
+
+		item := heap.Pop(&q.queue).(*ScheduledJob)
+		q.mu.Unlock()
+
+		go q.process(item)
+	}
+}
+
+// process runs the handler for item once, then either marks it done,
+// re-schedules it with a backoff delay, or dead-letters it once its
+// retries are exhausted.
+func (q *EventQueue) process(item *ScheduledJob) {
+	q.metrics.inFlight.Add(1)
+	defer q.metrics.inFlight.Add(-1)
+
+	ctx := context.Background()
+	item.Attempts++
+	q.metrics.attempts.Add(1)
+
+	err := q.handler(item.Job)
+	if err == nil {
+		if markErr := q.store.MarkDone(ctx, item.Job.ID); markErr != nil {
+			log.Printf("failed to mark job %s done: %v", item.Job.ID, markErr)
+		}
+		return
+	}
+
+	if item.Attempts >= item.MaxAttempts {
+		q.metrics.deadLettered.Add(1)
+		if markErr := q.store.MarkFailed(ctx, item.Job.ID, err); markErr != nil {
+			log.Printf("failed to mark job %s failed: %v", item.Job.ID, markErr)
+		}
+		if q.deadLetter != nil {
+			q.deadLetter(item.Job, err)
+		}
+		return
+	}
+
+	q.metrics.retries.Add(1)
+	item.ScheduledAt = time.Now().Add(q.retryPolicy.backoff(item.Attempts))
+	if storeErr := q.store.EnqueueTx(ctx, nil, item); storeErr != nil {
+		log.Printf("failed to persist retry for job %s: %v", item.Job.ID, storeErr)
+	}
+
+	q.mu.Lock()
+	heap.Push(&q.queue, item)
+	q.mu.Unlock()
+}
+
+// Pending returns the number of pending jobs.
+func (q *EventQueue) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.queue.Len()
+}
+
+// Stats returns a point-in-time snapshot of attempt/retry/dead-letter/
+// in-flight counters, complementing Pending for observability.
+func (q *EventQueue) Stats() Stats {
+	return q.metrics.snapshot()
+}
+
+// Clear clears all pending jobs, both in the heap and in the store.
+func (q *EventQueue) Clear() error {
+	if err := q.store.Clear(context.Background()); err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.queue = make(PriorityQueue, 0)
+	heap.Init(&q.queue)
+	return nil
+}
@@ -0,0 +1,222 @@
+package eventqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Store persists scheduled jobs so EventQueue survives a restart instead
+// of losing everything still sitting in its priority heap. NewEventQueue
+// hydrates the heap from LoadPending on Start and writes through on
+// EnqueueTx and after each handler invocation.
+//
+// EnqueueTx takes tx so a caller already inside a *gorm.DB transaction
+// (e.g. TrackingService.LogFoodEntry creating a FoodEntry) can share that
+// transaction's scope -- but neither implementation below actually does:
+// MemoryStore and RedisStore both write the job through (and, via
+// EventQueue.EnqueueTx, push it onto the live heap) immediately via their
+// own client, ignoring tx entirely, rather than only once tx commits.
+// This is NOT the outbox pattern tx's presence here might suggest; a tx
+// that later rolls back leaves a job already fired referencing a row
+// that never ends up existing. Every job here recalculates an aggregate
+// from rows that are either already committed or about to be, so that
+// orphaned job is harmless -- it just recomputes a total that was
+// already correct -- which makes the gap acceptable for now. A
+// SQL-backed Store that writes through tx's own connection, so the job
+// row itself is part of the same commit/rollback, would close it.
+type Store interface {
+	EnqueueTx(ctx context.Context, tx *gorm.DB, sj *ScheduledJob) error
+	LoadPending(ctx context.Context) ([]*ScheduledJob, error)
+	MarkDone(ctx context.Context, id string) error
+	MarkFailed(ctx context.Context, id string, handlerErr error) error
+	Clear(ctx context.Context) error
+}
+
+// MemoryStore is an in-memory Store: nothing survives a restart. It's the
+// fallback when EVENTQUEUE_REDIS_ADDR isn't configured.
+type MemoryStore struct {
+	mu    sync.Mutex
+	items map[string]*ScheduledJob
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: make(map[string]*ScheduledJob)}
+}
+
+// EnqueueTx implements Store.
+func (m *MemoryStore) EnqueueTx(ctx context.Context, tx *gorm.DB, sj *ScheduledJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items[sj.Job.ID] = sj
+	return nil
+}
+
+// LoadPending implements Store.
+func (m *MemoryStore) LoadPending(ctx context.Context) ([]*ScheduledJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	results := make([]*ScheduledJob, 0, len(m.items))
+	for _, sj := range m.items {
+		results = append(results, sj)
+	}
+	return results, nil
+}
+
+// MarkDone implements Store.
+func (m *MemoryStore) MarkDone(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, id)
+	return nil
+}
+
+// MarkFailed implements Store.
+func (m *MemoryStore) MarkFailed(ctx context.Context, id string, handlerErr error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, id)
+	return nil
+}
+
+// Clear implements Store.
+func (m *MemoryStore) Clear(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items = make(map[string]*ScheduledJob)
+	return nil
+}
+
+// redisScheduledJob is the JSON wire format a ScheduledJob is stored as
+// in Redis; it exists only so RedisStore doesn't need to export
+// ScheduledJob's unexported heap index.
+type redisScheduledJob struct {
+	Job         *Job      `json:"job"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	Attempts    int       `json:"attempts"`
+	MaxAttempts int       `json:"max_attempts"`
+}
+
+const (
+	redisJobKeyPrefix  = "eventqueue:job:"
+	redisPendingSetKey = "eventqueue:pending"
+)
+
+// RedisStore is a Store backed by Redis, so multiple calorie-tracker
+// replicas can share one durable event queue: each job is a key holding
+// its JSON encoding, with a sorted set on scheduled_at for LoadPending
+// ordering.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore dials addr (EVENTQUEUE_REDIS_ADDR) and verifies the
+// connection with a Ping.
+func NewRedisStore(addr string) (*RedisStore, error) {
+	client := redis.NewClient(&redis.Options{Addr: addr})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	return &RedisStore{client: client}, nil
+}
+
+// EnqueueTx implements Store.
+func (s *RedisStore) EnqueueTx(ctx context.Context, tx *gorm.DB, sj *ScheduledJob) error {
+	data, err := json.Marshal(redisScheduledJob{
+		Job:         sj.Job,
+		ScheduledAt: sj.ScheduledAt,
+		Attempts:    sj.Attempts,
+		MaxAttempts: sj.MaxAttempts,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal scheduled job: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, redisJobKeyPrefix+sj.Job.ID, data, 0)
+	pipe.ZAdd(ctx, redisPendingSetKey, redis.Z{Score: float64(sj.ScheduledAt.Unix()), Member: sj.Job.ID})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to enqueue job %s: %w", sj.Job.ID, err)
+	}
+	return nil
+}
+
+// LoadPending implements Store.
+func (s *RedisStore) LoadPending(ctx context.Context) ([]*ScheduledJob, error) {
+	ids, err := s.client.ZRange(ctx, redisPendingSetKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending jobs: %w", err)
+	}
+
+	results := make([]*ScheduledJob, 0, len(ids))
+	for _, id := range ids {
+		data, err := s.client.Get(ctx, redisJobKeyPrefix+id).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load job %s: %w", id, err)
+		}
+
+		var rsj redisScheduledJob
+		if err := json.Unmarshal([]byte(data), &rsj); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal job %s: %w", id, err)
+		}
+
+		results = append(results, &ScheduledJob{
+			Job:         rsj.Job,
+			ScheduledAt: rsj.ScheduledAt,
+			Attempts:    rsj.Attempts,
+			MaxAttempts: rsj.MaxAttempts,
+		})
+	}
+
+	return results, nil
+}
+
+// MarkDone implements Store.
+func (s *RedisStore) MarkDone(ctx context.Context, id string) error {
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, redisJobKeyPrefix+id)
+	pipe.ZRem(ctx, redisPendingSetKey, id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to mark job %s done: %w", id, err)
+	}
+	return nil
+}
+
+// MarkFailed implements Store. Dead-lettered jobs are removed from the
+// pending set the same as done ones; DeadLetterHandler is responsible
+// for recording handlerErr wherever the caller wants it kept.
+func (s *RedisStore) MarkFailed(ctx context.Context, id string, handlerErr error) error {
+	return s.MarkDone(ctx, id)
+}
+
+// Clear implements Store.
+func (s *RedisStore) Clear(ctx context.Context) error {
+	ids, err := s.client.ZRange(ctx, redisPendingSetKey, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list pending jobs: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	for _, id := range ids {
+		pipe.Del(ctx, redisJobKeyPrefix+id)
+	}
+	pipe.Del(ctx, redisPendingSetKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to clear event queue: %w", err)
+	}
+	return nil
+}
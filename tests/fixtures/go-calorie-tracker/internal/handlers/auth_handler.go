@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"strings"
 
@@ -132,7 +133,10 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	}
 
 	// Generate reset token (vulnerable implementation for testing)
-	token, _ := h.authService.GeneratePasswordResetToken(req.Email)
+	token, err := h.authService.GeneratePasswordResetToken(req.Email)
+	if err != nil {
+		log.Printf("failed to generate password reset token: %v", err)
+	}
 
 	// In production, you'd send this via email
 	// For demo purposes, we'll include it in the response (don't do this in production!)
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/example/calorie-tracker/internal/models"
+	"github.com/example/calorie-tracker/internal/notifications"
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationHandler handles notification preference endpoints.
+type NotificationHandler struct {
+	prefService *notifications.PreferenceService
+}
+
+// NewNotificationHandler creates a new NotificationHandler.
+func NewNotificationHandler(prefService *notifications.PreferenceService) *NotificationHandler {
+	return &NotificationHandler{prefService: prefService}
+}
+
+// GetPreferences returns the current user's notification preferences.
+// @Summary Get notification preferences
+// @Tags notifications
+// @Security Bearer
+// @Success 200 {array} models.NotificationPreference
+// @Router /notifications/preferences [get]
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	prefs, err := h.prefService.GetPreferences(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferencesRequest contains the preferences to upsert.
+type UpdatePreferencesRequest struct {
+	Preferences []models.NotificationPreference `json:"preferences" binding:"required"`
+}
+
+// UpdatePreferences upserts the current user's notification preferences.
+// @Summary Update notification preferences
+// @Tags notifications
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param request body UpdatePreferencesRequest true "Preferences to update"
+// @Success 200 {array} models.NotificationPreference
+// @Failure 400 {object} map[string]string
+// @Router /notifications/preferences [put]
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.prefService.UpdatePreferences(userID.(uint), req.Preferences); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	prefs, err := h.prefService.GetPreferences(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import "time"
+
+// NoopRecorder discards every metric. Used in tests and anywhere a
+// Recorder is required but no backend has been configured.
+type NoopRecorder struct{}
+
+// NewNoopRecorder returns a Recorder that discards every metric.
+func NewNoopRecorder() *NoopRecorder {
+	return &NoopRecorder{}
+}
+
+func (NoopRecorder) IncEntriesLogged() {}
+
+func (NoopRecorder) IncMealsCreated() {}
+
+func (NoopRecorder) IncDailyRecalc() {}
+
+func (NoopRecorder) ObserveDuration(operation string, d time.Duration) {}
+
+func (NoopRecorder) SetActiveUsers(n int) {}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// PrometheusRecorder is a Recorder backed by a dedicated
+// prometheus.Registry, served over Handler rather than the global
+// default registry.
+type PrometheusRecorder struct {
+	registry       *prometheus.Registry
+	entriesLogged  prometheus.Counter
+	mealsCreated   prometheus.Counter
+	dailyRecalc    prometheus.Counter
+	dbCallDuration *prometheus.HistogramVec
+	activeUsers    prometheus.Gauge
+}
+
+// NewPrometheusRecorder creates and registers a Recorder's collectors
+// against a fresh prometheus.Registry.
+func NewPrometheusRecorder() *PrometheusRecorder {
+	r := &PrometheusRecorder{
+		registry: prometheus.NewRegistry(),
+		entriesLogged: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "entries_logged_total",
+			Help: "Total number of food entries logged.",
+		}),
+		mealsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "meals_created_total",
+			Help: "Total number of meals created.",
+		}),
+		dailyRecalc: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "daily_recalc_total",
+			Help: "Total number of daily log recalculations.",
+		}),
+		dbCallDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_call_duration_seconds",
+			Help:    "Database call latency, by operation.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"operation"}),
+		activeUsers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "active_users",
+			Help: "Number of currently active users.",
+		}),
+	}
+
+	r.registry.MustRegister(
+		r.entriesLogged,
+		r.mealsCreated,
+		r.dailyRecalc,
+		r.dbCallDuration,
+		r.activeUsers,
+	)
+
+	return r
+}
+
+// IncEntriesLogged implements Recorder.
+func (r *PrometheusRecorder) IncEntriesLogged() { r.entriesLogged.Inc() }
+
+// IncMealsCreated implements Recorder.
+func (r *PrometheusRecorder) IncMealsCreated() { r.mealsCreated.Inc() }
+
+// IncDailyRecalc implements Recorder.
+func (r *PrometheusRecorder) IncDailyRecalc() { r.dailyRecalc.Inc() }
+
+// ObserveDuration implements Recorder.
+func (r *PrometheusRecorder) ObserveDuration(operation string, d time.Duration) {
+	r.dbCallDuration.WithLabelValues(operation).Observe(d.Seconds())
+}
+
+// SetActiveUsers implements Recorder.
+func (r *PrometheusRecorder) SetActiveUsers(n int) {
+	r.activeUsers.Set(float64(n))
+}
+
+// Handler returns the /metrics HTTP handler for r's registry.
+func (r *PrometheusRecorder) Handler() http.Handler {
+	return promhttp.HandlerFor(r.registry, promhttp.HandlerOpts{})
+}
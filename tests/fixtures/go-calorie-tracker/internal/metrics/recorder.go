@@ -0,0 +1,46 @@
+// Package metrics exports calorie-tracker counters, histograms and
+// gauges to either Prometheus or StatsD, selected at startup by
+// FromEnv so a deployment can pick whichever its existing collection
+// pipeline expects.
+package metrics
+
+import (
+	"os"
+	"time"
+)
+
+// Recorder is the metrics backend TrackingService and FoodRepository
+// record against.
+type Recorder interface {
+	// IncEntriesLogged increments entries_logged_total.
+	IncEntriesLogged()
+	// IncMealsCreated increments meals_created_total.
+	IncMealsCreated()
+	// IncDailyRecalc increments daily_recalc_total.
+	IncDailyRecalc()
+	// ObserveDuration records how long a named DB operation
+	// (e.g. "recalculate_daily_totals", "search", "get_popular") took.
+	ObserveDuration(operation string, d time.Duration)
+	// SetActiveUsers reports the current active-user count.
+	SetActiveUsers(n int)
+}
+
+// FromEnv selects a Recorder based on STATSD_URL: if set, a
+// StatsDRecorder dialed to it; otherwise a PrometheusRecorder with its
+// own registry.
+func FromEnv() (Recorder, error) {
+	if addr := os.Getenv("STATSD_URL"); addr != "" {
+		return NewStatsDRecorder(addr)
+	}
+	return NewPrometheusRecorder(), nil
+}
+
+// StartTimer starts timing operation and returns a func to call
+// (typically deferred) when it completes, which records the elapsed
+// duration against r.
+func StartTimer(r Recorder, operation string) func() {
+	start := time.Now()
+	return func() {
+		r.ObserveDuration(operation, time.Since(start))
+	}
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"fmt"
+	"time"
+
+	statsd "github.com/cactus/go-statsd-client/v5/statsd"
+)
+
+// StatsDRecorder is a Recorder backed by a StatsD client, for
+// deployments that aggregate metrics via a StatsD/DogStatsD sidecar
+// instead of scraping Prometheus.
+type StatsDRecorder struct {
+	client statsd.Statter
+}
+
+// NewStatsDRecorder dials addr (STATSD_URL, e.g. "127.0.0.1:8125").
+func NewStatsDRecorder(addr string) (*StatsDRecorder, error) {
+	client, err := statsd.NewClient(addr, "calorie_tracker")
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to StatsD at %s: %w", addr, err)
+	}
+	return &StatsDRecorder{client: client}, nil
+}
+
+// IncEntriesLogged implements Recorder.
+func (r *StatsDRecorder) IncEntriesLogged() {
+	_ = r.client.Inc("entries_logged_total", 1, 1.0)
+}
+
+// IncMealsCreated implements Recorder.
+func (r *StatsDRecorder) IncMealsCreated() {
+	_ = r.client.Inc("meals_created_total", 1, 1.0)
+}
+
+// IncDailyRecalc implements Recorder.
+func (r *StatsDRecorder) IncDailyRecalc() {
+	_ = r.client.Inc("daily_recalc_total", 1, 1.0)
+}
+
+// ObserveDuration implements Recorder.
+func (r *StatsDRecorder) ObserveDuration(operation string, d time.Duration) {
+	_ = r.client.TimingDuration(fmt.Sprintf("db_call_duration.%s", operation), d, 1.0)
+}
+
+// SetActiveUsers implements Recorder.
+func (r *StatsDRecorder) SetActiveUsers(n int) {
+	_ = r.client.Gauge("active_users", int64(n), 1.0)
+}
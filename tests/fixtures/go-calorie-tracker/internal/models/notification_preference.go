@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// EventType identifies a TrackingService domain event a user can opt
+// in/out of per NotificationChannel.
+type EventType string
+
+const (
+	EventMealLogged    EventType = "meal_logged"
+	EventGoalReached   EventType = "goal_reached"
+	EventGoalMissed    EventType = "goal_missed"
+	EventWeeklySummary EventType = "weekly_summary"
+)
+
+// NotificationChannel is the delivery channel a NotificationPreference
+// routes an EventType to.
+type NotificationChannel string
+
+const (
+	ChannelEmail NotificationChannel = "email"
+	ChannelPush  NotificationChannel = "push"
+	ChannelNone  NotificationChannel = "none"
+)
+
+// NotificationPreference is one user's delivery setting for a single
+// EventType: which NotificationChannel to use, and an optional
+// quiet-hours window during which delivery is suppressed.
+type NotificationPreference struct {
+	ID              uint                `gorm:"primaryKey" json:"id"`
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+	UserID          uint                `gorm:"not null;uniqueIndex:idx_user_event" json:"user_id"`
+	EventType       EventType           `gorm:"size:30;not null;uniqueIndex:idx_user_event" json:"event_type"`
+	Channel         NotificationChannel `gorm:"size:10;not null;default:email" json:"channel"`
+	QuietHoursStart string              `gorm:"size:5" json:"quiet_hours_start,omitempty"` // "HH:MM", empty means no quiet hours
+	QuietHoursEnd   string              `gorm:"size:5" json:"quiet_hours_end,omitempty"`   // "HH:MM"
+
+	// Relationships
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// TableName returns the table name for NotificationPreference model.
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+// InQuietHours reports whether t falls inside the preference's quiet-hours
+// window, handling windows that wrap past midnight (e.g. 22:00-07:00). A
+// preference with no quiet hours configured is never in quiet hours.
+func (p *NotificationPreference) InQuietHours(t time.Time) bool {
+	if p.QuietHoursStart == "" || p.QuietHoursEnd == "" {
+		return false
+	}
+
+	start, err := time.Parse("15:04", p.QuietHoursStart)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", p.QuietHoursEnd)
+	if err != nil {
+		return false
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	startMin := start.Hour()*60 + start.Minute()
+	endMin := end.Hour()*60 + end.Minute()
+
+	if startMin == endMin {
+		return false
+	}
+	if startMin < endMin {
+		return now >= startMin && now < endMin
+	}
+	// Window wraps past midnight, e.g. 22:00-07:00.
+	return now >= startMin || now < endMin
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecipeVisibility controls who besides a Recipe's owner can see it in
+// RecipeRepository.Search.
+type RecipeVisibility string
+
+const (
+	RecipeVisibilityPrivate RecipeVisibility = "private"
+	RecipeVisibilityFriends RecipeVisibility = "friends"
+	RecipeVisibilityPublic  RecipeVisibility = "public"
+)
+
+// Recipe is a reusable collection of Food ingredients a user can log as
+// one action (see RecipeService.LogRecipe) instead of logging each
+// FoodEntry individually.
+//
+// Recipes are versioned: editing one doesn't mutate Version or
+// PreviousVersionID in place, it inserts a new Recipe row pointing back
+// at the one it supersedes (see RecipeRepository.CreateVersion), so a
+// Meal or FoodEntry already logged against an earlier version keeps
+// referencing that version's ingredients and nutrition.
+type Recipe struct {
+	ID                uint             `gorm:"primaryKey" json:"id"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt   `gorm:"index" json:"-"`
+	UserID            uint             `gorm:"not null;index" json:"user_id"`
+	Name              string           `gorm:"size:255;not null;index" json:"name"`
+	Servings          float64          `gorm:"not null;default:1" json:"servings"`
+	Visibility        RecipeVisibility `gorm:"size:20;not null;default:private" json:"visibility"`
+	Version           int              `gorm:"not null;default:1" json:"version"`
+	PreviousVersionID *uint            `gorm:"index" json:"previous_version_id,omitempty"`
+	Notes             string           `gorm:"size:1000" json:"notes,omitempty"`
+
+	// Relationships
+	User        *User              `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Ingredients []RecipeIngredient `gorm:"foreignKey:RecipeID" json:"ingredients,omitempty"`
+}
+
+// TableName returns the table name for Recipe model.
+func (Recipe) TableName() string {
+	return "recipes"
+}
+
+// RecipeIngredient is one Food and its quantity within a Recipe. Quantity
+// follows the same serving-multiplier convention as FoodEntry.Quantity:
+// a Food's per-serving Calories/Protein/Carbs/Fat times Quantity gives
+// this ingredient's contribution.
+type RecipeIngredient struct {
+	ID       uint    `gorm:"primaryKey" json:"id"`
+	RecipeID uint    `gorm:"not null;index" json:"recipe_id"`
+	FoodID   uint    `gorm:"not null;index" json:"food_id"`
+	Quantity float64 `gorm:"not null" json:"quantity"`
+
+	// Relationships
+	Food *Food `gorm:"foreignKey:FoodID" json:"food,omitempty"`
+}
+
+// TableName returns the table name for RecipeIngredient model.
+func (RecipeIngredient) TableName() string {
+	return "recipe_ingredients"
+}
+
+// RecipeShare grants UserID access to a Recipe whose Visibility is
+// RecipeVisibilityFriends. There's no social-graph/friends model in this
+// app, so "friends" visibility is implemented as this explicit per-recipe
+// share list rather than a mutual-friendship lookup: whoever a recipe's
+// owner shares it with can see it.
+type RecipeShare struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	RecipeID  uint      `gorm:"not null;uniqueIndex:idx_recipe_user" json:"recipe_id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_recipe_user" json:"user_id"`
+}
+
+// TableName returns the table name for RecipeShare model.
+func (RecipeShare) TableName() string {
+	return "recipe_shares"
+}
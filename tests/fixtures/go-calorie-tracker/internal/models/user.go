@@ -6,7 +6,11 @@ import (
 	"gorm.io/gorm"
 )
 
-// User represents a registered user in the calorie tracking system.
+// User represents a registered user in the calorie tracking system. The
+// table/relationship/index graph resolved from this package's GORM tags is
+// captured in api/schema_graph.json for schema-graph-extraction fixture
+// coverage.
+//gorm:expect artifact=api/schema_graph.json
 type User struct {
 	ID           uint           `gorm:"primaryKey" json:"id"`
 	CreatedAt    time.Time      `json:"created_at"`
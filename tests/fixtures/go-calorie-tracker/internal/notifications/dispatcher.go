@@ -0,0 +1,25 @@
+// Package notifications publishes calorie-tracker domain events (a meal
+// logged, a daily goal reached/missed, a weekly summary) to the sibling
+// notification-service, gated by each user's NotificationPreference.
+package notifications
+
+import "github.com/example/calorie-tracker/internal/models"
+
+// Event is a calorie-tracker domain event a NotificationDispatcher
+// delivers to UserID, via whichever channel that user's
+// models.NotificationPreference for Type selects.
+type Event struct {
+	UserID uint
+	Type   models.EventType
+	Title  string
+	Body   string
+	Data   map[string]string
+}
+
+// NotificationDispatcher delivers Events, via the notification-service's
+// SendNotification RPC (see GRPCDispatcher) or a no-op for tests and
+// deployments without a notification-service connection (see
+// NoopDispatcher).
+type NotificationDispatcher interface {
+	Dispatch(event Event) error
+}
@@ -0,0 +1,39 @@
+package notifications
+
+import "time"
+
+// GatedDispatcher wraps a NotificationDispatcher with a PreferenceService
+// lookup: Dispatch is a no-op whenever the recipient's preference for
+// event.Type is ChannelNone or the event arrives during that
+// preference's quiet hours, and sets event.Data["channel"] from the
+// preference otherwise before delegating to next.
+type GatedDispatcher struct {
+	next  NotificationDispatcher
+	prefs *PreferenceService
+}
+
+// NewGatedDispatcher returns a NotificationDispatcher that consults prefs
+// before delegating to next.
+func NewGatedDispatcher(next NotificationDispatcher, prefs *PreferenceService) *GatedDispatcher {
+	return &GatedDispatcher{next: next, prefs: prefs}
+}
+
+// Dispatch implements NotificationDispatcher.
+func (d *GatedDispatcher) Dispatch(event Event) error {
+	ok, channel, err := d.prefs.ShouldDispatch(event, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+
+	if event.Data == nil {
+		event.Data = make(map[string]string, 1)
+	}
+	event.Data["channel"] = string(channel)
+
+	return d.next.Dispatch(event)
+}
+
+var _ NotificationDispatcher = (*GatedDispatcher)(nil)
@@ -0,0 +1,132 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/example/calorie-tracker/internal/models"
+	"google.golang.org/grpc"
+)
+
+// notificationType/priority mirror the sibling notification-service's
+// server.NotificationType/server.Priority enums
+// (notification-grpc/internal/server/server.go). They're duplicated here,
+// rather than imported, for the same package-isolation reason that
+// service's own internal packages duplicate its Notification type.
+type notificationType int32
+
+const (
+	notificationTypeEmail notificationType = 0
+	notificationTypePush  notificationType = 1
+)
+
+type priority int32
+
+const (
+	priorityNormal priority = 0
+	priorityHigh   priority = 1
+)
+
+// sendNotificationRequest/sendNotificationResponse mirror
+// notification-grpc/internal/server.SendNotificationRequest/Response. A
+// real deployment would generate these, and the client stub below, from
+// that service's .proto -- the same gap its own
+// server.RegisterService documents ("In a real app, this would use the
+// generated RegisterNotificationServiceServer"). Until that codegen
+// exists, GRPCDispatcher depends on the client interface below instead of
+// a generated pb.NotificationServiceClient.
+type sendNotificationRequest struct {
+	UserID   string
+	Type     notificationType
+	Title    string
+	Body     string
+	Data     map[string]string
+	Priority priority
+}
+
+type sendNotificationResponse struct {
+	NotificationID string
+	Success        bool
+	ErrorMessage   string
+}
+
+// client is the subset of a generated notification-service gRPC client
+// GRPCDispatcher depends on.
+type client interface {
+	SendNotification(ctx context.Context, req *sendNotificationRequest) (*sendNotificationResponse, error)
+}
+
+// rawGRPCClient implements client by invoking the notification-service's
+// SendNotification method directly over conn, without a generated stub.
+type rawGRPCClient struct {
+	conn *grpc.ClientConn
+}
+
+// NewGRPCClient wraps an already-dialed connection to the
+// notification-service for use with NewGRPCDispatcher.
+func NewGRPCClient(conn *grpc.ClientConn) client {
+	return &rawGRPCClient{conn: conn}
+}
+
+func (c *rawGRPCClient) SendNotification(ctx context.Context, req *sendNotificationRequest) (*sendNotificationResponse, error) {
+	var resp sendNotificationResponse
+	if err := c.conn.Invoke(ctx, "/notification.NotificationService/SendNotification", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GRPCDispatcher is a NotificationDispatcher backed by a gRPC connection
+// to the notification-service.
+type GRPCDispatcher struct {
+	client client
+}
+
+// NewGRPCDispatcher returns a NotificationDispatcher that calls the
+// notification-service's SendNotification RPC through client (see
+// NewGRPCClient).
+func NewGRPCDispatcher(client client) *GRPCDispatcher {
+	return &GRPCDispatcher{client: client}
+}
+
+// Dispatch implements NotificationDispatcher by calling the
+// notification-service's SendNotification RPC, routing event.Data["channel"]
+// to the matching server.NotificationType and event.Type to a
+// server.Priority (goal_reached/goal_missed get priorityHigh; everything
+// else is priorityNormal).
+func (d *GRPCDispatcher) Dispatch(event Event) error {
+	req := &sendNotificationRequest{
+		UserID:   strconv.FormatUint(uint64(event.UserID), 10),
+		Type:     channelToNotificationType(event.Data["channel"]),
+		Title:    event.Title,
+		Body:     event.Body,
+		Data:     event.Data,
+		Priority: priorityForEvent(event.Type),
+	}
+
+	resp, err := d.client.SendNotification(context.Background(), req)
+	if err != nil {
+		return fmt.Errorf("failed to dispatch %s notification for user %d: %w", event.Type, event.UserID, err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("notification-service rejected %s notification for user %d: %s", event.Type, event.UserID, resp.ErrorMessage)
+	}
+	return nil
+}
+
+func channelToNotificationType(channel string) notificationType {
+	if channel == "push" {
+		return notificationTypePush
+	}
+	return notificationTypeEmail
+}
+
+func priorityForEvent(eventType models.EventType) priority {
+	switch eventType {
+	case models.EventGoalReached, models.EventGoalMissed:
+		return priorityHigh
+	default:
+		return priorityNormal
+	}
+}
@@ -0,0 +1,15 @@
+package notifications
+
+// NoopDispatcher discards every Event. Used in tests and any deployment
+// that doesn't configure a notification-service connection.
+type NoopDispatcher struct{}
+
+// NewNoopDispatcher returns a NotificationDispatcher that discards every Event.
+func NewNoopDispatcher() *NoopDispatcher {
+	return &NoopDispatcher{}
+}
+
+// Dispatch implements NotificationDispatcher.
+func (NoopDispatcher) Dispatch(event Event) error {
+	return nil
+}
@@ -0,0 +1,63 @@
+package notifications
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/example/calorie-tracker/internal/models"
+	"github.com/example/calorie-tracker/internal/repository"
+)
+
+// PreferenceService manages per-user, per-event NotificationPreference rows.
+type PreferenceService struct {
+	prefRepo *repository.NotificationPreferenceRepository
+}
+
+// NewPreferenceService creates a new PreferenceService.
+func NewPreferenceService(prefRepo *repository.NotificationPreferenceRepository) *PreferenceService {
+	return &PreferenceService{prefRepo: prefRepo}
+}
+
+// GetPreferences returns a user's complete NotificationPreference set,
+// seeding ChannelEmail defaults for any EventType the user has no row for
+// yet.
+func (s *PreferenceService) GetPreferences(userID uint) ([]models.NotificationPreference, error) {
+	return s.prefRepo.EnsureDefaults(userID)
+}
+
+// EnsureDefaults seeds a ChannelEmail NotificationPreference row for every
+// EventType the user doesn't already have one for, leaving existing rows
+// untouched.
+func (s *PreferenceService) EnsureDefaults(userID uint) ([]models.NotificationPreference, error) {
+	return s.prefRepo.EnsureDefaults(userID)
+}
+
+// UpdatePreferences upserts one NotificationPreference per entry in
+// updates, keyed by EventType.
+func (s *PreferenceService) UpdatePreferences(userID uint, updates []models.NotificationPreference) error {
+	for _, update := range updates {
+		update.UserID = userID
+		if err := s.prefRepo.Upsert(&update); err != nil {
+			return fmt.Errorf("failed to update %s preference: %w", update.EventType, err)
+		}
+	}
+	return nil
+}
+
+// ShouldDispatch reports whether event should be delivered right now: the
+// user's preference for event.Type must route to a channel other than
+// ChannelNone, and the current time must fall outside that preference's
+// quiet hours.
+func (s *PreferenceService) ShouldDispatch(event Event, now time.Time) (bool, models.NotificationChannel, error) {
+	pref, err := s.prefRepo.GetByUserAndEvent(event.UserID, event.Type)
+	if err != nil {
+		return false, "", err
+	}
+	if pref.Channel == models.ChannelNone {
+		return false, pref.Channel, nil
+	}
+	if pref.InQuietHours(now) {
+		return false, pref.Channel, nil
+	}
+	return true, pref.Channel, nil
+}
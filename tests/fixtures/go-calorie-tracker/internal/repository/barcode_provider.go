@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrProductNotFound is returned by a BarcodeProvider when barcode isn't
+// a recognized product, as distinct from a transient fetch error.
+var ErrProductNotFound = errors.New("product not found")
+
+// ProductInfo is a barcode-scanned product's nutrition facts, mapped
+// from whatever shape the underlying BarcodeProvider speaks into the
+// fields GetOrFetchByBarcode needs to build a models.Food.
+type ProductInfo struct {
+	Name        string
+	Brand       string
+	ServingSize float64
+	ServingUnit string
+	Calories    int
+	Protein     float64
+	Carbs       float64
+	Fat         float64
+}
+
+// BarcodeProvider looks up a product by barcode from an external
+// source. GetOrFetchByBarcode calls it on a local cache miss.
+type BarcodeProvider interface {
+	FetchByBarcode(ctx context.Context, barcode string) (*ProductInfo, error)
+}
+
+const defaultOFFBaseURL = "https://world.openfoodfacts.org/api/v2/product"
+
+// OFFConfig configures an OFFProvider.
+type OFFConfig struct {
+	// BaseURL is the Open Food Facts product endpoint, with the barcode
+	// and ".json" appended to form the request URL. Defaults to
+	// defaultOFFBaseURL.
+	BaseURL string
+
+	// APIKey is sent as a Bearer token if set. OFF's public API doesn't
+	// require one; this is for self-hosted or rate-limited deployments.
+	APIKey string
+
+	// HTTPClient defaults to a client with a 10s timeout.
+	HTTPClient *http.Client
+}
+
+// DefaultOFFConfig returns the OFFConfig NewOFFProvider uses when the
+// caller doesn't need anything unusual.
+func DefaultOFFConfig() OFFConfig {
+	return OFFConfig{BaseURL: defaultOFFBaseURL}
+}
+
+// OFFProvider is a BarcodeProvider backed by the Open Food Facts API.
+type OFFProvider struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewOFFProvider creates an OFFProvider from cfg. A zero-value BaseURL
+// falls back to the public OFF API, and a nil HTTPClient falls back to
+// one with a 10s timeout.
+func NewOFFProvider(cfg OFFConfig) *OFFProvider {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultOFFBaseURL
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &OFFProvider{
+		baseURL:    cfg.BaseURL,
+		apiKey:     cfg.APIKey,
+		httpClient: cfg.HTTPClient,
+	}
+}
+
+// offProductResponse is the subset of Open Food Facts's product response
+// FetchByBarcode reads.
+type offProductResponse struct {
+	Status  int `json:"status"`
+	Product struct {
+		ProductName     string  `json:"product_name"`
+		Brands          string  `json:"brands"`
+		ServingQuantity float64 `json:"serving_quantity"`
+		Nutriments      struct {
+			EnergyKcal100g    float64 `json:"energy-kcal_100g"`
+			Proteins100g      float64 `json:"proteins_100g"`
+			Carbohydrates100g float64 `json:"carbohydrates_100g"`
+			Fat100g           float64 `json:"fat_100g"`
+		} `json:"nutriments"`
+	} `json:"product"`
+}
+
+// FetchByBarcode implements BarcodeProvider.
+func (p *OFFProvider) FetchByBarcode(ctx context.Context, barcode string) (*ProductInfo, error) {
+	url := fmt.Sprintf("%s/%s.json", p.baseURL, barcode)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build OFF request for barcode %s: %w", barcode, err)
+	}
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch product %s: %w", barcode, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OFF API returned status %d for barcode %s", resp.StatusCode, barcode)
+	}
+
+	var parsed offProductResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("failed to decode OFF response for barcode %s: %w", barcode, err)
+	}
+
+	// OFF returns status=0, rather than a 404, for an unrecognized barcode.
+	if parsed.Status == 0 {
+		return nil, ErrProductNotFound
+	}
+
+	servingSize := parsed.Product.ServingQuantity
+	if servingSize == 0 {
+		servingSize = 100
+	}
+
+	return &ProductInfo{
+		Name:        parsed.Product.ProductName,
+		Brand:       parsed.Product.Brands,
+		ServingSize: servingSize,
+		ServingUnit: "g",
+		Calories:    int(parsed.Product.Nutriments.EnergyKcal100g),
+		Protein:     parsed.Product.Nutriments.Proteins100g,
+		Carbs:       parsed.Product.Nutriments.Carbohydrates100g,
+		Fat:         parsed.Product.Nutriments.Fat100g,
+	}, nil
+}
+
+// MockBarcodeProvider is a BarcodeProvider backed by an in-memory map,
+// for tests and local development that shouldn't depend on reaching the
+// real OFF API.
+type MockBarcodeProvider struct {
+	Products map[string]*ProductInfo
+}
+
+// NewMockBarcodeProvider creates a MockBarcodeProvider seeded with
+// products. A nil products map is treated as empty.
+func NewMockBarcodeProvider(products map[string]*ProductInfo) *MockBarcodeProvider {
+	if products == nil {
+		products = make(map[string]*ProductInfo)
+	}
+	return &MockBarcodeProvider{Products: products}
+}
+
+// FetchByBarcode implements BarcodeProvider.
+func (m *MockBarcodeProvider) FetchByBarcode(ctx context.Context, barcode string) (*ProductInfo, error) {
+	info, ok := m.Products[barcode]
+	if !ok {
+		return nil, ErrProductNotFound
+	}
+	return info, nil
+}
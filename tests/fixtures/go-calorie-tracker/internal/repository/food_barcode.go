@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/example/calorie-tracker/internal/models"
+)
+
+// negativeBarcodeCacheTTL is how long GetOrFetchByBarcode remembers that
+// a barcode came back ErrProductNotFound from the provider, so scanning
+// an unrecognized barcode repeatedly doesn't re-hit the external API
+// every time.
+const negativeBarcodeCacheTTL = 10 * time.Minute
+
+const (
+	barcodeFetchMaxAttempts = 3
+	barcodeFetchBaseDelay   = 200 * time.Millisecond
+)
+
+// barcodeCall is the in-flight entry for one barcode's fetchAndStoreByBarcode
+// call: concurrent GetOrFetchByBarcode calls for the same barcode wait on
+// done instead of each issuing their own provider fetch and insert.
+type barcodeCall struct {
+	done chan struct{}
+	food *models.Food
+	err  error
+}
+
+// GetOrFetchByBarcode returns the food for barcode, checking the local
+// database first and falling back to r.barcodeProvider on a miss. A
+// provider-fetched food is persisted with IsVerified=false and
+// CreatedBy=0 (the system account), since nobody has reviewed it yet.
+// fetched reports whether this call fetched from the provider rather
+// than finding a row already in the database.
+func (r *FoodRepository) GetOrFetchByBarcode(ctx context.Context, barcode string) (food *models.Food, fetched bool, err error) {
+	food, err = r.GetByBarcode(barcode)
+	if err == nil {
+		return food, false, nil
+	}
+	if !errors.Is(err, ErrFoodNotFound) {
+		return nil, false, err
+	}
+
+	if r.barcodeNotFoundRecently(barcode) {
+		return nil, false, ErrFoodNotFound
+	}
+
+	food, err = r.fetchAndStoreByBarcode(ctx, barcode)
+	if err != nil {
+		if errors.Is(err, ErrProductNotFound) {
+			r.rememberBarcodeNotFound(barcode)
+			return nil, false, ErrFoodNotFound
+		}
+		return nil, false, err
+	}
+
+	return food, true, nil
+}
+
+// fetchAndStoreByBarcode runs the provider fetch and insert for barcode
+// under a singleflight keyed on barcode, so a burst of scans for the
+// same newly-encountered barcode results in one provider call and one
+// insert, with every other caller just waiting on the first's result.
+func (r *FoodRepository) fetchAndStoreByBarcode(ctx context.Context, barcode string) (*models.Food, error) {
+	r.barcodeMu.Lock()
+	if call, ok := r.barcodeInFlight[barcode]; ok {
+		r.barcodeMu.Unlock()
+		<-call.done
+		return call.food, call.err
+	}
+
+	call := &barcodeCall{done: make(chan struct{})}
+	r.barcodeInFlight[barcode] = call
+	r.barcodeMu.Unlock()
+
+	call.food, call.err = r.doFetchAndStore(ctx, barcode)
+
+	r.barcodeMu.Lock()
+	delete(r.barcodeInFlight, barcode)
+	r.barcodeMu.Unlock()
+
+	close(call.done)
+	return call.food, call.err
+}
+
+func (r *FoodRepository) doFetchAndStore(ctx context.Context, barcode string) (*models.Food, error) {
+	var info *ProductInfo
+	err := retryWithJitter(ctx, barcodeFetchMaxAttempts, barcodeFetchBaseDelay, func() error {
+		var fetchErr error
+		info, fetchErr = r.barcodeProvider.FetchByBarcode(ctx, barcode)
+		return fetchErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	food := info.toFood(barcode)
+	if err := r.Create(food); err != nil {
+		return nil, fmt.Errorf("failed to persist barcode-imported food %s: %w", barcode, err)
+	}
+
+	return food, nil
+}
+
+// toFood maps a provider's ProductInfo into an unverified, system-owned
+// Food row ready to Create.
+func (info *ProductInfo) toFood(barcode string) *models.Food {
+	systemCreatedBy := uint(0)
+	return &models.Food{
+		Name:        info.Name,
+		Brand:       info.Brand,
+		Barcode:     barcode,
+		ServingSize: info.ServingSize,
+		ServingUnit: info.ServingUnit,
+		Calories:    info.Calories,
+		Protein:     info.Protein,
+		Carbs:       info.Carbs,
+		Fat:         info.Fat,
+		IsVerified:  false,
+		CreatedBy:   &systemCreatedBy,
+	}
+}
+
+// retryWithJitter calls fn up to attempts times, waiting an exponential,
+// +/-20%-jittered backoff between tries (the same jitter fraction
+// eventqueue.RetryPolicy uses), and returns as soon as fn succeeds or
+// returns ErrProductNotFound -- that's a definitive answer from the
+// provider, not a transient failure worth retrying. The wait between
+// attempts is ctx-aware, so a caller's cancellation or deadline interrupts
+// it immediately instead of holding the goroutine past the deadline.
+func retryWithJitter(ctx context.Context, attempts int, baseDelay time.Duration, fn func() error) error {
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		err = fn()
+		if err == nil || errors.Is(err, ErrProductNotFound) {
+			return err
+		}
+		if attempt == attempts-1 {
+			break
+		}
+
+		delay := baseDelay * time.Duration(1<<attempt)
+		jitter := time.Duration((rand.Float64()*0.4 - 0.2) * float64(delay))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay + jitter):
+		}
+	}
+	return err
+}
+
+func (r *FoodRepository) barcodeNotFoundRecently(barcode string) bool {
+	r.barcodeNegMu.Lock()
+	defer r.barcodeNegMu.Unlock()
+
+	expiresAt, ok := r.barcodeNegCache[barcode]
+	if !ok {
+		return false
+	}
+	if time.Now().After(expiresAt) {
+		delete(r.barcodeNegCache, barcode)
+		return false
+	}
+	return true
+}
+
+func (r *FoodRepository) rememberBarcodeNotFound(barcode string) {
+	r.barcodeNegMu.Lock()
+	defer r.barcodeNegMu.Unlock()
+	r.barcodeNegCache[barcode] = time.Now().Add(negativeBarcodeCacheTTL)
+}
@@ -3,9 +3,11 @@ package repository
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/example/calorie-tracker/internal/database"
+	"github.com/example/calorie-tracker/internal/metrics"
 	"github.com/example/calorie-tracker/internal/models"
 	"gorm.io/gorm"
 )
@@ -16,12 +18,34 @@ var (
 
 // FoodRepository handles food data persistence.
 type FoodRepository struct {
-	db *gorm.DB
+	db      *gorm.DB
+	metrics metrics.Recorder
+
+	barcodeProvider BarcodeProvider
+	barcodeMu       sync.Mutex
+	barcodeInFlight map[string]*barcodeCall
+	barcodeNegMu    sync.Mutex
+	barcodeNegCache map[string]time.Time
 }
 
-// NewFoodRepository creates a new FoodRepository.
-func NewFoodRepository() *FoodRepository {
-	return &FoodRepository{db: database.DB}
+// NewFoodRepository creates a new FoodRepository. A nil recorder falls
+// back to metrics.NoopRecorder, and a nil barcodeProvider falls back to
+// an OFFProvider with DefaultOFFConfig, so callers that don't wire one
+// or the other (e.g. tests) can pass nil.
+func NewFoodRepository(recorder metrics.Recorder, barcodeProvider BarcodeProvider) *FoodRepository {
+	if recorder == nil {
+		recorder = metrics.NewNoopRecorder()
+	}
+	if barcodeProvider == nil {
+		barcodeProvider = NewOFFProvider(DefaultOFFConfig())
+	}
+	return &FoodRepository{
+		db:              database.DB,
+		metrics:         recorder,
+		barcodeProvider: barcodeProvider,
+		barcodeInFlight: make(map[string]*barcodeCall),
+		barcodeNegCache: make(map[string]time.Time),
+	}
 }
 
 // Create adds a new food item.
@@ -52,7 +76,10 @@ func (r *FoodRepository) GetByBarcode(barcode string) (*models.Food, error) {
 // Search searches for foods by name or brand.
 // WARNING: This function has a SQL injection vulnerability for testing purposes.
 // In production code, always use parameterized queries.
+//taint:expect sql-injection source=query sink=gorm.Raw orm=gorm
 func (r *FoodRepository) Search(query string, limit int) ([]models.Food, error) {
+	defer metrics.StartTimer(r.metrics, "search")()
+
 	var foods []models.Food
 
 	// VULNERABILITY: SQL injection - user input directly in query string
@@ -65,6 +92,8 @@ func (r *FoodRepository) Search(query string, limit int) ([]models.Food, error)
 
 // SearchSafe searches for foods using parameterized query (secure version).
 func (r *FoodRepository) SearchSafe(query string, limit int) ([]models.Food, error) {
+	defer metrics.StartTimer(r.metrics, "search")()
+
 	var foods []models.Food
 	searchPattern := "%" + query + "%"
 	err := r.db.
@@ -74,6 +103,42 @@ func (r *FoodRepository) SearchSafe(query string, limit int) ([]models.Food, err
 	return foods, err
 }
 
+// SearchByName searches for foods whose name matches exactly.
+// WARNING: This function has a SQL injection vulnerability for testing purposes.
+// In production code, always use parameterized queries.
+//taint:expect sql-injection source=name sink=gorm.Where orm=gorm
+func (r *FoodRepository) SearchByName(name string) ([]models.Food, error) {
+	var foods []models.Food
+
+	// VULNERABILITY: SQL injection - condition built via string concatenation
+	// and passed to Where, instead of using GORM's parameterized placeholders.
+	err := r.db.Where("name = '" + name + "'").Find(&foods).Error
+	return foods, err
+}
+
+// SearchByNameSafe searches for foods whose name matches exactly (secure version).
+func (r *FoodRepository) SearchByNameSafe(name string) ([]models.Food, error) {
+	var foods []models.Food
+	err := r.db.Where("name = ?", name).Find(&foods).Error
+	return foods, err
+}
+
+// GetByRawID retrieves a food item by an ID taken directly from user input.
+// WARNING: This function has a SQL injection vulnerability for testing purposes.
+// In production code, always use parameterized queries.
+//taint:expect sql-injection source=userInput sink=gorm.First orm=gorm
+func (r *FoodRepository) GetByRawID(userInput string) (*models.Food, error) {
+	var food models.Food
+
+	// VULNERABILITY: struct-scanned First() conditioned on raw user input
+	// rather than a parameter placeholder.
+	err := r.db.First(&food, userInput).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFoodNotFound
+	}
+	return &food, err
+}
+
 // Update updates a food item.
 func (r *FoodRepository) Update(food *models.Food) error {
 	return r.db.Save(food).Error
@@ -86,6 +151,8 @@ func (r *FoodRepository) Delete(id uint) error {
 
 // GetPopular returns the most frequently logged foods.
 func (r *FoodRepository) GetPopular(limit int) ([]models.Food, error) {
+	defer metrics.StartTimer(r.metrics, "get_popular")()
+
 	var foods []models.Food
 	err := r.db.
 		Select("foods.*, COUNT(food_entries.id) as entry_count").
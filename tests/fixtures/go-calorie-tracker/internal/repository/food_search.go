@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/example/calorie-tracker/internal/metrics"
+	"github.com/example/calorie-tracker/internal/models"
+)
+
+// SearchOptions tunes SearchRanked's scoring.
+type SearchOptions struct {
+	// MinSimilarity is the minimum pg_trgm similarity() (0-1) a row needs
+	// to match on trigram similarity alone, independent of the
+	// full-text-search side of the OR. Ignored on the SQLite FTS5 path.
+	MinSimilarity float64
+
+	// PopularityWeight is how much a food's food_entries count (the same
+	// join GetPopular uses), capped at 100 entries, contributes to score
+	// relative to the text-relevance terms.
+	PopularityWeight float64
+}
+
+// DefaultSearchOptions returns the SearchOptions SearchRanked uses when
+// the caller doesn't need anything unusual.
+func DefaultSearchOptions() SearchOptions {
+	return SearchOptions{
+		MinSimilarity:    0.2,
+		PopularityWeight: 0.1,
+	}
+}
+
+// ScoredFood is a Food annotated with its SearchRanked relevance score,
+// highest first.
+type ScoredFood struct {
+	models.Food
+	Score float64 `json:"score"`
+}
+
+// SearchRanked ranks foods against query by full-text relevance, trigram
+// similarity (typo tolerance) and popularity, instead of Search's
+// unindexed `LIKE '%q%'`. It uses PostgreSQL's pg_trgm/tsvector when
+// connected to Postgres, and SQLite's FTS5 otherwise -- call
+// MigrateSearchIndex once beforehand to create whichever of those the
+// connected driver needs.
+func (r *FoodRepository) SearchRanked(query string, limit int, opts SearchOptions) ([]ScoredFood, error) {
+	defer metrics.StartTimer(r.metrics, "search_ranked")()
+
+	if r.db.Dialector.Name() == "postgres" {
+		return r.searchRankedPostgres(query, limit, opts)
+	}
+	return r.searchRankedSQLite(query, limit)
+}
+
+func (r *FoodRepository) searchRankedPostgres(query string, limit int, opts SearchOptions) ([]ScoredFood, error) {
+	var results []ScoredFood
+
+	err := r.db.Raw(`
+		SELECT foods.*,
+			(ts_rank_cd(foods.tsv, plainto_tsquery('english', ?)) * 0.6
+				+ similarity(foods.name, ?) * 0.3
+				+ LEAST(COUNT(food_entries.id), 100) / 100.0 * ?) AS score
+		FROM foods
+		LEFT JOIN food_entries ON foods.id = food_entries.food_id
+		WHERE foods.tsv @@ plainto_tsquery('english', ?) OR similarity(foods.name, ?) > ?
+		GROUP BY foods.id
+		ORDER BY score DESC
+		LIMIT ?`,
+		query, query, opts.PopularityWeight, query, query, opts.MinSimilarity, limit,
+	).Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search foods: %w", err)
+	}
+
+	return results, nil
+}
+
+// searchRankedSQLite ranks against the foods_fts FTS5 table MigrateSearchIndex
+// creates. FTS5's bm25() returns lower-is-better, so it's negated to sort
+// consistently with the Postgres path's higher-is-better score. There's no
+// popularity term here: FTS5 has no equivalent to similarity()'s threshold
+// OR, so folding in a food_entries join would mean scoring rows that never
+// matched the query at all just because they're popular.
+func (r *FoodRepository) searchRankedSQLite(query string, limit int) ([]ScoredFood, error) {
+	var results []ScoredFood
+
+	err := r.db.Raw(`
+		SELECT foods.*, bm25(foods_fts) * -1 AS score
+		FROM foods_fts
+		JOIN foods ON foods.id = foods_fts.rowid
+		WHERE foods_fts MATCH ?
+		ORDER BY score DESC
+		LIMIT ?`,
+		query, limit,
+	).Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search foods: %w", err)
+	}
+
+	return results, nil
+}
+
+// MigrateSearchIndex creates the full-text search index SearchRanked
+// depends on: a generated tsvector column plus GIN trigram indexes on
+// Postgres, or an FTS5 virtual table kept in sync by triggers on SQLite.
+// gorm's AutoMigrate can't express either, so this runs once, after
+// database.Migrate, as its own step.
+func (r *FoodRepository) MigrateSearchIndex() error {
+	if r.db.Dialector.Name() == "postgres" {
+		return r.migrateSearchIndexPostgres()
+	}
+	return r.migrateSearchIndexSQLite()
+}
+
+func (r *FoodRepository) migrateSearchIndexPostgres() error {
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`ALTER TABLE foods ADD COLUMN IF NOT EXISTS tsv tsvector
+			GENERATED ALWAYS AS (to_tsvector('english', coalesce(name, '') || ' ' || coalesce(brand, ''))) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_foods_tsv ON foods USING GIN (tsv)`,
+		`CREATE INDEX IF NOT EXISTS idx_foods_name_trgm ON foods USING GIN (name gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_foods_brand_trgm ON foods USING GIN (brand gin_trgm_ops)`,
+	}
+
+	for _, stmt := range statements {
+		if err := r.db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to migrate search index: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *FoodRepository) migrateSearchIndexSQLite() error {
+	statements := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS foods_fts USING fts5(name, brand, content='foods', content_rowid='id')`,
+		`CREATE TRIGGER IF NOT EXISTS foods_fts_insert AFTER INSERT ON foods BEGIN
+			INSERT INTO foods_fts(rowid, name, brand) VALUES (new.id, new.name, new.brand);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS foods_fts_delete AFTER DELETE ON foods BEGIN
+			INSERT INTO foods_fts(foods_fts, rowid, name, brand) VALUES ('delete', old.id, old.name, old.brand);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS foods_fts_update AFTER UPDATE ON foods BEGIN
+			INSERT INTO foods_fts(foods_fts, rowid, name, brand) VALUES ('delete', old.id, old.name, old.brand);
+			INSERT INTO foods_fts(rowid, name, brand) VALUES (new.id, new.name, new.brand);
+		END`,
+	}
+
+	for _, stmt := range statements {
+		if err := r.db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to migrate search index: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// BackfillSearchIndex populates the search index for foods rows that
+// predate MigrateSearchIndex. Postgres's tsv column is GENERATED ALWAYS
+// AS ... STORED, so existing rows already have it computed and there's
+// nothing to do; SQLite's foods_fts is a separate contentless-adjacent
+// table that only the insert/update/delete triggers keep in sync, so it
+// needs an explicit one-time copy.
+func (r *FoodRepository) BackfillSearchIndex() error {
+	if r.db.Dialector.Name() == "postgres" {
+		return nil
+	}
+
+	err := r.db.Exec(`
+		INSERT INTO foods_fts(rowid, name, brand)
+		SELECT id, name, brand FROM foods
+		WHERE id NOT IN (SELECT rowid FROM foods_fts)`).Error
+	if err != nil {
+		return fmt.Errorf("failed to backfill search index: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/example/calorie-tracker/internal/database"
+	"github.com/example/calorie-tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrPreferenceNotFound = errors.New("notification preference not found")
+
+// defaultEventTypes is every EventType EnsureDefaults seeds a row for when
+// a user has none yet.
+var defaultEventTypes = []models.EventType{
+	models.EventMealLogged,
+	models.EventGoalReached,
+	models.EventGoalMissed,
+	models.EventWeeklySummary,
+}
+
+// NotificationPreferenceRepository handles notification preference persistence.
+type NotificationPreferenceRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationPreferenceRepository creates a new NotificationPreferenceRepository.
+func NewNotificationPreferenceRepository() *NotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{db: database.DB}
+}
+
+// GetByUser returns every NotificationPreference row a user has, unordered
+// by event type.
+func (r *NotificationPreferenceRepository) GetByUser(userID uint) ([]models.NotificationPreference, error) {
+	var prefs []models.NotificationPreference
+	err := r.db.Where("user_id = ?", userID).Find(&prefs).Error
+	return prefs, err
+}
+
+// GetByUserAndEvent returns a single NotificationPreference.
+func (r *NotificationPreferenceRepository) GetByUserAndEvent(userID uint, eventType models.EventType) (*models.NotificationPreference, error) {
+	var pref models.NotificationPreference
+	err := r.db.Where("user_id = ? AND event_type = ?", userID, eventType).First(&pref).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPreferenceNotFound
+	}
+	return &pref, err
+}
+
+// Upsert creates pref if no row exists for its (UserID, EventType), or
+// updates the existing row's Channel/quiet-hours otherwise.
+func (r *NotificationPreferenceRepository) Upsert(pref *models.NotificationPreference) error {
+	existing, err := r.GetByUserAndEvent(pref.UserID, pref.EventType)
+	if errors.Is(err, ErrPreferenceNotFound) {
+		return r.db.Create(pref).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	existing.Channel = pref.Channel
+	existing.QuietHoursStart = pref.QuietHoursStart
+	existing.QuietHoursEnd = pref.QuietHoursEnd
+	return r.db.Save(existing).Error
+}
+
+// EnsureDefaults creates a ChannelEmail NotificationPreference row for
+// every defaultEventTypes the user doesn't already have one for, and
+// returns the user's complete, up-to-date preference set.
+func (r *NotificationPreferenceRepository) EnsureDefaults(userID uint) ([]models.NotificationPreference, error) {
+	existing, err := r.GetByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	have := make(map[models.EventType]bool, len(existing))
+	for _, pref := range existing {
+		have[pref.EventType] = true
+	}
+
+	for _, eventType := range defaultEventTypes {
+		if have[eventType] {
+			continue
+		}
+		pref := &models.NotificationPreference{
+			UserID:    userID,
+			EventType: eventType,
+			Channel:   models.ChannelEmail,
+		}
+		if err := r.db.Create(pref).Error; err != nil {
+			return nil, err
+		}
+		existing = append(existing, *pref)
+	}
+
+	return existing, nil
+}
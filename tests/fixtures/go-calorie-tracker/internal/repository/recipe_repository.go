@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/example/calorie-tracker/internal/database"
+	"github.com/example/calorie-tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrRecipeNotFound = errors.New("recipe not found")
+
+// RecipeRepository handles recipe data persistence.
+type RecipeRepository struct {
+	db *gorm.DB
+}
+
+// NewRecipeRepository creates a new RecipeRepository.
+func NewRecipeRepository() *RecipeRepository {
+	return &RecipeRepository{db: database.DB}
+}
+
+// Create adds a new recipe along with its ingredients.
+func (r *RecipeRepository) Create(recipe *models.Recipe) error {
+	return r.db.Create(recipe).Error
+}
+
+// GetByID retrieves a recipe by ID with its ingredients and their foods preloaded.
+func (r *RecipeRepository) GetByID(id uint) (*models.Recipe, error) {
+	var recipe models.Recipe
+	err := r.db.
+		Preload("Ingredients.Food").
+		First(&recipe, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecipeNotFound
+	}
+	return &recipe, err
+}
+
+// Search returns recipes matching query that are visible to userID:
+// userID's own recipes regardless of visibility, public recipes from
+// anyone, and friends-visibility recipes userID has been explicitly
+// granted access to via Share.
+func (r *RecipeRepository) Search(userID uint, query string, limit int) ([]models.Recipe, error) {
+	var recipes []models.Recipe
+	pattern := "%" + query + "%"
+
+	sharedRecipeIDs := r.db.Model(&models.RecipeShare{}).Select("recipe_id").Where("user_id = ?", userID)
+
+	err := r.db.
+		Preload("Ingredients.Food").
+		Where("name LIKE ?", pattern).
+		Where("user_id = ? OR visibility = ? OR id IN (?)", userID, models.RecipeVisibilityPublic, sharedRecipeIDs).
+		Limit(limit).
+		Find(&recipes).Error
+
+	return recipes, err
+}
+
+// CreateVersion persists updated as a new, immutable version superseding
+// previous: Version increments and PreviousVersionID points back at
+// previous, rather than previous being mutated in place, so a Meal or
+// FoodEntry already logged against previous keeps referencing the
+// ingredients and nutrition that were true when it was logged.
+func (r *RecipeRepository) CreateVersion(previous, updated *models.Recipe) error {
+	updated.UserID = previous.UserID
+	updated.Version = previous.Version + 1
+	updated.PreviousVersionID = &previous.ID
+	return r.db.Create(updated).Error
+}
+
+// Share grants userID access to recipeID, the access RecipeVisibilityFriends
+// recipes check in Search. It's idempotent: sharing with the same user
+// twice is a no-op rather than an error.
+func (r *RecipeRepository) Share(recipeID, userID uint) error {
+	share := models.RecipeShare{RecipeID: recipeID, UserID: userID}
+	return r.db.Where(share).FirstOrCreate(&share).Error
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/example/calorie-tracker/internal/database"
+	"github.com/example/calorie-tracker/internal/models"
+	"github.com/example/calorie-tracker/internal/repository"
+	"gorm.io/gorm"
+)
+
+// RecipeService handles recipe creation and logging a recipe's
+// ingredients as food entries in one action.
+type RecipeService struct {
+	db   *gorm.DB
+	repo *repository.RecipeRepository
+}
+
+// NewRecipeService creates a new RecipeService. A nil repo falls back to
+// repository.NewRecipeRepository, so callers that don't wire one (e.g.
+// tests) can pass nil.
+func NewRecipeService(repo *repository.RecipeRepository) *RecipeService {
+	if repo == nil {
+		repo = repository.NewRecipeRepository()
+	}
+	return &RecipeService{db: database.DB, repo: repo}
+}
+
+// ScaleTo returns the multiplier to apply to recipe's ingredient
+// quantities to yield servings servings instead of recipe.Servings.
+func (s *RecipeService) ScaleTo(recipe *models.Recipe, servings float64) float64 {
+	if recipe.Servings <= 0 {
+		return 1
+	}
+	return servings / recipe.Servings
+}
+
+// RecipeNutrition is a recipe's aggregate nutrition -- the same SUM
+// projection recalculateDailyTotals uses for a day's food entries,
+// applied here to a recipe's ingredients instead.
+type RecipeNutrition struct {
+	TotalCalories int
+	TotalProtein  float64
+	TotalCarbs    float64
+	TotalFat      float64
+}
+
+// NutritionPerServing returns recipe's total nutrition, computed from its
+// ingredients joined against foods, divided across its Servings.
+func (s *RecipeService) NutritionPerServing(recipe *models.Recipe) (RecipeNutrition, error) {
+	var agg RecipeNutrition
+
+	err := s.db.Model(&models.RecipeIngredient{}).
+		Select(`
+			COALESCE(SUM(foods.calories * recipe_ingredients.quantity), 0) as total_calories,
+			COALESCE(SUM(foods.protein * recipe_ingredients.quantity), 0) as total_protein,
+			COALESCE(SUM(foods.carbs * recipe_ingredients.quantity), 0) as total_carbs,
+			COALESCE(SUM(foods.fat * recipe_ingredients.quantity), 0) as total_fat
+		`).
+		Joins("JOIN foods ON foods.id = recipe_ingredients.food_id").
+		Where("recipe_ingredients.recipe_id = ?", recipe.ID).
+		Scan(&agg).Error
+	if err != nil {
+		return RecipeNutrition{}, fmt.Errorf("failed to compute recipe nutrition: %w", err)
+	}
+
+	servings := recipe.Servings
+	if servings <= 0 {
+		servings = 1
+	}
+
+	return RecipeNutrition{
+		TotalCalories: int(float64(agg.TotalCalories) / servings),
+		TotalProtein:  agg.TotalProtein / servings,
+		TotalCarbs:    agg.TotalCarbs / servings,
+		TotalFat:      agg.TotalFat / servings,
+	}, nil
+}
+
+// LogRecipe expands recipe's ingredients into one FoodEntry per
+// ingredient, scaled via ScaleTo to servings, all inside a single
+// transaction -- the same all-or-nothing guarantee BulkLogEntries gives
+// a batch of unrelated entries, applied here to one recipe's ingredients.
+func (s *RecipeService) LogRecipe(userID uint, recipe *models.Recipe, servings float64, mealID *uint) ([]models.FoodEntry, error) {
+	scale := s.ScaleTo(recipe, servings)
+	loggedAt := time.Now()
+
+	entries := make([]models.FoodEntry, 0, len(recipe.Ingredients))
+	for _, ing := range recipe.Ingredients {
+		entries = append(entries, models.FoodEntry{
+			UserID:   userID,
+			FoodID:   ing.FoodID,
+			MealID:   mealID,
+			Quantity: ing.Quantity * scale,
+			LoggedAt: loggedAt,
+			Notes:    fmt.Sprintf("From recipe %q", recipe.Name),
+		})
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range entries {
+			if err := tx.Create(&entries[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to log recipe: %w", err)
+	}
+
+	return entries, nil
+}
+
+// CloneAsMeal creates a Meal for userID pre-populated with one FoodEntry
+// per recipe ingredient, unscaled (i.e. as if logging exactly
+// recipe.Servings servings) and linked to the new Meal.
+func (s *RecipeService) CloneAsMeal(userID uint, recipe *models.Recipe, mealType models.MealType) (*models.Meal, error) {
+	meal := &models.Meal{
+		UserID:   userID,
+		Type:     mealType,
+		Name:     recipe.Name,
+		LoggedAt: time.Now(),
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(meal).Error; err != nil {
+			return err
+		}
+
+		for _, ing := range recipe.Ingredients {
+			entry := models.FoodEntry{
+				UserID:   userID,
+				FoodID:   ing.FoodID,
+				MealID:   &meal.ID,
+				Quantity: ing.Quantity,
+				LoggedAt: meal.LoggedAt,
+				Notes:    fmt.Sprintf("From recipe %q", recipe.Name),
+			}
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone recipe as meal: %w", err)
+	}
+
+	return meal, nil
+}
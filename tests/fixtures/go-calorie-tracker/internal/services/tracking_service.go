@@ -1,13 +1,19 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"sync"
+	"log"
 	"time"
 
 	"github.com/example/calorie-tracker/internal/database"
+	"github.com/example/calorie-tracker/internal/eventqueue"
+	"github.com/example/calorie-tracker/internal/metrics"
 	"github.com/example/calorie-tracker/internal/models"
+	"github.com/example/calorie-tracker/internal/notifications"
+	"github.com/example/calorie-tracker/internal/repository"
 	"gorm.io/gorm"
 )
 
@@ -16,18 +22,145 @@ var (
 	ErrEntryNotFound = errors.New("food entry not found")
 )
 
-// Global stats counter - intentional race condition for testing
-var totalEntriesLogged int
-var statsLock sync.Mutex
-
 // TrackingService handles food logging and daily tracking.
 type TrackingService struct {
-	db *gorm.DB
+	db         *gorm.DB
+	dispatcher notifications.NotificationDispatcher
+	metrics    metrics.Recorder
+	queue      *eventqueue.EventQueue
+	foodRepo   *repository.FoodRepository
+}
+
+// NewTrackingService creates a new TrackingService. A nil dispatcher
+// falls back to notifications.NoopDispatcher, a nil recorder falls back
+// to metrics.NoopRecorder, and a nil foodRepo falls back to
+// repository.NewFoodRepository's own defaults, so callers that don't
+// wire one or more of these (e.g. tests) can pass nil.
+//
+// NewTrackingService also starts a self-contained, in-memory EventQueue
+// bound to s.HandleJob so the service is usable out of the box; a caller
+// that wants a durable, Redis-backed queue shared across replicas should
+// call UseEventQueue right after construction, before any real traffic
+// arrives.
+func NewTrackingService(dispatcher notifications.NotificationDispatcher, recorder metrics.Recorder, foodRepo *repository.FoodRepository) *TrackingService {
+	if dispatcher == nil {
+		dispatcher = notifications.NewNoopDispatcher()
+	}
+	if recorder == nil {
+		recorder = metrics.NewNoopRecorder()
+	}
+	if foodRepo == nil {
+		foodRepo = repository.NewFoodRepository(recorder, nil)
+	}
+
+	s := &TrackingService{db: database.DB, dispatcher: dispatcher, metrics: recorder, foodRepo: foodRepo}
+
+	queue := eventqueue.NewEventQueue(eventqueue.NewMemoryStore(), s.HandleJob, eventqueue.Config{})
+	if err := queue.Start(context.Background()); err != nil {
+		log.Printf("failed to start default event queue: %v", err)
+	}
+	s.queue = queue
+
+	return s
+}
+
+// UseEventQueue stops s's current EventQueue (the self-contained
+// in-memory one NewTrackingService starts by default) and swaps in q.
+// Call it once, right after construction, before the service handles any
+// real traffic.
+func (s *TrackingService) UseEventQueue(q *eventqueue.EventQueue) {
+	s.queue.Stop()
+	s.queue = q
+}
+
+// HandleJob dispatches a durable eventqueue.Job to its TrackingService
+// handler by Type, decoding Payload against the matching payload struct.
+// It's exported so main can pass it to eventqueue.NewEventQueue.
+func (s *TrackingService) HandleJob(job *eventqueue.Job) error {
+	switch job.Type {
+	case eventqueue.JobRecalcDailyLog:
+		var payload eventqueue.RecalcDailyLog
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return fmt.Errorf("failed to unmarshal recalc_daily_log payload: %w", err)
+		}
+		return s.handleRecalcDailyLog(payload)
+	case eventqueue.JobUpdateStats:
+		var payload eventqueue.UpdateStats
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return fmt.Errorf("failed to unmarshal update_stats payload: %w", err)
+		}
+		return s.handleUpdateStats(payload)
+	default:
+		return fmt.Errorf("unknown event queue job type %q", job.Type)
+	}
+}
+
+// handleRecalcDailyLog gets or creates the DailyLog for payload.UserID on
+// payload.Date and recalculates its totals -- the durable replacement
+// for the old fire-and-forget updateDailyLogAsync goroutine.
+func (s *TrackingService) handleRecalcDailyLog(payload eventqueue.RecalcDailyLog) error {
+	dateOnly := time.Date(payload.Date.Year(), payload.Date.Month(), payload.Date.Day(), 0, 0, 0, 0, payload.Date.Location())
+
+	var dailyLog models.DailyLog
+	err := s.db.Where("user_id = ? AND date = ?", payload.UserID, dateOnly).First(&dailyLog).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var user models.User
+		if err := s.db.First(&user, payload.UserID).Error; err != nil {
+			return fmt.Errorf("failed to load user %d for daily log: %w", payload.UserID, err)
+		}
+
+		dailyLog = models.DailyLog{
+			UserID:       payload.UserID,
+			Date:         dateOnly,
+			GoalCalories: user.DailyGoal,
+		}
+		if err := s.db.Create(&dailyLog).Error; err != nil {
+			return fmt.Errorf("failed to create daily log: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to load daily log: %w", err)
+	}
+
+	return s.recalculateDailyTotals(&dailyLog)
 }
 
-// NewTrackingService creates a new TrackingService.
-func NewTrackingService() *TrackingService {
-	return &TrackingService{db: database.DB}
+// handleUpdateStats is reserved for a future aggregate-stats job; there's
+// nothing to do yet.
+func (s *TrackingService) handleUpdateStats(payload eventqueue.UpdateStats) error {
+	return nil
+}
+
+// dispatchEvent publishes event through s.dispatcher, logging rather than
+// propagating a failure -- a notification-service outage shouldn't fail
+// the tracking operation that triggered it.
+func (s *TrackingService) dispatchEvent(event notifications.Event) {
+	if err := s.dispatcher.Dispatch(event); err != nil {
+		log.Printf("failed to dispatch %s notification for user %d: %v", event.Type, event.UserID, err)
+	}
+}
+
+// enqueueRecalc durably schedules a JobRecalcDailyLog for entry, replacing
+// the bare `go` goroutine that used to desync DailyLog permanently on a
+// crash between the two. It's called inside the same tx as entry's own
+// insert so the two roll back together on error, but that's not a true
+// outbox: eventqueue.MemoryStore and eventqueue.RedisStore both write
+// through (and push onto the live heap) immediately, ignoring tx, rather
+// than only after tx commits -- see eventqueue.Store's doc comment for
+// why that's an acceptable tradeoff here regardless.
+func (s *TrackingService) enqueueRecalc(tx *gorm.DB, entry *models.FoodEntry) error {
+	payload, err := json.Marshal(eventqueue.RecalcDailyLog{UserID: entry.UserID, Date: entry.LoggedAt})
+	if err != nil {
+		return fmt.Errorf("failed to marshal recalc_daily_log payload: %w", err)
+	}
+
+	job := &eventqueue.Job{
+		ID:        fmt.Sprintf("recalc-%d-%d", entry.UserID, entry.ID),
+		Type:      eventqueue.JobRecalcDailyLog,
+		Payload:   payload,
+		CreatedAt: time.Now().Unix(),
+	}
+
+	return s.queue.EnqueueTx(context.Background(), tx, job)
 }
 
 // LogFoodEntry logs a food entry for a user.
@@ -41,23 +174,33 @@ func (s *TrackingService) LogFoodEntry(userID, foodID uint, quantity float64, me
 		Notes:    notes,
 	}
 
-	if err := s.db.Create(entry).Error; err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return s.enqueueRecalc(tx, entry)
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to log food entry: %w", err)
 	}
 
-	// Update stats asynchronously
-	// VULNERABILITY: Race condition - accessing global var without proper sync
-	go func() {
-		totalEntriesLogged++
-	}()
+	s.metrics.IncEntriesLogged()
 
-	// Update daily log
-	go s.updateDailyLogAsync(userID, entry.LoggedAt)
+	go s.dispatchEvent(notifications.Event{
+		UserID: userID,
+		Type:   models.EventMealLogged,
+		Title:  "Food logged",
+		Body:   fmt.Sprintf("Logged %.0fg toward today's total", quantity),
+	})
 
 	return entry, nil
 }
 
-// LogFoodEntrySafe is the thread-safe version.
+// LogFoodEntrySafe logs a food entry for a user. It used to be the
+// thread-safe alternative to LogFoodEntry's racy stats update; now that
+// both go through metrics.Recorder (safe for concurrent use by
+// construction), the two are equivalent and kept separate only because
+// existing callers reference both names.
 func (s *TrackingService) LogFoodEntrySafe(userID, foodID uint, quantity float64, mealID *uint, notes string) (*models.FoodEntry, error) {
 	entry := &models.FoodEntry{
 		UserID:   userID,
@@ -68,50 +211,53 @@ func (s *TrackingService) LogFoodEntrySafe(userID, foodID uint, quantity float64
 		Notes:    notes,
 	}
 
-	if err := s.db.Create(entry).Error; err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return s.enqueueRecalc(tx, entry)
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to log food entry: %w", err)
 	}
 
-	// Thread-safe stats update
-	go func() {
-		statsLock.Lock()
-		defer statsLock.Unlock()
-		totalEntriesLogged++
-	}()
+	s.metrics.IncEntriesLogged()
 
-	go s.updateDailyLogAsync(userID, entry.LoggedAt)
+	go s.dispatchEvent(notifications.Event{
+		UserID: userID,
+		Type:   models.EventMealLogged,
+		Title:  "Food logged",
+		Body:   fmt.Sprintf("Logged %.0fg toward today's total", quantity),
+	})
 
 	return entry, nil
 }
 
-// updateDailyLogAsync updates the daily log asynchronously.
-func (s *TrackingService) updateDailyLogAsync(userID uint, date time.Time) {
-	dateOnly := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-
-	// Get or create daily log
-	var dailyLog models.DailyLog
-	err := s.db.Where("user_id = ? AND date = ?", userID, dateOnly).First(&dailyLog).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		// Get user's goal
-		var user models.User
-		if err := s.db.First(&user, userID).Error; err != nil {
-			return
-		}
-
-		dailyLog = models.DailyLog{
-			UserID:       userID,
-			Date:         dateOnly,
-			GoalCalories: user.DailyGoal,
-		}
-		s.db.Create(&dailyLog)
+// LogFoodEntryByBarcode resolves barcode to a Food via
+// FoodRepository.GetOrFetchByBarcode -- fetching and persisting it from
+// the configured BarcodeProvider on a local miss -- and logs it as a
+// food entry, so a client can scan a barcode without a separate
+// lookup-then-log round trip.
+func (s *TrackingService) LogFoodEntryByBarcode(ctx context.Context, userID uint, barcode string, quantity float64, mealID *uint, notes string) (*models.FoodEntry, error) {
+	food, _, err := s.foodRepo.GetOrFetchByBarcode(ctx, barcode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve barcode %s: %w", barcode, err)
 	}
 
-	// Calculate totals for the day
-	s.recalculateDailyTotals(&dailyLog)
+	return s.LogFoodEntry(userID, food.ID, quantity, mealID, notes)
 }
 
-// recalculateDailyTotals recalculates all totals for a daily log.
+// recalculateDailyTotals recalculates all totals for a daily log. If this
+// recalculation is the one that first brings the day's calories up to
+// GoalCalories, it dispatches an EventGoalReached notification.
+//
+// EventGoalMissed and EventWeeklySummary aren't fired from here: both
+// depend on a day having fully elapsed, so they belong in a scheduled job
+// (see JobsService.dailySummaryJob) rather than this per-entry
+// recalculation -- that wiring isn't done yet.
 func (s *TrackingService) recalculateDailyTotals(log *models.DailyLog) error {
+	defer metrics.StartTimer(s.metrics, "recalculate_daily_totals")()
+
 	type result struct {
 		TotalCalories int
 		TotalProtein  float64
@@ -139,12 +285,29 @@ func (s *TrackingService) recalculateDailyTotals(log *models.DailyLog) error {
 		return err
 	}
 
+	wasUnderGoal := log.GoalCalories > 0 && log.TotalCalories < log.GoalCalories
+
 	log.TotalCalories = r.TotalCalories
 	log.TotalProtein = r.TotalProtein
 	log.TotalCarbs = r.TotalCarbs
 	log.TotalFat = r.TotalFat
 
-	return s.db.Save(log).Error
+	if err := s.db.Save(log).Error; err != nil {
+		return err
+	}
+
+	s.metrics.IncDailyRecalc()
+
+	if wasUnderGoal && log.TotalCalories >= log.GoalCalories {
+		go s.dispatchEvent(notifications.Event{
+			UserID: log.UserID,
+			Type:   models.EventGoalReached,
+			Title:  "Daily goal reached",
+			Body:   fmt.Sprintf("You've hit your %d calorie goal for today", log.GoalCalories),
+		})
+	}
+
+	return nil
 }
 
 // CreateMeal creates a new meal.
@@ -161,6 +324,8 @@ func (s *TrackingService) CreateMeal(userID uint, mealType models.MealType, name
 		return nil, fmt.Errorf("failed to create meal: %w", err)
 	}
 
+	s.metrics.IncMealsCreated()
+
 	return meal, nil
 }
 
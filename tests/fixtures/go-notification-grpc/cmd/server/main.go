@@ -2,17 +2,24 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/example/notification-service/internal/broker"
 	"github.com/example/notification-service/internal/interceptors"
+	"github.com/example/notification-service/internal/metrics"
 	"github.com/example/notification-service/internal/queue"
 	"github.com/example/notification-service/internal/server"
 	"github.com/example/notification-service/internal/store"
+	"github.com/example/notification-service/internal/transport"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 )
@@ -20,7 +27,17 @@ import (
 func main() {
 	// Configuration
 	port := getEnv("GRPC_PORT", "50051")
+	metricsPort := getEnv("METRICS_PORT", "9090")
 	redisAddr := getEnv("REDIS_ADDR", "localhost:6379")
+	queueDSN := getEnv("QUEUE_POSTGRES_DSN", "")
+
+	// Collectors is shared between the broker (which reports dropped
+	// notifications as they happen) and the notification server (which
+	// reports send outcomes/duration and active subscribers), and
+	// registered against its own Registry so /metrics doesn't also serve
+	// the Go runtime's default collectors.
+	metricsRegistry := prometheus.NewRegistry()
+	collectors := metrics.New(metricsRegistry)
 
 	// Initialize store
 	var notificationStore *store.NotificationStore
@@ -39,14 +56,42 @@ func main() {
 	}
 	defer notificationStore.Close()
 
+	// Initialize the scheduled-notification queue's durable store
+	var queueStore queue.Store
+	if queueDSN != "" {
+		pgStore, err := queue.NewPostgresStore(queueDSN)
+		if err != nil {
+			log.Printf("Failed to connect to queue Postgres store, using in-memory: %v", err)
+			queueStore = queue.NewMemoryStore()
+		} else if err := pgStore.Migrate(context.Background()); err != nil {
+			log.Printf("Failed to migrate queue Postgres store, using in-memory: %v", err)
+			queueStore = queue.NewMemoryStore()
+		} else {
+			defer pgStore.Close()
+			queueStore = pgStore
+		}
+	} else {
+		queueStore = queue.NewMemoryStore()
+	}
+
+	// Initialize the pub/sub broker behind StreamNotifications
+	notifBroker, err := brokerFromEnv(collectors)
+	if err != nil {
+		log.Printf("Failed to initialize Postgres broker, falling back to in-memory: %v", err)
+		notifBroker = nil
+	}
+
 	// Initialize notification server
 	notifServer := server.NewNotificationServer(
 		notificationStore,
 		nil, // Queue will be set up below
+		transportRegistryFromEnv(),
+		notifBroker,
+		server.WithMetrics(collectors),
 	)
 
 	// Initialize queue with handler
-	notifQueue := queue.NewNotificationQueue(func(n *queue.Notification) error {
+	notifQueue := queue.NewNotificationQueue(queueStore, func(n *queue.Notification) error {
 		// Convert to server notification and send
 		serverNotif := &server.Notification{
 			ID:        n.ID,
@@ -67,19 +112,30 @@ func main() {
 			Priority: serverNotif.Priority,
 		})
 		return err
+	}, queue.NotificationQueueConfig{
+		DeadLetterHandler: func(n *queue.Notification, err error) {
+			log.Printf("notification %s dead-lettered: %v", n.ID, err)
+		},
 	})
 
 	// Start queue processor
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	notifQueue.Start(ctx)
+	if err := notifQueue.Start(ctx); err != nil {
+		log.Fatalf("Failed to start notification queue: %v", err)
+	}
 	defer notifQueue.Stop()
 
-	// Create gRPC server with interceptors
+	// Create gRPC server with interceptors. The resolved interceptor
+	// chain per RPC and its auth-coverage verdict are captured in
+	// api/interceptor_chains.json for interceptor-resolution fixture
+	// coverage.
+	//grpc:expect artifact=api/interceptor_chains.json
 	grpcServer := grpc.NewServer(
 		grpc.ChainUnaryInterceptor(
 			interceptors.RecoveryUnaryInterceptor,
 			interceptors.LoggingUnaryInterceptor,
+			interceptors.MetricsUnaryInterceptor(collectors),
 			interceptors.RateLimitUnaryInterceptor(100),
 		),
 		grpc.ChainStreamInterceptor(
@@ -107,6 +163,21 @@ func main() {
 		}
 	}()
 
+	// Start the /metrics and /health HTTP server in its own goroutine,
+	// alongside the gRPC listener.
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+	metricsMux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(notifServer.Health())
+	})
+	go func() {
+		log.Printf("metrics/health server listening on port %s", metricsPort)
+		if err := http.ListenAndServe(fmt.Sprintf(":%s", metricsPort), metricsMux); err != nil {
+			log.Printf("metrics/health server stopped: %v", err)
+		}
+	}()
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -123,3 +194,73 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// transportRegistryFromEnv builds a transport.Registry from whichever
+// provider env vars are set, leaving a type unregistered (falling back
+// to transport.FakeTransport, see server.NewNotificationServer) if
+// neither its dedicated provider nor WEBHOOK_URL is configured.
+func transportRegistryFromEnv() *transport.Registry {
+	var cfg transport.Config
+
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		cfg.SMTP = &transport.SMTPConfig{
+			Host:     host,
+			Port:     getEnv("SMTP_PORT", "587"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		}
+	}
+
+	if fcmKey, apnsToken := os.Getenv("FCM_SERVER_KEY"), os.Getenv("APNS_AUTH_TOKEN"); fcmKey != "" || apnsToken != "" {
+		cfg.Push = &transport.PushConfig{
+			FCM:  transport.FCMConfig{ServerKey: fcmKey},
+			APNS: transport.APNSConfig{BundleID: os.Getenv("APNS_BUNDLE_ID"), AuthToken: apnsToken},
+		}
+	}
+
+	if sid := os.Getenv("TWILIO_ACCOUNT_SID"); sid != "" {
+		cfg.SMS = &transport.SMSConfig{
+			Endpoint:   getEnv("TWILIO_ENDPOINT", fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", sid)),
+			AccountSID: sid,
+			AuthToken:  os.Getenv("TWILIO_AUTH_TOKEN"),
+			From:       os.Getenv("TWILIO_FROM"),
+		}
+	}
+
+	if url := os.Getenv("WEBHOOK_URL"); url != "" {
+		cfg.Webhook = &transport.WebhookConfig{URL: url}
+	}
+
+	if cfg.SMTP == nil && cfg.Push == nil && cfg.SMS == nil && cfg.Webhook == nil {
+		// No provider configured at all; let NewNotificationServer fall
+		// back to a FakeTransport for every channel instead of a
+		// Registry that errors on every send.
+		return nil
+	}
+
+	return transport.NewRegistryFromConfig(cfg)
+}
+
+// brokerFromEnv builds a broker.PostgresBroker from BROKER_POSTGRES_DSN
+// if set, migrating its outbox table, so StreamNotifications survives a
+// restart and fans out across multiple server instances. A nil, nil
+// return (no env var set) leaves server.NewNotificationServer to fall
+// back to its in-process broker.MemoryBroker. m records every dropped
+// notification (a full subscriber channel) regardless of which broker
+// ends up in use.
+func brokerFromEnv(m *metrics.Collectors) (broker.Broker, error) {
+	dsn := os.Getenv("BROKER_POSTGRES_DSN")
+	if dsn == "" {
+		return nil, nil
+	}
+
+	b, err := broker.NewPostgresBroker(dsn, broker.WithDropHook(m.RecordDropped))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to broker Postgres: %w", err)
+	}
+	if err := b.Migrate(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to migrate notifications_outbox: %w", err)
+	}
+	return b, nil
+}
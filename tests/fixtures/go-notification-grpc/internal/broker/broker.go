@@ -0,0 +1,42 @@
+// Package broker delivers notifications to StreamNotifications
+// subscribers. The in-process subscribers map server.NotificationServer
+// used to own directly loses every pending notification on restart and
+// can't fan out across multiple server instances; Broker abstracts that
+// fan-out behind an interface so a single-process deployment can keep
+// MemoryBroker while a multi-instance one switches to PostgresBroker
+// without any server.go changes.
+package broker
+
+import "context"
+
+// Notification is the subset of server.Notification a Broker needs to
+// publish and replay, duplicated for the same package-isolation reason
+// as Notification in internal/store, internal/queue and
+// internal/transport. ID must be monotonically sortable (server's
+// generateID, a decimal nanosecond timestamp, already is) since
+// Subscribe's gap-fill compares IDs with ">".
+type Notification struct {
+	ID        string
+	UserID    string
+	Type      int32
+	Title     string
+	Body      string
+	Data      map[string]string
+	Priority  int32
+	CreatedAt int64
+}
+
+// Broker fans notifications out to StreamNotifications subscribers.
+type Broker interface {
+	// Publish makes n visible to every current and future Subscribe call
+	// for n.UserID.
+	Publish(ctx context.Context, n *Notification) error
+
+	// Subscribe returns a channel of notifications for userID. If
+	// sinceID is non-empty, every durably-stored notification with
+	// ID > sinceID is replayed on the channel before live ones, so a
+	// reconnecting client resumes without loss. The channel is closed,
+	// and the returned cleanup func is a no-op to call more than once,
+	// when ctx is done.
+	Subscribe(ctx context.Context, userID string, sinceID string) (<-chan *Notification, func(), error)
+}
@@ -0,0 +1,91 @@
+package broker
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBroker fans notifications out to in-process subscriber channels,
+// the behavior server.NotificationServer used to implement directly.
+// It keeps no history, so a sinceID passed to Subscribe is ignored:
+// there's nothing durable to replay, and nothing to fan out to other
+// instances. Use PostgresBroker where either of those matters.
+type MemoryBroker struct {
+	mu          sync.Mutex
+	subscribers map[string][]chan *Notification
+	onDrop      func(reason string)
+}
+
+// Option configures optional MemoryBroker behavior.
+type Option func(*MemoryBroker)
+
+// WithDropHook calls fn with a reason (e.g. "subscriber_channel_full")
+// whenever Publish drops a notification instead of delivering it, so a
+// caller can feed that into its own metrics.Collectors.RecordDropped
+// without MemoryBroker importing internal/metrics itself.
+func WithDropHook(fn func(reason string)) Option {
+	return func(b *MemoryBroker) {
+		b.onDrop = fn
+	}
+}
+
+// NewMemoryBroker returns an empty MemoryBroker.
+func NewMemoryBroker(opts ...Option) *MemoryBroker {
+	b := &MemoryBroker{subscribers: make(map[string][]chan *Notification)}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Publish implements Broker.
+func (b *MemoryBroker) Publish(ctx context.Context, n *Notification) error {
+	b.mu.Lock()
+	channels := append([]chan *Notification(nil), b.subscribers[n.UserID]...)
+	b.mu.Unlock()
+
+	for _, ch := range channels {
+		select {
+		case ch <- n:
+		default:
+			// Subscriber's channel is full; drop rather than block the
+			// publisher on a slow reader.
+			if b.onDrop != nil {
+				b.onDrop("subscriber_channel_full")
+			}
+		}
+	}
+	return nil
+}
+
+// Subscribe implements Broker.
+func (b *MemoryBroker) Subscribe(ctx context.Context, userID string, sinceID string) (<-chan *Notification, func(), error) {
+	ch := make(chan *Notification, 16)
+
+	b.mu.Lock()
+	b.subscribers[userID] = append(b.subscribers[userID], ch)
+	b.mu.Unlock()
+
+	var once sync.Once
+	cleanup := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			channels := b.subscribers[userID]
+			for i, c := range channels {
+				if c == ch {
+					b.subscribers[userID] = append(channels[:i], channels[i+1:]...)
+					break
+				}
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	go func() {
+		<-ctx.Done()
+		cleanup()
+	}()
+
+	return ch, cleanup, nil
+}
@@ -0,0 +1,298 @@
+package broker
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const createOutboxTableSQL = `
+CREATE TABLE IF NOT EXISTS notifications_outbox (
+	id         TEXT PRIMARY KEY,
+	user_id    TEXT NOT NULL,
+	type       INTEGER NOT NULL,
+	title      TEXT NOT NULL,
+	body       TEXT NOT NULL,
+	data       JSONB,
+	priority   INTEGER NOT NULL,
+	created_at BIGINT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS notifications_outbox_user_id_idx ON notifications_outbox (user_id, id);`
+
+// PostgresBroker is a Broker backed by a notifications_outbox table plus
+// Postgres LISTEN/NOTIFY, modeled on the rudder-server notifier: Publish
+// durably writes the row and then pg_notifies a per-user channel;
+// Subscribe LISTENs on that channel and first replays any row with
+// id > sinceID so a reconnecting client catches up on whatever it missed
+// while disconnected (including a gap caused by this process itself
+// restarting, or a brief network blip the underlying pq.Listener
+// reconnects through).
+type PostgresBroker struct {
+	db       *sql.DB
+	listener *pq.Listener
+	onDrop   func(reason string)
+
+	mu   sync.Mutex
+	subs map[string][]chan *Notification // keyed by pg_notify channel name
+}
+
+// Option configures optional PostgresBroker behavior.
+type Option func(*PostgresBroker)
+
+// WithDropHook calls fn with a reason (e.g. "subscriber_channel_full")
+// whenever dispatchLoop drops a notification instead of delivering it,
+// so a caller can feed that into its own metrics.Collectors.RecordDropped
+// without PostgresBroker importing internal/metrics itself.
+func WithDropHook(fn func(reason string)) Option {
+	return func(b *PostgresBroker) {
+		b.onDrop = fn
+	}
+}
+
+// NewPostgresBroker opens a connection pool and a dedicated LISTEN
+// connection against dsn, verifies the pool with a Ping, and starts the
+// background dispatch loop that fans incoming NOTIFYs out to Subscribe
+// channels.
+func NewPostgresBroker(dsn string, opts ...Option) (*PostgresBroker, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	listener := pq.NewListener(dsn, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("broker: listener event %d: %v", ev, err)
+		}
+	})
+
+	b := &PostgresBroker{db: db, listener: listener, subs: make(map[string][]chan *Notification)}
+	for _, opt := range opts {
+		opt(b)
+	}
+	go b.dispatchLoop()
+	return b, nil
+}
+
+// Migrate creates the notifications_outbox table if it doesn't already
+// exist.
+func (b *PostgresBroker) Migrate(ctx context.Context) error {
+	_, err := b.db.ExecContext(ctx, createOutboxTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate notifications_outbox: %w", err)
+	}
+	return nil
+}
+
+// Close releases the listener connection and connection pool.
+func (b *PostgresBroker) Close() error {
+	listenErr := b.listener.Close()
+	dbErr := b.db.Close()
+	if listenErr != nil {
+		return listenErr
+	}
+	return dbErr
+}
+
+// channelFor derives a pg_notify channel name from userID. Postgres
+// channel identifiers are more limited than arbitrary text, so the raw
+// userID isn't used directly; hashing also keeps the channel name a
+// fixed, short length regardless of userID's.
+func channelFor(userID string) string {
+	sum := sha256.Sum256([]byte(userID))
+	return "notif_user_" + hex.EncodeToString(sum[:8])
+}
+
+// Publish implements Broker.
+func (b *PostgresBroker) Publish(ctx context.Context, n *Notification) error {
+	data, err := json.Marshal(n.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification data: %w", err)
+	}
+
+	_, err = b.db.ExecContext(ctx, `
+		INSERT INTO notifications_outbox (id, user_id, type, title, body, data, priority, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO NOTHING`,
+		n.ID, n.UserID, n.Type, n.Title, n.Body, data, n.Priority, n.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to write notification to outbox: %w", err)
+	}
+
+	channel := channelFor(n.UserID)
+	if _, err := b.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, channel, n.ID); err != nil {
+		return fmt.Errorf("failed to pg_notify %s: %w", channel, err)
+	}
+	return nil
+}
+
+// Subscribe implements Broker.
+func (b *PostgresBroker) Subscribe(ctx context.Context, userID string, sinceID string) (<-chan *Notification, func(), error) {
+	channel := channelFor(userID)
+	ch := make(chan *Notification, 64)
+
+	b.mu.Lock()
+	b.subs[channel] = append(b.subs[channel], ch)
+	b.mu.Unlock()
+
+	if err := b.listener.Listen(channel); err != nil && err != pq.ErrChannelAlreadyOpen {
+		b.removeSub(channel, ch)
+		return nil, nil, fmt.Errorf("failed to listen on %s: %w", channel, err)
+	}
+
+	var once sync.Once
+	cleanup := func() {
+		once.Do(func() {
+			remaining := b.removeSub(channel, ch)
+			if remaining == 0 {
+				_ = b.listener.Unlisten(channel)
+			}
+			close(ch)
+		})
+	}
+
+	go func() {
+		<-ctx.Done()
+		cleanup()
+	}()
+
+	if err := b.replay(ctx, userID, sinceID, ch); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	return ch, cleanup, nil
+}
+
+// replay sends every outbox row for userID with id > sinceID into ch, in
+// id order, so a reconnecting subscriber catches up before live
+// notifications start arriving via dispatchLoop. An empty sinceID
+// replays nothing (a fresh subscriber only wants what's published from
+// here on).
+func (b *PostgresBroker) replay(ctx context.Context, userID, sinceID string, ch chan *Notification) error {
+	if sinceID == "" {
+		return nil
+	}
+
+	rows, err := b.db.QueryContext(ctx, `
+		SELECT id, user_id, type, title, body, data, priority, created_at
+		FROM notifications_outbox
+		WHERE user_id = $1 AND id > $2
+		ORDER BY id ASC`, userID, sinceID)
+	if err != nil {
+		return fmt.Errorf("failed to replay notifications since %s: %w", sinceID, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan replayed notification: %w", err)
+		}
+		select {
+		case ch <- n:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return rows.Err()
+}
+
+func (b *PostgresBroker) loadByID(ctx context.Context, id string) (*Notification, error) {
+	row := b.db.QueryRowContext(ctx, `
+		SELECT id, user_id, type, title, body, data, priority, created_at
+		FROM notifications_outbox WHERE id = $1`, id)
+	return scanNotification(row)
+}
+
+// scanRower is satisfied by both *sql.Row and *sql.Rows so scanNotification
+// can back both loadByID and replay.
+type scanRower interface {
+	Scan(dest ...any) error
+}
+
+func scanNotification(row scanRower) (*Notification, error) {
+	var n Notification
+	var data []byte
+	if err := row.Scan(&n.ID, &n.UserID, &n.Type, &n.Title, &n.Body, &data, &n.Priority, &n.CreatedAt); err != nil {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &n.Data); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal notification data: %w", err)
+		}
+	}
+	return &n, nil
+}
+
+func (b *PostgresBroker) removeSub(channel string, ch chan *Notification) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	channels := b.subs[channel]
+	for i, c := range channels {
+		if c == ch {
+			channels = append(channels[:i], channels[i+1:]...)
+			break
+		}
+	}
+	if len(channels) == 0 {
+		delete(b.subs, channel)
+		return 0
+	}
+	b.subs[channel] = channels
+	return len(channels)
+}
+
+// dispatchLoop reads every NOTIFY pq.Listener receives and fans the full
+// row (fetched by the ID carried as the NOTIFY payload, since Postgres
+// caps payload size and the outbox row can exceed it) out to every
+// channel currently Subscribed to that notification's pg_notify channel.
+// A nil notification means the listener's connection was lost and
+// reconnected; subscribers recover any gap through their next replay
+// rather than this loop trying to special-case it.
+func (b *PostgresBroker) dispatchLoop() {
+	for notice := range b.listener.Notify {
+		if notice == nil {
+			continue
+		}
+
+		b.mu.Lock()
+		channels := append([]chan *Notification(nil), b.subs[notice.Channel]...)
+		b.mu.Unlock()
+		if len(channels) == 0 {
+			continue
+		}
+
+		n, err := b.loadByID(context.Background(), notice.Extra)
+		if err != nil {
+			log.Printf("broker: failed to load notified row %s: %v", notice.Extra, err)
+			continue
+		}
+
+		for _, ch := range channels {
+			select {
+			case ch <- n:
+			default:
+				// Subscriber's channel is full; drop rather than block
+				// the dispatch loop on a slow reader.
+				if b.onDrop != nil {
+					b.onDrop("subscriber_channel_full")
+				}
+			}
+		}
+	}
+}
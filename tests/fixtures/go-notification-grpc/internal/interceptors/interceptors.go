@@ -5,6 +5,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/example/notification-service/internal/metrics"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -78,6 +79,34 @@ func LoggingStreamInterceptor(
 	return err
 }
 
+// MetricsUnaryInterceptor records RPC-level request counts and latency
+// against m, so a deployment can chart notification-service alongside
+// calorie-tracker's metrics.Recorder-backed db_call_duration_seconds on
+// a consistent method/status/duration shape.
+func MetricsUnaryInterceptor(m *metrics.Collectors) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		code := codes.OK
+		if err != nil {
+			if st, ok := status.FromError(err); ok {
+				code = st.Code()
+			}
+		}
+
+		m.RecordRPC(info.FullMethod, code.String(), time.Since(start))
+
+		return resp, err
+	}
+}
+
 // AuthUnaryInterceptor validates authentication for unary calls.
 func AuthUnaryInterceptor(
 	ctx context.Context,
@@ -0,0 +1,65 @@
+// Package logging provides a minimal structured logger for the send
+// paths in internal/server, replacing ad hoc log.Printf calls with a
+// With(key, value)-chained interface similar to prometheus/common/log:
+// fields accumulate on the logger returned by With rather than being
+// formatted into the message string at each call site.
+package logging
+
+import (
+	"fmt"
+	"log"
+)
+
+// Logger is a structured logger. With returns a derived Logger carrying
+// one more field; the Debug/Info/Warn/Error methods emit msg plus every
+// field accumulated so far.
+type Logger interface {
+	With(key string, value any) Logger
+	Debug(msg string)
+	Info(msg string)
+	Warn(msg string)
+	Error(msg string)
+}
+
+// field is one key/value pair accumulated by With.
+type field struct {
+	key   string
+	value any
+}
+
+// stdLogger is a Logger backed by the standard log package. The zero
+// value is usable (no fields, logs via the default logger); use New for
+// clarity at call sites.
+type stdLogger struct {
+	fields []field
+}
+
+// New returns a Logger with no fields set.
+func New() Logger {
+	return &stdLogger{}
+}
+
+// With implements Logger.
+func (l *stdLogger) With(key string, value any) Logger {
+	fields := make([]field, len(l.fields), len(l.fields)+1)
+	copy(fields, l.fields)
+	fields = append(fields, field{key: key, value: value})
+	return &stdLogger{fields: fields}
+}
+
+func (l *stdLogger) log(level, msg string) {
+	log.Printf("level=%s msg=%q%s", level, msg, l.formatFields())
+}
+
+func (l *stdLogger) formatFields() string {
+	var out string
+	for _, f := range l.fields {
+		out += fmt.Sprintf(" %s=%v", f.key, f.value)
+	}
+	return out
+}
+
+func (l *stdLogger) Debug(msg string) { l.log("debug", msg) }
+func (l *stdLogger) Info(msg string)  { l.log("info", msg) }
+func (l *stdLogger) Warn(msg string)  { l.log("warn", msg) }
+func (l *stdLogger) Error(msg string) { l.log("error", msg) }
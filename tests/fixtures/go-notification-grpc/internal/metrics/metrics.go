@@ -0,0 +1,117 @@
+// Package metrics exports notification delivery metrics to Prometheus,
+// the same Collectors-struct pattern internal/worker/metrics uses in the
+// sibling task-queue service: a struct of registered collectors plus
+// small helpers to record against them, instead of server.NotificationServer
+// accumulating raw counts itself.
+package metrics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collectors wraps the Prometheus collectors registered for a
+// NotificationServer.
+type Collectors struct {
+	NotificationsSent    *prometheus.CounterVec
+	SendDuration         *prometheus.HistogramVec
+	NotificationsDropped *prometheus.CounterVec
+	SubscribersActive    *prometheus.GaugeVec
+	RPCRequests          *prometheus.CounterVec
+	RPCDuration          *prometheus.HistogramVec
+}
+
+// New creates and registers the server's collectors against reg. Passing
+// a nil Registerer skips registration (useful for tests, or a caller
+// that just wants the increment/observe helpers without exposing
+// /metrics).
+func New(reg prometheus.Registerer) *Collectors {
+	c := &Collectors{
+		NotificationsSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "notifications_sent_total",
+			Help: "Total number of notifications attempted, by type, priority and outcome.",
+		}, []string{"type", "priority", "status"}),
+		SendDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "notifications_send_duration_seconds",
+			Help:    "Time spent delivering a notification through its Transport, by type.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"type"}),
+		NotificationsDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "notifications_dropped_total",
+			Help: "Total number of notifications dropped before delivery, by reason (e.g. subscriber_channel_full).",
+		}, []string{"reason"}),
+		SubscribersActive: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "subscribers_active",
+			Help: "Number of open StreamNotifications subscriptions, bucketed by user_bucket to bound cardinality.",
+		}, []string{"user_bucket"}),
+		RPCRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "rpc_requests_total",
+			Help: "Total number of unary RPCs handled, by method and status code.",
+		}, []string{"method", "status"}),
+		RPCDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "rpc_duration_seconds",
+			Help:    "Unary RPC handler latency, by method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+
+	if reg != nil {
+		reg.MustRegister(c.Collectors()...)
+	}
+
+	return c
+}
+
+// Collectors returns every collector so callers can register them with a
+// custom prometheus.Registerer.
+func (c *Collectors) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.NotificationsSent,
+		c.SendDuration,
+		c.NotificationsDropped,
+		c.SubscribersActive,
+		c.RPCRequests,
+		c.RPCDuration,
+	}
+}
+
+// RecordRPC increments RPCRequests for method/status and observes d
+// against RPCDuration for method.
+func (c *Collectors) RecordRPC(method, status string, d time.Duration) {
+	c.RPCRequests.WithLabelValues(method, status).Inc()
+	c.RPCDuration.WithLabelValues(method).Observe(d.Seconds())
+}
+
+// RecordSend increments NotificationsSent for the given labels and
+// observes d against SendDuration.
+func (c *Collectors) RecordSend(typ, priority, status string, d time.Duration) {
+	c.NotificationsSent.WithLabelValues(typ, priority, status).Inc()
+	c.SendDuration.WithLabelValues(typ).Observe(d.Seconds())
+}
+
+// RecordDropped increments NotificationsDropped for reason.
+func (c *Collectors) RecordDropped(reason string) {
+	c.NotificationsDropped.WithLabelValues(reason).Inc()
+}
+
+// SubscriberOpened increments SubscribersActive for userID's bucket.
+func (c *Collectors) SubscriberOpened(userID string) {
+	c.SubscribersActive.WithLabelValues(UserBucket(userID)).Inc()
+}
+
+// SubscriberClosed decrements SubscribersActive for userID's bucket.
+func (c *Collectors) SubscriberClosed(userID string) {
+	c.SubscribersActive.WithLabelValues(UserBucket(userID)).Dec()
+}
+
+// UserBucket maps userID to one of 256 buckets via the first byte of its
+// SHA-256 hash, the same hash-to-fixed-width-identifier approach
+// broker.channelFor uses for pg_notify channel names, so a gauge indexed
+// by user_bucket doesn't grow one series per distinct userID.
+func UserBucket(userID string) string {
+	sum := sha256.Sum256([]byte(userID))
+	return hex.EncodeToString(sum[:1])
+}
@@ -3,6 +3,7 @@ package queue
 import (
 	"container/heap"
 	"context"
+	"log"
 	"sync"
 	"time"
 )
@@ -24,7 +25,17 @@ type Notification struct {
 type ScheduledNotification struct {
 	Notification *Notification
 	ScheduledAt  time.Time
-	index        int
+
+	// Attempts counts how many times the handler has been invoked for
+	// this notification so far.
+	Attempts int
+
+	// MaxAttempts is the RetryPolicy.MaxAttempts in effect when this
+	// notification was scheduled, carried alongside it so a later change
+	// to NotificationQueue's policy doesn't affect items already queued.
+	MaxAttempts int
+
+	index int
 }
 
 // PriorityQueue implements heap.Interface for scheduled notifications.
@@ -61,28 +72,63 @@ func (pq *PriorityQueue) Pop() interface{} {
 
 // NotificationQueue manages scheduled notifications.
 type NotificationQueue struct {
-	queue    PriorityQueue
-	mu       sync.Mutex
-	handler  func(*Notification) error
-	stopChan chan struct{}
-	wg       sync.WaitGroup
+	queue       PriorityQueue
+	mu          sync.Mutex
+	store       Store
+	handler     func(*Notification) error
+	retryPolicy RetryPolicy
+	deadLetter  DeadLetterHandler
+	metrics     queueMetrics
+	stopChan    chan struct{}
+	wg          sync.WaitGroup
 }
 
-// NewNotificationQueue creates a new NotificationQueue.
-func NewNotificationQueue(handler func(*Notification) error) *NotificationQueue {
+// NotificationQueueConfig configures a NotificationQueue's retry and
+// dead-letter behavior. The zero value is usable: RetryPolicy defaults to
+// DefaultRetryPolicy, and a nil DeadLetterHandler simply drops
+// notifications once they exhaust their retries.
+type NotificationQueueConfig struct {
+	RetryPolicy       RetryPolicy
+	DeadLetterHandler DeadLetterHandler
+}
+
+// NewNotificationQueue creates a new NotificationQueue backed by store.
+// Start hydrates the heap from store.LoadPending before it begins
+// processing, so notifications scheduled before a restart aren't lost.
+func NewNotificationQueue(store Store, handler func(*Notification) error, cfg NotificationQueueConfig) *NotificationQueue {
+	if cfg.RetryPolicy.MaxAttempts == 0 {
+		cfg.RetryPolicy = DefaultRetryPolicy()
+	}
+
 	q := &NotificationQueue{
-		queue:    make(PriorityQueue, 0),
-		handler:  handler,
-		stopChan: make(chan struct{}),
+		queue:       make(PriorityQueue, 0),
+		store:       store,
+		handler:     handler,
+		retryPolicy: cfg.RetryPolicy,
+		deadLetter:  cfg.DeadLetterHandler,
+		stopChan:    make(chan struct{}),
 	}
 	heap.Init(&q.queue)
 	return q
 }
 
-// Start starts the queue processor.
-func (q *NotificationQueue) Start(ctx context.Context) {
+// Start hydrates the heap from the store's pending notifications and
+// starts the queue processor.
+func (q *NotificationQueue) Start(ctx context.Context) error {
+	pending, err := q.store.LoadPending(ctx)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	for _, item := range pending {
+		heap.Push(&q.queue, item)
+	}
+	q.mu.Unlock()
+
 	q.wg.Add(1)
 	go q.processLoop(ctx)
+	return nil
 }
 
 // Stop stops the queue processor.
@@ -91,15 +137,21 @@ func (q *NotificationQueue) Stop() {
 	q.wg.Wait()
 }
 
-// Schedule schedules a notification for future delivery.
+// Schedule schedules a notification for future delivery, persisting it to
+// the store before adding it to the in-memory heap.
 func (q *NotificationQueue) Schedule(n *Notification, at time.Time) error {
-	q.mu.Lock()
-	defer q.mu.Unlock()
-
 	item := &ScheduledNotification{
 		Notification: n,
 		ScheduledAt:  at,
+		MaxAttempts:  q.retryPolicy.MaxAttempts,
 	}
+
+	if err := q.store.Enqueue(context.Background(), item); err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
 	heap.Push(&q.queue, item)
 
 	return nil
@@ -146,17 +198,51 @@ func (q *NotificationQueue) processReady() {
 		item := heap.Pop(&q.queue).(*ScheduledNotification)
 		q.mu.Unlock()
 
-		// Process in goroutine
-		go func(n *Notification) {
-			if err := q.handler(n); err != nil {
-				// Log error and potentially retry
-				// For now, just log
-				println("Failed to send scheduled notification:", err.Error())
-			}
-		}(item.Notification)
+		go q.process(item)
 	}
 }
 
+// process runs the handler for item once, then either marks it sent,
+// re-schedules it with a backoff delay, or dead-letters it once its
+// retries are exhausted.
+func (q *NotificationQueue) process(item *ScheduledNotification) {
+	q.metrics.inFlight.Add(1)
+	defer q.metrics.inFlight.Add(-1)
+
+	ctx := context.Background()
+	item.Attempts++
+	q.metrics.attempts.Add(1)
+
+	err := q.handler(item.Notification)
+	if err == nil {
+		if markErr := q.store.MarkSent(ctx, item.Notification.ID); markErr != nil {
+			log.Printf("failed to mark notification %s sent: %v", item.Notification.ID, markErr)
+		}
+		return
+	}
+
+	if item.Attempts >= item.MaxAttempts {
+		q.metrics.deadLettered.Add(1)
+		if markErr := q.store.MarkFailed(ctx, item.Notification.ID, err); markErr != nil {
+			log.Printf("failed to mark notification %s failed: %v", item.Notification.ID, markErr)
+		}
+		if q.deadLetter != nil {
+			q.deadLetter(item.Notification, err)
+		}
+		return
+	}
+
+	q.metrics.retries.Add(1)
+	item.ScheduledAt = time.Now().Add(q.retryPolicy.backoff(item.Attempts))
+	if storeErr := q.store.Enqueue(ctx, item); storeErr != nil {
+		log.Printf("failed to persist retry for notification %s: %v", item.Notification.ID, storeErr)
+	}
+
+	q.mu.Lock()
+	heap.Push(&q.queue, item)
+	q.mu.Unlock()
+}
+
 // Pending returns the number of pending notifications.
 func (q *NotificationQueue) Pending() int {
 	q.mu.Lock()
@@ -164,10 +250,22 @@ func (q *NotificationQueue) Pending() int {
 	return q.queue.Len()
 }
 
-// Clear clears all pending notifications.
-func (q *NotificationQueue) Clear() {
+// Stats returns a point-in-time snapshot of attempt/retry/dead-letter/
+// in-flight counters, complementing Pending for observability.
+func (q *NotificationQueue) Stats() Stats {
+	return q.metrics.snapshot()
+}
+
+// Clear clears all pending notifications, both in the heap and in the
+// store.
+func (q *NotificationQueue) Clear() error {
+	if err := q.store.Clear(context.Background()); err != nil {
+		return err
+	}
+
 	q.mu.Lock()
 	defer q.mu.Unlock()
 	q.queue = make(PriorityQueue, 0)
 	heap.Init(&q.queue)
+	return nil
 }
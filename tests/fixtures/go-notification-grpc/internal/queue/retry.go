@@ -0,0 +1,74 @@
+package queue
+
+import (
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy controls how NotificationQueue retries a failed handler
+// invocation before dead-lettering it.
+type RetryPolicy struct {
+	// BaseDelay is the delay before the first retry.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the exponential backoff.
+	MaxDelay time.Duration
+
+	// MaxAttempts is the maximum number of handler invocations (including
+	// the first) before a notification is handed to DeadLetterHandler.
+	MaxAttempts int
+}
+
+// DefaultRetryPolicy returns a 1s base delay doubling up to a 5m cap,
+// for a total of five attempts before dead-lettering.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		BaseDelay:   time.Second,
+		MaxDelay:    5 * time.Minute,
+		MaxAttempts: 5,
+	}
+}
+
+// backoff returns the delay before retrying a notification that has just
+// failed its attempt'th attempt: base*2^attempt, capped at MaxDelay, with
+// ±20% jitter so many notifications failing at once don't all retry in
+// lockstep.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.BaseDelay) * math.Pow(2, float64(attempt))
+	if max := float64(p.MaxDelay); delay > max {
+		delay = max
+	}
+	jitter := 1 + (rand.Float64()*0.4 - 0.2)
+	return time.Duration(delay * jitter)
+}
+
+// DeadLetterHandler is invoked once a notification exhausts
+// RetryPolicy.MaxAttempts, with the error from its final attempt.
+type DeadLetterHandler func(n *Notification, err error)
+
+// Stats is a point-in-time snapshot of a NotificationQueue's counters.
+type Stats struct {
+	Attempts     int64
+	Retries      int64
+	DeadLettered int64
+	InFlight     int64
+}
+
+// queueMetrics holds a NotificationQueue's atomic counters.
+type queueMetrics struct {
+	attempts     atomic.Int64
+	retries      atomic.Int64
+	deadLettered atomic.Int64
+	inFlight     atomic.Int64
+}
+
+func (m *queueMetrics) snapshot() Stats {
+	return Stats{
+		Attempts:     m.attempts.Load(),
+		Retries:      m.retries.Load(),
+		DeadLettered: m.deadLettered.Load(),
+		InFlight:     m.inFlight.Load(),
+	}
+}
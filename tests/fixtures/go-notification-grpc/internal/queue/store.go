@@ -0,0 +1,281 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Store persists scheduled notifications so NotificationQueue survives a
+// restart instead of losing everything still sitting in its priority
+// heap. NewNotificationQueue hydrates the heap from LoadPending on Start
+// and writes through to the store on Schedule/Clear and after each
+// handler invocation.
+type Store interface {
+	// Enqueue durably records a scheduled notification.
+	Enqueue(ctx context.Context, sn *ScheduledNotification) error
+
+	// LoadPending returns every notification not yet marked sent or
+	// failed, for NotificationQueue to hydrate its heap with.
+	LoadPending(ctx context.Context) ([]*ScheduledNotification, error)
+
+	// MarkSent records that a notification was delivered successfully.
+	MarkSent(ctx context.Context, id string) error
+
+	// MarkFailed records that a notification's handler returned err.
+	MarkFailed(ctx context.Context, id string, handlerErr error) error
+
+	// Clear removes every scheduled notification, pending or not.
+	Clear(ctx context.Context) error
+}
+
+// MemoryStore is an in-memory Store, preserving NotificationQueue's
+// original behavior: nothing survives a restart.
+type MemoryStore struct {
+	mu    sync.Mutex
+	items map[string]*ScheduledNotification
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: make(map[string]*ScheduledNotification)}
+}
+
+// Enqueue implements Store.
+func (m *MemoryStore) Enqueue(ctx context.Context, sn *ScheduledNotification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items[sn.Notification.ID] = sn
+	return nil
+}
+
+// LoadPending implements Store.
+func (m *MemoryStore) LoadPending(ctx context.Context) ([]*ScheduledNotification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	results := make([]*ScheduledNotification, 0, len(m.items))
+	for _, sn := range m.items {
+		results = append(results, sn)
+	}
+	return results, nil
+}
+
+// MarkSent implements Store.
+func (m *MemoryStore) MarkSent(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, id)
+	return nil
+}
+
+// MarkFailed implements Store.
+func (m *MemoryStore) MarkFailed(ctx context.Context, id string, handlerErr error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, id)
+	return nil
+}
+
+// Clear implements Store.
+func (m *MemoryStore) Clear(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items = make(map[string]*ScheduledNotification)
+	return nil
+}
+
+// createScheduledNotificationsTableSQL creates the table PostgresStore
+// depends on. PostgresStore.Migrate runs it, and it's written so running
+// it again against an already-migrated database is a no-op.
+const createScheduledNotificationsTableSQL = `
+CREATE TABLE IF NOT EXISTS scheduled_notifications (
+	id           TEXT PRIMARY KEY,
+	user_id      TEXT NOT NULL,
+	type         INTEGER NOT NULL,
+	title        TEXT NOT NULL,
+	body         TEXT NOT NULL,
+	data         JSONB NOT NULL DEFAULT '{}',
+	priority     INTEGER NOT NULL,
+	created_at   BIGINT NOT NULL,
+	scheduled_at TIMESTAMPTZ NOT NULL,
+	attempts     INTEGER NOT NULL DEFAULT 0,
+	max_attempts INTEGER NOT NULL DEFAULT 0,
+	status       TEXT NOT NULL DEFAULT 'pending',
+	error        TEXT,
+	sent_at      BIGINT
+)`
+
+// PostgresStore is a Store backed by a scheduled_notifications table,
+// letting multiple NotificationQueue processes share one durable queue:
+// LoadPending claims rows with SELECT ... FOR UPDATE SKIP LOCKED so two
+// workers polling the same table never hydrate the same notification
+// into their heap at once.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore opens a connection pool against dsn and verifies it
+// with a Ping.
+func NewPostgresStore(dsn string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	return &PostgresStore{db: db}, nil
+}
+
+// Migrate creates the scheduled_notifications table if it doesn't
+// already exist.
+func (s *PostgresStore) Migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, createScheduledNotificationsTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate scheduled_notifications: %w", err)
+	}
+	return nil
+}
+
+// Enqueue implements Store.
+func (s *PostgresStore) Enqueue(ctx context.Context, sn *ScheduledNotification) error {
+	data, err := json.Marshal(sn.Notification.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification data: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO scheduled_notifications
+			(id, user_id, type, title, body, data, priority, created_at, scheduled_at, attempts, max_attempts, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, 'pending')
+		ON CONFLICT (id) DO UPDATE SET
+			scheduled_at = EXCLUDED.scheduled_at,
+			attempts = EXCLUDED.attempts,
+			max_attempts = EXCLUDED.max_attempts,
+			status = 'pending'`,
+		sn.Notification.ID, sn.Notification.UserID, sn.Notification.Type,
+		sn.Notification.Title, sn.Notification.Body, data,
+		sn.Notification.Priority, sn.Notification.CreatedAt, sn.ScheduledAt,
+		sn.Attempts, sn.MaxAttempts)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue scheduled notification: %w", err)
+	}
+	return nil
+}
+
+// LoadPending claims every still-pending row (status 'pending' -> status
+// 'claimed', in one transaction) and returns it, so the caller can
+// hydrate its in-memory heap without racing a second worker process
+// calling LoadPending against the same table.
+func (s *PostgresStore) LoadPending(ctx context.Context) ([]*ScheduledNotification, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, user_id, type, title, body, data, priority, created_at, scheduled_at, attempts, max_attempts
+		FROM scheduled_notifications
+		WHERE status = 'pending'
+		ORDER BY scheduled_at
+		FOR UPDATE SKIP LOCKED`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending notifications: %w", err)
+	}
+
+	var results []*ScheduledNotification
+	var ids []string
+	for rows.Next() {
+		var n Notification
+		var data []byte
+		var scheduledAt time.Time
+		var attempts, maxAttempts int
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.Title, &n.Body, &data,
+			&n.Priority, &n.CreatedAt, &scheduledAt, &attempts, &maxAttempts); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan scheduled notification: %w", err)
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &n.Data); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to unmarshal notification data: %w", err)
+			}
+		}
+		results = append(results, &ScheduledNotification{
+			Notification: &n,
+			ScheduledAt:  scheduledAt,
+			Attempts:     attempts,
+			MaxAttempts:  maxAttempts,
+		})
+		ids = append(ids, n.ID)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+
+	if len(ids) > 0 {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE scheduled_notifications SET status = 'claimed' WHERE id = ANY($1)`,
+			pq.Array(ids)); err != nil {
+			return nil, fmt.Errorf("failed to claim scheduled notifications: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit claim of pending notifications: %w", err)
+	}
+
+	return results, nil
+}
+
+// MarkSent implements Store.
+func (s *PostgresStore) MarkSent(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE scheduled_notifications SET status = 'sent', sent_at = $2 WHERE id = $1`,
+		id, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to mark notification sent: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed implements Store.
+func (s *PostgresStore) MarkFailed(ctx context.Context, id string, handlerErr error) error {
+	var errMsg string
+	if handlerErr != nil {
+		errMsg = handlerErr.Error()
+	}
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE scheduled_notifications SET status = 'failed', error = $2 WHERE id = $1`,
+		id, errMsg)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification failed: %w", err)
+	}
+	return nil
+}
+
+// Clear implements Store.
+func (s *PostgresStore) Clear(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM scheduled_notifications`)
+	if err != nil {
+		return fmt.Errorf("failed to clear scheduled notifications: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying connection pool.
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
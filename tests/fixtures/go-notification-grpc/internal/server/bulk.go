@@ -0,0 +1,295 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BulkConfig tunes SendBulkNotifications/SendBulkNotificationsStream. The
+// zero value is invalid; use DefaultBulkConfig and override individual
+// fields.
+type BulkConfig struct {
+	// MaxConcurrency bounds how many SendNotification calls are
+	// in-flight at once, replacing the old one-goroutine-per-user fan-out.
+	MaxConcurrency int
+
+	// PerUserRateLimit is the max sends/second allowed for a single
+	// (userID, type) pair, so one hot user can't starve the rest of the
+	// batch or flood their own device.
+	PerUserRateLimit float64
+
+	// PerTypeRateLimit is the max sends/second allowed across all users
+	// for a given NotificationType, bounding load on that type's
+	// Transport (e.g. a provider's own rate limit).
+	PerTypeRateLimit float64
+
+	// BatchSize caps how many userIDs are dispatched to the worker pool
+	// at once; the rest wait behind it instead of all being queued as
+	// goroutines up front.
+	BatchSize int
+
+	// ShutdownTimeout bounds how long SendBulkNotificationsStream keeps
+	// draining in-flight sends after ctx is done before it gives up and
+	// returns early.
+	ShutdownTimeout time.Duration
+}
+
+// DefaultBulkConfig returns sane defaults for SendBulkNotifications.
+func DefaultBulkConfig() BulkConfig {
+	return BulkConfig{
+		MaxConcurrency:   20,
+		PerUserRateLimit: 1,
+		PerTypeRateLimit: 50,
+		BatchSize:        100,
+		ShutdownTimeout:  10 * time.Second,
+	}
+}
+
+// BulkResult is one user's outcome from a bulk send, emitted as soon as
+// it's known so a caller can stream progress instead of waiting for the
+// whole batch.
+type BulkResult struct {
+	UserID string
+	Sent   bool
+	Err    error
+}
+
+// tokenBucket is a minimal, mutex-guarded token-bucket limiter: it holds
+// at most Burst tokens, refilling at Rate tokens/second, and Allow
+// consumes one if available.
+type tokenBucket struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    float64
+	tokens   float64
+	lastFill time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: rate, tokens: rate, lastFill: time.Now()}
+}
+
+// Allow reports whether a token is available and consumes it if so.
+func (b *tokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(b.lastFill).Seconds()
+	b.lastFill = now
+	b.tokens += elapsed * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// bulkLimiter enforces BulkConfig.PerUserRateLimit and PerTypeRateLimit
+// via per-key tokenBuckets created lazily on first use.
+type bulkLimiter struct {
+	cfg BulkConfig
+
+	mu      sync.Mutex
+	perUser map[string]*tokenBucket
+	perType map[NotificationType]*tokenBucket
+}
+
+func newBulkLimiter(cfg BulkConfig) *bulkLimiter {
+	return &bulkLimiter{
+		cfg:     cfg,
+		perUser: make(map[string]*tokenBucket),
+		perType: make(map[NotificationType]*tokenBucket),
+	}
+}
+
+// Wait blocks, polling at a short interval, until both the user's and
+// the type's buckets have a token, or ctx is done.
+func (l *bulkLimiter) Wait(ctx context.Context, userID string, typ NotificationType) error {
+	for {
+		if l.userBucket(userID).Allow() && l.typeBucket(typ).Allow() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func (l *bulkLimiter) userBucket(userID string) *tokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.perUser[userID]
+	if !ok {
+		b = newTokenBucket(l.cfg.PerUserRateLimit)
+		l.perUser[userID] = b
+	}
+	return b
+}
+
+func (l *bulkLimiter) typeBucket(typ NotificationType) *tokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.perType[typ]
+	if !ok {
+		b = newTokenBucket(l.cfg.PerTypeRateLimit)
+		l.perType[typ] = b
+	}
+	return b
+}
+
+// SendBulkNotifications sends notifications to multiple users using
+// DefaultBulkConfig, returning the aggregate counts the original
+// unbounded implementation did. Callers that want progress as it
+// happens, or their own concurrency/rate limits, should use
+// SendBulkNotificationsStream directly.
+func (s *NotificationServer) SendBulkNotifications(ctx context.Context, userIDs []string, notificationType NotificationType, title, body string, data map[string]string, priority Priority) (int, int, []string) {
+	req := &BulkSendRequest{
+		UserIDs:  userIDs,
+		Type:     notificationType,
+		Title:    title,
+		Body:     body,
+		Data:     data,
+		Priority: priority,
+		Config:   DefaultBulkConfig(),
+	}
+
+	out := make(chan BulkResult)
+	done := make(chan struct{})
+
+	var totalSent, totalFailed int
+	var failedIDs []string
+	go func() {
+		defer close(done)
+		for r := range out {
+			if r.Sent {
+				totalSent++
+			} else {
+				totalFailed++
+				failedIDs = append(failedIDs, r.UserID)
+			}
+		}
+	}()
+
+	s.SendBulkNotificationsStream(ctx, req, out)
+	<-done
+
+	return totalSent, totalFailed, failedIDs
+}
+
+// BulkSendRequest is the input to SendBulkNotificationsStream.
+type BulkSendRequest struct {
+	UserIDs  []string
+	Type     NotificationType
+	Title    string
+	Body     string
+	Data     map[string]string
+	Priority Priority
+	Config   BulkConfig
+
+	// IdempotencyKey, if set, is combined with each UserID (see
+	// bulkUserIdempotencyKey) to derive that user's per-send
+	// SendNotificationRequest.IdempotencyKey. Retrying an entire bulk
+	// send with the same IdempotencyKey re-derives the same per-user
+	// keys, so SendNotification's own dedup prevents a retried batch
+	// from double-delivering to anyone it already reached.
+	IdempotencyKey string
+}
+
+// SendBulkNotificationsStream sends req to every UserID with bounded
+// concurrency and per-(user,type) rate limiting, emitting a BulkResult on
+// out as each send completes so a caller can render progress instead of
+// waiting for the whole batch. out is closed before this returns,
+// including on early exit via ctx cancellation.
+//
+// This replaces the previous one-goroutine-per-user fan-out, which had no
+// concurrency bound and could exhaust resources on a large UserIDs list.
+func (s *NotificationServer) SendBulkNotificationsStream(ctx context.Context, req *BulkSendRequest, out chan<- BulkResult) {
+	defer close(out)
+
+	cfg := req.Config
+	if cfg.MaxConcurrency <= 0 {
+		cfg = DefaultBulkConfig()
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = len(req.UserIDs)
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = DefaultBulkConfig().ShutdownTimeout
+	}
+
+	limiter := newBulkLimiter(cfg)
+	sem := make(chan struct{}, cfg.MaxConcurrency)
+	var wg sync.WaitGroup
+
+batches:
+	for batchStart := 0; batchStart < len(req.UserIDs); batchStart += cfg.BatchSize {
+		batchEnd := batchStart + cfg.BatchSize
+		if batchEnd > len(req.UserIDs) {
+			batchEnd = len(req.UserIDs)
+		}
+
+		for _, userID := range req.UserIDs[batchStart:batchEnd] {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				break batches
+			}
+
+			wg.Add(1)
+			go func(uid string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				if err := limiter.Wait(ctx, uid, req.Type); err != nil {
+					s.logger.With("user_id", uid).Warn(fmt.Sprintf("bulk send rate-limit wait aborted: %v", err))
+					out <- BulkResult{UserID: uid, Sent: false, Err: err}
+					return
+				}
+
+				sendReq := &SendNotificationRequest{
+					UserID:   uid,
+					Type:     req.Type,
+					Title:    req.Title,
+					Body:     req.Body,
+					Data:     req.Data,
+					Priority: req.Priority,
+				}
+				if req.IdempotencyKey != "" {
+					sendReq.IdempotencyKey = bulkUserIdempotencyKey(req.IdempotencyKey, uid)
+				}
+
+				resp, err := s.SendNotification(ctx, sendReq)
+				if err != nil || !resp.Success {
+					s.logger.With("user_id", uid).Error(fmt.Sprintf("bulk send failed: %v", err))
+					out <- BulkResult{UserID: uid, Sent: false, Err: err}
+					return
+				}
+
+				out <- BulkResult{UserID: uid, Sent: true}
+			}(userID)
+		}
+	}
+
+	// Drain in-flight sends, but don't block shutdown forever if ctx is
+	// already done -- give it ShutdownTimeout before giving up.
+	wgDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(wgDone)
+	}()
+
+	select {
+	case <-wgDone:
+	case <-time.After(cfg.ShutdownTimeout):
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import "github.com/example/notification-service/internal/transport"
+
+// HealthStatus summarizes a NotificationServer's readiness for an HTTP
+// health endpoint (see cmd/server/main.go): which channels have a
+// Transport registered, and how many scheduled notifications are
+// waiting in s.queue.
+type HealthStatus struct {
+	Status     string          `json:"status"`
+	QueueDepth int             `json:"queue_depth"`
+	Transports map[string]bool `json:"transports"`
+}
+
+// Health reports s's current transport registration and queue depth. A
+// nil s.queue (a server constructed with queue wiring done separately,
+// as cmd/server/main.go does) reports a QueueDepth of 0 rather than
+// panicking. Status is "degraded" if no channel has a Transport
+// registered -- SendNotification would fall through to sendInApp for
+// every type, which is a meaningful signal a deployment is misconfigured
+// even though SendNotification itself still succeeds.
+func (s *NotificationServer) Health() HealthStatus {
+	transports := map[string]bool{
+		"email": s.hasTransport(transport.TypeEmail),
+		"push":  s.hasTransport(transport.TypePush),
+		"sms":   s.hasTransport(transport.TypeSMS),
+	}
+
+	status := "degraded"
+	for _, ok := range transports {
+		if ok {
+			status = "ok"
+			break
+		}
+	}
+
+	depth := 0
+	if s.queue != nil {
+		depth = s.queue.Pending()
+	}
+
+	return HealthStatus{
+		Status:     status,
+		QueueDepth: depth,
+		Transports: transports,
+	}
+}
+
+func (s *NotificationServer) hasTransport(typ transport.NotificationType) bool {
+	_, ok := s.transports.Get(typ)
+	return ok
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// IdempotencyTTL bounds how long a SendNotificationRequest.IdempotencyKey
+// is remembered. A retry with the same key after this window elapses is
+// treated as a new send rather than a duplicate.
+const IdempotencyTTL = 24 * time.Hour
+
+// idempotencyHash derives the store key for (userID, typ, key): the
+// request body's IdempotencyKey is caller-chosen and may collide across
+// users or notification types, so it's namespaced by both before
+// hashing rather than stored directly.
+func idempotencyHash(userID string, typ NotificationType, key string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%s", userID, typ, key)))
+	return hex.EncodeToString(sum[:])
+}
+
+// bulkUserIdempotencyKey derives uid's SendNotificationRequest.IdempotencyKey
+// from a BulkSendRequest.IdempotencyKey, so retrying an entire bulk send
+// (the same batchKey) re-derives the same per-user key for every uid and
+// SendNotification's own dedup catches the double-delivery -- callers of
+// SendBulkNotificationsStream/SendBulkNotifications don't need to track
+// per-user keys themselves.
+func bulkUserIdempotencyKey(batchKey, uid string) string {
+	return batchKey + ":" + uid
+}
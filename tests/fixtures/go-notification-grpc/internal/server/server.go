@@ -4,11 +4,15 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"sync"
 	"time"
 
+	"github.com/example/notification-service/internal/broker"
+	"github.com/example/notification-service/internal/logging"
+	"github.com/example/notification-service/internal/metrics"
 	"github.com/example/notification-service/internal/queue"
 	"github.com/example/notification-service/internal/store"
+	"github.com/example/notification-service/internal/topic"
+	"github.com/example/notification-service/internal/transport"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -48,6 +52,12 @@ type Notification struct {
 	CreatedAt int64
 	SentAt    int64
 	IsRead    bool
+
+	// ProviderID is the message ID the delivering Transport returned
+	// (e.g. an FCM/APNS message ID, a Twilio SID, or the notification ID
+	// itself for SMTP), set by sendNow once sendEmail/sendPush/sendSMS
+	// returns successfully.
+	ProviderID string
 }
 
 // SendNotificationRequest is the request to send a notification.
@@ -59,6 +69,13 @@ type SendNotificationRequest struct {
 	Data       map[string]string
 	Priority   Priority
 	ScheduleAt int64
+
+	// IdempotencyKey, if set, makes a retried SendNotification with the
+	// same (UserID, Type, IdempotencyKey) return the first call's
+	// SendNotificationResponse instead of sending (and storing) a
+	// second, duplicate notification. See idempotencyHash and
+	// store.NotificationStore.SaveIdempotencyKey.
+	IdempotencyKey string
 }
 
 // SendNotificationResponse is the response.
@@ -70,19 +87,82 @@ type SendNotificationResponse struct {
 
 // NotificationServer implements the NotificationService gRPC service.
 type NotificationServer struct {
-	store       *store.NotificationStore
-	queue       *queue.NotificationQueue
-	subscribers map[string][]chan *Notification
-	mu          sync.RWMutex
+	store      *store.NotificationStore
+	queue      *queue.NotificationQueue
+	transports *transport.Registry
+	broker     broker.Broker
+	topics     *topic.Tree
+	metrics    *metrics.Collectors
+	logger     logging.Logger
+}
+
+// NotificationServerOption configures optional NotificationServer
+// behavior that doesn't belong on the constructor's required parameter
+// list, following the same opt-in pattern api.WithTokens/api.WithMetrics
+// use in the sibling task-queue service.
+type NotificationServerOption func(*NotificationServer)
+
+// WithMetrics registers m as the server's Prometheus collectors. Without
+// it, NewNotificationServer creates an unregistered *metrics.Collectors
+// (see metrics.New), so every send path can record against s.metrics
+// unconditionally even when no /metrics endpoint is exposed.
+func WithMetrics(m *metrics.Collectors) NotificationServerOption {
+	return func(s *NotificationServer) {
+		s.metrics = m
+	}
 }
 
-// NewNotificationServer creates a new NotificationServer.
-func NewNotificationServer(store *store.NotificationStore, queue *queue.NotificationQueue) *NotificationServer {
-	return &NotificationServer{
-		store:       store,
-		queue:       queue,
-		subscribers: make(map[string][]chan *Notification),
+// WithLogger sets the structured logger send paths log through. Without
+// it, NewNotificationServer falls back to logging.New().
+func WithLogger(l logging.Logger) NotificationServerOption {
+	return func(s *NotificationServer) {
+		s.logger = l
+	}
+}
+
+// NewNotificationServer creates a new NotificationServer. A nil
+// transports registers a transport.FakeTransport for every channel, so a
+// caller that doesn't care about real delivery (tests, local dev) still
+// gets a server that "sends" successfully instead of one that errors on
+// every SendNotification. A nil b registers a broker.MemoryBroker, the
+// single-process, no-replay fan-out this type originally implemented
+// inline; pass a broker.PostgresBroker for durable, multi-instance
+// delivery. That default MemoryBroker's dropped-notification hook feeds
+// s.metrics, so WithMetrics should be passed before opts are applied if
+// the caller wants dropped-subscriber-channel counts -- pass your own
+// already-hooked broker.Broker instead if that ordering doesn't fit.
+func NewNotificationServer(store *store.NotificationStore, queue *queue.NotificationQueue, transports *transport.Registry, b broker.Broker, opts ...NotificationServerOption) *NotificationServer {
+	if transports == nil {
+		transports = transport.NewRegistry()
+		fake := transport.NewFakeTransport()
+		transports.Register(transport.TypeEmail, fake)
+		transports.Register(transport.TypePush, fake)
+		transports.Register(transport.TypeSMS, fake)
+	}
+
+	s := &NotificationServer{
+		store:      store,
+		queue:      queue,
+		transports: transports,
+		topics:     topic.NewTree(),
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.metrics == nil {
+		s.metrics = metrics.New(nil)
+	}
+	if s.logger == nil {
+		s.logger = logging.New()
+	}
+
+	if b == nil {
+		b = broker.NewMemoryBroker(broker.WithDropHook(s.metrics.RecordDropped))
+	}
+	s.broker = b
+
+	return s
 }
 
 // SendNotification sends a single notification.
@@ -106,9 +186,26 @@ func (s *NotificationServer) SendNotification(ctx context.Context, req *SendNoti
 		CreatedAt: time.Now().Unix(),
 	}
 
+	var idempotencyKey string
+	if req.IdempotencyKey != "" {
+		idempotencyKey = idempotencyHash(req.UserID, req.Type, req.IdempotencyKey)
+		stored, _, err := s.store.SaveIdempotencyKey(idempotencyKey, notification.ID, IdempotencyTTL)
+		if err != nil {
+			return nil, status.Error(codes.Internal, fmt.Sprintf("failed to check idempotency key: %v", err))
+		}
+		if !stored {
+			existing, err := s.store.GetByIdempotencyKey(idempotencyKey)
+			if err != nil {
+				return nil, status.Error(codes.Internal, fmt.Sprintf("failed to load existing send for idempotency key: %v", err))
+			}
+			return &SendNotificationResponse{NotificationID: existing.ID, Success: true}, nil
+		}
+	}
+
 	// If scheduled for later, queue it
 	if req.ScheduleAt > 0 && req.ScheduleAt > time.Now().Unix() {
 		if err := s.queue.Schedule(notification, time.Unix(req.ScheduleAt, 0)); err != nil {
+			s.releaseIdempotencyKey(idempotencyKey)
 			return &SendNotificationResponse{
 				Success:      false,
 				ErrorMessage: fmt.Sprintf("failed to schedule: %v", err),
@@ -123,6 +220,7 @@ func (s *NotificationServer) SendNotification(ctx context.Context, req *SendNoti
 
 	// Send immediately
 	if err := s.sendNow(ctx, notification); err != nil {
+		s.releaseIdempotencyKey(idempotencyKey)
 		return &SendNotificationResponse{
 			Success:      false,
 			ErrorMessage: err.Error(),
@@ -135,6 +233,20 @@ func (s *NotificationServer) SendNotification(ctx context.Context, req *SendNoti
 	}, nil
 }
 
+// releaseIdempotencyKey deletes a claimed idempotency key after the send
+// or schedule it was claimed for has failed, so a client's retry with the
+// same key claims it again instead of getting back a false success. key
+// is empty when the request had no IdempotencyKey, in which case this is
+// a no-op.
+func (s *NotificationServer) releaseIdempotencyKey(key string) {
+	if key == "" {
+		return
+	}
+	if err := s.store.DeleteIdempotencyKey(key); err != nil {
+		s.logger.With("idempotency_key", key).Error(fmt.Sprintf("failed to release idempotency key after failed send: %v", err))
+	}
+}
+
 // sendNow sends a notification immediately.
 func (s *NotificationServer) sendNow(ctx context.Context, notification *Notification) error {
 	// Store the notification
@@ -145,44 +257,97 @@ func (s *NotificationServer) sendNow(ctx context.Context, notification *Notifica
 	// Send based on type
 	switch notification.Type {
 	case NotificationTypeEmail:
-		return s.sendEmail(notification)
+		return s.sendEmail(ctx, notification)
 	case NotificationTypePush:
-		return s.sendPush(notification)
+		return s.sendPush(ctx, notification)
 	case NotificationTypeSMS:
-		return s.sendSMS(notification)
+		return s.sendSMS(ctx, notification)
 	case NotificationTypeInApp:
-		return s.sendInApp(notification)
+		return s.sendInApp(ctx, notification)
 	default:
-		return s.sendInApp(notification)
+		return s.sendInApp(ctx, notification)
 	}
 }
 
-// sendEmail sends an email notification.
-func (s *NotificationServer) sendEmail(n *Notification) error {
-	// In a real app, this would use an email service
-	log.Printf("Sending email to user %s: %s", n.UserID, n.Title)
-	n.SentAt = time.Now().Unix()
-	return s.store.Update(n)
+// sendEmail sends an email notification via the registered email
+// Transport (SMTP or a webhook fallback).
+func (s *NotificationServer) sendEmail(ctx context.Context, n *Notification) error {
+	return s.sendVia(ctx, n, transport.TypeEmail)
 }
 
-// sendPush sends a push notification.
-func (s *NotificationServer) sendPush(n *Notification) error {
-	// In a real app, this would use FCM/APNS
-	log.Printf("Sending push to user %s: %s", n.UserID, n.Title)
-	n.SentAt = time.Now().Unix()
-	return s.store.Update(n)
+// sendPush sends a push notification via the registered push Transport
+// (FCM/APNS or a webhook fallback).
+func (s *NotificationServer) sendPush(ctx context.Context, n *Notification) error {
+	return s.sendVia(ctx, n, transport.TypePush)
+}
+
+// sendSMS sends an SMS notification via the registered SMS Transport
+// (Twilio or a webhook fallback).
+func (s *NotificationServer) sendSMS(ctx context.Context, n *Notification) error {
+	return s.sendVia(ctx, n, transport.TypeSMS)
 }
 
-// sendSMS sends an SMS notification.
-func (s *NotificationServer) sendSMS(n *Notification) error {
-	// In a real app, this would use Twilio/SMS gateway
-	log.Printf("Sending SMS to user %s: %s", n.UserID, n.Body)
+// sendVia dispatches n to the Transport registered for typ, recording
+// the provider's message ID on success.
+func (s *NotificationServer) sendVia(ctx context.Context, n *Notification, typ transport.NotificationType) error {
+	start := time.Now()
+	providerID, err := s.transports.Send(ctx, &transport.Notification{
+		ID:       n.ID,
+		UserID:   n.UserID,
+		Type:     typ,
+		Title:    n.Title,
+		Body:     n.Body,
+		Data:     n.Data,
+		Priority: int32(n.Priority),
+	})
+
+	typeLabel := notificationTypeLabel(n.Type)
+	if err != nil {
+		s.metrics.RecordSend(typeLabel, priorityLabel(n.Priority), "error", time.Since(start))
+		s.logger.With("notification_id", n.ID).With("type", typeLabel).Error(fmt.Sprintf("send failed: %v", err))
+		return fmt.Errorf("send notification %s: %w", n.ID, err)
+	}
+	s.metrics.RecordSend(typeLabel, priorityLabel(n.Priority), "ok", time.Since(start))
+
+	n.ProviderID = providerID
 	n.SentAt = time.Now().Unix()
 	return s.store.Update(n)
 }
 
+// notificationTypeLabel renders typ for a Prometheus label/log field.
+func notificationTypeLabel(typ NotificationType) string {
+	switch typ {
+	case NotificationTypeEmail:
+		return "email"
+	case NotificationTypePush:
+		return "push"
+	case NotificationTypeSMS:
+		return "sms"
+	case NotificationTypeInApp:
+		return "in_app"
+	default:
+		return "unspecified"
+	}
+}
+
+// priorityLabel renders p for a Prometheus label/log field.
+func priorityLabel(p Priority) string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	case PriorityUrgent:
+		return "urgent"
+	default:
+		return "unspecified"
+	}
+}
+
 // sendInApp sends an in-app notification.
-func (s *NotificationServer) sendInApp(n *Notification) error {
+func (s *NotificationServer) sendInApp(ctx context.Context, n *Notification) error {
 	n.SentAt = time.Now().Unix()
 
 	// Update store
@@ -191,98 +356,89 @@ func (s *NotificationServer) sendInApp(n *Notification) error {
 	}
 
 	// Notify subscribers
-	s.notifySubscribers(n)
-
-	return nil
+	return s.notifySubscribers(ctx, n)
 }
 
-// notifySubscribers sends notification to all subscribed clients.
-func (s *NotificationServer) notifySubscribers(n *Notification) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	channels, ok := s.subscribers[n.UserID]
-	if !ok {
-		return
+// notifySubscribers publishes n to every StreamNotifications subscriber
+// for n.UserID via s.broker (MemoryBroker by default, or a
+// broker.PostgresBroker for durable multi-instance fan-out).
+func (s *NotificationServer) notifySubscribers(ctx context.Context, n *Notification) error {
+	err := s.broker.Publish(ctx, &broker.Notification{
+		ID:        n.ID,
+		UserID:    n.UserID,
+		Type:      int32(n.Type),
+		Title:     n.Title,
+		Body:      n.Body,
+		Data:      n.Data,
+		Priority:  int32(n.Priority),
+		CreatedAt: n.CreatedAt,
+	})
+	if err != nil {
+		s.logger.With("notification_id", n.ID).With("user_id", n.UserID).Error(fmt.Sprintf("publish failed: %v", err))
 	}
+	return err
+}
 
-	for _, ch := range channels {
-		select {
-		case ch <- n:
-		default:
-			// Channel full, skip
-		}
+// heartbeatInterval is how often StreamNotifications sends a heartbeat
+// (a Notification with Type NotificationTypeUnspecified) so a client can
+// tell a quiet-but-alive stream from a dead one.
+const heartbeatInterval = 30 * time.Second
+
+// StreamNotifications streams notifications of the given types to a
+// client. If sinceID is non-empty, every notification the subscriber
+// missed (e.g. across a reconnect) with ID greater than sinceID is
+// replayed before live ones. StreamNotifications blocks, sending a
+// heartbeat every heartbeatInterval, until ctx is done.
+func (s *NotificationServer) StreamNotifications(ctx context.Context, userID string, types []NotificationType, sinceID string, out chan *Notification) error {
+	wanted := make(map[NotificationType]bool, len(types))
+	for _, t := range types {
+		wanted[t] = true
 	}
-}
 
-// StreamNotifications streams notifications to a client.
-func (s *NotificationServer) StreamNotifications(userID string, types []NotificationType, stream chan *Notification) error {
-	// Register subscriber
-	s.mu.Lock()
-	if s.subscribers[userID] == nil {
-		s.subscribers[userID] = make([]chan *Notification, 0)
+	sub, cleanup, err := s.broker.Subscribe(ctx, userID, sinceID)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe user %s: %w", userID, err)
 	}
-	s.subscribers[userID] = append(s.subscribers[userID], stream)
-	s.mu.Unlock()
-
-	// Cleanup on exit
-	defer func() {
-		s.mu.Lock()
-		channels := s.subscribers[userID]
-		for i, ch := range channels {
-			if ch == stream {
-				s.subscribers[userID] = append(channels[:i], channels[i+1:]...)
-				break
-			}
-		}
-		s.mu.Unlock()
-		close(stream)
-	}()
+	s.metrics.SubscriberOpened(userID)
+	defer s.metrics.SubscriberClosed(userID)
+	defer cleanup()
 
-	// Keep connection alive
-	select {}
-}
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
 
-// SendBulkNotifications sends notifications to multiple users.
-func (s *NotificationServer) SendBulkNotifications(ctx context.Context, userIDs []string, notificationType NotificationType, title, body string, data map[string]string, priority Priority) (int, int, []string) {
-	var (
-		totalSent   int
-		totalFailed int
-		failedIDs   []string
-		wg          sync.WaitGroup
-		mu          sync.Mutex
-	)
-
-	// VULNERABILITY: Unbounded concurrency - could exhaust resources
-	for _, userID := range userIDs {
-		wg.Add(1)
-		go func(uid string) {
-			defer wg.Done()
-
-			req := &SendNotificationRequest{
-				UserID:   uid,
-				Type:     notificationType,
-				Title:    title,
-				Body:     body,
-				Data:     data,
-				Priority: priority,
+	for {
+		select {
+		case <-ctx.Done():
+			close(out)
+			return ctx.Err()
+
+		case n, ok := <-sub:
+			if !ok {
+				close(out)
+				return nil
 			}
-
-			resp, err := s.SendNotification(ctx, req)
-			mu.Lock()
-			defer mu.Unlock()
-
-			if err != nil || !resp.Success {
-				totalFailed++
-				failedIDs = append(failedIDs, uid)
-			} else {
-				totalSent++
+			if len(wanted) > 0 && !wanted[NotificationType(n.Type)] {
+				continue
 			}
-		}(userID)
+			out <- brokerToNotification(n)
+
+		case <-ticker.C:
+			out <- &Notification{Type: NotificationTypeUnspecified, CreatedAt: time.Now().Unix()}
+		}
 	}
+}
 
-	wg.Wait()
-	return totalSent, totalFailed, failedIDs
+func brokerToNotification(n *broker.Notification) *Notification {
+	return &Notification{
+		ID:        n.ID,
+		UserID:    n.UserID,
+		Type:      NotificationType(n.Type),
+		Title:     n.Title,
+		Body:      n.Body,
+		Data:      n.Data,
+		Priority:  Priority(n.Priority),
+		CreatedAt: n.CreatedAt,
+	}
 }
 
 // GetNotificationStatus gets the status of a notification.
@@ -299,26 +455,6 @@ func (s *NotificationServer) GetNotificationStatus(ctx context.Context, notifica
 	return n, nil
 }
 
-// SubscribeToTopic subscribes a user to topics.
-func (s *NotificationServer) SubscribeToTopic(ctx context.Context, userID string, topics []string) ([]string, error) {
-	if userID == "" {
-		return nil, status.Error(codes.InvalidArgument, "user_id is required")
-	}
-
-	if len(topics) == 0 {
-		return nil, status.Error(codes.InvalidArgument, "topics are required")
-	}
-
-	// Store subscriptions
-	for _, topic := range topics {
-		if err := s.store.Subscribe(userID, topic); err != nil {
-			return nil, status.Error(codes.Internal, fmt.Sprintf("failed to subscribe to %s: %v", topic, err))
-		}
-	}
-
-	return topics, nil
-}
-
 // generateID generates a simple unique ID.
 func generateID() string {
 	return fmt.Sprintf("notif_%d", time.Now().UnixNano())
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SubscribeToTopic subscribes a user to topics, each of which may be a
+// literal topic ("orders.created") or contain the hierarchical
+// wildcards PublishToTopic matches against ("orders.*", "orders.>").
+// Subscriptions are persisted via s.store (so GetSubscriptions/the
+// Redis-backed history survive a restart) and indexed in s.topics for
+// O(depth) PublishToTopic matching.
+func (s *NotificationServer) SubscribeToTopic(ctx context.Context, userID string, topics []string) ([]string, error) {
+	if userID == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	if len(topics) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "topics are required")
+	}
+
+	for _, t := range topics {
+		if err := s.store.Subscribe(userID, t); err != nil {
+			return nil, status.Error(codes.Internal, fmt.Sprintf("failed to subscribe to %s: %v", t, err))
+		}
+		s.topics.Subscribe(userID, t)
+	}
+
+	return topics, nil
+}
+
+// MuteTopic adds a negative filter so userID stops receiving
+// PublishToTopic matches against pattern, even though a broader
+// subscription (e.g. "orders.>") still matches it.
+func (s *NotificationServer) MuteTopic(ctx context.Context, userID, pattern string) error {
+	if userID == "" || pattern == "" {
+		return status.Error(codes.InvalidArgument, "user_id and pattern are required")
+	}
+	s.topics.Mute(userID, pattern)
+	return nil
+}
+
+// UnmuteTopic removes a previously-set MuteTopic filter.
+func (s *NotificationServer) UnmuteTopic(ctx context.Context, userID, pattern string) error {
+	if userID == "" || pattern == "" {
+		return status.Error(codes.InvalidArgument, "user_id and pattern are required")
+	}
+	s.topics.Unmute(userID, pattern)
+	return nil
+}
+
+// ListTopicSubscribers is an admin API returning every userID subscribed
+// at exactly pattern (no wildcard expansion -- a subscriber to
+// "orders.>" isn't listed under "orders.created").
+func (s *NotificationServer) ListTopicSubscribers(ctx context.Context, pattern string) ([]string, error) {
+	if pattern == "" {
+		return nil, status.Error(codes.InvalidArgument, "pattern is required")
+	}
+	return s.topics.Subscribers(pattern), nil
+}
+
+// BulkUnsubscribeTopic is an admin API that removes userID's subscription
+// to every pattern in patterns in one call, from both the durable store
+// and the topic index.
+func (s *NotificationServer) BulkUnsubscribeTopic(ctx context.Context, userID string, patterns []string) error {
+	if userID == "" {
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	for _, pattern := range patterns {
+		if err := s.store.Unsubscribe(userID, pattern); err != nil {
+			return status.Error(codes.Internal, fmt.Sprintf("failed to unsubscribe from %s: %v", pattern, err))
+		}
+		s.topics.Unsubscribe(userID, pattern)
+	}
+	return nil
+}
+
+// NotificationTemplate is the content PublishToTopic sends to every
+// subscriber it fans a topic out to; it's the UserID-less subset of
+// SendNotificationRequest.
+type NotificationTemplate struct {
+	Type     NotificationType
+	Title    string
+	Body     string
+	Data     map[string]string
+	Priority Priority
+}
+
+// BulkResponse aggregates a bulk/fan-out send's outcome.
+type BulkResponse struct {
+	TotalSent   int
+	TotalFailed int
+	FailedIDs   []string
+}
+
+// PublishToTopic sends template to every user whose subscription matches
+// topic (via s.topics.Match), using the same bounded-concurrency,
+// rate-limited pipeline as SendBulkNotifications.
+func (s *NotificationServer) PublishToTopic(ctx context.Context, topicStr string, template NotificationTemplate) (*BulkResponse, error) {
+	if topicStr == "" {
+		return nil, status.Error(codes.InvalidArgument, "topic is required")
+	}
+
+	userIDs := s.topics.Match(topicStr)
+	resp := &BulkResponse{}
+	if len(userIDs) == 0 {
+		return resp, nil
+	}
+
+	out := make(chan BulkResult)
+	go s.SendBulkNotificationsStream(ctx, &BulkSendRequest{
+		UserIDs:  userIDs,
+		Type:     template.Type,
+		Title:    template.Title,
+		Body:     template.Body,
+		Data:     template.Data,
+		Priority: template.Priority,
+		Config:   DefaultBulkConfig(),
+	}, out)
+
+	for r := range out {
+		if r.Sent {
+			resp.TotalSent++
+		} else {
+			resp.TotalFailed++
+			resp.FailedIDs = append(resp.FailedIDs, r.UserID)
+		}
+	}
+
+	return resp, nil
+}
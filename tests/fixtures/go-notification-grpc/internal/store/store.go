@@ -36,6 +36,16 @@ type NotificationStore struct {
 	cacheMu     sync.RWMutex
 	subscribers map[string][]string // userID -> topics
 	subMu       sync.RWMutex
+
+	idempotency map[string]idempotencyEntry // hashed idempotency key -> notification ID, local fallback
+	idempMu     sync.Mutex
+}
+
+// idempotencyEntry is the local-cache fallback for an idempotency key
+// when no Redis client is configured; see SaveIdempotencyKey.
+type idempotencyEntry struct {
+	notificationID string
+	expiresAt      time.Time
 }
 
 // Config holds Redis configuration.
@@ -65,6 +75,7 @@ func NewNotificationStore(cfg Config) (*NotificationStore, error) {
 		client:      client,
 		localCache:  make(map[string]*Notification),
 		subscribers: make(map[string][]string),
+		idempotency: make(map[string]idempotencyEntry),
 	}, nil
 }
 
@@ -73,6 +84,7 @@ func NewInMemoryStore() *NotificationStore {
 	return &NotificationStore{
 		localCache:  make(map[string]*Notification),
 		subscribers: make(map[string][]string),
+		idempotency: make(map[string]idempotencyEntry),
 	}
 }
 
@@ -305,6 +317,93 @@ func (s *NotificationStore) GetTopicSubscribers(topic string) ([]string, error)
 	return subscribers, nil
 }
 
+// SaveIdempotencyKey atomically associates key with notificationID,
+// unless key is already associated with an earlier, unexpired
+// notificationID -- in which case it leaves that association alone and
+// reports it via existingID. stored is true only when key was newly
+// claimed by this call, so the caller knows it's the one that should
+// actually perform the send. ttl bounds how long key is remembered;
+// after it elapses a repeat of the same key is treated as a new send.
+func (s *NotificationStore) SaveIdempotencyKey(key, notificationID string, ttl time.Duration) (stored bool, existingID string, err error) {
+	if s.client != nil {
+		ctx := context.Background()
+		redisKey := fmt.Sprintf("idempotency:%s", key)
+
+		ok, err := s.client.SetNX(ctx, redisKey, notificationID, ttl).Result()
+		if err != nil {
+			return false, "", fmt.Errorf("failed to save idempotency key: %w", err)
+		}
+		if ok {
+			return true, "", nil
+		}
+
+		existing, err := s.client.Get(ctx, redisKey).Result()
+		if err != nil {
+			return false, "", fmt.Errorf("failed to load existing idempotency key: %w", err)
+		}
+		return false, existing, nil
+	}
+
+	s.idempMu.Lock()
+	defer s.idempMu.Unlock()
+
+	if entry, ok := s.idempotency[key]; ok && time.Now().Before(entry.expiresAt) {
+		return false, entry.notificationID, nil
+	}
+	s.idempotency[key] = idempotencyEntry{notificationID: notificationID, expiresAt: time.Now().Add(ttl)}
+	return true, "", nil
+}
+
+// GetByIdempotencyKey returns the notification a previous
+// SaveIdempotencyKey(key, ...) claimed, for a retried send to return
+// instead of re-delivering. It returns ErrNotFound if key is unclaimed
+// or has expired.
+func (s *NotificationStore) GetByIdempotencyKey(key string) (*Notification, error) {
+	var notificationID string
+
+	if s.client != nil {
+		ctx := context.Background()
+		id, err := s.client.Get(ctx, fmt.Sprintf("idempotency:%s", key)).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return nil, ErrNotFound
+			}
+			return nil, fmt.Errorf("failed to look up idempotency key: %w", err)
+		}
+		notificationID = id
+	} else {
+		s.idempMu.Lock()
+		entry, ok := s.idempotency[key]
+		s.idempMu.Unlock()
+		if !ok || time.Now().After(entry.expiresAt) {
+			return nil, ErrNotFound
+		}
+		notificationID = entry.notificationID
+	}
+
+	return s.Get(notificationID)
+}
+
+// DeleteIdempotencyKey removes a previously claimed idempotency key,
+// e.g. when the send or schedule it was claimed for turned out to fail,
+// so a client's retry with the same key is free to claim it again
+// instead of getting back a false success for a notification that was
+// never actually delivered.
+func (s *NotificationStore) DeleteIdempotencyKey(key string) error {
+	if s.client != nil {
+		ctx := context.Background()
+		if err := s.client.Del(ctx, fmt.Sprintf("idempotency:%s", key)).Err(); err != nil {
+			return fmt.Errorf("failed to delete idempotency key: %w", err)
+		}
+		return nil
+	}
+
+	s.idempMu.Lock()
+	defer s.idempMu.Unlock()
+	delete(s.idempotency, key)
+	return nil
+}
+
 // Close closes the store connection.
 func (s *NotificationStore) Close() error {
 	if s.client != nil {
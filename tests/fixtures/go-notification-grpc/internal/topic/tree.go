@@ -0,0 +1,206 @@
+// Package topic implements hierarchical topic matching for
+// PublishToTopic, replacing the linear scan over every (userID, topic)
+// pair store.NotificationStore.GetTopicSubscribers did. Patterns are
+// dot-separated segments with two wildcards, matching the MQTT
+// convention: "*" matches exactly one segment ("orders.*" matches
+// "orders.created" but not "orders.created.v2"), and ">" matches one or
+// more trailing segments ("orders.>" matches both of those). A concrete
+// topic published to PublishToTopic never itself contains "*"/">"; only
+// subscription patterns do.
+package topic
+
+import (
+	"strings"
+	"sync"
+)
+
+// node is one segment of the trie. Children are keyed by literal segment
+// text, with the wildcard segments "*" and ">" stored as ordinary map
+// entries -- a concrete topic's segments are never literally "*" or ">",
+// so a lookup by topic segment can never collide with a wildcard child.
+type node struct {
+	children map[string]*node
+	users    map[string]bool // subscribed at this exact pattern
+	muted    map[string]bool // muted (negative filter) at this exact pattern
+}
+
+func newNode() *node {
+	return &node{children: make(map[string]*node)}
+}
+
+// Tree is a trie-backed index of topic subscriptions, safe for
+// concurrent use. The zero value is not usable; use NewTree.
+type Tree struct {
+	mu   sync.RWMutex
+	root *node
+}
+
+// NewTree returns an empty Tree.
+func NewTree() *Tree {
+	return &Tree{root: newNode()}
+}
+
+func segments(pattern string) []string {
+	return strings.Split(pattern, ".")
+}
+
+// walk returns the node at pattern, creating intermediate nodes along
+// the way if create is true; otherwise it returns (nil, false) as soon
+// as a segment has no existing child.
+func (t *Tree) walk(pattern string, create bool) (*node, bool) {
+	n := t.root
+	for _, seg := range segments(pattern) {
+		child, ok := n.children[seg]
+		if !ok {
+			if !create {
+				return nil, false
+			}
+			child = newNode()
+			n.children[seg] = child
+		}
+		n = child
+	}
+	return n, true
+}
+
+// Subscribe registers userID for pattern, which may contain "*"/">"
+// wildcard segments.
+func (t *Tree) Subscribe(userID, pattern string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n, _ := t.walk(pattern, true)
+	if n.users == nil {
+		n.users = make(map[string]bool)
+	}
+	n.users[userID] = true
+}
+
+// Unsubscribe removes userID's subscription to pattern, if any.
+func (t *Tree) Unsubscribe(userID, pattern string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n, ok := t.walk(pattern, false)
+	if !ok {
+		return
+	}
+	delete(n.users, userID)
+}
+
+// UnsubscribeAll removes every subscription (and mute) userID has
+// across every pattern, for a bulk-unsubscribe admin action.
+func (t *Tree) UnsubscribeAll(userID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	removeUser(t.root, userID)
+}
+
+func removeUser(n *node, userID string) {
+	delete(n.users, userID)
+	delete(n.muted, userID)
+	for _, child := range n.children {
+		removeUser(child, userID)
+	}
+}
+
+// Mute adds a negative filter: userID won't receive a PublishToTopic
+// match against pattern even if a broader subscription also matches it,
+// e.g. subscribed to "orders.>" but muted on "orders.cancelled".
+func (t *Tree) Mute(userID, pattern string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n, _ := t.walk(pattern, true)
+	if n.muted == nil {
+		n.muted = make(map[string]bool)
+	}
+	n.muted[userID] = true
+}
+
+// Unmute removes a previously-set mute on pattern for userID.
+func (t *Tree) Unmute(userID, pattern string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n, ok := t.walk(pattern, false)
+	if !ok {
+		return
+	}
+	delete(n.muted, userID)
+}
+
+// Subscribers returns every userID subscribed at exactly pattern
+// (no wildcard expansion), for the admin "list subscribers per topic"
+// API.
+func (t *Tree) Subscribers(pattern string) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	n, ok := t.walk(pattern, false)
+	if !ok {
+		return nil
+	}
+	return keys(n.users)
+}
+
+// Match returns every userID whose subscription pattern matches the
+// concrete topic, minus anyone who muted a pattern that also matches it.
+func (t *Tree) Match(topicStr string) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	matched := make(map[string]bool)
+	muted := make(map[string]bool)
+	match(t.root, segments(topicStr), matched, muted)
+
+	out := make([]string, 0, len(matched))
+	for userID := range matched {
+		if !muted[userID] {
+			out = append(out, userID)
+		}
+	}
+	return out
+}
+
+// match walks every trie path that could match segs, collecting users at
+// each node along the way whose pattern fully accounts for segs: an
+// exact/"*"-only path when segs is empty, or a ">" node at any depth
+// (since ">" matches one-or-more trailing segments, it also has to have
+// consumed at least one).
+func match(n *node, segs []string, matched, muted map[string]bool) {
+	if len(segs) == 0 {
+		collect(n.users, matched)
+		collect(n.muted, muted)
+		return
+	}
+
+	head, rest := segs[0], segs[1:]
+
+	if child, ok := n.children[head]; ok {
+		match(child, rest, matched, muted)
+	}
+	if child, ok := n.children["*"]; ok {
+		match(child, rest, matched, muted)
+	}
+	if child, ok := n.children[">"]; ok {
+		// ">" matches everything from here to the end of the topic,
+		// regardless of how many segments remain.
+		collect(child.users, matched)
+		collect(child.muted, muted)
+	}
+}
+
+func collect(src, dst map[string]bool) {
+	for userID := range src {
+		dst[userID] = true
+	}
+}
+
+func keys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
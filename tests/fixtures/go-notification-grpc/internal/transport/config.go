@@ -0,0 +1,63 @@
+package transport
+
+// Config selects and configures which Transport backs each notification
+// type. A nil field leaves that type's slot in the Registry unset;
+// NewRegistryFromConfig registers a FakeTransport for InApp by default
+// since in-app delivery is handled by server.sendInApp's subscriber
+// fan-out rather than an external provider.
+type Config struct {
+	SMTP    *SMTPConfig
+	Push    *PushConfig
+	SMS     *SMSConfig
+	Webhook *WebhookConfig
+}
+
+// PushConfig configures PushTransport's two providers together, since a
+// deployment either wants push at all (both FCM and APNS wired) or not.
+type PushConfig struct {
+	FCM  FCMConfig
+	APNS APNSConfig
+}
+
+// NewRegistryFromConfig builds a Registry wiring each configured
+// provider to its NotificationType: SMTP -> TypeEmail, Push -> TypePush,
+// SMS -> TypeSMS. TypeInApp is left to server.sendInApp, which doesn't
+// go through a Transport. cfg.Webhook, if set, backs any of those three
+// types that has no dedicated provider configured, so a deployment with
+// only a webhook integration can still cover every channel. A type left
+// with no transport at all returns ErrNoTransport from Registry.Send
+// until the caller registers one (e.g. FakeTransport in tests).
+func NewRegistryFromConfig(cfg Config) *Registry {
+	r := NewRegistry()
+
+	var webhook *WebhookTransport
+	if cfg.Webhook != nil {
+		webhook = NewWebhookTransport(*cfg.Webhook)
+	}
+
+	switch {
+	case cfg.SMTP != nil:
+		r.Register(TypeEmail, NewSMTPTransport(*cfg.SMTP))
+	case webhook != nil:
+		r.Register(TypeEmail, webhook)
+	}
+
+	switch {
+	case cfg.Push != nil:
+		r.Register(TypePush, &PushTransport{
+			FCM:  NewFCMProvider(cfg.Push.FCM),
+			APNS: NewAPNSProvider(cfg.Push.APNS),
+		})
+	case webhook != nil:
+		r.Register(TypePush, webhook)
+	}
+
+	switch {
+	case cfg.SMS != nil:
+		r.Register(TypeSMS, NewSMSTransport(*cfg.SMS))
+	case webhook != nil:
+		r.Register(TypeSMS, webhook)
+	}
+
+	return r
+}
@@ -0,0 +1,48 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// FakeTransport records every notification it's asked to send instead of
+// delivering it anywhere, for use in tests that exercise the registry/
+// server dispatch path without a real SMTP/FCM/Twilio/webhook endpoint.
+type FakeTransport struct {
+	mu   sync.Mutex
+	sent []*Notification
+
+	// Err, if set, is returned by every Send instead of succeeding.
+	Err error
+
+	nextID int
+}
+
+// NewFakeTransport returns a FakeTransport that succeeds on every Send.
+func NewFakeTransport() *FakeTransport {
+	return &FakeTransport{}
+}
+
+// Send implements Transport.
+func (f *FakeTransport) Send(ctx context.Context, n *Notification) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return "", f.Err
+	}
+
+	f.sent = append(f.sent, n)
+	f.nextID++
+	return fmt.Sprintf("fake-%d", f.nextID), nil
+}
+
+// Sent returns every notification passed to Send so far, in order.
+func (f *FakeTransport) Sent() []*Notification {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]*Notification, len(f.sent))
+	copy(out, f.sent)
+	return out
+}
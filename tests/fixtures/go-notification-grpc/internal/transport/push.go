@@ -0,0 +1,242 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// PushProvider sends a single push notification to one device token and
+// returns the provider's message ID, the shared shape behind both FCM
+// and APNS so PushTransport can fan a notification's Data["device_tokens"]
+// out across providers without caring which one is backing a given
+// token.
+type PushProvider interface {
+	SendPush(ctx context.Context, deviceToken, title, body string, data map[string]string) (providerID string, err error)
+}
+
+// PushTransport delivers a notification to every device token in
+// n.Data["device_tokens"] (comma-separated), routing each token to
+// FCMProvider or APNSProvider by Data["platform"] ("android"/"ios"),
+// mirroring how cross-platform push SDKs split delivery between FCM and
+// APNS behind one call.
+type PushTransport struct {
+	FCM  PushProvider
+	APNS PushProvider
+}
+
+// Send implements Transport. It returns the first successful provider ID
+// and joins any per-token errors rather than failing the whole
+// notification for one bad token.
+func (t *PushTransport) Send(ctx context.Context, n *Notification) (string, error) {
+	tokens := splitTokens(n.Data["device_tokens"])
+	if len(tokens) == 0 {
+		return "", fmt.Errorf("push transport: no device tokens for notification %s", n.ID)
+	}
+
+	provider := t.FCM
+	if n.Data["platform"] == "ios" {
+		provider = t.APNS
+	}
+	if provider == nil {
+		return "", fmt.Errorf("push transport: no provider configured for platform %q", n.Data["platform"])
+	}
+
+	var providerID string
+	var errs []error
+	for _, token := range tokens {
+		id, err := provider.SendPush(ctx, token, n.Title, n.Body, n.Data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("token %s: %w", token, err))
+			continue
+		}
+		if providerID == "" {
+			providerID = id
+		}
+	}
+
+	if providerID == "" {
+		return "", fmt.Errorf("push transport: all %d token(s) failed: %v", len(tokens), errs)
+	}
+	return providerID, nil
+}
+
+func splitTokens(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	var tokens []string
+	start := 0
+	for i := 0; i <= len(raw); i++ {
+		if i == len(raw) || raw[i] == ',' {
+			if i > start {
+				tokens = append(tokens, raw[start:i])
+			}
+			start = i + 1
+		}
+	}
+	return tokens
+}
+
+// FCMConfig configures an FCMProvider.
+type FCMConfig struct {
+	// Endpoint is FCM's send endpoint; overridable for testing against a
+	// local httptest.Server.
+	Endpoint   string
+	ServerKey  string
+	HTTPClient *http.Client
+}
+
+// FCMProvider sends push notifications through Firebase Cloud
+// Messaging's legacy HTTP send endpoint.
+type FCMProvider struct {
+	cfg FCMConfig
+}
+
+// NewFCMProvider builds an FCMProvider, defaulting Endpoint to FCM's send
+// endpoint and HTTPClient to http.DefaultClient.
+func NewFCMProvider(cfg FCMConfig) *FCMProvider {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://fcm.googleapis.com/fcm/send"
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	return &FCMProvider{cfg: cfg}
+}
+
+type fcmRequest struct {
+	To           string            `json:"to"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data,omitempty"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmResponse struct {
+	MulticastID int64 `json:"multicast_id"`
+	Success     int   `json:"success"`
+	Failure     int   `json:"failure"`
+	Results     []struct {
+		MessageID string `json:"message_id"`
+		Error     string `json:"error"`
+	} `json:"results"`
+}
+
+// SendPush implements PushProvider.
+func (p *FCMProvider) SendPush(ctx context.Context, deviceToken, title, body string, data map[string]string) (string, error) {
+	payload, err := json.Marshal(fcmRequest{
+		To:           deviceToken,
+		Notification: fcmNotification{Title: title, Body: body},
+		Data:         data,
+	})
+	if err != nil {
+		return "", fmt.Errorf("fcm: failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("fcm: failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "key="+p.cfg.ServerKey)
+
+	resp, err := p.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fcm: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body2, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fcm: unexpected status %d: %s", resp.StatusCode, body2)
+	}
+
+	var fcmResp fcmResponse
+	if err := json.Unmarshal(body2, &fcmResp); err != nil {
+		return "", fmt.Errorf("fcm: failed to decode response: %w", err)
+	}
+	if fcmResp.Failure > 0 && len(fcmResp.Results) > 0 {
+		return "", fmt.Errorf("fcm: send failed: %s", fcmResp.Results[0].Error)
+	}
+	if len(fcmResp.Results) == 0 {
+		return "", fmt.Errorf("fcm: response had no results")
+	}
+	return fcmResp.Results[0].MessageID, nil
+}
+
+// APNSConfig configures an APNSProvider.
+type APNSConfig struct {
+	// Endpoint is Apple's HTTP/2 APNS gateway; overridable for testing.
+	Endpoint   string
+	BundleID   string
+	AuthToken  string // JWT signed with the APNs auth key (.p8)
+	HTTPClient *http.Client
+}
+
+// APNSProvider sends push notifications through Apple's HTTP/2 APNS
+// provider API using a token-based (.p8) auth, the approach Apple
+// recommends over certificate-based auth.
+type APNSProvider struct {
+	cfg APNSConfig
+}
+
+// NewAPNSProvider builds an APNSProvider, defaulting Endpoint to Apple's
+// production gateway and HTTPClient to http.DefaultClient.
+func NewAPNSProvider(cfg APNSConfig) *APNSProvider {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://api.push.apple.com"
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	return &APNSProvider{cfg: cfg}
+}
+
+type apnsPayload struct {
+	Aps struct {
+		Alert struct {
+			Title string `json:"title"`
+			Body  string `json:"body"`
+		} `json:"alert"`
+	} `json:"aps"`
+}
+
+// SendPush implements PushProvider.
+func (p *APNSProvider) SendPush(ctx context.Context, deviceToken, title, body string, data map[string]string) (string, error) {
+	var payload apnsPayload
+	payload.Aps.Alert.Title = title
+	payload.Aps.Alert.Body = body
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("apns: failed to marshal payload: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/3/device/%s", p.cfg.Endpoint, deviceToken)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(encoded))
+	if err != nil {
+		return "", fmt.Errorf("apns: failed to build request: %w", err)
+	}
+	req.Header.Set("authorization", "bearer "+p.cfg.AuthToken)
+	req.Header.Set("apns-topic", p.cfg.BundleID)
+
+	resp, err := p.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("apns: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("apns: unexpected status %d: %s", resp.StatusCode, respBody)
+	}
+
+	return resp.Header.Get("apns-id"), nil
+}
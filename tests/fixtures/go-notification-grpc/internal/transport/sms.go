@@ -0,0 +1,91 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SMSConfig configures an SMSTransport against Twilio's REST API (or any
+// Twilio-compatible gateway that accepts the same form-encoded POST).
+type SMSConfig struct {
+	// Endpoint is Twilio's Messages resource URL for the account, e.g.
+	// "https://api.twilio.com/2010-04-01/Accounts/<SID>/Messages.json".
+	// Overridable for testing against a local httptest.Server.
+	Endpoint   string
+	AccountSID string
+	AuthToken  string
+	From       string
+
+	// ToNumber resolves a notification's UserID to an E.164 phone
+	// number; defaults to Data["phone"] if unset.
+	ToNumber   func(n *Notification) string
+	HTTPClient *http.Client
+}
+
+// SMSTransport sends notifications as SMS via Twilio's REST API.
+type SMSTransport struct {
+	cfg SMSConfig
+}
+
+// NewSMSTransport builds an SMSTransport from cfg.
+func NewSMSTransport(cfg SMSConfig) *SMSTransport {
+	if cfg.ToNumber == nil {
+		cfg.ToNumber = func(n *Notification) string { return n.Data["phone"] }
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	return &SMSTransport{cfg: cfg}
+}
+
+type twilioResponse struct {
+	SID   string `json:"sid"`
+	Error string `json:"message"`
+}
+
+// Send implements Transport.
+func (t *SMSTransport) Send(ctx context.Context, n *Notification) (string, error) {
+	to := t.cfg.ToNumber(n)
+	if to == "" {
+		return "", fmt.Errorf("sms transport: no phone number for notification %s", n.ID)
+	}
+
+	form := url.Values{
+		"To":   {to},
+		"From": {t.cfg.From},
+		"Body": {n.Body},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.cfg.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("sms transport: failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(t.cfg.AccountSID, t.cfg.AuthToken)
+
+	resp, err := t.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("sms transport: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("sms transport: unexpected status %d: %s", resp.StatusCode, body)
+	}
+
+	var tr twilioResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return "", fmt.Errorf("sms transport: failed to decode response: %w", err)
+	}
+	if tr.SID == "" {
+		return "", fmt.Errorf("sms transport: no message sid in response: %s", tr.Error)
+	}
+
+	return tr.SID, nil
+}
@@ -0,0 +1,62 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+)
+
+// SMTPConfig configures an SMTPTransport.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+
+	// ToHeader resolves a notification's UserID to a recipient email
+	// address. The registry only carries UserID/Data, not an email
+	// address, so the caller supplies the lookup; a Data["email"] field
+	// is the common case.
+	ToHeader func(n *Notification) string
+}
+
+// SMTPTransport sends notifications as plain-text email via net/smtp.
+type SMTPTransport struct {
+	cfg SMTPConfig
+	// sendMail is smtp.SendMail by default, overridable in tests.
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPTransport builds an SMTPTransport from cfg. cfg.ToHeader
+// defaults to Data["email"] if unset.
+func NewSMTPTransport(cfg SMTPConfig) *SMTPTransport {
+	if cfg.ToHeader == nil {
+		cfg.ToHeader = func(n *Notification) string { return n.Data["email"] }
+	}
+	return &SMTPTransport{cfg: cfg, sendMail: smtp.SendMail}
+}
+
+// Send implements Transport. The "provider ID" for SMTP is simply the
+// notification ID, since net/smtp's SendMail gives back no message ID of
+// its own.
+func (t *SMTPTransport) Send(ctx context.Context, n *Notification) (string, error) {
+	to := t.cfg.ToHeader(n)
+	if to == "" {
+		return "", fmt.Errorf("smtp transport: no recipient address for notification %s", n.ID)
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n", t.cfg.From, to, n.Title, n.Body)
+
+	var auth smtp.Auth
+	if t.cfg.Username != "" {
+		auth = smtp.PlainAuth("", t.cfg.Username, t.cfg.Password, t.cfg.Host)
+	}
+
+	addr := fmt.Sprintf("%s:%s", t.cfg.Host, t.cfg.Port)
+	if err := t.sendMail(addr, auth, t.cfg.From, []string{to}, []byte(msg)); err != nil {
+		return "", fmt.Errorf("smtp transport: failed to send to %s: %w", to, err)
+	}
+
+	return n.ID, nil
+}
@@ -0,0 +1,83 @@
+// Package transport implements the delivery side of a notification send:
+// given a notification, actually get it to the user over email, push,
+// SMS, or a webhook, instead of the log-only stubs server.sendEmail/
+// sendPush/sendSMS used to be.
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoTransport is returned by Registry.Send when no Transport is
+// registered for a notification's type.
+var ErrNoTransport = errors.New("no transport registered for notification type")
+
+// NotificationType mirrors server.NotificationType, duplicated so this
+// package doesn't import internal/server (which will import this
+// package to dispatch sends).
+type NotificationType int32
+
+const (
+	TypeUnspecified NotificationType = 0
+	TypeEmail       NotificationType = 1
+	TypePush        NotificationType = 2
+	TypeSMS         NotificationType = 3
+	TypeInApp       NotificationType = 4
+)
+
+// Notification is the subset of server.Notification a Transport needs to
+// deliver it, duplicated for the same package-isolation reason as
+// Notification in internal/store and internal/queue.
+type Notification struct {
+	ID       string
+	UserID   string
+	Type     NotificationType
+	Title    string
+	Body     string
+	Data     map[string]string
+	Priority int32
+}
+
+// Transport delivers a notification over one channel (email, push, SMS,
+// webhook, ...) and reports back the sending provider's message ID so
+// callers can correlate delivery failures/receipts with it later.
+type Transport interface {
+	Send(ctx context.Context, n *Notification) (providerID string, err error)
+}
+
+// Registry dispatches a notification to the Transport registered for its
+// Type. It's safe for concurrent use after construction; Register isn't
+// meant to be called concurrently with Send.
+type Registry struct {
+	transports map[NotificationType]Transport
+}
+
+// NewRegistry returns an empty Registry; register transports with
+// Register before calling Send.
+func NewRegistry() *Registry {
+	return &Registry{transports: make(map[NotificationType]Transport)}
+}
+
+// Register wires transport as the handler for every notification of
+// typ, replacing whatever was registered for typ before.
+func (r *Registry) Register(typ NotificationType, transport Transport) {
+	r.transports[typ] = transport
+}
+
+// Get returns the Transport registered for typ, if any.
+func (r *Registry) Get(typ NotificationType) (Transport, bool) {
+	t, ok := r.transports[typ]
+	return t, ok
+}
+
+// Send dispatches n to the Transport registered for n.Type, returning
+// ErrNoTransport if none is registered.
+func (r *Registry) Send(ctx context.Context, n *Notification) (providerID string, err error) {
+	t, ok := r.transports[n.Type]
+	if !ok {
+		return "", fmt.Errorf("%w: %d", ErrNoTransport, n.Type)
+	}
+	return t.Send(ctx, n)
+}
@@ -0,0 +1,114 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// WebhookConfig configures a WebhookTransport.
+type WebhookConfig struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+// WebhookTransport POSTs a notification as a generic JSON payload so any
+// downstream listener that already integrates with an uptime-kuma-style
+// webhook (heartbeat/monitor/message envelope) can reuse that same
+// integration for notification-service sends.
+type WebhookTransport struct {
+	cfg WebhookConfig
+}
+
+// NewWebhookTransport builds a WebhookTransport from cfg, defaulting
+// HTTPClient to http.DefaultClient.
+func NewWebhookTransport(cfg WebhookConfig) *WebhookTransport {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	return &WebhookTransport{cfg: cfg}
+}
+
+// webhookPayload mirrors uptime-kuma's webhook shape: heartbeat/monitor
+// describe what happened, msg is the human-readable summary.
+type webhookPayload struct {
+	Heartbeat webhookHeartbeat `json:"heartbeat"`
+	Monitor   webhookMonitor   `json:"monitor"`
+	Msg       string           `json:"msg"`
+}
+
+type webhookHeartbeat struct {
+	Status  int    `json:"status"`
+	Message string `json:"msg"`
+	Time    string `json:"time"`
+}
+
+type webhookMonitor struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Priority int32  `json:"priority"`
+}
+
+// Send implements Transport. The provider ID is a hash of the payload,
+// since a generic webhook endpoint has no standard message-ID response.
+func (t *WebhookTransport) Send(ctx context.Context, n *Notification) (string, error) {
+	payload := webhookPayload{
+		Heartbeat: webhookHeartbeat{
+			Status:  1,
+			Message: n.Body,
+		},
+		Monitor: webhookMonitor{
+			ID:       n.UserID,
+			Name:     n.Title,
+			Type:     notificationTypeName(n.Type),
+			Priority: n.Priority,
+		},
+		Msg: fmt.Sprintf("%s: %s", n.Title, n.Body),
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("webhook transport: failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.cfg.URL, bytes.NewReader(encoded))
+	if err != nil {
+		return "", fmt.Errorf("webhook transport: failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("webhook transport: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("webhook transport: unexpected status %d", resp.StatusCode)
+	}
+
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+func notificationTypeName(t NotificationType) string {
+	switch t {
+	case TypeEmail:
+		return "email"
+	case TypePush:
+		return "push"
+	case TypeSMS:
+		return "sms"
+	case TypeInApp:
+		return "in_app"
+	default:
+		return "unspecified"
+	}
+}
@@ -2,32 +2,52 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
+	"math"
+	"math/rand"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/example/task-queue/internal/logging"
+
+	"log/slog"
 )
 
 // Global configuration
 var (
-	baseURL string
-	timeout time.Duration
-	verbose bool
+	baseURL      string
+	timeout      time.Duration
+	verbose      bool
+	logLevel     string
+	logFormat    string
+	retryTimeout time.Duration
+	retrySleep   time.Duration
+	maxAttempts  int
+	logger       *slog.Logger
 )
 
 func init() {
 	flag.StringVar(&baseURL, "url", "http://localhost:8080", "Task queue server URL")
 	flag.DurationVar(&timeout, "timeout", 30*time.Second, "Request timeout")
 	flag.BoolVar(&verbose, "verbose", false, "Verbose output")
+	flag.StringVar(&logLevel, "log-level", "info", "Log level (debug, info, warn, error)")
+	flag.StringVar(&logFormat, "log-format", "text", "Log format (text, json)")
+	flag.DurationVar(&retryTimeout, "retry-timeout", 0, "Max elapsed time to keep retrying a request before giving up (0 disables retries)")
+	flag.DurationVar(&retrySleep, "retry-sleep", 500*time.Millisecond, "Base delay between retries, grown with full jitter backoff")
+	flag.IntVar(&maxAttempts, "max-attempts", 5, "Maximum request attempts when -retry-timeout is set")
 }
 
 func main() {
 	flag.Parse()
+	logger = logging.New(logLevel, logFormat)
 
 	if len(flag.Args()) < 1 {
 		printUsage()
@@ -57,14 +77,18 @@ func main() {
 		err = cmdHealth(args)
 	case "bulk":
 		err = cmdBulk(args)
+	case "watch":
+		err = cmdWatch(args)
+	case "logs":
+		err = cmdLogs(args)
 	default:
-		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", cmd)
+		logger.Error("unknown command", "command", cmd)
 		printUsage()
 		os.Exit(1)
 	}
 
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		logger.Error("command failed", "command", cmd, "error", err)
 		os.Exit(1)
 	}
 }
@@ -83,7 +107,9 @@ func printUsage() {
 	fmt.Println("  delete <id>               Delete a task")
 	fmt.Println("  stats                     Show queue statistics")
 	fmt.Println("  health                    Check server health")
-	fmt.Println("  bulk <file>               Enqueue tasks from JSON file")
+	fmt.Println("  bulk [opts] <file>        Enqueue tasks from an NDJSON or JSON array file")
+	fmt.Println("  watch <id>                Stream a task's lifecycle events")
+	fmt.Println("  logs [-f] <id>            Show (or -f: follow) a task's event stream")
 	fmt.Println()
 	fmt.Println("Options:")
 	flag.PrintDefaults()
@@ -105,48 +131,104 @@ func NewClient(baseURL string, timeout time.Duration) *Client {
 	}
 }
 
-// Request makes an HTTP request
+// Request makes an HTTP request, retrying transient failures (5xx
+// responses, network errors, context deadline exceeded) with full jitter
+// backoff when -retry-timeout is set. Without -retry-timeout it behaves
+// exactly like a single do().
 func (c *Client) Request(method, path string, body interface{}) ([]byte, error) {
-	url := c.baseURL + path
-
-	var bodyReader io.Reader
+	var data []byte
 	if body != nil {
-		data, err := json.Marshal(body)
+		var err error
+		data, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal body: %w", err)
 		}
+	}
+
+	start := time.Now()
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		respBody, status, err := c.do(method, path, data)
+		if err == nil && !isRetryableStatus(status) {
+			return respBody, nil
+		}
+
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = fmt.Errorf("server returned status %d", status)
+		}
+
+		if retryTimeout <= 0 || attempt >= maxAttempts || time.Since(start) >= retryTimeout {
+			if err != nil {
+				return nil, lastErr
+			}
+			return respBody, nil
+		}
+
+		delay := fullJitterDelay(attempt, retrySleep)
+		logger.Warn("retrying request", "method", method, "path", path, "attempt", attempt, "error", lastErr, "delay", delay)
+		time.Sleep(delay)
+	}
+}
+
+// do performs a single HTTP attempt and reports the response body (or nil
+// on transport failure) alongside the status code for retry decisions.
+func (c *Client) do(method, path string, data []byte) ([]byte, int, error) {
+	url := c.baseURL + path
+
+	var bodyReader io.Reader
+	if data != nil {
 		bodyReader = bytes.NewReader(data)
 	}
 
 	req, err := http.NewRequest(method, url, bodyReader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	if body != nil {
+	if data != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
 	if verbose {
-		fmt.Printf("%s %s\n", method, url)
+		logger.Info("request", "method", method, "url", url)
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, 0, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, resp.StatusCode, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if verbose {
-		fmt.Printf("Response: %d\n", resp.StatusCode)
+		logger.Info("response", "method", method, "url", url, "status", resp.StatusCode)
 	}
 
-	return respBody, nil
+	return respBody, resp.StatusCode, nil
+}
+
+// isRetryableStatus reports whether status is a transient server error
+// worth retrying.
+func isRetryableStatus(status int) bool {
+	return status >= 500
+}
+
+// fullJitterDelay implements the "full jitter" backoff formula: a uniform
+// random delay between 0 and min(cap, base*2^attempt), capping growth at
+// 30 base intervals to keep -retry-sleep the dominant knob.
+func fullJitterDelay(attempt int, base time.Duration) time.Duration {
+	capDelay := base * 30
+	ceiling := float64(base) * math.Pow(2, float64(attempt))
+	if ceiling > float64(capDelay) {
+		ceiling = float64(capDelay)
+	}
+	return time.Duration(ceiling * rand.Float64())
 }
 
 // Response is the standard API response
@@ -383,43 +465,463 @@ func cmdHealth(args []string) error {
 	return nil
 }
 
+// bulkItem is a single task read from the input file, tagged with its
+// 0-based position in the original stream so results and checkpoints stay
+// addressable by line/item number regardless of how chunks are split.
+type bulkItem struct {
+	index   int
+	payload map[string]interface{}
+}
+
+// bulkState is the on-disk checkpoint for a bulk upload, stored alongside
+// the input file as "<input>.state". Completed records which item indices
+// have already been enqueued (and their task IDs) so --resume can skip
+// them; Offset/NextIndex let a resumed NDJSON run seek straight past
+// already-processed lines instead of re-scanning the whole file. Offset is
+// only ever advanced past an unbroken run of fully-successful chunks, so a
+// chunk containing failures is always re-read (and its failed items
+// retried) on the next --resume run, even though its successes are still
+// skipped via Completed.
+type bulkState struct {
+	Offset    int64          `json:"offset"`
+	NextIndex int            `json:"next_index"`
+	Completed map[int]string `json:"completed"`
+}
+
+func newBulkState() *bulkState {
+	return &bulkState{Completed: make(map[int]string)}
+}
+
+func loadBulkState(path string) (*bulkState, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return newBulkState(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := newBulkState()
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	if state.Completed == nil {
+		state.Completed = make(map[int]string)
+	}
+	return state, nil
+}
+
+func (s *bulkState) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// bulkReader stream-parses the bulk input file, detecting NDJSON (one task
+// object per line) vs. a single JSON array by peeking its first non-space
+// byte. For NDJSON it reports the byte offset immediately after each item,
+// which is safe to seek back to; the JSON array fallback always reports -1,
+// since an arbitrary mid-array byte position isn't a resumable seek point.
+type bulkReader struct {
+	ndjson bool
+	br     *bufio.Reader
+	dec    *json.Decoder
+	offset int64
+	index  int
+}
+
+// newBulkReader wraps f, assuming startIndex items have already been
+// consumed (set when resuming from a checkpoint so NDJSON item numbering
+// stays aligned with the original, unsought file).
+func newBulkReader(f *os.File, startIndex int) (*bulkReader, error) {
+	br := bufio.NewReader(f)
+
+	first, err := br.Peek(1)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if len(first) > 0 && first[0] == '[' {
+		dec := json.NewDecoder(br)
+		if _, err := dec.Token(); err != nil {
+			return nil, fmt.Errorf("invalid JSON array: %w", err)
+		}
+		return &bulkReader{dec: dec}, nil
+	}
+
+	return &bulkReader{ndjson: true, br: br, index: startIndex}, nil
+}
+
+// next returns the next item read. For NDJSON input offset is the byte
+// position immediately after it (-1 for the JSON array fallback). io.EOF
+// ends the stream; it may arrive alongside a final, valid item.
+func (r *bulkReader) next() (bulkItem, int64, error) {
+	if r.ndjson {
+		for {
+			line, err := r.br.ReadString('\n')
+			r.offset += int64(len(line))
+
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" {
+				if err != nil {
+					return bulkItem{}, r.offset, err
+				}
+				continue
+			}
+
+			var payload map[string]interface{}
+			if jsonErr := json.Unmarshal([]byte(trimmed), &payload); jsonErr != nil {
+				return bulkItem{}, 0, fmt.Errorf("line %d: %w", r.index+1, jsonErr)
+			}
+
+			item := bulkItem{index: r.index, payload: payload}
+			r.index++
+			return item, r.offset, err
+		}
+	}
+
+	if !r.dec.More() {
+		return bulkItem{}, -1, io.EOF
+	}
+
+	var payload map[string]interface{}
+	if err := r.dec.Decode(&payload); err != nil {
+		return bulkItem{}, -1, fmt.Errorf("item %d: %w", r.index, err)
+	}
+
+	item := bulkItem{index: r.index, payload: payload}
+	r.index++
+	return item, -1, nil
+}
+
+// bulkItemResult mirrors the server's per-item response from POST
+// /tasks/bulk.
+type bulkItemResult struct {
+	Index  int    `json:"index"`
+	TaskID string `json:"task_id"`
+	Error  string `json:"error"`
+}
+
+// bulkChunk is one group of items submitted together in a single POST
+// /tasks/bulk request. num is its position among all chunks in this run
+// (independent of the items' own indices), used to replay completions back
+// into the checkpoint in original file order even though chunks may finish
+// out of order. offset is the byte position immediately after the chunk's
+// last item (-1 for the JSON array fallback).
+type bulkChunk struct {
+	num    int
+	items  []bulkItem
+	offset int64
+}
+
+// bulkChunkResult is the outcome of submitting one bulkChunk.
+type bulkChunkResult struct {
+	ids      map[int]string
+	failures []string
+	offset   int64
+}
+
+// cmdBulk enqueues tasks from a file, accepting either NDJSON (one task per
+// line) or a JSON array, stream-parsed rather than loaded into memory
+// whole. Items are submitted in -chunk-size groups, up to -parallel chunks
+// in flight at once, and progress is checkpointed to "<input>.state" after
+// each chunk so a --resume run skips whatever already succeeded.
 func cmdBulk(args []string) error {
-	if len(args) < 1 {
-		return fmt.Errorf("usage: bulk <json_file>")
+	fs := flag.NewFlagSet("bulk", flag.ExitOnError)
+	chunkSize := fs.Int("chunk-size", 500, "Tasks per bulk request chunk")
+	parallel := fs.Int("parallel", 4, "Maximum in-flight chunk requests")
+	resume := fs.Bool("resume", false, "Skip tasks already recorded in <input>.state")
+	fs.Parse(args)
+
+	if len(fs.Args()) < 1 {
+		return fmt.Errorf("usage: bulk [-chunk-size=N] [-parallel=N] [--resume] <file>")
+	}
+	filename := fs.Args()[0]
+	statePath := filename + ".state"
+
+	state := newBulkState()
+	if *resume {
+		var err error
+		state, err = loadBulkState(statePath)
+		if err != nil {
+			return fmt.Errorf("failed to load checkpoint %s: %w", statePath, err)
+		}
 	}
 
-	filename := args[0]
-	data, err := os.ReadFile(filename)
+	f, err := os.Open(filename)
 	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+		return fmt.Errorf("failed to open file: %w", err)
 	}
+	defer f.Close()
 
-	var tasks []map[string]interface{}
-	if err := json.Unmarshal(data, &tasks); err != nil {
-		return fmt.Errorf("invalid JSON: %w", err)
+	if state.Offset > 0 {
+		if _, err := f.Seek(state.Offset, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to resume at offset %d: %w", state.Offset, err)
+		}
 	}
 
-	client := NewClient(baseURL, timeout)
-	resp, err := client.Request("POST", "/tasks/bulk", tasks)
+	reader, err := newBulkReader(f, state.NextIndex)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to read %s: %w", filename, err)
 	}
 
-	var result struct {
-		Created  []interface{} `json:"created"`
-		Failures []string      `json:"failures"`
+	var chunks []bulkChunk
+	var current []bulkItem
+	var currentOffset int64 = -1
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		chunks = append(chunks, bulkChunk{num: len(chunks), items: current, offset: currentOffset})
+		current = nil
 	}
-	if err := parseResponse(resp, &result); err != nil {
-		return err
+
+	for {
+		item, offset, err := reader.next()
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to parse %s: %w", filename, err)
+		}
+
+		if item.payload != nil {
+			if _, done := state.Completed[item.index]; !done {
+				current = append(current, item)
+				currentOffset = offset
+				if len(current) >= *chunkSize {
+					flush()
+				}
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+	flush()
+
+	if len(chunks) == 0 {
+		fmt.Println("Nothing to enqueue")
+		return nil
+	}
+
+	client := NewClient(baseURL, timeout)
+	results := make([]*bulkChunkResult, len(chunks))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sem := make(chan struct{}, *parallel)
+
+	for _, c := range chunks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(c bulkChunk) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			res := submitBulkChunk(client, c)
+
+			mu.Lock()
+			results[c.num] = res
+			mu.Unlock()
+		}(c)
 	}
+	wg.Wait()
 
-	fmt.Printf("Created: %d tasks\n", len(result.Created))
-	if len(result.Failures) > 0 {
-		fmt.Printf("Failures: %d\n", len(result.Failures))
-		for _, f := range result.Failures {
+	created, failed := 0, 0
+	var failures []string
+	offsetAdvancing := true
+
+	for _, c := range chunks {
+		res := results[c.num]
+		for idx, id := range res.ids {
+			state.Completed[idx] = id
+			created++
+		}
+		failed += len(res.failures)
+		failures = append(failures, res.failures...)
+
+		if offsetAdvancing && len(res.failures) == 0 && res.offset >= 0 {
+			state.Offset = res.offset
+			state.NextIndex += len(c.items)
+		} else {
+			offsetAdvancing = false
+		}
+	}
+
+	if err := state.save(statePath); err != nil {
+		logger.Warn("failed to write checkpoint", "path", statePath, "error", err)
+	}
+
+	fmt.Printf("Created: %d tasks\n", created)
+	if failed > 0 {
+		fmt.Printf("Failures: %d\n", failed)
+		for _, f := range failures {
 			fmt.Printf("  - %s\n", f)
 		}
+		return fmt.Errorf("%d task(s) failed to enqueue; re-run with --resume to retry", failed)
 	}
 
 	return nil
 }
+
+// submitBulkChunk POSTs one chunk to /tasks/bulk and maps its indexed
+// per-item results back onto the chunk's original item indices.
+func submitBulkChunk(client *Client, c bulkChunk) *bulkChunkResult {
+	res := &bulkChunkResult{ids: make(map[int]string), offset: c.offset}
+
+	payloads := make([]map[string]interface{}, len(c.items))
+	for i, it := range c.items {
+		payloads[i] = it.payload
+	}
+
+	resp, err := client.Request("POST", "/tasks/bulk", payloads)
+	if err != nil {
+		res.failures = append(res.failures, fmt.Sprintf("chunk %d: %v", c.num, err))
+		return res
+	}
+
+	var items []bulkItemResult
+	if err := parseResponse(resp, &items); err != nil {
+		res.failures = append(res.failures, fmt.Sprintf("chunk %d: %v", c.num, err))
+		return res
+	}
+
+	for i, it := range items {
+		if i >= len(c.items) {
+			break
+		}
+		globalIndex := c.items[i].index
+		if it.Error != "" {
+			res.failures = append(res.failures, fmt.Sprintf("line %d: %s", globalIndex+1, it.Error))
+			continue
+		}
+		res.ids[globalIndex] = it.TaskID
+	}
+
+	return res
+}
+
+// cmdWatch streams a task's lifecycle events from GET /tasks/{id}/events
+// until it completes, fails, or the command is interrupted, reconnecting
+// with full jitter backoff across network hiccups. This replaces polling
+// cmdGet for long-running tasks like "index", where -timeout would
+// otherwise cut the connection off mid-task.
+func cmdWatch(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: watch <task_id>")
+	}
+	return streamEvents(args[0], func(line string) {
+		fmt.Println(line)
+	})
+}
+
+// cmdLogs streams the same per-task event feed as cmdWatch. This tree has
+// no separate worker log aggregation/storage, so "-f" tails the task's
+// lifecycle events rather than raw log lines; each event still carries
+// enough (task_id, type, error, timestamp) to follow along.
+func cmdLogs(args []string) error {
+	fs := flag.NewFlagSet("logs", flag.ExitOnError)
+	follow := fs.Bool("f", false, "Keep streaming until the task finishes")
+	fs.Parse(args)
+
+	if len(fs.Args()) < 1 {
+		return fmt.Errorf("usage: logs [-f] <task_id>")
+	}
+	taskID := fs.Args()[0]
+
+	if !*follow {
+		client := NewClient(baseURL, timeout)
+		resp, err := client.Request("GET", "/tasks/"+taskID, nil)
+		if err != nil {
+			return err
+		}
+		var task map[string]interface{}
+		if err := parseResponse(resp, &task); err != nil {
+			return err
+		}
+		output, _ := json.MarshalIndent(task, "", "  ")
+		fmt.Println(string(output))
+		return nil
+	}
+
+	return streamEvents(taskID, func(line string) {
+		fmt.Println(line)
+	})
+}
+
+// streamEvents connects to the task's SSE event stream and invokes emit
+// for each JSON event line, until the task reaches a terminal state or the
+// server closes the stream. A dropped connection is retried with full
+// jitter backoff (capped by -retry-timeout, or indefinitely if unset)
+// rather than giving up on the first hiccup.
+func streamEvents(taskID string, emit func(line string)) error {
+	url := baseURL + "/tasks/" + taskID + "/events"
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		done, err := streamEventsOnce(url, emit)
+		if done {
+			return nil
+		}
+		if err != nil {
+			logger.Warn("stream disconnected", "task_id", taskID, "error", err)
+		}
+
+		if retryTimeout > 0 && time.Since(start) >= retryTimeout {
+			return fmt.Errorf("gave up watching task %s: %w", taskID, err)
+		}
+
+		delay := fullJitterDelay(attempt, retrySleep)
+		logger.Info("reconnecting", "task_id", taskID, "attempt", attempt, "delay", delay)
+		time.Sleep(delay)
+	}
+}
+
+// streamEventsOnce opens a single SSE connection and reads events until
+// the stream ends. done is true once a terminal event (completed/failed)
+// or a clean server close is observed, meaning the caller should stop
+// retrying even though err may be nil.
+func streamEventsOnce(url string, emit func(line string)) (done bool, err error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return true, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return true, fmt.Errorf("server returned status %d: %s", resp.StatusCode, body)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, ":"):
+			continue // keepalive comment
+		case strings.HasPrefix(line, "data: "):
+			data := strings.TrimPrefix(line, "data: ")
+			emit(data)
+
+			var ev struct {
+				Type string `json:"type"`
+			}
+			if json.Unmarshal([]byte(data), &ev) == nil &&
+				(ev.Type == "completed" || ev.Type == "failed") {
+				return true, nil
+			}
+		}
+	}
+
+	return false, scanner.Err()
+}
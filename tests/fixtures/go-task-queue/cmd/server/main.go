@@ -3,43 +3,97 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"database/sql"
 	"flag"
 	"fmt"
-	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/example/task-queue/internal/api"
+	apigrpc "github.com/example/task-queue/internal/api/grpc"
+	"github.com/example/task-queue/internal/api/grpc/taskqueuepb"
+	"github.com/example/task-queue/internal/api/service"
+	"github.com/example/task-queue/internal/logging"
 	"github.com/example/task-queue/internal/queue"
 	"github.com/example/task-queue/internal/storage"
 	"github.com/example/task-queue/internal/task"
 	"github.com/example/task-queue/internal/worker"
+	grpclib "google.golang.org/grpc"
+
+	"log/slog"
 )
 
 // Config holds server configuration
 type Config struct {
 	Port            int
+	GRPCPort        int
 	NumWorkers      int
 	QueueSize       int
 	TaskTimeout     time.Duration
 	ShutdownTimeout time.Duration
 	DBPath          string
 	LogLevel        string
+	LogFormat       string
+
+	// AuthMode selects how the server authenticates requests: "none" (no
+	// Authn/CertAuth wired in at all), "token" or "jwt" (bearer-token
+	// auth only, via MemoryAuthenticator or JWTAuthenticator depending on
+	// JWTSecret), "mtls" (client-certificate auth only, requiring a cert
+	// on every connection), or "mtls+jwt" (either a valid client cert or
+	// a valid bearer token is accepted). There's no per-route selection
+	// here -- like Authn/RequireScope, auth mode is a deployment-wide
+	// choice, not something Router() threads per pattern.
+	AuthMode string
+
+	// JWTSecret, if set, makes "token"/"jwt"/"mtls+jwt" AuthMode verify
+	// bearer tokens as HS256 JWTs (see api.JWTAuthenticator) instead of
+	// looking them up in a static table.
+	JWTSecret string
+
+	// TLSCertFile/TLSKeyFile serve the API over HTTPS. Required by
+	// "mtls"/"mtls+jwt" AuthMode, since client certificates only exist on
+	// a TLS connection; optional otherwise.
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// MTLSCAFile is the PEM bundle of CAs trusted to sign client
+	// certificates, required by "mtls"/"mtls+jwt" AuthMode.
+	MTLSCAFile string
+
+	// MTLSAllowedCNs is a comma-separated list of client certificate
+	// common names to admit, each granted every scope. It's a minimal
+	// CLI-flag equivalent of api.AllowedIdentity for operators who don't
+	// need SAN/OU matching or per-identity scopes -- construct a
+	// api.CertAuthenticator directly for anything more specific.
+	MTLSAllowedCNs string
+
+	// BootstrapToken, if true, issues one full-scope API token in
+	// TokenStore on startup and logs the raw value once -- the only way
+	// to obtain a first token for "token"/"mtls+jwt" AuthMode before
+	// /account/tokens itself is reachable, since that endpoint requires
+	// a token to call in the first place.
+	BootstrapToken bool
 }
 
 // DefaultConfig returns default configuration
 func DefaultConfig() Config {
 	return Config{
 		Port:            8080,
+		GRPCPort:        9090,
 		NumWorkers:      4,
 		QueueSize:       10000,
 		TaskTimeout:     30 * time.Second,
 		ShutdownTimeout: 30 * time.Second,
 		DBPath:          "tasks.db",
 		LogLevel:        "info",
+		LogFormat:       "text",
+		AuthMode:        "none",
 	}
 }
 
@@ -48,12 +102,21 @@ func ParseFlags() Config {
 	cfg := DefaultConfig()
 
 	flag.IntVar(&cfg.Port, "port", cfg.Port, "Server port")
+	flag.IntVar(&cfg.GRPCPort, "grpc-port", cfg.GRPCPort, "gRPC server port (0 disables the gRPC listener; requires -auth-mode token, jwt, or mtls+jwt)")
 	flag.IntVar(&cfg.NumWorkers, "workers", cfg.NumWorkers, "Number of workers")
 	flag.IntVar(&cfg.QueueSize, "queue-size", cfg.QueueSize, "Maximum queue size")
 	flag.DurationVar(&cfg.TaskTimeout, "task-timeout", cfg.TaskTimeout, "Task execution timeout")
 	flag.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", cfg.ShutdownTimeout, "Graceful shutdown timeout")
 	flag.StringVar(&cfg.DBPath, "db", cfg.DBPath, "Database path")
 	flag.StringVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "Log level (debug, info, warn, error)")
+	flag.StringVar(&cfg.LogFormat, "log-format", cfg.LogFormat, "Log format (text, json)")
+	flag.StringVar(&cfg.AuthMode, "auth-mode", cfg.AuthMode, "Authentication mode (none, token, jwt, mtls, mtls+jwt)")
+	flag.StringVar(&cfg.JWTSecret, "jwt-secret", cfg.JWTSecret, "HMAC secret for JWT bearer tokens (token/jwt/mtls+jwt mode)")
+	flag.StringVar(&cfg.TLSCertFile, "tls-cert", cfg.TLSCertFile, "TLS certificate file (required for mtls/mtls+jwt)")
+	flag.StringVar(&cfg.TLSKeyFile, "tls-key", cfg.TLSKeyFile, "TLS private key file (required for mtls/mtls+jwt)")
+	flag.StringVar(&cfg.MTLSCAFile, "mtls-ca", cfg.MTLSCAFile, "PEM bundle of CAs trusted for client certificates (mtls/mtls+jwt)")
+	flag.StringVar(&cfg.MTLSAllowedCNs, "mtls-allowed-cns", cfg.MTLSAllowedCNs, "Comma-separated client certificate CNs to admit (mtls/mtls+jwt)")
+	flag.BoolVar(&cfg.BootstrapToken, "bootstrap-token", cfg.BootstrapToken, "Issue one full-scope API token on startup and log it once")
 
 	flag.Parse()
 
@@ -63,18 +126,22 @@ func ParseFlags() Config {
 func main() {
 	cfg := ParseFlags()
 
-	logger := log.New(os.Stdout, "[taskqueue] ", log.LstdFlags|log.Lshortfile)
-	logger.Printf("Starting task queue server with config: %+v", cfg)
+	logger := logging.New(cfg.LogLevel, cfg.LogFormat)
+	logger.Info("starting task queue server", "config", fmt.Sprintf("%+v", cfg))
 
 	// Initialize storage
-	store, err := storage.NewSQLiteStorage(storage.SQLiteConfig{
-		Path:            cfg.DBPath,
-		MaxOpenConns:    10,
-		MaxIdleConns:    5,
-		ConnMaxLifetime: time.Hour,
+	store, err := storage.New(storage.Config{
+		Type: storage.TypeSQLite,
+		SQLite: storage.SQLiteConfig{
+			Path:            cfg.DBPath,
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: time.Hour,
+		},
 	})
 	if err != nil {
-		logger.Fatalf("Failed to initialize storage: %v", err)
+		logger.Error("failed to initialize storage", "error", err)
+		os.Exit(1)
 	}
 	defer store.Close()
 
@@ -82,10 +149,10 @@ func main() {
 	q := queue.NewMemoryQueue(
 		queue.WithMaxSize(cfg.QueueSize),
 		queue.WithEnqueueCallback(func(t *queue.Task) {
-			logger.Printf("Task enqueued: %s (type=%s)", t.ID, t.Type)
+			logger.Info("task enqueued", "task_id", t.ID, "task_type", t.Type)
 		}),
 		queue.WithDequeueCallback(func(t *queue.Task) {
-			logger.Printf("Task dequeued: %s (type=%s)", t.ID, t.Type)
+			logger.Info("task dequeued", "task_id", t.ID, "task_type", t.Type)
 		}),
 	)
 	defer q.Close()
@@ -95,6 +162,28 @@ func main() {
 	registerTaskHandlers(registry, logger)
 
 	// Initialize worker pool
+	poolOpts := []worker.PoolOption{
+		worker.WithLogger(worker.NewSlogLogger(logger)),
+		worker.WithTaskCompleteCallback(func(r *worker.Result) {
+			logger.Info("task completed", "task_id", r.Task.ID, "task_type", r.Task.Type,
+				"worker_id", r.WorkerID, "duration_ms", r.Duration.Milliseconds())
+		}),
+		worker.WithTaskFailCallback(func(t *queue.Task, err error) {
+			logger.Error("task failed", "task_id", t.ID, "task_type", t.Type, "error", err)
+		}),
+		worker.WithPanicHandler(func(id int, recovered interface{}) {
+			logger.Error("worker panicked", "worker_id", id, "recovered", recovered)
+		}),
+	}
+	// store's SQLiteStorage backend honors PauseTask/PauseType (see
+	// storage.SQLiteStorage.IsPaused); queue.MemoryQueue itself has no
+	// notion of pause, so Pool needs this hook to actually skip dispatch
+	// of a task or type paused through the storage/HTTP API instead of
+	// just hiding it from ListTasks.
+	if checker, ok := store.(worker.PauseChecker); ok {
+		poolOpts = append(poolOpts, worker.WithPauseChecker(checker))
+	}
+
 	pool := worker.NewPool(
 		q,
 		registry,
@@ -105,44 +194,122 @@ func main() {
 			ShutdownTimeout: cfg.ShutdownTimeout,
 			RetryDelay:      time.Second,
 			MaxRetries:      3,
+			Backoff:         worker.FullJitterBackoff{Base: time.Second, Max: 30 * time.Second},
 		},
-		worker.WithTaskCompleteCallback(func(r *worker.Result) {
-			logger.Printf("Task completed: %s (duration=%v)", r.Task.ID, r.Duration)
-		}),
-		worker.WithTaskFailCallback(func(t *queue.Task, err error) {
-			logger.Printf("Task failed: %s (error=%v)", t.ID, err)
-		}),
-		worker.WithPanicHandler(func(id int, recovered interface{}) {
-			logger.Printf("Worker %d panicked: %v", id, recovered)
-		}),
+		poolOpts...,
 	)
 
 	// Start worker pool
 	if err := pool.Start(); err != nil {
-		logger.Fatalf("Failed to start worker pool: %v", err)
+		logger.Error("failed to start worker pool", "error", err)
+		os.Exit(1)
 	}
 	defer pool.Stop()
 
-	// Initialize API handler
-	handler := api.NewHandler(q, pool, store)
+	// Initialize API handler. Token management shares the task queue's
+	// own database rather than needing a separate one, when the backend
+	// exposes its *sql.DB (sqlite and postgres; TypeMemory has none).
+	dbStore, ok := store.(interface{ DB() *sql.DB })
+	if !ok {
+		logger.Error("failed to initialize token store", "error", "storage backend does not expose a *sql.DB")
+		os.Exit(1)
+	}
+	tokenStore, err := api.NewTokenStore(dbStore.DB())
+	if err != nil {
+		logger.Error("failed to initialize token store", "error", err)
+		os.Exit(1)
+	}
+	apiMetrics := api.NewMetrics(api.WithExemplars())
+	handler := api.NewHandler(q, pool, store,
+		api.WithTokens(api.NewTokenHandler(tokenStore)),
+		api.WithMetrics(apiMetrics),
+	)
+
+	if cfg.BootstrapToken {
+		raw, _, err := tokenStore.IssueToken("bootstrap", "", []string{
+			string(api.ScopeTasksEnqueue), string(api.ScopeTasksRead),
+			string(api.ScopeTasksAdmin), string(api.ScopeStatsRead), string(api.ScopeAccountTokens),
+		}, 0, 0)
+		if err != nil {
+			logger.Error("failed to issue bootstrap token", "error", err)
+			os.Exit(1)
+		}
+		logger.Warn("issued bootstrap API token; store it now, it will not be shown again", "token", raw)
+	}
+
+	authMiddlewares, bearerAuthenticator, tlsConfig, err := buildAuth(cfg, tokenStore)
+	if err != nil {
+		logger.Error("failed to configure authentication", "error", err)
+		os.Exit(1)
+	}
+
+	// The gRPC server wraps its own service.Service over the same
+	// queue/pool/storage the HTTP handler uses, so both transports see
+	// identical validation and behavior even though they don't share the
+	// literal Service value. Unlike HTTP, gRPC has no "no auth configured"
+	// mode of its own to fall back to, so it refuses to start at all
+	// without a bearer-token Authenticator (-auth-mode token/jwt/mtls+jwt)
+	// for apigrpc.UnaryAuthInterceptor/StreamAuthInterceptor to enforce on
+	// every RPC -- there's no equivalent of mTLS-only auth for gRPC yet.
+	var grpcServer *grpclib.Server
+	if cfg.GRPCPort != 0 {
+		if bearerAuthenticator == nil {
+			logger.Error("refusing to start gRPC listener: -grpc-port is set but -auth-mode has no bearer-token authenticator configured (use token, jwt, or mtls+jwt)")
+			os.Exit(1)
+		}
+		grpcServer = grpclib.NewServer(
+			grpclib.ChainUnaryInterceptor(apigrpc.UnaryAuthInterceptor(bearerAuthenticator)),
+			grpclib.ChainStreamInterceptor(apigrpc.StreamAuthInterceptor(bearerAuthenticator)),
+		)
+		taskqueuepb.RegisterTaskQueueServiceServer(grpcServer, apigrpc.NewServer(service.New(q, pool, store)))
+	}
 
 	// Set up middleware
-	rateLimiter := api.NewRateLimiter(100, time.Minute)
-	metrics := api.NewMetrics()
+	rateLimiter := api.NewRateLimiter(api.NewTokenBucketFactory(), 100, 100, api.RemoteAddrKey)
+	rateLimiter.ForRoute("POST /tasks", 20, 20)
+	rateLimiter.ForRoute("POST /tasks/bulk", 5, 5)
+	rateLimiter.OnReject(func(r *http.Request) {
+		apiMetrics.RecordRejection(r.URL.Path, r.Method)
+	})
+	idempotency := api.NewIdempotencyStore()
+	defer idempotency.StartJanitor(time.Hour)()
+
+	// timeouts gives the long-poll/SSE routes an exemption from the
+	// buffered per-request deadline everything else gets: they flush
+	// incrementally and would break if Middleware held their writes in
+	// timeoutWriter's buffer until the handler returned.
+	timeouts := api.Timeouts(30*time.Second, map[string]time.Duration{
+		"GET /tasks/{id}/wait":   0,
+		"GET /tasks/{id}/events": 0,
+		"GET /tasks/{id}/watch":  0,
+		"GET /tasks/events":      0,
+	})
+
+	// drain lets the SIGTERM handler below reject new requests and cancel
+	// in-flight ones before srv.Shutdown starts closing connections, and
+	// stops pool the same way pool.Stop's own defer would, just earlier --
+	// that defer becomes a harmless no-op second call on the happy path.
+	drain := api.NewDrainMode()
+	drain.Register(pool)
 
-	mux := api.Chain(
-		handler.Router(),
+	middlewares := append([]api.Middleware{
 		api.RequestID(),
+		drain.Middleware(),
 		api.Logger(logger),
 		api.Recover(logger),
-		api.Timeout(30*time.Second),
+		timeouts.Middleware(),
+	}, authMiddlewares...)
+	middlewares = append(middlewares,
 		rateLimiter.Middleware(),
-		metrics.Middleware(),
+		apiMetrics.Middleware(),
 		api.SecureHeaders(),
 		api.MaxBodySize(10<<20), // 10MB
 		api.CORS([]string{"*"}),
+		api.Idempotency(idempotency),
 	)
 
+	mux := api.Chain(handler.Router(), middlewares...)
+
 	// Create server
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Port),
@@ -150,40 +317,165 @@ func main() {
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
+		TLSConfig:    tlsConfig,
 	}
 
 	// Start server in goroutine
 	go func() {
-		logger.Printf("Server listening on port %d", cfg.Port)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Fatalf("Server error: %v", err)
+		logger.Info("server listening", "port", cfg.Port, "tls", tlsConfig != nil)
+		var err error
+		if tlsConfig != nil {
+			err = srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			logger.Error("server error", "error", err)
+			os.Exit(1)
 		}
 	}()
 
+	if grpcServer != nil {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
+		if err != nil {
+			logger.Error("failed to listen for grpc", "error", err)
+			os.Exit(1)
+		}
+		go func() {
+			logger.Info("grpc server listening", "port", cfg.GRPCPort)
+			if err := grpcServer.Serve(lis); err != nil {
+				logger.Error("grpc server error", "error", err)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	logger.Println("Shutting down server...")
+	logger.Info("draining: rejecting new requests, canceling in-flight ones after grace period")
+	if err := drain.Begin(5 * time.Second); err != nil {
+		logger.Error("drain: stopping registered drainers failed", "error", err)
+	}
+
+	logger.Info("shutting down server")
 
 	// Graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		logger.Printf("Server shutdown error: %v", err)
+		logger.Error("server shutdown error", "error", err)
+	}
+
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+
+	logger.Info("server stopped")
+}
+
+// buildAuth turns cfg's AuthMode into the Authn/CertAuth middlewares to
+// prepend to the chain and, for "mtls"/"mtls+jwt", the *tls.Config srv
+// should serve with. It also returns the bearer-token api.Authenticator
+// wantsBearer built, if any -- the gRPC listener reuses it for
+// apigrpc.UnaryAuthInterceptor/StreamAuthInterceptor, since gRPC has no
+// mTLS-equivalent wired in yet and so can only authenticate that way. It
+// returns (nil, nil, nil, nil) for AuthMode "none", which keeps the
+// server's pre-chunk7-2 behavior of running with no authentication wired
+// in at all.
+func buildAuth(cfg Config, tokenStore *api.TokenStore) ([]api.Middleware, api.Authenticator, *tls.Config, error) {
+	if cfg.AuthMode == "none" || cfg.AuthMode == "" {
+		return nil, nil, nil, nil
+	}
+
+	var middlewares []api.Middleware
+	var bearerAuthenticator api.Authenticator
+	var tlsConfig *tls.Config
+
+	wantsMTLS := cfg.AuthMode == "mtls" || cfg.AuthMode == "mtls+jwt"
+	wantsBearer := cfg.AuthMode == "token" || cfg.AuthMode == "jwt" || cfg.AuthMode == "mtls+jwt"
+
+	if !wantsMTLS && !wantsBearer {
+		return nil, nil, nil, fmt.Errorf("unknown auth mode %q", cfg.AuthMode)
+	}
+
+	if wantsMTLS {
+		if cfg.MTLSCAFile == "" || cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+			return nil, nil, nil, fmt.Errorf("auth mode %q requires -mtls-ca, -tls-cert, and -tls-key", cfg.AuthMode)
+		}
+
+		caPEM, err := os.ReadFile(cfg.MTLSCAFile)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to read mtls CA bundle: %w", err)
+		}
+		var allowed []api.AllowedIdentity
+		for _, cn := range strings.Split(cfg.MTLSAllowedCNs, ",") {
+			cn = strings.TrimSpace(cn)
+			if cn == "" {
+				continue
+			}
+			allowed = append(allowed, api.AllowedIdentity{
+				CN: cn,
+				Principal: &api.Principal{
+					Scopes: []string{
+						string(api.ScopeTasksEnqueue),
+						string(api.ScopeTasksRead),
+						string(api.ScopeTasksAdmin),
+						string(api.ScopeStatsRead),
+					},
+				},
+			})
+		}
+
+		certAuthenticator, err := api.NewCertAuthenticator(caPEM, allowed)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to load mtls CA bundle: %w", err)
+		}
+		middlewares = append(middlewares, api.CertAuth(certAuthenticator))
+
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		clientAuth := tls.RequireAndVerifyClientCert
+		if cfg.AuthMode == "mtls+jwt" {
+			// A bearer token is an acceptable alternative to a client
+			// cert, so the TLS handshake itself can't require one --
+			// CertAuth enforces it only when a cert is actually
+			// presented (see CertAuth's doc comment).
+			clientAuth = tls.VerifyClientCertIfGiven
+		}
+		tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientAuth:   clientAuth,
+		}
+	}
+
+	if wantsBearer {
+		// A JWT secret selects the stateless IdP-backed path; otherwise
+		// bearer tokens are looked up in tokenStore, the persistent,
+		// CRUD-manageable, revocable token subsystem -- not the
+		// in-process MemoryAuthenticator, which forgets every token on
+		// restart.
+		if cfg.JWTSecret != "" {
+			bearerAuthenticator = api.NewJWTAuthenticator([]byte(cfg.JWTSecret))
+		} else {
+			bearerAuthenticator = tokenStore
+		}
+		middlewares = append(middlewares, api.Authn(bearerAuthenticator, api.NewTenantRateLimiter()))
 	}
 
-	logger.Println("Server stopped")
+	return middlewares, bearerAuthenticator, tlsConfig, nil
 }
 
 // registerTaskHandlers registers all task handlers
-func registerTaskHandlers(registry *task.Registry, logger *log.Logger) {
+func registerTaskHandlers(registry *task.Registry, logger *slog.Logger) {
 	// Email task handler
 	task.RegisterFunc(registry, "email", 30*time.Second,
 		func(ctx context.Context, payload EmailPayload) (EmailResult, error) {
-			logger.Printf("Sending email to %s", payload.To)
+			logger.Info("sending email", "to", payload.To)
 			// Simulate email sending
 			time.Sleep(100 * time.Millisecond)
 			return EmailResult{
@@ -195,7 +487,7 @@ func registerTaskHandlers(registry *task.Registry, logger *log.Logger) {
 	// Data processing task handler
 	task.RegisterFunc(registry, "process_data", 60*time.Second,
 		func(ctx context.Context, payload DataPayload) (DataResult, error) {
-			logger.Printf("Processing data: %s", payload.Source)
+			logger.Info("processing data", "source", payload.Source)
 			// Simulate processing
 			time.Sleep(200 * time.Millisecond)
 			return DataResult{
@@ -207,7 +499,7 @@ func registerTaskHandlers(registry *task.Registry, logger *log.Logger) {
 	// Report generation task handler
 	task.RegisterFunc(registry, "generate_report", 120*time.Second,
 		func(ctx context.Context, payload ReportPayload) (ReportResult, error) {
-			logger.Printf("Generating report: %s", payload.ReportType)
+			logger.Info("generating report", "report_type", payload.ReportType)
 			// Simulate report generation
 			time.Sleep(500 * time.Millisecond)
 			return ReportResult{
@@ -219,7 +511,7 @@ func registerTaskHandlers(registry *task.Registry, logger *log.Logger) {
 	// Notification task handler
 	task.RegisterFunc(registry, "notification", 10*time.Second,
 		func(ctx context.Context, payload NotificationPayload) (NotificationResult, error) {
-			logger.Printf("Sending notification to user %s", payload.UserID)
+			logger.Info("sending notification", "user_id", payload.UserID)
 			time.Sleep(50 * time.Millisecond)
 			return NotificationResult{
 				Delivered: true,
@@ -230,7 +522,7 @@ func registerTaskHandlers(registry *task.Registry, logger *log.Logger) {
 	// Cleanup task handler
 	task.RegisterFunc(registry, "cleanup", 300*time.Second,
 		func(ctx context.Context, payload CleanupPayload) (CleanupResult, error) {
-			logger.Printf("Running cleanup for %s", payload.Target)
+			logger.Info("running cleanup", "target", payload.Target)
 			time.Sleep(100 * time.Millisecond)
 			return CleanupResult{
 				Cleaned: 42,
@@ -5,61 +5,70 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"log"
+	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/example/task-queue/internal/logging"
 	"github.com/example/task-queue/internal/queue"
+	"github.com/example/task-queue/internal/queue/redisq"
 	"github.com/example/task-queue/internal/task"
 	"github.com/example/task-queue/internal/worker"
+	"github.com/redis/go-redis/v9"
+
+	"log/slog"
 )
 
 // WorkerConfig holds worker configuration
 type WorkerConfig struct {
-	QueueURL     string
-	NumWorkers   int
-	TaskTimeout  time.Duration
-	PollInterval time.Duration
-	LogLevel     string
+	QueueURL        string
+	NumWorkers      int
+	TaskTimeout     time.Duration
+	PollInterval    time.Duration
+	LogLevel        string
+	LogFormat       string
+	ShutdownTimeout time.Duration
+	Drain           bool
 }
 
 // DefaultWorkerConfig returns default worker configuration
 func DefaultWorkerConfig() WorkerConfig {
 	return WorkerConfig{
-		QueueURL:     "memory://",
-		NumWorkers:   2,
-		TaskTimeout:  60 * time.Second,
-		PollInterval: time.Second,
-		LogLevel:     "info",
+		QueueURL:        "memory://",
+		NumWorkers:      2,
+		TaskTimeout:     60 * time.Second,
+		PollInterval:    time.Second,
+		LogLevel:        "info",
+		LogFormat:       "text",
+		ShutdownTimeout: 30 * time.Second,
+		Drain:           true,
 	}
 }
 
 func main() {
 	cfg := parseFlags()
 
-	logger := log.New(os.Stdout, "[worker] ", log.LstdFlags|log.Lshortfile)
-	logger.Printf("Starting worker with config: %+v", cfg)
-
-	// Initialize queue (in real app, this would connect to a shared queue)
-	q := queue.NewMemoryQueue()
-	defer q.Close()
+	logger := logging.New(cfg.LogLevel, cfg.LogFormat)
+	logger.Info("starting worker", "config", fmt.Sprintf("%+v", cfg))
 
 	// Initialize registry
 	registry := task.NewRegistry()
 
 	// Add logging hook
 	registry.AddHook(&task.HookFunc{
-		before: func(ctx context.Context, t *queue.Task) error {
-			logger.Printf("Starting task %s (type=%s)", t.ID, t.Type)
+		before: func(ctx context.Context, t *queue.Task, hc task.HookContext) error {
+			logger.Info("task started", "task_id", t.ID, "task_type", t.Type, "attempt", hc.Attempt)
 			return nil
 		},
-		after: func(ctx context.Context, t *queue.Task, result interface{}, err error) {
+		after: func(ctx context.Context, t *queue.Task, hc task.HookContext, result interface{}, err error) {
 			if err != nil {
-				logger.Printf("Task %s failed: %v", t.ID, err)
+				logger.Error("task failed", "task_id", t.ID, "task_type", t.Type,
+					"attempt", hc.Attempt, "duration_ms", time.Since(hc.StartTime).Milliseconds(), "error", err)
 			} else {
-				logger.Printf("Task %s completed", t.ID)
+				logger.Info("task completed", "task_id", t.ID, "task_type", t.Type,
+					"attempt", hc.Attempt, "duration_ms", time.Since(hc.StartTime).Milliseconds())
 			}
 		},
 	})
@@ -67,27 +76,57 @@ func main() {
 	// Register handlers
 	registerWorkerHandlers(registry, logger)
 
+	// A redis:// queue URL runs on redisq's own Run/ReapLoop instead of
+	// worker.Pool: redisq.Queue predates queue.Open, lives in its own
+	// package to avoid an import cycle with internal/task, and already
+	// dispatches directly through the registry.
+	parsedURL, err := url.Parse(cfg.QueueURL)
+	if err != nil {
+		logger.Error("invalid queue URL", "queue_url", cfg.QueueURL, "error", err)
+		os.Exit(1)
+	}
+	if parsedURL.Scheme == "redis" {
+		runRedisWorker(cfg, parsedURL, registry, logger)
+		return
+	}
+
+	q, err := queue.Open(cfg.QueueURL)
+	if err != nil {
+		logger.Error("failed to open queue", "queue_url", cfg.QueueURL, "error", err)
+		os.Exit(1)
+	}
+	defer q.Close()
+
+	bq, ok := q.(queue.BlockingQueue)
+	if !ok {
+		logger.Error("queue doesn't support blocking dequeue", "queue_url", cfg.QueueURL)
+		os.Exit(1)
+	}
+
 	// Create worker pool
 	pool := worker.NewPool(
-		q,
+		bq,
 		registry,
 		worker.PoolConfig{
 			NumWorkers:      cfg.NumWorkers,
 			MaxQueueSize:    1000,
 			TaskTimeout:     cfg.TaskTimeout,
-			ShutdownTimeout: 30 * time.Second,
+			ShutdownTimeout: cfg.ShutdownTimeout,
 			RetryDelay:      time.Second,
 			MaxRetries:      3,
+			Backoff:         worker.FullJitterBackoff{Base: time.Second, Max: 30 * time.Second},
 		},
+		worker.WithLogger(worker.NewSlogLogger(logger)),
 		worker.WithTaskCompleteCallback(func(r *worker.Result) {
-			logger.Printf("Task %s processed by worker %d in %v",
-				r.Task.ID, r.WorkerID, r.Duration)
+			logger.Info("task processed", "task_id", r.Task.ID, "task_type", r.Task.Type,
+				"worker_id", r.WorkerID, "duration_ms", r.Duration.Milliseconds())
 		}),
 	)
 
 	// Start pool
 	if err := pool.Start(); err != nil {
-		logger.Fatalf("Failed to start worker pool: %v", err)
+		logger.Error("failed to start worker pool", "error", err)
+		os.Exit(1)
 	}
 
 	// Enqueue some test tasks
@@ -100,7 +139,7 @@ func main() {
 				},
 			}
 			if err := q.Enqueue(context.Background(), task); err != nil {
-				logger.Printf("Failed to enqueue task: %v", err)
+				logger.Error("failed to enqueue task", "error", err)
 			}
 			time.Sleep(500 * time.Millisecond)
 		}
@@ -111,13 +150,37 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	logger.Println("Shutting down worker...")
+	logger.Info("shutting down worker")
 
-	if err := pool.Stop(); err != nil {
-		logger.Printf("Error stopping pool: %v", err)
+	if !cfg.Drain {
+		if err := pool.Stop(); err != nil {
+			logger.Error("error stopping pool", "error", err)
+		}
+		logger.Info("worker stopped")
+		return
 	}
 
-	logger.Println("Worker stopped")
+	// First signal drains: stop accepting new tasks and let in-flight
+	// handlers finish within -shutdown-timeout. A second signal escalates
+	// to an immediate abort, cancelling whatever's still running.
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancelShutdown()
+
+	shutdownDone := make(chan error, 1)
+	go func() { shutdownDone <- pool.Shutdown(shutdownCtx) }()
+
+	select {
+	case err := <-shutdownDone:
+		if err != nil {
+			logger.Error("worker shutdown did not drain cleanly", "error", err)
+		}
+	case <-quit:
+		logger.Warn("second interrupt received, forcing worker shutdown")
+		cancelShutdown()
+		<-shutdownDone
+	}
+
+	logger.Info("worker stopped")
 }
 
 func parseFlags() WorkerConfig {
@@ -128,17 +191,95 @@ func parseFlags() WorkerConfig {
 	flag.DurationVar(&cfg.TaskTimeout, "timeout", cfg.TaskTimeout, "Task timeout")
 	flag.DurationVar(&cfg.PollInterval, "poll", cfg.PollInterval, "Poll interval")
 	flag.StringVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "Log level")
+	flag.StringVar(&cfg.LogFormat, "log-format", cfg.LogFormat, "Log format (text, json)")
+	flag.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", cfg.ShutdownTimeout, "Max time to wait for in-flight tasks to drain on shutdown")
+	flag.BoolVar(&cfg.Drain, "drain", cfg.Drain, "Drain in-flight tasks on shutdown instead of cancelling them immediately")
 
 	flag.Parse()
 
 	return cfg
 }
 
-func registerWorkerHandlers(registry *task.Registry, logger *log.Logger) {
+// runRedisWorker runs against a Redis-backed queue using redisq (BLMOVE
+// lists plus a heartbeat reaper) instead of worker.Pool, since redisq.Queue
+// predates queue.Open and dispatches to registry directly through its own
+// Run loop. A stream or pending query param on the URL overrides the
+// shared pending list key (redisq.DefaultConfig's "redisq:pending").
+//
+// The client itself comes from redisq.ClientFor rather than a fresh
+// redis.NewClient, so running more than one worker process (or, in a
+// future that embeds multiple redisq.Queues in one process) against the
+// same Redis instance reuses one connection pool -- which also means
+// this function must not close it: ClientFor's cache may still be
+// serving another caller when this one shuts down.
+func runRedisWorker(cfg WorkerConfig, u *url.URL, registry *task.Registry, logger *slog.Logger) {
+	opts, err := redis.ParseURL(u.String())
+	if err != nil {
+		logger.Error("invalid redis queue URL", "queue_url", u.String(), "error", err)
+		os.Exit(1)
+	}
+	client := redisq.ClientFor(opts)
+
+	rqCfg := redisq.DefaultConfig(fmt.Sprintf("worker-%d", os.Getpid()))
+	if pending := u.Query().Get("stream"); pending != "" {
+		rqCfg.Pending = pending
+	} else if pending := u.Query().Get("pending"); pending != "" {
+		rqCfg.Pending = pending
+	}
+	q := redisq.New(client, rqCfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go q.ReapLoop(ctx)
+
+	// Enqueue some test tasks
+	go func() {
+		for i := 0; i < 10; i++ {
+			t := &queue.Task{
+				Type:    "compute",
+				Payload: map[string]interface{}{"value": i},
+			}
+			if err := q.Enqueue(context.Background(), t); err != nil {
+				logger.Error("failed to enqueue task", "error", err)
+			}
+			time.Sleep(500 * time.Millisecond)
+		}
+	}()
+
+	numWorkers := cfg.NumWorkers
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+
+	done := make(chan struct{}, numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			if err := q.Run(ctx, registry); err != nil && ctx.Err() == nil {
+				logger.Error("redis worker loop stopped", "error", err)
+			}
+			done <- struct{}{}
+		}()
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logger.Info("shutting down worker")
+	cancel()
+	for i := 0; i < numWorkers; i++ {
+		<-done
+	}
+
+	logger.Info("worker stopped")
+}
+
+func registerWorkerHandlers(registry *task.Registry, logger *slog.Logger) {
 	// Compute task
 	task.RegisterFunc(registry, "compute", 30*time.Second,
 		func(ctx context.Context, payload ComputePayload) (ComputeResult, error) {
-			logger.Printf("Computing value: %d", payload.Value)
+			logger.Debug("computing value", "value", payload.Value)
 			// Simulate computation
 			time.Sleep(100 * time.Millisecond)
 			return ComputeResult{
@@ -149,7 +290,7 @@ func registerWorkerHandlers(registry *task.Registry, logger *log.Logger) {
 	// Transform task
 	task.RegisterFunc(registry, "transform", 30*time.Second,
 		func(ctx context.Context, payload TransformPayload) (TransformResult, error) {
-			logger.Printf("Transforming data: %s", payload.Data)
+			logger.Debug("transforming data", "data", payload.Data)
 			time.Sleep(50 * time.Millisecond)
 			return TransformResult{
 				Transformed: fmt.Sprintf("TRANSFORMED(%s)", payload.Data),
@@ -159,7 +300,7 @@ func registerWorkerHandlers(registry *task.Registry, logger *log.Logger) {
 	// Aggregate task
 	task.RegisterFunc(registry, "aggregate", 60*time.Second,
 		func(ctx context.Context, payload AggregatePayload) (AggregateResult, error) {
-			logger.Printf("Aggregating %d values", len(payload.Values))
+			logger.Debug("aggregating values", "count", len(payload.Values))
 			var sum float64
 			for _, v := range payload.Values {
 				sum += v
@@ -174,7 +315,7 @@ func registerWorkerHandlers(registry *task.Registry, logger *log.Logger) {
 	// Index task
 	task.RegisterFunc(registry, "index", 120*time.Second,
 		func(ctx context.Context, payload IndexPayload) (IndexResult, error) {
-			logger.Printf("Indexing documents from %s", payload.Source)
+			logger.Debug("indexing documents", "source", payload.Source)
 			time.Sleep(200 * time.Millisecond)
 			return IndexResult{
 				Indexed:  100,
@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/example/task-queue/internal/api"
+)
+
+// methodScopes maps each RPC's info.FullMethod to the api.Scope it
+// requires, mirroring the ScopeXxx gating Handler.Router applies to the
+// equivalent HTTP route. Health is intentionally absent: it carries no
+// task data, so requireScope lets any authenticated caller through.
+var methodScopes = map[string]api.Scope{
+	"/taskqueuepb.TaskQueueService/Enqueue":     api.ScopeTasksEnqueue,
+	"/taskqueuepb.TaskQueueService/BulkEnqueue": api.ScopeTasksEnqueue,
+	"/taskqueuepb.TaskQueueService/Get":         api.ScopeTasksRead,
+	"/taskqueuepb.TaskQueueService/List":        api.ScopeTasksRead,
+	"/taskqueuepb.TaskQueueService/Watch":       api.ScopeTasksRead,
+	"/taskqueuepb.TaskQueueService/Wait":        api.ScopeTasksRead,
+	"/taskqueuepb.TaskQueueService/Cancel":      api.ScopeTasksAdmin,
+	"/taskqueuepb.TaskQueueService/Retry":       api.ScopeTasksAdmin,
+	"/taskqueuepb.TaskQueueService/Delete":      api.ScopeTasksAdmin,
+	"/taskqueuepb.TaskQueueService/Stats":       api.ScopeStatsRead,
+}
+
+// authenticate resolves ctx's "authorization: Bearer <token>" metadata
+// into a Principal via authenticator, the gRPC counterpart of HTTP's
+// bearerToken extraction plus Authn's lookup. Unlike Authn, a missing or
+// invalid token always fails: gRPC has no notion of a route that doesn't
+// require auth, so leaving it optional here would mean every RPC was
+// reachable without one.
+func authenticate(ctx context.Context, authenticator api.Authenticator) (*api.Principal, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing request metadata")
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing authorization metadata")
+	}
+
+	const prefix = "Bearer "
+	if !strings.HasPrefix(values[0], prefix) {
+		return nil, status.Error(codes.Unauthenticated, "authorization metadata must use the Bearer scheme")
+	}
+
+	principal, err := authenticator.Authenticate(ctx, strings.TrimPrefix(values[0], prefix))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid or unknown token")
+	}
+	return principal, nil
+}
+
+// requireScope denies fullMethod unless principal holds the scope
+// methodScopes maps it to; a method absent from methodScopes is allowed
+// for any authenticated caller.
+func requireScope(principal *api.Principal, fullMethod string) error {
+	scope, ok := methodScopes[fullMethod]
+	if !ok {
+		return nil
+	}
+	if !principal.HasScope(scope) {
+		return status.Errorf(codes.PermissionDenied, "missing required scope: %s", scope)
+	}
+	return nil
+}
+
+// UnaryAuthInterceptor authenticates every unary RPC against authenticator
+// -- the same Authenticator HTTP's api.Authn uses -- and denies it unless
+// the resolved Principal holds the scope methodScopes requires. The
+// Principal is attached to the context handlers receive via
+// api.ContextWithPrincipal, so Server methods can read TenantID back out
+// with api.PrincipalFromContext instead of hardcoding an empty one.
+func UnaryAuthInterceptor(authenticator api.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		principal, err := authenticate(ctx, authenticator)
+		if err != nil {
+			return nil, err
+		}
+		if err := requireScope(principal, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(api.ContextWithPrincipal(ctx, principal), req)
+	}
+}
+
+// StreamAuthInterceptor is UnaryAuthInterceptor's counterpart for
+// BulkEnqueue and Watch, gRPC's two streaming RPCs.
+func StreamAuthInterceptor(authenticator api.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		principal, err := authenticate(ss.Context(), authenticator)
+		if err != nil {
+			return err
+		}
+		if err := requireScope(principal, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, &principalServerStream{ServerStream: ss, ctx: api.ContextWithPrincipal(ss.Context(), principal)})
+	}
+}
+
+// principalServerStream wraps a ServerStream to override Context, so a
+// streaming handler's stream.Context() returns the Principal-bearing
+// context StreamAuthInterceptor built rather than the original.
+type principalServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *principalServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,66 @@
+package grpc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/example/task-queue/internal/api/grpc/taskqueuepb"
+	"github.com/example/task-queue/internal/api/service"
+	"github.com/example/task-queue/internal/queue"
+	"github.com/example/task-queue/internal/storage"
+)
+
+// enqueueRequestFromProto decodes an on-the-wire EnqueueRequest's
+// JSON-encoded payload, the same convention taskpb.Task.payload uses for
+// map[string]interface{} -- see internal/storage.ProtoSerializer.
+func enqueueRequestFromProto(req *taskqueuepb.EnqueueRequest) (service.EnqueueRequest, error) {
+	var payload map[string]interface{}
+	if len(req.Payload) > 0 {
+		if err := json.Unmarshal(req.Payload, &payload); err != nil {
+			return service.EnqueueRequest{}, fmt.Errorf("invalid payload: %w", err)
+		}
+	}
+
+	return service.EnqueueRequest{
+		Type:       req.Type,
+		Payload:    payload,
+		Priority:   queue.Priority(req.Priority),
+		MaxRetries: int(req.MaxRetries),
+		Metadata:   req.Metadata,
+	}, nil
+}
+
+// filterFromListRequest converts a ListRequest to a service.TaskFilter.
+func filterFromListRequest(req *taskqueuepb.ListRequest) service.TaskFilter {
+	return service.TaskFilter{
+		State:    storage.StateFromProto(req.State),
+		Type:     req.Type,
+		Priority: queue.Priority(req.Priority),
+		Limit:    int(req.Limit),
+		Offset:   int(req.Offset),
+	}
+}
+
+// eventFilterFromWatchRequest converts a WatchRequest to a
+// queue.EventFilter.
+func eventFilterFromWatchRequest(req *taskqueuepb.WatchRequest) queue.EventFilter {
+	return queue.EventFilter{
+		TaskID: req.TaskId,
+		Type:   req.Type,
+		State:  storage.StateFromProto(req.State),
+	}
+}
+
+// taskEventToProto converts a queue.TaskEvent to its wire representation.
+func taskEventToProto(ev queue.TaskEvent) *taskqueuepb.TaskEvent {
+	return &taskqueuepb.TaskEvent{
+		TaskId:    ev.TaskID,
+		TaskType:  ev.TaskType,
+		State:     storage.StateToProto(ev.State),
+		Error:     ev.Error,
+		Timestamp: timestamppb.New(ev.Timestamp),
+		Revision:  ev.Revision,
+	}
+}
@@ -0,0 +1,277 @@
+// Package grpc implements TaskQueueService (api/proto/taskqueue.proto) on
+// top of the same internal/api/service.Service the HTTP handlers in
+// internal/api use, so both transports validate requests and talk to
+// queue.Queue/Storage identically. Run `make proto-grpc` to (re)generate
+// taskqueuepb from the .proto before building this package.
+//
+// Server itself does no authentication -- see auth.go's
+// UnaryAuthInterceptor/StreamAuthInterceptor, which cmd/server/main.go
+// installs on the grpc.Server in front of it, the gRPC equivalent of
+// internal/api.Authn. Unlike HTTP, a gRPC deployment has no "no auth
+// configured" mode: main.go refuses to start the gRPC listener at all
+// without a bearer-token Authenticator for the interceptors to enforce.
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/example/task-queue/internal/api"
+	"github.com/example/task-queue/internal/api/grpc/taskqueuepb"
+	"github.com/example/task-queue/internal/api/service"
+	"github.com/example/task-queue/internal/queue"
+	"github.com/example/task-queue/internal/storage"
+	"github.com/example/task-queue/internal/storage/taskpb"
+)
+
+// Server implements taskqueuepb.TaskQueueServiceServer by delegating to a
+// shared service.Service -- see internal/api.Handler for the HTTP sibling
+// built on the same service.
+type Server struct {
+	taskqueuepb.UnimplementedTaskQueueServiceServer
+
+	svc *service.Service
+}
+
+// NewServer creates a Server backed by svc.
+func NewServer(svc *service.Service) *Server {
+	return &Server{svc: svc}
+}
+
+// tenantIDFromContext returns the TenantID of the Principal
+// UnaryAuthInterceptor/StreamAuthInterceptor attached to ctx, or "" if
+// none -- the "skip tenant enforcement" value service.Service's
+// tenant-scoped methods expect, the same fallback HTTP uses for a request
+// with no Principal.
+func tenantIDFromContext(ctx context.Context) string {
+	principal, ok := api.PrincipalFromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return principal.TenantID
+}
+
+// Enqueue implements TaskQueueService.Enqueue.
+func (s *Server) Enqueue(ctx context.Context, req *taskqueuepb.EnqueueRequest) (*taskqueuepb.EnqueueResponse, error) {
+	svcReq, err := enqueueRequestFromProto(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	svcReq.TenantID = tenantIDFromContext(ctx)
+
+	task, err := s.svc.Enqueue(ctx, svcReq)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &taskqueuepb.EnqueueResponse{
+		TaskId:    task.ID,
+		CreatedAt: timestamppb.New(task.CreatedAt),
+	}, nil
+}
+
+// BulkEnqueue implements TaskQueueService.BulkEnqueue, reading the whole
+// client stream before calling service.Service.BulkEnqueue once -- the
+// underlying queue has no notion of a partial batch, so there's nothing to
+// gain from enqueueing item-by-item as they arrive.
+func (s *Server) BulkEnqueue(stream taskqueuepb.TaskQueueService_BulkEnqueueServer) error {
+	var reqs []service.EnqueueRequest
+
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		req, err := enqueueRequestFromProto(msg)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		req.TenantID = tenantIDFromContext(stream.Context())
+		reqs = append(reqs, req)
+	}
+
+	results, err := s.svc.BulkEnqueue(stream.Context(), reqs)
+	if err != nil {
+		return grpcError(err)
+	}
+
+	pbResults := make([]*taskqueuepb.BulkItemResult, len(results))
+	for i, r := range results {
+		pbResults[i] = &taskqueuepb.BulkItemResult{Index: int32(r.Index), TaskId: r.TaskID, Error: r.Error}
+	}
+
+	return stream.SendAndClose(&taskqueuepb.BulkEnqueueResponse{Results: pbResults})
+}
+
+// Get implements TaskQueueService.Get.
+func (s *Server) Get(ctx context.Context, req *taskqueuepb.GetRequest) (*taskpb.Task, error) {
+	task, err := s.svc.Get(ctx, req.Id, tenantIDFromContext(ctx))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return storage.TaskToProto(task)
+}
+
+// List implements TaskQueueService.List.
+func (s *Server) List(ctx context.Context, req *taskqueuepb.ListRequest) (*taskqueuepb.ListResponse, error) {
+	filter := filterFromListRequest(req)
+	filter.TenantID = tenantIDFromContext(ctx)
+
+	tasks, err := s.svc.List(ctx, filter)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	pbTasks := make([]*taskpb.Task, len(tasks))
+	for i, t := range tasks {
+		pbTask, err := storage.TaskToProto(t)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		pbTasks[i] = pbTask
+	}
+
+	return &taskqueuepb.ListResponse{Tasks: pbTasks, Total: int32(len(pbTasks))}, nil
+}
+
+// Cancel implements TaskQueueService.Cancel.
+func (s *Server) Cancel(ctx context.Context, req *taskqueuepb.TaskIDRequest) (*taskpb.Task, error) {
+	task, err := s.svc.Cancel(ctx, req.Id, tenantIDFromContext(ctx))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return storage.TaskToProto(task)
+}
+
+// Retry implements TaskQueueService.Retry.
+func (s *Server) Retry(ctx context.Context, req *taskqueuepb.TaskIDRequest) (*taskpb.Task, error) {
+	task, err := s.svc.Retry(ctx, req.Id, tenantIDFromContext(ctx))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return storage.TaskToProto(task)
+}
+
+// Delete implements TaskQueueService.Delete.
+func (s *Server) Delete(ctx context.Context, req *taskqueuepb.TaskIDRequest) (*taskqueuepb.DeleteResponse, error) {
+	if err := s.svc.Delete(ctx, req.Id, tenantIDFromContext(ctx)); err != nil {
+		return nil, grpcError(err)
+	}
+	return &taskqueuepb.DeleteResponse{}, nil
+}
+
+// Stats implements TaskQueueService.Stats. The underlying stats value is
+// either worker.PoolMetrics or queue.Stats depending on how the Service was
+// configured; neither shares a message shape with the other, so it's
+// carried JSON-encoded the same way taskpb.Task carries Payload/Result.
+func (s *Server) Stats(ctx context.Context, req *taskqueuepb.StatsRequest) (*taskqueuepb.StatsResponse, error) {
+	stats, err := s.svc.Stats(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &taskqueuepb.StatsResponse{Stats: data}, nil
+}
+
+// Health implements TaskQueueService.Health.
+func (s *Server) Health(ctx context.Context, req *taskqueuepb.HealthRequest) (*taskqueuepb.HealthResponse, error) {
+	health, err := s.svc.Health(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &taskqueuepb.HealthResponse{
+		Status:    health.Status,
+		QueueSize: int32(health.QueueSize),
+		Timestamp: timestamppb.New(health.Timestamp),
+	}, nil
+}
+
+// Watch implements TaskQueueService.Watch, the streaming counterpart of
+// internal/api's WatchTaskState/WatchAllTasks SSE handlers -- all three sit
+// on top of the same service.Service.Watch call.
+func (s *Server) Watch(req *taskqueuepb.WatchRequest, stream taskqueuepb.TaskQueueService_WatchServer) error {
+	ctx := stream.Context()
+	filter := eventFilterFromWatchRequest(req)
+	filter.TenantID = tenantIDFromContext(ctx)
+
+	events, err := s.svc.Watch(ctx, filter)
+	if err != nil {
+		return grpcError(err)
+	}
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(taskEventToProto(ev)); err != nil {
+				return err
+			}
+
+			if filter.TaskID != "" {
+				switch ev.State {
+				case queue.StateCompleted, queue.StateFailed, queue.StateCancelled:
+					return nil
+				}
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Wait implements TaskQueueService.Wait, the unary counterpart of
+// GET /tasks/{id}/wait -- both long-poll the same service.Service.Wait
+// call. An unset req.Timeout falls back to defaultWaitTimeout.
+func (s *Server) Wait(ctx context.Context, req *taskqueuepb.WaitRequest) (*taskpb.Task, error) {
+	timeout := defaultWaitTimeout
+	if req.Timeout != nil {
+		timeout = req.Timeout.AsDuration()
+	}
+
+	task, err := s.svc.Wait(ctx, req.Id, tenantIDFromContext(ctx), req.Revision, timeout)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return storage.TaskToProto(task)
+}
+
+// defaultWaitTimeout is the fallback when a Wait caller doesn't set a
+// timeout, matching internal/api's default for GET /tasks/{id}/wait.
+const defaultWaitTimeout = 30 * time.Second
+
+// grpcError maps a service.Service error to a grpc status error.
+// Unrecognized errors (anything from the queue/storage layer that isn't one
+// of the sentinels below) become codes.Internal.
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidRequest):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, queue.ErrTaskNotFound):
+		return status.Error(codes.NotFound, "task not found")
+	case errors.Is(err, service.ErrTaskNotCancellable), errors.Is(err, service.ErrTaskNotRetryable), errors.Is(err, queue.ErrRevisionConflict):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, service.ErrStorageUnavailable), errors.Is(err, service.ErrStatsUnavailable), errors.Is(err, service.ErrWatchUnavailable):
+		return status.Error(codes.Unimplemented, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
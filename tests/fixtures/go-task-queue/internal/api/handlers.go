@@ -5,46 +5,90 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/example/task-queue/internal/api/service"
 	"github.com/example/task-queue/internal/queue"
+	"github.com/example/task-queue/internal/storage"
 	"github.com/example/task-queue/internal/worker"
 )
 
-// Handler holds dependencies for HTTP handlers
+// Handler holds dependencies for HTTP handlers. Request handling itself --
+// validation, talking to the queue/storage/pool, shaping the result -- is
+// delegated to service.Service so it's shared with internal/api/grpc; a
+// Handler method's job is just to decode the HTTP request, call the
+// service, and encode the result (or map a service error to a status
+// code).
 type Handler struct {
-	queue   queue.Queue
+	svc     *service.Service
 	pool    *worker.Pool
-	storage Storage
+	tokens  *TokenHandler
+	metrics *Metrics
 }
 
-// Storage interface for task persistence
-type Storage interface {
-	SaveTask(ctx context.Context, task *queue.Task) error
-	GetTask(ctx context.Context, id string) (*queue.Task, error)
-	ListTasks(ctx context.Context, filter TaskFilter) ([]*queue.Task, error)
-	DeleteTask(ctx context.Context, id string) error
+// Storage interface for task persistence.
+type Storage = service.Storage
+
+// TaskFilter for listing tasks.
+type TaskFilter = service.TaskFilter
+
+// HandlerOption configures optional Handler behavior not every deployment
+// needs, following the same pattern as queue.WithMaxSize and friends.
+type HandlerOption func(*Handler)
+
+// WithTokens registers tokens' /account/tokens routes on the Handler's
+// Router. Without it, Router doesn't expose token management at all --
+// the same opt-in-per-deployment approach Authn/RequireScope use.
+func WithTokens(tokens *TokenHandler) HandlerOption {
+	return func(h *Handler) {
+		h.tokens = tokens
+	}
 }
 
-// TaskFilter for listing tasks
-type TaskFilter struct {
-	State    queue.TaskState
-	Type     string
-	Priority queue.Priority
-	Limit    int
-	Offset   int
+// WithMetrics registers m's /metrics endpoint on the Handler's Router,
+// and labels every task route's "handler" metric with its method name so
+// Metrics.Middleware doesn't fall back to raw request paths (which would
+// give every distinct task ID its own Prometheus series). Without it,
+// Router doesn't expose /metrics at all -- the same opt-in-per-deployment
+// approach WithTokens uses.
+func WithMetrics(m *Metrics) HandlerOption {
+	return func(h *Handler) {
+		m.ForRoute("POST /tasks", "Enqueue").
+			ForRoute("POST /tasks/bulk", "BulkEnqueue").
+			ForRoute("GET /tasks", "ListTasks").
+			ForRoute("GET /tasks/search", "SearchTasks").
+			ForRoute("GET /tasks/{id}", "GetTask").
+			ForRoute("DELETE /tasks/{id}", "DeleteTask").
+			ForRoute("POST /tasks/{id}/cancel", "CancelTask").
+			ForRoute("POST /tasks/{id}/retry", "RetryTask").
+			ForRoute("POST /tasks/{id}/pause", "PauseTask").
+			ForRoute("POST /tasks/{id}/resume", "ResumeTask").
+			ForRoute("POST /tasks/types/{type}/pause", "PauseType").
+			ForRoute("POST /tasks/types/{type}/resume", "ResumeType").
+			ForRoute("GET /tasks/{id}/logs", "GetTaskLogs").
+			ForRoute("GET /tasks/{id}/wait", "WaitTask").
+			ForRoute("GET /tasks/{id}/events", "WatchTask").
+			ForRoute("GET /tasks/{id}/watch", "WatchTaskState").
+			ForRoute("GET /tasks/events", "WatchAllTasks").
+			ForRoute("GET /stats", "GetStats")
+		h.metrics = m
+	}
 }
 
 // NewHandler creates a new API handler
-func NewHandler(q queue.Queue, p *worker.Pool, s Storage) *Handler {
-	return &Handler{
-		queue:   q,
-		pool:    p,
-		storage: s,
+func NewHandler(q queue.Queue, p *worker.Pool, s Storage, opts ...HandlerOption) *Handler {
+	h := &Handler{
+		svc:  service.New(q, p, s),
+		pool: p,
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
 // Response is a standard API response
@@ -72,15 +116,51 @@ type EnqueueRequest struct {
 	Metadata   map[string]string      `json:"metadata,omitempty"`
 }
 
-// Validate validates the enqueue request
-func (r *EnqueueRequest) Validate() error {
-	if r.Type == "" {
-		return errors.New("task type is required")
+func (r EnqueueRequest) toService() service.EnqueueRequest {
+	return service.EnqueueRequest{
+		Type:       r.Type,
+		Payload:    r.Payload,
+		Priority:   r.Priority,
+		MaxRetries: r.MaxRetries,
+		Metadata:   r.Metadata,
+	}
+}
+
+// stampRequestID adds r's request id to req.Metadata under "request_id",
+// unless the caller already set one, so a task can be traced back to the
+// API request that created it (see RequestIDFromContext).
+func stampRequestID(req *service.EnqueueRequest, r *http.Request) {
+	if _, ok := req.Metadata["request_id"]; ok {
+		return
+	}
+	id := RequestIDFromContext(r.Context())
+	if id == "" {
+		return
+	}
+	if req.Metadata == nil {
+		req.Metadata = make(map[string]string, 1)
+	}
+	req.Metadata["request_id"] = id
+}
+
+// stampTenantID sets req.TenantID from r's Principal (see
+// PrincipalFromContext), if Authn attached one. A request with no
+// Principal leaves TenantID empty, same as before tenant enforcement
+// existed.
+func stampTenantID(req *service.EnqueueRequest, r *http.Request) {
+	if principal, ok := PrincipalFromContext(r.Context()); ok {
+		req.TenantID = principal.TenantID
 	}
-	if r.Payload == nil {
-		return errors.New("payload is required")
+}
+
+// requestTenantID returns r's Principal's TenantID, or "" if r has no
+// Principal -- the "skip tenant enforcement" value service.Service.Get/
+// Delete expect.
+func requestTenantID(r *http.Request) string {
+	if principal, ok := PrincipalFromContext(r.Context()); ok {
+		return principal.TenantID
 	}
-	return nil
+	return ""
 }
 
 // EnqueueResponse is returned after enqueueing a task
@@ -93,36 +173,21 @@ type EnqueueResponse struct {
 func (h *Handler) Enqueue(w http.ResponseWriter, r *http.Request) {
 	var req EnqueueRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
-		return
-	}
-
-	if err := req.Validate(); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeError(w, r, http.StatusBadRequest, "invalid request body: "+err.Error())
 		return
 	}
 
-	task := &queue.Task{
-		Type:       req.Type,
-		Payload:    req.Payload,
-		Priority:   req.Priority,
-		MaxRetries: req.MaxRetries,
-		Metadata:   req.Metadata,
-	}
+	svcReq := req.toService()
+	stampRequestID(&svcReq, r)
+	stampTenantID(&svcReq, r)
 
-	if err := h.queue.Enqueue(r.Context(), task); err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to enqueue task: "+err.Error())
+	task, err := h.svc.Enqueue(r.Context(), svcReq)
+	if err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	// Persist to storage
-	if h.storage != nil {
-		if err := h.storage.SaveTask(r.Context(), task); err != nil {
-			// Log but don't fail the request
-		}
-	}
-
-	writeJSON(w, http.StatusCreated, Response{
+	writeJSON(w, r, http.StatusCreated, Response{
 		Success: true,
 		Data: EnqueueResponse{
 			TaskID:    task.ID,
@@ -133,23 +198,13 @@ func (h *Handler) Enqueue(w http.ResponseWriter, r *http.Request) {
 
 // GetTask handles GET /tasks/{id}
 func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
-	if id == "" {
-		writeError(w, http.StatusBadRequest, "task id is required")
-		return
-	}
-
-	task, err := h.queue.Get(r.Context(), id)
+	task, err := h.svc.Get(r.Context(), r.PathValue("id"), requestTenantID(r))
 	if err != nil {
-		if errors.Is(err, queue.ErrTaskNotFound) {
-			writeError(w, http.StatusNotFound, "task not found")
-			return
-		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeServiceError(w, r, err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, Response{
+	writeJSON(w, r, http.StatusOK, Response{
 		Success: true,
 		Data:    task,
 	})
@@ -158,8 +213,9 @@ func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
 // ListTasks handles GET /tasks
 func (h *Handler) ListTasks(w http.ResponseWriter, r *http.Request) {
 	filter := TaskFilter{
-		Limit:  50,
-		Offset: 0,
+		Limit:    50,
+		Offset:   0,
+		TenantID: requestTenantID(r),
 	}
 
 	// Parse query parameters
@@ -180,18 +236,13 @@ func (h *Handler) ListTasks(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if h.storage == nil {
-		writeError(w, http.StatusNotImplemented, "storage not configured")
-		return
-	}
-
-	tasks, err := h.storage.ListTasks(r.Context(), filter)
+	tasks, err := h.svc.List(r.Context(), filter)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeServiceError(w, r, err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, Response{
+	writeJSON(w, r, http.StatusOK, Response{
 		Success: true,
 		Data:    tasks,
 		Meta: &Meta{
@@ -203,59 +254,137 @@ func (h *Handler) ListTasks(w http.ResponseWriter, r *http.Request) {
 
 // DeleteTask handles DELETE /tasks/{id}
 func (h *Handler) DeleteTask(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
-	if id == "" {
-		writeError(w, http.StatusBadRequest, "task id is required")
+	if err := h.svc.Delete(r.Context(), r.PathValue("id"), requestTenantID(r)); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	if err := h.queue.Delete(r.Context(), id); err != nil {
-		if errors.Is(err, queue.ErrTaskNotFound) {
-			writeError(w, http.StatusNotFound, "task not found")
-			return
+	writeJSON(w, r, http.StatusOK, Response{
+		Success: true,
+	})
+}
+
+// SearchTasks handles GET /tasks/search
+func (h *Handler) SearchTasks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		writeError(w, r, http.StatusBadRequest, "q is required")
+		return
+	}
+
+	filter := storage.TaskFilter{
+		Limit:    50,
+		TenantID: requestTenantID(r),
+	}
+	if state := r.URL.Query().Get("state"); state != "" {
+		filter.State = queue.TaskState(state)
+	}
+	if taskType := r.URL.Query().Get("type"); taskType != "" {
+		filter.Type = taskType
+	}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil && l > 0 {
+			filter.Limit = l
 		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+
+	tasks, err := h.svc.SearchTasks(r.Context(), query, filter)
+	if err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, Response{
+	writeJSON(w, r, http.StatusOK, Response{
 		Success: true,
+		Data:    tasks,
+		Meta:    &Meta{Total: len(tasks), PerPage: filter.Limit},
 	})
 }
 
-// CancelTask handles POST /tasks/{id}/cancel
-func (h *Handler) CancelTask(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
-	if id == "" {
-		writeError(w, http.StatusBadRequest, "task id is required")
+// PauseTask handles POST /tasks/{id}/pause
+func (h *Handler) PauseTask(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.PauseTask(r.Context(), r.PathValue("id"), requestTenantID(r)); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
+	writeJSON(w, r, http.StatusOK, Response{Success: true})
+}
 
-	task, err := h.queue.Get(r.Context(), id)
-	if err != nil {
-		if errors.Is(err, queue.ErrTaskNotFound) {
-			writeError(w, http.StatusNotFound, "task not found")
-			return
-		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+// ResumeTask handles POST /tasks/{id}/resume
+func (h *Handler) ResumeTask(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.ResumeTask(r.Context(), r.PathValue("id"), requestTenantID(r)); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, Response{Success: true})
+}
+
+// pauseTypeRequest is the body of POST /tasks/types/{type}/pause.
+type pauseTypeRequest struct {
+	Reason string `json:"reason"`
+}
+
+// PauseType handles POST /tasks/types/{type}/pause
+func (h *Handler) PauseType(w http.ResponseWriter, r *http.Request) {
+	var req pauseTypeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, r, http.StatusBadRequest, "invalid request body: "+err.Error())
 		return
 	}
 
-	if task.State != queue.StatePending && task.State != queue.StateRetrying {
-		writeError(w, http.StatusConflict, "task cannot be cancelled in current state")
+	if err := h.svc.PauseType(r.Context(), r.PathValue("type"), req.Reason); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
+	writeJSON(w, r, http.StatusOK, Response{Success: true})
+}
 
-	task.State = queue.StateCancelled
-	now := time.Now()
-	task.CompletedAt = &now
+// ResumeType handles POST /tasks/types/{type}/resume
+func (h *Handler) ResumeType(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.ResumeType(r.Context(), r.PathValue("type")); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, Response{Success: true})
+}
 
-	if err := h.queue.Update(r.Context(), task); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+// GetTaskLogs handles GET /tasks/{id}/logs?since=<seq>&limit=<n>
+func (h *Handler) GetTaskLogs(w http.ResponseWriter, r *http.Request) {
+	var since int
+	if s := r.URL.Query().Get("since"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil {
+			since = v
+		}
+	}
+	limit := 100
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil && v > 0 {
+			limit = v
+		}
+	}
+
+	lines, err := h.svc.ReadTaskLogs(r.Context(), r.PathValue("id"), requestTenantID(r), since, limit)
+	if err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, Response{
+	writeJSON(w, r, http.StatusOK, Response{
+		Success: true,
+		Data:    lines,
+		Meta:    &Meta{Total: len(lines), PerPage: limit},
+	})
+}
+
+// CancelTask handles POST /tasks/{id}/cancel
+func (h *Handler) CancelTask(w http.ResponseWriter, r *http.Request) {
+	task, err := h.svc.Cancel(r.Context(), r.PathValue("id"), requestTenantID(r))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, Response{
 		Success: true,
 		Data:    task,
 	})
@@ -263,60 +392,232 @@ func (h *Handler) CancelTask(w http.ResponseWriter, r *http.Request) {
 
 // RetryTask handles POST /tasks/{id}/retry
 func (h *Handler) RetryTask(w http.ResponseWriter, r *http.Request) {
+	task, err := h.svc.Retry(r.Context(), r.PathValue("id"), requestTenantID(r))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, Response{
+		Success: true,
+		Data:    task,
+	})
+}
+
+// defaultWaitTimeout and maxWaitTimeout bound the ?timeout= query param
+// WaitTask accepts: unset falls back to the default, and anything larger
+// is clamped to the max so a slow or misbehaving client can't tie up a
+// handler goroutine indefinitely.
+const (
+	defaultWaitTimeout = 30 * time.Second
+	maxWaitTimeout     = 2 * time.Minute
+)
+
+// WaitTask handles GET /tasks/{id}/wait?timeout=30s&revision=N, long-polling
+// until id's revision advances past N, or until timeout elapses, before
+// returning the task's current state -- a lower-latency alternative to a
+// client polling GetTask in a loop. See service.Service.Wait.
+func (h *Handler) WaitTask(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, "task id is required")
+		writeError(w, r, http.StatusBadRequest, "task id is required")
 		return
 	}
 
-	task, err := h.queue.Get(r.Context(), id)
+	var since uint64
+	if rev := r.URL.Query().Get("revision"); rev != "" {
+		v, err := strconv.ParseUint(rev, 10, 64)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "invalid revision: "+err.Error())
+			return
+		}
+		since = v
+	}
+
+	timeout := defaultWaitTimeout
+	if t := r.URL.Query().Get("timeout"); t != "" {
+		d, err := time.ParseDuration(t)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "invalid timeout: "+err.Error())
+			return
+		}
+		timeout = d
+	}
+	if timeout > maxWaitTimeout {
+		timeout = maxWaitTimeout
+	}
+
+	task, err := h.svc.Wait(r.Context(), id, requestTenantID(r), since, timeout)
 	if err != nil {
-		if errors.Is(err, queue.ErrTaskNotFound) {
-			writeError(w, http.StatusNotFound, "task not found")
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, Response{
+		Success: true,
+		Data:    task,
+	})
+}
+
+// sseKeepalive is how often WatchTask writes a comment line to keep an
+// idle connection (and any intermediate proxy) from timing out.
+const sseKeepalive = 15 * time.Second
+
+// WatchTask handles GET /tasks/{id}/events, streaming the task's lifecycle
+// transitions (started, retrying, completed, failed) as server-sent
+// events instead of requiring the client to poll GetTask. Each event is a
+// JSON-encoded worker.Event on its own "data:" line. This follows
+// worker.Pool's in-memory lifecycle events directly rather than going
+// through service.Service, since it only sees a task once a worker has
+// picked it up -- see WatchTaskState for the queue-level equivalent.
+func (h *Handler) WatchTask(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeError(w, r, http.StatusBadRequest, "task id is required")
+		return
+	}
+
+	if h.pool == nil {
+		writeError(w, r, http.StatusNotImplemented, "task streaming not available")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, r, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	events, unsubscribe := h.pool.Subscribe(id)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(sseKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+
+			if ev.Type == worker.EventCompleted || ev.Type == worker.EventFailed {
+				return
+			}
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
 			return
 		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// WatchTaskState handles GET /tasks/{id}/watch, streaming id's queue-level
+// state transitions (enqueued, started, retrying, completed, failed,
+// cancelled) as server-sent events. Unlike WatchTask, which follows
+// worker.Pool's in-memory lifecycle events, this follows
+// service.Service.Watch (queue.SubscribableQueue.Subscribe under the
+// hood), so it also sees a task get enqueued or cancelled before any
+// worker touches it. internal/api/grpc's streaming Watch RPC shares this
+// same service call.
+func (h *Handler) WatchTaskState(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeError(w, r, http.StatusBadRequest, "task id is required")
 		return
 	}
+	h.streamTaskEvents(w, r, queue.EventFilter{TaskID: id, TenantID: requestTenantID(r)})
+}
+
+// WatchAllTasks handles GET /tasks/events, streaming queue.TaskEvents for
+// every task as they happen, optionally narrowed with ?type= and ?state=
+// query params.
+func (h *Handler) WatchAllTasks(w http.ResponseWriter, r *http.Request) {
+	filter := queue.EventFilter{
+		Type:     r.URL.Query().Get("type"),
+		State:    queue.TaskState(r.URL.Query().Get("state")),
+		TenantID: requestTenantID(r),
+	}
+	h.streamTaskEvents(w, r, filter)
+}
 
-	if task.State != queue.StateFailed {
-		writeError(w, http.StatusConflict, "only failed tasks can be retried")
+// streamTaskEvents is the shared SSE loop behind WatchTaskState and
+// WatchAllTasks.
+func (h *Handler) streamTaskEvents(w http.ResponseWriter, r *http.Request, filter queue.EventFilter) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, r, http.StatusInternalServerError, "streaming unsupported")
 		return
 	}
 
-	// Reset task state
-	task.State = queue.StatePending
-	task.Error = ""
-	task.StartedAt = nil
-	task.CompletedAt = nil
-	task.Retries = 0
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
 
-	// Re-enqueue
-	if err := h.queue.Enqueue(r.Context(), task); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+	events, err := h.svc.Watch(ctx, filter)
+	if err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, Response{
-		Success: true,
-		Data:    task,
-	})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(sseKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+
+			if filter.TaskID != "" {
+				switch ev.State {
+				case queue.StateCompleted, queue.StateFailed, queue.StateCancelled:
+					return
+				}
+			}
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
 }
 
 // GetStats handles GET /stats
 func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
-	var stats interface{}
-
-	if h.pool != nil {
-		stats = h.pool.Metrics()
-	} else if collector, ok := h.queue.(queue.StatsCollector); ok {
-		stats = collector.Stats()
-	} else {
-		writeError(w, http.StatusNotImplemented, "stats not available")
+	stats, err := h.svc.Stats(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, Response{
+	writeJSON(w, r, http.StatusOK, Response{
 		Success: true,
 		Data:    stats,
 	})
@@ -324,122 +625,167 @@ func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
 
 // HealthCheck handles GET /health
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	status := "healthy"
-
-	// Check queue
-	if _, err := h.queue.Peek(r.Context()); err != nil && err != queue.ErrQueueEmpty {
-		status = "degraded"
-	}
-
-	// Check pool
-	if h.pool != nil && h.pool.State() != worker.StateRunning {
-		status = "degraded"
+	health, err := h.svc.Health(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
 	}
 
-	writeJSON(w, http.StatusOK, Response{
+	writeJSON(w, r, http.StatusOK, Response{
 		Success: true,
 		Data: map[string]interface{}{
-			"status":     status,
-			"queue_size": h.queue.Len(),
-			"timestamp":  time.Now().UTC(),
+			"status":     health.Status,
+			"queue_size": health.QueueSize,
+			"timestamp":  health.Timestamp,
 		},
 	})
 }
 
-// BulkEnqueue handles POST /tasks/bulk
+// BulkItemResult reports the outcome of a single item from a bulk enqueue
+// request, indexed to match its position in the submitted chunk so a
+// streaming client (e.g. the CLI's chunked NDJSON upload) can map failures
+// back to source line numbers.
+type BulkItemResult struct {
+	Index  int    `json:"index"`
+	TaskID string `json:"task_id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkEnqueue handles POST /tasks/bulk. Each request is one chunk of a
+// (possibly much larger) client-side batch, so service.MaxBulkSize bounds
+// a single chunk rather than the whole upload.
 func (h *Handler) BulkEnqueue(w http.ResponseWriter, r *http.Request) {
 	var requests []EnqueueRequest
 	if err := json.NewDecoder(io.LimitReader(r.Body, 10<<20)).Decode(&requests); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		writeError(w, r, http.StatusBadRequest, "invalid request body: "+err.Error())
 		return
 	}
 
-	if len(requests) == 0 {
-		writeError(w, http.StatusBadRequest, "no tasks provided")
-		return
+	svcReqs := make([]service.EnqueueRequest, len(requests))
+	for i, req := range requests {
+		svcReqs[i] = req.toService()
+		stampRequestID(&svcReqs[i], r)
+		stampTenantID(&svcReqs[i], r)
 	}
 
-	if len(requests) > 1000 {
-		writeError(w, http.StatusBadRequest, "maximum 1000 tasks per bulk request")
+	svcResults, err := h.svc.BulkEnqueue(r.Context(), svcReqs)
+	if err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	results := make([]EnqueueResponse, 0, len(requests))
-	failures := make([]string, 0)
-
-	for i, req := range requests {
-		if err := req.Validate(); err != nil {
-			failures = append(failures, "task "+strconv.Itoa(i)+": "+err.Error())
-			continue
-		}
-
-		task := &queue.Task{
-			Type:       req.Type,
-			Payload:    req.Payload,
-			Priority:   req.Priority,
-			MaxRetries: req.MaxRetries,
-			Metadata:   req.Metadata,
+	results := make([]BulkItemResult, len(svcResults))
+	failed := 0
+	for i, res := range svcResults {
+		results[i] = BulkItemResult{Index: res.Index, TaskID: res.TaskID, Error: res.Error}
+		if res.Error != "" {
+			failed++
 		}
-
-		if err := h.queue.Enqueue(r.Context(), task); err != nil {
-			failures = append(failures, "task "+strconv.Itoa(i)+": "+err.Error())
-			continue
-		}
-
-		results = append(results, EnqueueResponse{
-			TaskID:    task.ID,
-			CreatedAt: task.CreatedAt,
-		})
 	}
 
 	statusCode := http.StatusCreated
-	if len(failures) > 0 {
+	if failed > 0 {
 		statusCode = http.StatusMultiStatus
 	}
 
-	writeJSON(w, statusCode, Response{
-		Success: len(failures) == 0,
-		Data: map[string]interface{}{
-			"created":  results,
-			"failures": failures,
-		},
+	writeJSON(w, r, statusCode, Response{
+		Success: failed == 0,
+		Data:    results,
 		Meta: &Meta{
 			Total: len(results),
 		},
 	})
 }
 
-// writeJSON writes a JSON response
-func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+// writeJSON writes a JSON response, stamping Meta.TraceID with r's request
+// id (see RequestID/RequestIDFromContext) when data is a Response that
+// doesn't already carry one.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	if resp, ok := data.(Response); ok {
+		if resp.Meta == nil {
+			resp.Meta = &Meta{}
+		}
+		if resp.Meta.TraceID == "" {
+			resp.Meta.TraceID = RequestIDFromContext(r.Context())
+		}
+		data = resp
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(data)
 }
 
 // writeError writes an error response
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, Response{
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	writeJSON(w, r, status, Response{
 		Success: false,
 		Error:   message,
 	})
 }
 
+// writeServiceError maps a service.Service error to an HTTP status code
+// and writes it. Unrecognized errors (anything from the queue/storage
+// layer that isn't one of the sentinels below) become a 500.
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRequest):
+		writeError(w, r, http.StatusBadRequest, err.Error())
+	case errors.Is(err, queue.ErrTaskNotFound):
+		writeError(w, r, http.StatusNotFound, "task not found")
+	case errors.Is(err, service.ErrTaskNotCancellable), errors.Is(err, service.ErrTaskNotRetryable), errors.Is(err, queue.ErrRevisionConflict):
+		writeError(w, r, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrStorageUnavailable), errors.Is(err, service.ErrStatsUnavailable), errors.Is(err, service.ErrWatchUnavailable),
+		errors.Is(err, service.ErrSearchUnavailable), errors.Is(err, service.ErrPauseUnavailable), errors.Is(err, service.ErrLogsUnavailable):
+		writeError(w, r, http.StatusNotImplemented, err.Error())
+	case errors.Is(err, storage.ErrNotFound):
+		writeError(w, r, http.StatusNotFound, "task not found")
+	default:
+		writeError(w, r, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// scoped wraps handler with a RequireScope check for scope. A request
+// with no Principal attached (Authn not in the middleware chain, or the
+// caller sent no bearer token) still reaches handler -- see RequireScope.
+func scoped(scope Scope, handler http.HandlerFunc) http.Handler {
+	return Chain(handler, RequireScope(scope))
+}
+
 // Router creates and configures the HTTP router
 func (h *Handler) Router() http.Handler {
 	mux := http.NewServeMux()
 
 	// Task endpoints
-	mux.HandleFunc("POST /tasks", h.Enqueue)
-	mux.HandleFunc("POST /tasks/bulk", h.BulkEnqueue)
-	mux.HandleFunc("GET /tasks", h.ListTasks)
-	mux.HandleFunc("GET /tasks/{id}", h.GetTask)
-	mux.HandleFunc("DELETE /tasks/{id}", h.DeleteTask)
-	mux.HandleFunc("POST /tasks/{id}/cancel", h.CancelTask)
-	mux.HandleFunc("POST /tasks/{id}/retry", h.RetryTask)
+	mux.Handle("POST /tasks", scoped(ScopeTasksEnqueue, h.Enqueue))
+	mux.Handle("POST /tasks/bulk", scoped(ScopeTasksEnqueue, h.BulkEnqueue))
+	mux.Handle("GET /tasks", scoped(ScopeTasksRead, h.ListTasks))
+	mux.Handle("GET /tasks/search", scoped(ScopeTasksRead, h.SearchTasks))
+	mux.Handle("GET /tasks/{id}", scoped(ScopeTasksRead, h.GetTask))
+	mux.Handle("DELETE /tasks/{id}", scoped(ScopeTasksAdmin, h.DeleteTask))
+	mux.Handle("POST /tasks/{id}/cancel", scoped(ScopeTasksAdmin, h.CancelTask))
+	mux.Handle("POST /tasks/{id}/retry", scoped(ScopeTasksAdmin, h.RetryTask))
+	mux.Handle("POST /tasks/{id}/pause", scoped(ScopeTasksAdmin, h.PauseTask))
+	mux.Handle("POST /tasks/{id}/resume", scoped(ScopeTasksAdmin, h.ResumeTask))
+	mux.Handle("POST /tasks/types/{type}/pause", scoped(ScopeTasksAdmin, h.PauseType))
+	mux.Handle("POST /tasks/types/{type}/resume", scoped(ScopeTasksAdmin, h.ResumeType))
+	mux.Handle("GET /tasks/{id}/logs", scoped(ScopeTasksRead, h.GetTaskLogs))
+	mux.Handle("GET /tasks/{id}/wait", scoped(ScopeTasksRead, h.WaitTask))
+	mux.Handle("GET /tasks/{id}/events", scoped(ScopeTasksRead, h.WatchTask))
+	mux.Handle("GET /tasks/{id}/watch", scoped(ScopeTasksRead, h.WatchTaskState))
+	mux.Handle("GET /tasks/events", scoped(ScopeTasksRead, h.WatchAllTasks))
 
 	// Monitoring endpoints
-	mux.HandleFunc("GET /stats", h.GetStats)
+	mux.Handle("GET /stats", scoped(ScopeStatsRead, h.GetStats))
 	mux.HandleFunc("GET /health", h.HealthCheck)
 
+	if h.tokens != nil {
+		h.tokens.registerRoutes(mux)
+	}
+
+	if h.metrics != nil {
+		mux.Handle("GET /metrics", h.metrics.Handler())
+	}
+
 	return mux
 }
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// idempotencyTTL is how long a completed response is replayed for a given
+// Idempotency-Key before the store forgets it and a later resubmission is
+// treated as a brand new request.
+const idempotencyTTL = 24 * time.Hour
+
+// idempotencyWaitTimeout bounds how long a request blocks behind an
+// in-flight original sharing its key before giving up, so a retry doesn't
+// hang forever behind an original that stalled or died without responding.
+const idempotencyWaitTimeout = 10 * time.Second
+
+// idempotencyRecord is the stored outcome of one Idempotency-Key. done is
+// closed once status/body are populated, letting concurrent waiters block
+// on it instead of polling; expiresAt stays zero while the original
+// request is still in flight.
+type idempotencyRecord struct {
+	status    int
+	body      []byte
+	expiresAt time.Time
+	done      chan struct{}
+}
+
+// IdempotencyStore remembers the response for each Idempotency-Key the
+// Idempotency middleware has seen, so a producer retrying a POST after a
+// network error gets back the original result instead of executing it
+// twice. See internal/queue.MemoryQueue's uniqueKeys for the analogous
+// dedup mechanism at the queue layer, which this doesn't replace -- that
+// one dedups tasks by payload-derived key, this one dedups HTTP requests
+// by a caller-supplied key.
+type IdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*idempotencyRecord
+}
+
+// NewIdempotencyStore creates an empty IdempotencyStore.
+func NewIdempotencyStore() *IdempotencyStore {
+	return &IdempotencyStore{records: make(map[string]*idempotencyRecord)}
+}
+
+// begin registers key as in flight and reports true if this call is the
+// one that should execute the request. If key is already known, it
+// returns the existing record and false; the caller should wait on
+// record.done (which may already be closed) instead of executing again.
+func (s *IdempotencyStore) begin(key string) (rec *idempotencyRecord, started bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec, ok := s.records[key]; ok {
+		return rec, false
+	}
+
+	rec = &idempotencyRecord{done: make(chan struct{})}
+	s.records[key] = rec
+	return rec, true
+}
+
+// finish records rec's outcome and wakes anyone waiting on it.
+func (s *IdempotencyStore) finish(rec *idempotencyRecord, status int, body []byte) {
+	s.mu.Lock()
+	rec.status = status
+	rec.body = body
+	rec.expiresAt = time.Now().Add(idempotencyTTL)
+	s.mu.Unlock()
+	close(rec.done)
+}
+
+// abandon removes key's record without ever completing it. Used when the
+// original request's handler panics (Recover sits above this middleware
+// in the chain and will turn the panic into a 500 after our deferred
+// cleanup runs) so a retried request isn't left waiting on a record that
+// will never close.
+func (s *IdempotencyStore) abandon(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+}
+
+// purgeExpired removes records whose TTL has elapsed as of now, returning
+// the count removed. Records still in flight (expiresAt still zero) are
+// never purged.
+func (s *IdempotencyStore) purgeExpired(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := 0
+	for key, rec := range s.records {
+		if rec.expiresAt.IsZero() {
+			continue
+		}
+		if now.After(rec.expiresAt) {
+			delete(s.records, key)
+			purged++
+		}
+	}
+	return purged
+}
+
+// StartJanitor starts a goroutine that purges expired records on a timer,
+// mirroring queue.MemoryQueue.StartRetentionJanitor. Calling the returned
+// stop function terminates it.
+func (s *IdempotencyStore) StartJanitor(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				s.purgeExpired(now)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// bufferingResponseWriter buffers a handler's status and body so they can
+// be stored for replay, while still writing through to the real
+// http.ResponseWriter as usual.
+type bufferingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferingResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *bufferingResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Idempotency replays the stored response for a repeated Idempotency-Key
+// header on POST requests, and stores the response under new keys once
+// the handler completes. A request whose key is already in flight blocks
+// up to idempotencyWaitTimeout for the original to finish rather than
+// executing concurrently; if the original doesn't finish in time, the
+// request receives a 409 so the caller can retry instead of risking a
+// double submission. Requests without the header, or that aren't POST,
+// pass through untouched.
+func Idempotency(store *IdempotencyStore) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec, started := store.begin(key)
+			if !started {
+				select {
+				case <-rec.done:
+					w.Header().Set("Idempotency-Replayed", "true")
+					w.WriteHeader(rec.status)
+					w.Write(rec.body)
+				case <-time.After(idempotencyWaitTimeout):
+					writeError(w, r, http.StatusConflict, "original request for this idempotency key is still in progress")
+				}
+				return
+			}
+
+			bw := &bufferingResponseWriter{ResponseWriter: w}
+			ok := false
+			defer func() {
+				if !ok {
+					store.abandon(key)
+				}
+			}()
+
+			next.ServeHTTP(bw, r)
+
+			ok = true
+			store.finish(rec, bw.status, bw.body.Bytes())
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package api
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWTAuthenticator is an Authenticator that verifies bearer tokens as JWTs
+// instead of looking them up in a static table (see MemoryAuthenticator).
+// It supports HS256 against a single shared secret and RS256 against a set
+// of public keys keyed by "kid", either supplied directly or kept fresh
+// from a JWKS endpoint via StartJWKSRefresh.
+type JWTAuthenticator struct {
+	hmacSecret []byte
+
+	mu      sync.RWMutex
+	rsaKeys map[string]*rsa.PublicKey
+
+	httpClient *http.Client
+}
+
+// NewJWTAuthenticator creates a JWTAuthenticator that verifies HS256
+// tokens against hmacSecret. Call AddRSAKey or StartJWKSRefresh
+// afterwards to also accept RS256 tokens.
+func NewJWTAuthenticator(hmacSecret []byte) *JWTAuthenticator {
+	return &JWTAuthenticator{
+		hmacSecret: hmacSecret,
+		rsaKeys:    make(map[string]*rsa.PublicKey),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// AddRSAKey registers pub under kid for RS256 verification.
+func (a *JWTAuthenticator) AddRSAKey(kid string, pub *rsa.PublicKey) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rsaKeys[kid] = pub
+}
+
+// jwk is one entry of a JWKS document's "keys" array, restricted to the
+// RSA fields (kty "RSA") this package verifies.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// refreshJWKS fetches url and replaces the authenticator's RSA key set
+// with whatever it contains, so a key rotated or revoked at the IdP takes
+// effect on the next refresh instead of requiring a restart.
+func (a *JWTAuthenticator) refreshJWKS(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("JWKS endpoint returned %d", resp.StatusCode)
+	}
+
+	var doc jwks
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Kty != "RSA" || k.Kid == "" {
+			continue
+		}
+		pub, err := rsaPublicKeyFromJWK(k)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+
+	a.mu.Lock()
+	a.rsaKeys = keys
+	a.mu.Unlock()
+
+	return nil
+}
+
+// rsaPublicKeyFromJWK decodes a JWK's base64url-encoded modulus/exponent
+// into an *rsa.PublicKey.
+func rsaPublicKeyFromJWK(k jwk) (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+
+	e := new(big.Int).SetBytes(eBytes)
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: int(e.Int64()),
+	}, nil
+}
+
+// StartJWKSRefresh fetches url immediately and then on every interval,
+// keeping the authenticator's RS256 keys current. Calling the returned
+// stop function terminates the refresh goroutine; it mirrors
+// IdempotencyStore.StartJanitor's ticker/done-channel pattern.
+func (a *JWTAuthenticator) StartJWKSRefresh(url string, interval time.Duration) (stop func(), err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := a.refreshJWKS(ctx, url); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				a.refreshJWKS(context.Background(), url)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}, nil
+}
+
+// jwtHeader is the fields of a JWT header this package understands.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwtClaims is the subset of a JWT's payload Authenticate maps onto a
+// Principal. Scopes accepts either a space-separated string (the OAuth2
+// "scope" convention) or a JSON array, since IdPs differ on this.
+type jwtClaims struct {
+	Exp       int64           `json:"exp"`
+	Nbf       int64           `json:"nbf"`
+	TenantID  string          `json:"tenant_id"`
+	RateLimit int             `json:"rate_limit"`
+	Scope     string          `json:"scope"`
+	Scopes    json.RawMessage `json:"scopes"`
+}
+
+func (c jwtClaims) scopeList() []string {
+	if len(c.Scopes) > 0 {
+		var list []string
+		if err := json.Unmarshal(c.Scopes, &list); err == nil {
+			return list
+		}
+	}
+	if c.Scope != "" {
+		return strings.Fields(c.Scope)
+	}
+	return nil
+}
+
+// Authenticate implements Authenticator by verifying token as a JWT
+// (HS256 against the configured secret, or RS256 against a key looked up
+// by the header's "kid") and mapping its claims onto a Principal.
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+	signingInput := parts[0] + "." + parts[1]
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	switch header.Alg {
+	case "HS256":
+		if err := a.verifyHS256(signingInput, sig); err != nil {
+			return nil, err
+		}
+	case "RS256":
+		if err := a.verifyRS256(signingInput, sig, header.Kid); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, header.Alg)
+	}
+
+	claimsBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims jwtClaims
+	if err := json.Unmarshal(claimsBytes, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	now := time.Now().Unix()
+	if claims.Exp != 0 && now >= claims.Exp {
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+	if claims.Nbf != 0 && now < claims.Nbf {
+		return nil, fmt.Errorf("%w: token not yet valid", ErrInvalidToken)
+	}
+
+	return &Principal{
+		TenantID:  claims.TenantID,
+		Scopes:    claims.scopeList(),
+		RateLimit: claims.RateLimit,
+	}, nil
+}
+
+func (a *JWTAuthenticator) verifyHS256(signingInput string, sig []byte) error {
+	if len(a.hmacSecret) == 0 {
+		return fmt.Errorf("%w: no HMAC secret configured", ErrInvalidToken)
+	}
+	mac := hmac.New(sha256.New, a.hmacSecret)
+	mac.Write([]byte(signingInput))
+	if !hmac.Equal(mac.Sum(nil), sig) {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+	}
+	return nil
+}
+
+func (a *JWTAuthenticator) verifyRS256(signingInput string, sig []byte, kid string) error {
+	a.mu.RLock()
+	pub, ok := a.rsaKeys[kid]
+	a.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: unknown key id %q", ErrInvalidToken, kid)
+	}
+
+	hashed := sha256.Sum256([]byte(signingInput))
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, hashed[:], sig); err != nil {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+	}
+	return nil
+}
+
+// ParseRSAPublicKeyFromPEM is a convenience for operators wiring a static
+// RS256 key (e.g. AddRSAKey) from a PEM-encoded SubjectPublicKeyInfo
+// instead of a JWKS endpoint.
+func ParseRSAPublicKeyFromPEM(pemBytes []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an RSA public key")
+	}
+	return rsaPub, nil
+}
@@ -0,0 +1,121 @@
+// Package metrics exports HTTP API request metrics to Prometheus, the
+// same way internal/worker/metrics exports pool metrics: a Collectors
+// struct of registered collectors plus small helpers to record against
+// them, instead of the API handler package accumulating raw samples
+// itself.
+package metrics
+
+import (
+	"runtime"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// requestLabels is shared by every per-request collector so a query can
+// join across them on the same label set.
+var requestLabels = []string{"path", "method", "status", "handler"}
+
+// Collectors wraps the Prometheus collectors registered for the HTTP API.
+type Collectors struct {
+	RequestsTotal    *prometheus.CounterVec
+	RequestErrors    *prometheus.CounterVec
+	RequestDuration  *prometheus.HistogramVec
+	RequestsInFlight prometheus.Gauge
+
+	// ActiveGoroutines samples runtime.NumGoroutine() at scrape time.
+	// There's no JobsService in this tree to report worker-goroutine
+	// counts from -- internal/worker.Pool.Metrics().ActiveWorkers is the
+	// closest equivalent, and is already exported separately via
+	// worker/metrics.Collectors.ActiveWorkers. This gauge covers the rest
+	// of the process (HTTP handlers, the gRPC server, etc).
+	ActiveGoroutines prometheus.GaugeFunc
+
+	// RateLimiterRejections counts requests a Limiter turned away, by
+	// path and method (see RateLimiter.OnReject). It's a Counter rather
+	// than the Gauge other request-scoped instruments here use --
+	// rejections only accumulate over the process's lifetime, so a rate
+	// query (rate(...)[5m]) is the useful view, the same reasoning
+	// RequestsTotal/RequestErrors are Counters.
+	RateLimiterRejections *prometheus.CounterVec
+}
+
+// New creates and registers the API's collectors against reg. Passing a
+// nil Registerer skips registration (useful for tests).
+func New(reg prometheus.Registerer) *Collectors {
+	c := &Collectors{
+		RequestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "taskqueue_api_requests_total",
+			Help: "Total number of HTTP API requests.",
+		}, requestLabels),
+		RequestErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "taskqueue_api_request_errors_total",
+			Help: "Total number of HTTP API requests that returned a 4xx or 5xx status.",
+		}, requestLabels),
+		RequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "taskqueue_api_request_duration_seconds",
+			Help:    "HTTP API request latency in seconds.",
+			Buckets: prometheus.DefBuckets,
+		}, requestLabels),
+		RequestsInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "taskqueue_api_requests_in_flight",
+			Help: "Number of HTTP API requests currently being handled.",
+		}),
+		ActiveGoroutines: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "taskqueue_api_active_goroutines",
+			Help: "Number of active goroutines in the process, sampled at scrape time.",
+		}, func() float64 {
+			return float64(runtime.NumGoroutine())
+		}),
+		RateLimiterRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "taskqueue_api_ratelimit_rejections_total",
+			Help: "Total number of requests rejected by a rate limiter, by path and method.",
+		}, []string{"path", "method"}),
+	}
+
+	if reg != nil {
+		reg.MustRegister(c.Collectors()...)
+	}
+
+	return c
+}
+
+// Collectors returns every collector so callers can register them with a
+// custom prometheus.Registerer (e.g. alongside worker/metrics.Collectors).
+func (c *Collectors) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.RequestsTotal,
+		c.RequestErrors,
+		c.RequestDuration,
+		c.RequestsInFlight,
+		c.ActiveGoroutines,
+		c.RateLimiterRejections,
+	}
+}
+
+// ObserveRequest records one completed request's outcome: it increments
+// RequestsTotal (and RequestErrors if status >= 400) and observes duration
+// into RequestDuration, all under the same label set.
+func (c *Collectors) ObserveRequest(path, method, status, handler string, duration float64, errored bool) {
+	c.RequestsTotal.WithLabelValues(path, method, status, handler).Inc()
+	if errored {
+		c.RequestErrors.WithLabelValues(path, method, status, handler).Inc()
+	}
+	c.RequestDuration.WithLabelValues(path, method, status, handler).Observe(duration)
+}
+
+// ObserveRequestWithExemplar is ObserveRequest plus an OpenMetrics
+// exemplar attached to the duration observation, carrying exemplarLabels
+// (e.g. {"trace_id": requestID}) so a latency spike in a histogram bucket
+// can be traced back to the individual request that produced it.
+func (c *Collectors) ObserveRequestWithExemplar(path, method, status, handler string, duration float64, errored bool, exemplarLabels prometheus.Labels) {
+	c.RequestsTotal.WithLabelValues(path, method, status, handler).Inc()
+	if errored {
+		c.RequestErrors.WithLabelValues(path, method, status, handler).Inc()
+	}
+	c.RequestDuration.WithLabelValues(path, method, status, handler).(prometheus.ExemplarObserver).ObserveWithExemplar(duration, exemplarLabels)
+}
+
+// RecordRejection increments RateLimiterRejections for path and method.
+func (c *Collectors) RecordRejection(path, method string) {
+	c.RateLimiterRejections.WithLabelValues(path, method).Inc()
+}
@@ -1,14 +1,21 @@
 package api
 
 import (
+	"bytes"
 	"context"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 	"runtime/debug"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/example/task-queue/internal/api/metrics"
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // ContextKey is a type for context keys
@@ -30,13 +37,15 @@ func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
 	return h
 }
 
-// RequestID adds a unique request ID to each request
+// RequestID adds a unique request ID to each request, propagating one the
+// caller already supplied via X-Request-ID instead of minting a new one so
+// a trace started upstream carries through.
 func RequestID() Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			id := r.Header.Get("X-Request-ID")
 			if id == "" {
-				id = uuid.New().String()
+				id = newRequestID()
 			}
 
 			ctx := context.WithValue(r.Context(), RequestIDKey, id)
@@ -47,16 +56,30 @@ func RequestID() Middleware {
 	}
 }
 
-// GetRequestID retrieves the request ID from context
-func GetRequestID(ctx context.Context) string {
+// newRequestID generates a UUIDv7: like uuid.New()'s v4, collision-resistant
+// via crypto/rand, but time-ordered so request IDs sort (and compress in an
+// index) the way an incrementing ID would.
+func newRequestID() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.New().String()
+	}
+	return id.String()
+}
+
+// RequestIDFromContext retrieves the request ID from context, e.g. so
+// Handler.Enqueue can stamp it into Task.Metadata for end-to-end
+// correlation between an API request and the task it created.
+func RequestIDFromContext(ctx context.Context) string {
 	if id, ok := ctx.Value(RequestIDKey).(string); ok {
 		return id
 	}
 	return ""
 }
 
-// Logger logs each request
-func Logger(logger *log.Logger) Middleware {
+// Logger logs each request with fields correlated by request_id, so a
+// request can be traced across it and whatever task it goes on to enqueue.
+func Logger(logger *slog.Logger) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
@@ -66,15 +89,13 @@ func Logger(logger *log.Logger) Middleware {
 
 			next.ServeHTTP(wrapped, r)
 
-			duration := time.Since(start)
-
-			logger.Printf(
-				"[%s] %s %s %d %v",
-				GetRequestID(r.Context()),
-				r.Method,
-				r.URL.Path,
-				wrapped.statusCode,
-				duration,
+			logger.Info("request",
+				"request_id", RequestIDFromContext(r.Context()),
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", wrapped.statusCode,
+				"duration_ms", time.Since(start).Milliseconds(),
+				"client_ip", r.RemoteAddr,
 			)
 		})
 	}
@@ -92,18 +113,17 @@ func (rw *responseWriter) WriteHeader(code int) {
 }
 
 // Recover recovers from panics and returns a 500 error
-func Recover(logger *log.Logger) Middleware {
+func Recover(logger *slog.Logger) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
 				if err := recover(); err != nil {
-					logger.Printf(
-						"[%s] PANIC: %v\n%s",
-						GetRequestID(r.Context()),
-						err,
-						debug.Stack(),
+					logger.Error("panic recovered",
+						"request_id", RequestIDFromContext(r.Context()),
+						"error", err,
+						"stack", string(debug.Stack()),
 					)
-					writeError(w, http.StatusInternalServerError, "internal server error")
+					writeError(w, r, http.StatusInternalServerError, "internal server error")
 				}
 			}()
 
@@ -112,113 +132,250 @@ func Recover(logger *log.Logger) Middleware {
 	}
 }
 
-// Timeout adds a timeout to requests
-func Timeout(d time.Duration) Middleware {
+// timeoutWriter buffers a handler's response until it either finishes or
+// its deadline expires. On success, Middleware copies the buffer onto
+// the real http.ResponseWriter in one shot; on timeout it's abandoned,
+// so whatever the handler goroutine (which keeps running until it
+// returns -- Go gives no way to preempt it) writes afterward is silently
+// dropped instead of racing the 504 Middleware already sent. This is the
+// same technique net/http.TimeoutHandler uses internally; it's
+// reimplemented here so the timeout body matches writeError's JSON shape
+// instead of TimeoutHandler's fixed plain-text message.
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	code        int
+	wroteHeader bool
+	abandoned   bool
+}
+
+func newTimeoutWriter() *timeoutWriter {
+	return &timeoutWriter{header: make(http.Header), code: http.StatusOK}
+}
+
+func (tw *timeoutWriter) Header() http.Header { return tw.header }
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.abandoned || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	tw.code = code
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.abandoned {
+		return len(p), nil
+	}
+	tw.wroteHeader = true
+	return tw.buf.Write(p)
+}
+
+// abandon marks tw so the handler goroutine's remaining writes, if any,
+// are dropped instead of reaching the real ResponseWriter after
+// Middleware has already written the timeout response to it.
+func (tw *timeoutWriter) abandon() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.abandoned = true
+}
+
+// flush copies tw's buffered header/status/body onto w. Only called
+// after the handler goroutine has returned (the done case below), so no
+// locking is needed here.
+func (tw *timeoutWriter) flush(w http.ResponseWriter) {
+	dst := w.Header()
+	for k, vv := range tw.header {
+		dst[k] = vv
+	}
+	w.WriteHeader(tw.code)
+	w.Write(tw.buf.Bytes())
+}
+
+// TimeoutPolicy applies a default per-request deadline, overridden per
+// route the same way RateLimiter.ForRoute and Metrics.ForRoute resolve
+// theirs. It replaces the old Timeout middleware, which spawned a
+// goroutine and returned a 504 without waiting for or canceling it --
+// the abandoned handler kept running and could still write to the
+// already-returned ResponseWriter, racing whatever the client read next.
+type TimeoutPolicy struct {
+	def       time.Duration
+	mux       *http.ServeMux
+	overrides map[string]time.Duration
+}
+
+// Timeouts builds a TimeoutPolicy whose default request timeout is def,
+// overridden per http.ServeMux pattern (e.g. "POST /tasks") by overrides.
+// A zero (or negative) override disables the timeout entirely for that
+// route -- used for the SSE routes (WatchTask, WatchTaskState,
+// WatchAllTasks) and the long-poll WaitTask, which flush incrementally
+// and would break under the buffered timeoutWriter Middleware wraps
+// every other response in.
+func Timeouts(def time.Duration, overrides map[string]time.Duration) *TimeoutPolicy {
+	tp := &TimeoutPolicy{
+		def:       def,
+		mux:       http.NewServeMux(),
+		overrides: make(map[string]time.Duration, len(overrides)),
+	}
+	for pattern, d := range overrides {
+		tp.overrides[pattern] = d
+		tp.mux.HandleFunc(pattern, func(http.ResponseWriter, *http.Request) {})
+	}
+	return tp
+}
+
+// timeoutFor resolves r's matching override, if any, falling back to
+// tp's default.
+func (tp *TimeoutPolicy) timeoutFor(r *http.Request) time.Duration {
+	if _, pattern := tp.mux.Handler(r); pattern != "" {
+		if d, ok := tp.overrides[pattern]; ok {
+			return d
+		}
+	}
+	return tp.def
+}
+
+// Middleware enforces tp's per-route timeout. It cancels r.Context() at
+// the deadline, so any call threaded through it -- this repo's
+// database/sql ExecContext/QueryContext calls down in internal/storage,
+// or a GORM WithContext call in a deployment that used one instead --
+// aborts along with the response, rather than continuing to run against
+// a client that's already moved on.
+func (tp *TimeoutPolicy) Middleware() Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d := tp.timeoutFor(r)
+			if d <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			ctx, cancel := context.WithTimeout(r.Context(), d)
 			defer cancel()
+			r = r.WithContext(ctx)
 
+			tw := newTimeoutWriter()
 			done := make(chan struct{})
+			panicked := make(chan any, 1)
+
 			go func() {
-				next.ServeHTTP(w, r.WithContext(ctx))
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r)
 				close(done)
 			}()
 
 			select {
 			case <-done:
-				return
+				tw.flush(w)
+			case p := <-panicked:
+				// Re-panic here, in the goroutine Recover actually
+				// wraps, instead of letting it crash the process from
+				// the handler's own goroutine where no recover() above
+				// it can catch it.
+				panic(p)
 			case <-ctx.Done():
-				writeError(w, http.StatusGatewayTimeout, "request timeout")
+				tw.abandon()
+				writeError(w, r, http.StatusGatewayTimeout, "request timeout")
 			}
 		})
 	}
 }
 
-// RateLimiter limits requests per client
-type RateLimiter struct {
-	mu       sync.Mutex
-	requests map[string][]time.Time
-	rate     int
-	window   time.Duration
+// Drainer is stopped when a DrainMode finishes its grace period, e.g.
+// *worker.Pool (already registered by cmd/server) or *queue.JobScheduler.
+type Drainer interface {
+	Stop() error
 }
 
-// NewRateLimiter creates a new rate limiter
-func NewRateLimiter(rate int, window time.Duration) *RateLimiter {
-	rl := &RateLimiter{
-		requests: make(map[string][]time.Time),
-		rate:     rate,
-		window:   window,
-	}
-
-	// Cleanup goroutine
-	go rl.cleanup()
+// DrainMode coordinates a graceful server-wide shutdown: Begin stops
+// Middleware from accepting new requests immediately, then after grace
+// elapses cancels every request Middleware derived a context for (so
+// in-flight handlers abort instead of running to completion against a
+// client that may already be gone) and stops every registered Drainer.
+type DrainMode struct {
+	mu       sync.Mutex
+	draining bool
+	drainers []Drainer
 
-	return rl
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-// cleanup removes old entries
-func (rl *RateLimiter) cleanup() {
-	ticker := time.NewTicker(rl.window)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		rl.mu.Lock()
-		now := time.Now()
-		for key, times := range rl.requests {
-			var valid []time.Time
-			for _, t := range times {
-				if now.Sub(t) < rl.window {
-					valid = append(valid, t)
-				}
-			}
-			if len(valid) == 0 {
-				delete(rl.requests, key)
-			} else {
-				rl.requests[key] = valid
-			}
-		}
-		rl.mu.Unlock()
-	}
+// NewDrainMode creates a DrainMode in normal (non-draining) operation.
+func NewDrainMode() *DrainMode {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &DrainMode{ctx: ctx, cancel: cancel}
 }
 
-// Allow checks if a request is allowed
-func (rl *RateLimiter) Allow(key string) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+// Register adds d to the list DrainMode.Begin stops once its grace
+// period elapses.
+func (d *DrainMode) Register(drainer Drainer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.drainers = append(d.drainers, drainer)
+}
 
-	now := time.Now()
+// Draining reports whether Begin has been called.
+func (d *DrainMode) Draining() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.draining
+}
 
-	// Remove old requests
-	var valid []time.Time
-	for _, t := range rl.requests[key] {
-		if now.Sub(t) < rl.window {
-			valid = append(valid, t)
+// Begin puts the server into drain mode. Middleware starts rejecting new
+// requests immediately; after grace elapses, every in-flight request's
+// context is canceled and every registered Drainer is stopped. It
+// returns once that's done, so the caller (main's SIGTERM handler) can
+// safely follow it with http.Server.Shutdown; any Drainer.Stop errors
+// are joined together for the caller to log.
+func (d *DrainMode) Begin(grace time.Duration) error {
+	d.mu.Lock()
+	d.draining = true
+	d.mu.Unlock()
+
+	time.Sleep(grace)
+	d.cancel()
+
+	d.mu.Lock()
+	drainers := append([]Drainer(nil), d.drainers...)
+	d.mu.Unlock()
+
+	var errs []error
+	for _, drainer := range drainers {
+		if err := drainer.Stop(); err != nil {
+			errs = append(errs, err)
 		}
 	}
-
-	if len(valid) >= rl.rate {
-		return false
-	}
-
-	rl.requests[key] = append(valid, now)
-	return true
+	return errors.Join(errs...)
 }
 
-// Middleware creates a rate limiting middleware
-func (rl *RateLimiter) Middleware() Middleware {
+// Middleware rejects new requests with 503 and Retry-After once d is
+// draining, and otherwise derives each request's context from d's base
+// context so Begin's eventual cancel reaches every request still running
+// when grace elapses, not just ones started after it.
+func (d *DrainMode) Middleware() Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Use client IP as key
-			key := r.RemoteAddr
-
-			if !rl.Allow(key) {
-				w.Header().Set("Retry-After", rl.window.String())
-				writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			if d.Draining() {
+				w.Header().Set("Retry-After", "5")
+				writeError(w, r, http.StatusServiceUnavailable, "server is shutting down")
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			ctx, cancel := context.WithCancel(r.Context())
+			stop := context.AfterFunc(d.ctx, cancel)
+			defer stop()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
@@ -301,7 +458,7 @@ func (a *Auth) Middleware() Middleware {
 			a.mu.RUnlock()
 
 			if !valid {
-				writeError(w, http.StatusUnauthorized, "unauthorized")
+				writeError(w, r, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 
@@ -317,7 +474,7 @@ func ContentType(contentType string) Middleware {
 			if r.Method == http.MethodPost || r.Method == http.MethodPut {
 				ct := r.Header.Get("Content-Type")
 				if ct != contentType {
-					writeError(w, http.StatusUnsupportedMediaType,
+					writeError(w, r, http.StatusUnsupportedMediaType,
 						"content type must be "+contentType)
 					return
 				}
@@ -352,64 +509,137 @@ func SecureHeaders() Middleware {
 	}
 }
 
-// Metrics collects request metrics
+// Metrics collects HTTP API request metrics and exports them to
+// Prometheus via internal/api/metrics.Collectors, in place of the
+// unbounded []time.Duration this type used to append every request's
+// latency into -- that leaked memory under sustained load and only ever
+// produced a mean. The hot path (Middleware) only touches the atomics
+// inside Collectors' counters/gauges/histogram, never mu -- mu just
+// guards the ForRoute label table, which is written at startup and read
+// once per request.
 type Metrics struct {
-	mu             sync.RWMutex
-	totalRequests  int64
-	totalErrors    int64
-	requestsByPath map[string]int64
-	latencies      []time.Duration
-}
-
-// NewMetrics creates a new Metrics collector
-func NewMetrics() *Metrics {
-	return &Metrics{
-		requestsByPath: make(map[string]int64),
-		latencies:      make([]time.Duration, 0),
+	collectors *metrics.Collectors
+	registry   *prometheus.Registry
+	exemplars  bool
+
+	mu       sync.RWMutex
+	mux      *http.ServeMux
+	handlers map[string]string
+}
+
+// MetricsOption configures optional Metrics behavior, following the same
+// pattern as HandlerOption and worker.PoolOption.
+type MetricsOption func(*Metrics)
+
+// WithExemplars enables OpenMetrics exemplars on the request duration
+// histogram, carrying the request's ID (see RequestIDFromContext) as a
+// "trace_id" exemplar label so a latency spike in a bucket can be traced
+// back to the request that produced it. Exemplars only surface to
+// scrapers that request the OpenMetrics format, so enabling this doesn't
+// change what a plain Prometheus-text scrape sees.
+func WithExemplars() MetricsOption {
+	return func(m *Metrics) {
+		m.exemplars = true
+	}
+}
+
+// NewMetrics creates a Metrics collector with its own Prometheus
+// registry, so tests constructing more than one Metrics don't collide
+// over a shared default registry.
+func NewMetrics(opts ...MetricsOption) *Metrics {
+	registry := prometheus.NewRegistry()
+	m := &Metrics{
+		collectors: metrics.New(registry),
+		registry:   registry,
+		mux:        http.NewServeMux(),
+		handlers:   make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// ForRoute labels requests matching pattern (an http.ServeMux pattern,
+// e.g. "GET /tasks/{id}", matching how Handler.Router registers it) with
+// handlerName in the "handler" label. Without it, a route's "path" and
+// "handler" labels fall back to the raw request path -- which would give
+// every distinct task ID its own Prometheus series. Returns m so calls
+// can be chained, matching RateLimiter.ForRoute.
+func (m *Metrics) ForRoute(pattern, handlerName string) *Metrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.handlers[pattern] = handlerName
+	m.mux.HandleFunc(pattern, func(http.ResponseWriter, *http.Request) {})
+	return m
+}
+
+// routeLabels resolves r's "path" and "handler" labels from the ForRoute
+// pattern it matches, if any.
+func (m *Metrics) routeLabels(r *http.Request) (path, handler string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, pattern := m.mux.Handler(r); pattern != "" {
+		if name, ok := m.handlers[pattern]; ok {
+			return routePath(pattern), name
+		}
 	}
+	return r.URL.Path, r.URL.Path
 }
 
-// Middleware creates a metrics middleware
+// routePath strips the leading "METHOD " off an http.ServeMux pattern
+// (e.g. "GET /tasks/{id}" -> "/tasks/{id}") so the "path" label reads
+// like a path instead of duplicating "method".
+func routePath(pattern string) string {
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		return pattern[i+1:]
+	}
+	return pattern
+}
+
+// Middleware creates a metrics middleware. It tracks in-flight requests
+// and records each completed one's outcome under Prometheus
+// counters/histograms instead of an ever-growing slice.
 func (m *Metrics) Middleware() Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			start := time.Now()
+			m.collectors.RequestsInFlight.Inc()
+			defer m.collectors.RequestsInFlight.Dec()
 
+			start := time.Now()
 			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 			next.ServeHTTP(wrapped, r)
+			duration := time.Since(start).Seconds()
 
-			duration := time.Since(start)
+			path, handler := m.routeLabels(r)
+			status := strconv.Itoa(wrapped.statusCode)
+			errored := wrapped.statusCode >= 400
 
-			m.mu.Lock()
-			m.totalRequests++
-			m.requestsByPath[r.URL.Path]++
-			m.latencies = append(m.latencies, duration)
-			if wrapped.statusCode >= 400 {
-				m.totalErrors++
+			if m.exemplars {
+				if requestID := RequestIDFromContext(r.Context()); requestID != "" {
+					m.collectors.ObserveRequestWithExemplar(path, r.Method, status, handler, duration, errored,
+						prometheus.Labels{"trace_id": requestID})
+					return
+				}
 			}
-			m.mu.Unlock()
+			m.collectors.ObserveRequest(path, r.Method, status, handler, duration, errored)
 		})
 	}
 }
 
-// Stats returns current metrics
-func (m *Metrics) Stats() map[string]interface{} {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	var avgLatency time.Duration
-	if len(m.latencies) > 0 {
-		var total time.Duration
-		for _, l := range m.latencies {
-			total += l
-		}
-		avgLatency = total / time.Duration(len(m.latencies))
-	}
+// RecordRejection increments the rate-limiter-rejections counter for
+// path and method. RateLimiter calls this via OnReject; it's exposed
+// separately rather than folded into RateLimiter itself so Metrics stays
+// the one place all API metrics are registered.
+func (m *Metrics) RecordRejection(path, method string) {
+	m.collectors.RecordRejection(path, method)
+}
 
-	return map[string]interface{}{
-		"total_requests":   m.totalRequests,
-		"total_errors":     m.totalErrors,
-		"requests_by_path": m.requestsByPath,
-		"avg_latency":      avgLatency.String(),
-	}
+// Handler serves /metrics in Prometheus text format (or OpenMetrics, for
+// a scraper that asks for it and content-negotiates exemplars) against
+// this Metrics' own registry.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{EnableOpenMetrics: m.exemplars})
 }
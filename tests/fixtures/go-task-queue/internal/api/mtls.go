@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ErrNoClientCertificate is returned when CertAuth runs on a connection
+// that never presented a client certificate at all -- a plain TLS
+// handshake, or TLS isn't even in use.
+var ErrNoClientCertificate = errors.New("no client certificate presented")
+
+// ErrCertificateNotAllowed is returned when a client certificate's chain
+// verifies against the CA bundle but its identity (CN/SAN/OU) doesn't
+// match any configured AllowedIdentity.
+var ErrCertificateNotAllowed = errors.New("certificate identity not in allow-list")
+
+// AllowedIdentity maps one expected certificate identity to the Principal
+// a matching client should be treated as. A field left empty in an entry
+// is not checked against that entry's certificates -- e.g. an entry with
+// only OU set matches on organizational unit regardless of CN/SAN, which
+// is convenient for "every cert this CA issues to the billing team" style
+// allow-listing.
+type AllowedIdentity struct {
+	CN  string
+	SAN string
+	OU  string
+
+	Principal *Principal
+}
+
+// matches reports whether cert satisfies every non-empty field of id.
+func (id AllowedIdentity) matches(cert *x509.Certificate) bool {
+	if id.CN != "" && cert.Subject.CommonName != id.CN {
+		return false
+	}
+	if id.OU != "" && !containsString(cert.Subject.OrganizationalUnit, id.OU) {
+		return false
+	}
+	if id.SAN != "" && !containsString(cert.DNSNames, id.SAN) {
+		return false
+	}
+	return true
+}
+
+func containsString(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
+
+// CertAuthenticator verifies a client certificate's chain against a CA
+// bundle and maps its identity onto a Principal via an allow-list. Unlike
+// Authenticator, it doesn't take a bearer token -- CertAuth reads the
+// certificate straight off the TLS connection -- so it isn't itself an
+// Authenticator, but it plays the same role for mTLS that MemoryAuthenticator
+// and JWTAuthenticator play for bearer tokens.
+type CertAuthenticator struct {
+	mu      sync.RWMutex
+	roots   *x509.CertPool
+	allowed []AllowedIdentity
+}
+
+// NewCertAuthenticator creates a CertAuthenticator that trusts the CA
+// certificates in caPEM (a PEM bundle, possibly containing more than one
+// certificate) and maps client identities through allowed.
+func NewCertAuthenticator(caPEM []byte, allowed []AllowedIdentity) (*CertAuthenticator, error) {
+	roots, err := parseCAPool(caPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &CertAuthenticator{roots: roots, allowed: allowed}, nil
+}
+
+func parseCAPool(caPEM []byte) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, errors.New("no certificates found in CA bundle")
+	}
+	return pool, nil
+}
+
+// ReloadCA replaces the trusted CA bundle with caPEM, so a rotated or
+// revoked CA takes effect on the next request instead of requiring a
+// restart. It mirrors JWTAuthenticator.refreshJWKS's swap-under-lock
+// pattern.
+func (a *CertAuthenticator) ReloadCA(caPEM []byte) error {
+	roots, err := parseCAPool(caPEM)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.roots = roots
+	a.mu.Unlock()
+	return nil
+}
+
+// SetAllowed replaces the identity allow-list, so it can be updated
+// alongside a CA reload without restarting the server.
+func (a *CertAuthenticator) SetAllowed(allowed []AllowedIdentity) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.allowed = allowed
+}
+
+// Authenticate verifies cert's chain against the CA bundle and matches it
+// against the allow-list, returning the Principal of the first matching
+// entry.
+func (a *CertAuthenticator) Authenticate(cert *x509.Certificate, intermediates *x509.CertPool) (*Principal, error) {
+	a.mu.RLock()
+	roots := a.roots
+	allowed := a.allowed
+	a.mu.RUnlock()
+
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return nil, fmt.Errorf("certificate chain verification failed: %w", err)
+	}
+
+	for _, id := range allowed {
+		if id.matches(cert) {
+			return id.Principal, nil
+		}
+	}
+	return nil, ErrCertificateNotAllowed
+}
+
+// CertAuth authenticates requests by their TLS client certificate instead
+// of (or alongside) a bearer token, attaching the resulting Principal to
+// the request context under the same key Authn uses -- so RequireScope,
+// Logger, and any handler reading PrincipalFromContext treat an
+// mTLS-authenticated caller identically to a JWT-authenticated one.
+//
+// A request with no client certificate at all passes through with no
+// Principal attached, the same way Authn passes through a request with no
+// bearer token -- RequireScope is what actually denies an unauthenticated
+// request. This lets CertAuth and Authn both sit in the same middleware
+// chain ("mtls+jwt"): a caller may authenticate with either, and if both
+// are present CertAuth runs first so a later Authn failure on an invalid
+// bearer token still takes precedence (Authn doesn't pass through once a
+// header is present).
+func CertAuth(authenticator *CertAuthenticator) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			leaf := r.TLS.PeerCertificates[0]
+			intermediates := x509.NewCertPool()
+			for _, c := range r.TLS.PeerCertificates[1:] {
+				intermediates.AddCert(c)
+			}
+
+			principal, err := authenticator.Authenticate(leaf, intermediates)
+			if err != nil {
+				writeError(w, r, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), principalKey{}, principal)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
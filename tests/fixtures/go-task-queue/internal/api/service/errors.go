@@ -0,0 +1,49 @@
+package service
+
+import "errors"
+
+// Sentinel errors returned by Service methods. Transports translate these
+// (and the queue/storage errors a method may also return, e.g.
+// queue.ErrTaskNotFound) into their own error representation -- an HTTP
+// status code for internal/api, a grpc/codes.Code for internal/api/grpc --
+// with errors.Is, so neither transport needs a parallel copy of this
+// validation logic.
+var (
+	// ErrInvalidRequest means the caller's request failed validation before
+	// it ever reached the queue or storage layer.
+	ErrInvalidRequest = errors.New("invalid request")
+
+	// ErrTaskNotCancellable means Cancel was called on a task that isn't
+	// pending or retrying.
+	ErrTaskNotCancellable = errors.New("task cannot be cancelled in current state")
+
+	// ErrTaskNotRetryable means Retry was called on a task that isn't failed.
+	ErrTaskNotRetryable = errors.New("only failed tasks can be retried")
+
+	// ErrStorageUnavailable means a method that requires persistent storage
+	// (e.g. List) was called on a Service configured without one.
+	ErrStorageUnavailable = errors.New("storage not configured")
+
+	// ErrStatsUnavailable means Stats was called on a Service whose queue
+	// doesn't implement queue.StatsCollector and has no worker pool.
+	ErrStatsUnavailable = errors.New("stats not available")
+
+	// ErrWatchUnavailable means Watch was called on a Service whose queue
+	// doesn't implement queue.SubscribableQueue.
+	ErrWatchUnavailable = errors.New("task event streaming not available")
+
+	// ErrSearchUnavailable means SearchTasks was called on a Service whose
+	// Storage doesn't implement SearchableStorage (only storage.SQLiteStorage
+	// currently does).
+	ErrSearchUnavailable = errors.New("task search not available")
+
+	// ErrPauseUnavailable means PauseTask, ResumeTask, PauseType, or
+	// ResumeType was called on a Service whose Storage doesn't implement
+	// PausableStorage (only storage.SQLiteStorage currently does).
+	ErrPauseUnavailable = errors.New("task pausing not available")
+
+	// ErrLogsUnavailable means ReadTaskLogs was called on a Service whose
+	// Storage doesn't implement LogReadableStorage (only
+	// storage.SQLiteStorage currently does).
+	ErrLogsUnavailable = errors.New("task logs not available")
+)
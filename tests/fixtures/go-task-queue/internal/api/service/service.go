@@ -0,0 +1,466 @@
+// Package service holds the transport-agnostic request handling shared by
+// internal/api's HTTP handlers and internal/api/grpc's gRPC server: request
+// validation, talking to queue.Queue/Storage/worker.Pool, and shaping the
+// result. Each transport is left to do only what's actually
+// transport-specific -- decoding/encoding the wire format and mapping
+// Service's sentinel errors to its own status representation.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/example/task-queue/internal/queue"
+	"github.com/example/task-queue/internal/storage"
+	"github.com/example/task-queue/internal/worker"
+)
+
+// MaxBulkSize is the largest batch BulkEnqueue accepts in one call. It
+// bounds a single chunk of a larger client-side upload, not the upload as
+// a whole -- see cmd/cli's chunked bulk command.
+const MaxBulkSize = 1000
+
+// Storage is the subset of task persistence a Service needs. It's optional:
+// a Service can be constructed with a nil Storage, in which case Enqueue
+// skips persistence and List/Get-from-storage style calls fail with
+// ErrStorageUnavailable.
+type Storage interface {
+	SaveTask(ctx context.Context, task *queue.Task) error
+	GetTask(ctx context.Context, id string) (*queue.Task, error)
+	ListTasks(ctx context.Context, filter TaskFilter) ([]*queue.Task, error)
+	DeleteTask(ctx context.Context, id string) error
+}
+
+// SearchableStorage is implemented by a Storage backend that supports
+// full-text search over tasks (currently only storage.SQLiteStorage).
+// Service type-asserts for it rather than widening Storage, since most
+// backends don't implement it -- the same optional-capability pattern
+// worker.PauseChecker uses for Pool.
+type SearchableStorage interface {
+	SearchTasks(ctx context.Context, query string, filter storage.TaskFilter) ([]*queue.Task, error)
+}
+
+// PausableStorage is implemented by a Storage backend that supports
+// pausing dispatch of an individual task or a whole task Type (currently
+// only storage.SQLiteStorage). See worker.PauseChecker for how a paused
+// task or Type actually gets skipped by a running worker.Pool.
+type PausableStorage interface {
+	PauseTask(ctx context.Context, id string) error
+	ResumeTask(ctx context.Context, id string) error
+	PauseType(ctx context.Context, taskType, reason string) error
+	ResumeType(ctx context.Context, taskType string) error
+}
+
+// LogReadableStorage is implemented by a Storage backend that persists
+// per-task log lines (currently only storage.SQLiteStorage, via
+// storage.SQLiteStorage.LogWriter/ReadLogs).
+type LogReadableStorage interface {
+	ReadLogs(ctx context.Context, taskID string, fromSeq, limit int) ([]storage.LogLine, error)
+}
+
+// TaskFilter narrows a List call.
+type TaskFilter struct {
+	State    queue.TaskState
+	Type     string
+	Priority queue.Priority
+	Limit    int
+	Offset   int
+
+	// TenantID, if set, restricts List to tasks owned by that tenant. See
+	// queue.Task.TenantID.
+	TenantID string
+}
+
+// EnqueueRequest is a caller's request to enqueue a single task.
+type EnqueueRequest struct {
+	Type       string
+	Payload    map[string]interface{}
+	Priority   queue.Priority
+	MaxRetries int
+	Metadata   map[string]string
+
+	// TenantID, if set, is stamped onto the enqueued queue.Task so a later
+	// List/Get/Delete can be scoped to it. A transport populates this from
+	// the caller's api.Principal, not from caller-supplied input.
+	TenantID string
+}
+
+// Validate reports whether r is well-formed enough to enqueue.
+func (r *EnqueueRequest) Validate() error {
+	if r.Type == "" {
+		return fmt.Errorf("%w: task type is required", ErrInvalidRequest)
+	}
+	if r.Payload == nil {
+		return fmt.Errorf("%w: payload is required", ErrInvalidRequest)
+	}
+	return nil
+}
+
+// BulkItemResult reports the outcome of a single item from a BulkEnqueue
+// call, indexed to match its position in the submitted batch so a caller
+// can map failures back to source items.
+type BulkItemResult struct {
+	Index  int
+	TaskID string
+	Error  string
+}
+
+// HealthStatus is the result of a Health call.
+type HealthStatus struct {
+	Status    string
+	QueueSize int
+	Timestamp time.Time
+}
+
+// Service wraps a queue.Queue (and, optionally, a worker.Pool and Storage)
+// with the validation and request handling shared by every transport.
+type Service struct {
+	queue   queue.Queue
+	pool    *worker.Pool
+	storage Storage
+}
+
+// New creates a Service. pool and storage may be nil; Stats/Health degrade
+// gracefully without a pool, and Enqueue/List degrade gracefully without
+// storage.
+func New(q queue.Queue, pool *worker.Pool, storage Storage) *Service {
+	return &Service{queue: q, pool: pool, storage: storage}
+}
+
+// Enqueue validates req, enqueues it, and best-effort persists it to
+// storage if one is configured.
+func (s *Service) Enqueue(ctx context.Context, req EnqueueRequest) (*queue.Task, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	task := &queue.Task{
+		Type:       req.Type,
+		Payload:    req.Payload,
+		Priority:   req.Priority,
+		MaxRetries: req.MaxRetries,
+		Metadata:   req.Metadata,
+		TenantID:   req.TenantID,
+	}
+
+	if err := s.queue.Enqueue(ctx, task); err != nil {
+		return nil, fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	if s.storage != nil {
+		if err := s.storage.SaveTask(ctx, task); err != nil {
+			// Best-effort: the task is already in the queue and will run
+			// regardless of whether storage has a durable copy of it.
+		}
+	}
+
+	return task, nil
+}
+
+// BulkEnqueue enqueues each of reqs independently, reporting a per-item
+// result rather than failing the whole batch when some items are invalid
+// or fail to enqueue.
+func (s *Service) BulkEnqueue(ctx context.Context, reqs []EnqueueRequest) ([]BulkItemResult, error) {
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("%w: no tasks provided", ErrInvalidRequest)
+	}
+	if len(reqs) > MaxBulkSize {
+		return nil, fmt.Errorf("%w: maximum %d tasks per bulk request", ErrInvalidRequest, MaxBulkSize)
+	}
+
+	results := make([]BulkItemResult, len(reqs))
+
+	for i, req := range reqs {
+		task, err := s.Enqueue(ctx, req)
+		if err != nil {
+			results[i] = BulkItemResult{Index: i, Error: err.Error()}
+			continue
+		}
+		results[i] = BulkItemResult{Index: i, TaskID: task.ID}
+	}
+
+	return results, nil
+}
+
+// Get retrieves a task by ID. If tenantID is non-empty, the task must
+// belong to it or Get returns queue.ErrTaskNotFound -- not a permission
+// error -- so a tenant can't distinguish another tenant's task from one
+// that never existed. An empty tenantID skips the check, for callers with
+// no Principal (see internal/api.Authn).
+func (s *Service) Get(ctx context.Context, id string, tenantID string) (*queue.Task, error) {
+	if id == "" {
+		return nil, fmt.Errorf("%w: task id is required", ErrInvalidRequest)
+	}
+	task, err := s.queue.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if tenantID != "" && task.TenantID != tenantID {
+		return nil, queue.ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// List lists tasks matching filter. It requires a configured Storage, since
+// queue.Queue itself only exposes the tasks still pending/in-flight.
+func (s *Service) List(ctx context.Context, filter TaskFilter) ([]*queue.Task, error) {
+	if s.storage == nil {
+		return nil, ErrStorageUnavailable
+	}
+	return s.storage.ListTasks(ctx, filter)
+}
+
+// SearchTasks runs a full-text search over tasks matching query, scoped
+// by filter the same way List's TaskFilter does. It requires a Storage
+// that implements SearchableStorage, returning ErrSearchUnavailable
+// otherwise.
+func (s *Service) SearchTasks(ctx context.Context, query string, filter storage.TaskFilter) ([]*queue.Task, error) {
+	searchable, ok := s.storage.(SearchableStorage)
+	if !ok {
+		return nil, ErrSearchUnavailable
+	}
+	return searchable.SearchTasks(ctx, query, filter)
+}
+
+// PauseTask pauses dispatch of a single task, leaving it otherwise
+// untouched. tenantID behaves as in Get: non-empty requires the task to
+// belong to that tenant, empty skips the check. It requires a Storage
+// that implements PausableStorage, returning ErrPauseUnavailable
+// otherwise.
+func (s *Service) PauseTask(ctx context.Context, id string, tenantID string) error {
+	pausable, ok := s.storage.(PausableStorage)
+	if !ok {
+		return ErrPauseUnavailable
+	}
+	if _, err := s.Get(ctx, id, tenantID); err != nil {
+		return err
+	}
+	return pausable.PauseTask(ctx, id)
+}
+
+// ResumeTask undoes a PauseTask call for id. tenantID behaves as in Get.
+func (s *Service) ResumeTask(ctx context.Context, id string, tenantID string) error {
+	pausable, ok := s.storage.(PausableStorage)
+	if !ok {
+		return ErrPauseUnavailable
+	}
+	if _, err := s.Get(ctx, id, tenantID); err != nil {
+		return err
+	}
+	return pausable.ResumeTask(ctx, id)
+}
+
+// PauseType pauses dispatch of every task of taskType, present and
+// future, across all tenants, until ResumeType is called. Unlike
+// PauseTask, this takes no tenantID: a task type isn't owned by any one
+// tenant, so there's no ownership to check -- callers are expected to
+// gate this behind ScopeTasksAdmin rather than per-tenant scoping.
+func (s *Service) PauseType(ctx context.Context, taskType, reason string) error {
+	pausable, ok := s.storage.(PausableStorage)
+	if !ok {
+		return ErrPauseUnavailable
+	}
+	return pausable.PauseType(ctx, taskType, reason)
+}
+
+// ResumeType undoes a PauseType call for taskType.
+func (s *Service) ResumeType(ctx context.Context, taskType string) error {
+	pausable, ok := s.storage.(PausableStorage)
+	if !ok {
+		return ErrPauseUnavailable
+	}
+	return pausable.ResumeType(ctx, taskType)
+}
+
+// ReadTaskLogs returns up to limit log lines for taskID with seq greater
+// than fromSeq, oldest first. tenantID behaves as in Get: non-empty
+// requires the task to belong to that tenant, empty skips the check. It
+// requires a Storage that implements LogReadableStorage, returning
+// ErrLogsUnavailable otherwise.
+func (s *Service) ReadTaskLogs(ctx context.Context, taskID string, tenantID string, fromSeq, limit int) ([]storage.LogLine, error) {
+	readable, ok := s.storage.(LogReadableStorage)
+	if !ok {
+		return nil, ErrLogsUnavailable
+	}
+	if _, err := s.Get(ctx, taskID, tenantID); err != nil {
+		return nil, err
+	}
+	return readable.ReadLogs(ctx, taskID, fromSeq, limit)
+}
+
+// maxRevisionConflictRetries bounds how many times Cancel and Retry
+// re-read and re-apply their transition after losing a race against a
+// concurrent Update -- a worker completing or failing the same task
+// between this call's Get and Update -- before giving up and surfacing
+// queue.ErrRevisionConflict to the caller.
+const maxRevisionConflictRetries = 5
+
+// Cancel cancels a pending or retrying task. tenantID behaves as in Get:
+// non-empty requires the task to belong to that tenant, empty skips the
+// check.
+func (s *Service) Cancel(ctx context.Context, id string, tenantID string) (*queue.Task, error) {
+	var task *queue.Task
+	var err error
+
+	for attempt := 0; attempt <= maxRevisionConflictRetries; attempt++ {
+		task, err = s.Get(ctx, id, tenantID)
+		if err != nil {
+			return nil, err
+		}
+
+		if task.State != queue.StatePending && task.State != queue.StateRetrying {
+			return nil, ErrTaskNotCancellable
+		}
+
+		task.State = queue.StateCancelled
+		now := time.Now()
+		task.CompletedAt = &now
+
+		err = s.queue.Update(ctx, task)
+		if !errors.Is(err, queue.ErrRevisionConflict) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// Retry resets a failed task back to pending so it's claimable again,
+// clearing its error and attempt state. tenantID behaves as in Get:
+// non-empty requires the task to belong to that tenant, empty skips the
+// check.
+func (s *Service) Retry(ctx context.Context, id string, tenantID string) (*queue.Task, error) {
+	var task *queue.Task
+	var err error
+
+	for attempt := 0; attempt <= maxRevisionConflictRetries; attempt++ {
+		task, err = s.Get(ctx, id, tenantID)
+		if err != nil {
+			return nil, err
+		}
+
+		if task.State != queue.StateFailed {
+			return nil, ErrTaskNotRetryable
+		}
+
+		task.State = queue.StatePending
+		task.Error = ""
+		task.StartedAt = nil
+		task.CompletedAt = nil
+		task.Retries = 0
+
+		err = s.queue.Update(ctx, task)
+		if !errors.Is(err, queue.ErrRevisionConflict) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// Delete removes a task. tenantID behaves as in Get: non-empty requires
+// the task to belong to that tenant, empty skips the check.
+func (s *Service) Delete(ctx context.Context, id string, tenantID string) error {
+	if id == "" {
+		return fmt.Errorf("%w: task id is required", ErrInvalidRequest)
+	}
+	if tenantID != "" {
+		if _, err := s.Get(ctx, id, tenantID); err != nil {
+			return err
+		}
+	}
+	return s.queue.Delete(ctx, id)
+}
+
+// Stats reports queue/worker statistics, preferring the worker pool's
+// metrics (they include per-worker detail a bare queue doesn't have) and
+// falling back to the queue's own queue.StatsCollector if there's no pool.
+func (s *Service) Stats(ctx context.Context) (interface{}, error) {
+	if s.pool != nil {
+		return s.pool.Metrics(), nil
+	}
+	if collector, ok := s.queue.(queue.StatsCollector); ok {
+		return collector.Stats(), nil
+	}
+	return nil, ErrStatsUnavailable
+}
+
+// Health reports "degraded" if the queue can't be peeked or the worker
+// pool (when configured) isn't running, "healthy" otherwise.
+func (s *Service) Health(ctx context.Context) (HealthStatus, error) {
+	status := "healthy"
+
+	if _, err := s.queue.Peek(ctx); err != nil && !errors.Is(err, queue.ErrQueueEmpty) {
+		status = "degraded"
+	}
+	if s.pool != nil && s.pool.State() != worker.StateRunning {
+		status = "degraded"
+	}
+
+	return HealthStatus{
+		Status:    status,
+		QueueSize: s.queue.Len(),
+		Timestamp: time.Now().UTC(),
+	}, nil
+}
+
+// Watch streams queue.TaskEvents matching filter. It requires the
+// configured queue to implement queue.SubscribableQueue.
+func (s *Service) Watch(ctx context.Context, filter queue.EventFilter) (<-chan queue.TaskEvent, error) {
+	subscribable, ok := s.queue.(queue.SubscribableQueue)
+	if !ok {
+		return nil, ErrWatchUnavailable
+	}
+	return subscribable.Subscribe(ctx, filter)
+}
+
+// Wait blocks until task id's Revision advances past since, then returns
+// the task in its new state. If since is already stale -- the task moved
+// past it between the caller's last read and this call -- Wait returns
+// immediately. If timeout elapses first, Wait returns the task in
+// whatever state it's currently in, not an error: a long poll timing out
+// is a normal outcome, not a failure, and it's on the caller to decide
+// whether to poll again. It requires the configured queue to implement
+// queue.SubscribableQueue. tenantID behaves as in Get: non-empty requires
+// the task to belong to that tenant, empty skips the check.
+func (s *Service) Wait(ctx context.Context, id string, tenantID string, since uint64, timeout time.Duration) (*queue.Task, error) {
+	task, err := s.Get(ctx, id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if task.Revision > since {
+		return task, nil
+	}
+
+	subscribable, ok := s.queue.(queue.SubscribableQueue)
+	if !ok {
+		return nil, ErrWatchUnavailable
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	events, err := subscribable.Subscribe(waitCtx, queue.EventFilter{TaskID: id})
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok || ev.Revision > since {
+				return s.Get(ctx, id, tenantID)
+			}
+		case <-waitCtx.Done():
+			return s.Get(ctx, id, tenantID)
+		}
+	}
+}
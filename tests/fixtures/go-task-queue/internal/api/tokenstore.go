@@ -0,0 +1,421 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// tokenPrefixLen is how many hex characters of a token's hash TokenStore
+// indexes on, so a lookup doesn't have to scan every row to find the
+// candidate to compare in constant time. It's short enough to keep the
+// index small and long enough (4 bytes) that prefix collisions are rare
+// rather than the common case.
+const tokenPrefixLen = 8
+
+// generateSecureToken returns 32 bytes from crypto/rand, hex-encoded, and
+// the SHA-256 hash of that encoding. The raw value is returned to the
+// caller exactly once -- callers must persist only hash/prefix, never the
+// raw value itself. This is the same primitive a password-reset flow
+// would use (crypto/rand + SHA-256-hashed storage instead of the MD5 +
+// math/rand this was modeled on); this tree has no user/password
+// subsystem to hang a reset flow off of, so only the API-token case below
+// is wired up.
+func generateSecureToken() (raw string, hashHex string, prefix string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	raw = hex.EncodeToString(buf)
+	sum := sha256.Sum256([]byte(raw))
+	hashHex = hex.EncodeToString(sum[:])
+	return raw, hashHex, hashHex[:tokenPrefixLen], nil
+}
+
+// hashToken returns the same hash generateSecureToken would have computed
+// for raw, for looking up a caller-supplied token.
+func hashToken(raw string) (hashHex string, prefix string) {
+	sum := sha256.Sum256([]byte(raw))
+	hashHex = hex.EncodeToString(sum[:])
+	return hashHex, hashHex[:tokenPrefixLen]
+}
+
+// TokenRecord is one API token's metadata. It never carries the raw
+// token or anything it could be recovered from -- HashHex is one-way.
+type TokenRecord struct {
+	ID           string
+	Label        string
+	TenantID     string
+	Scopes       []string
+	RateLimit    int
+	HashHex      string
+	CreatedAt    time.Time
+	LastAccessAt time.Time
+	ExpiresAt    *time.Time
+	RevokedAt    *time.Time
+}
+
+// expired reports whether t should no longer authenticate, as of now.
+func (t *TokenRecord) expired(now time.Time) bool {
+	return t.RevokedAt != nil || (t.ExpiresAt != nil && now.After(*t.ExpiresAt))
+}
+
+func (t *TokenRecord) principal() *Principal {
+	return &Principal{TenantID: t.TenantID, Scopes: t.Scopes, RateLimit: t.RateLimit}
+}
+
+// TokenStore is an Authenticator backed by a SQLite table of hashed API
+// tokens, with last-access tracking and revocation -- the persistent,
+// CRUD-manageable alternative to MemoryAuthenticator's in-process map.
+// Authenticate's last_access_at write is throttled to once per
+// lastAccessCoalesce per token, so a hot token under load doesn't turn
+// every request into a write.
+type TokenStore struct {
+	db *sql.DB
+
+	mu       sync.Mutex
+	lastSeen map[string]time.Time
+}
+
+// lastAccessCoalesce bounds how often Authenticate persists
+// last_access_at for the same token, regardless of request rate.
+const lastAccessCoalesce = time.Minute
+
+// NewTokenStore opens (or creates) the api_tokens table in db. db is
+// ordinarily storage.SQLiteStorage's own *sql.DB -- tokens share the
+// task queue's database file rather than needing a separate one -- but
+// TokenStore only ever touches its own table, so any *sql.DB works.
+func NewTokenStore(db *sql.DB) (*TokenStore, error) {
+	s := &TokenStore{db: db, lastSeen: make(map[string]time.Time)}
+	if err := s.migrate(); err != nil {
+		return nil, fmt.Errorf("failed to run token store migrations: %w", err)
+	}
+	return s, nil
+}
+
+func (s *TokenStore) migrate() error {
+	schema := `
+		CREATE TABLE IF NOT EXISTS api_tokens (
+			id TEXT PRIMARY KEY,
+			label TEXT NOT NULL DEFAULT '',
+			tenant_id TEXT NOT NULL DEFAULT '',
+			scopes TEXT NOT NULL DEFAULT '[]',
+			rate_limit INTEGER DEFAULT 0,
+			token_hash TEXT NOT NULL,
+			token_prefix TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			last_access_at DATETIME,
+			expires_at DATETIME,
+			revoked_at DATETIME
+		);
+
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_api_tokens_hash ON api_tokens(token_hash);
+		CREATE INDEX IF NOT EXISTS idx_api_tokens_prefix ON api_tokens(token_prefix);
+		CREATE INDEX IF NOT EXISTS idx_api_tokens_tenant_id ON api_tokens(tenant_id);
+	`
+	_, err := s.db.Exec(schema)
+	return err
+}
+
+// IssueToken generates a new token for tenantID with the given scopes,
+// persists its hash, and returns the raw token -- the only time it's ever
+// available. ttl of zero means the token never expires.
+func (s *TokenStore) IssueToken(label, tenantID string, scopes []string, rateLimit int, ttl time.Duration) (raw string, rec *TokenRecord, err error) {
+	raw, hashHex, prefix, err := generateSecureToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	id := hashHex[:16]
+	scopesJSON, err := json.Marshal(scopes)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal scopes: %w", err)
+	}
+
+	now := time.Now()
+	var expiresAt *time.Time
+	if ttl > 0 {
+		t := now.Add(ttl)
+		expiresAt = &t
+	}
+
+	_, err = s.db.Exec(
+		`INSERT INTO api_tokens (id, label, tenant_id, scopes, rate_limit, token_hash, token_prefix, created_at, expires_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, label, tenantID, string(scopesJSON), rateLimit, hashHex, prefix, now, expiresAt,
+	)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to persist token: %w", err)
+	}
+
+	return raw, &TokenRecord{
+		ID: id, Label: label, TenantID: tenantID, Scopes: scopes, RateLimit: rateLimit,
+		HashHex: hashHex, CreatedAt: now, ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Authenticate implements Authenticator by hashing token, looking it up
+// by its hash prefix (narrowing to a handful of candidate rows instead of
+// a full table scan), and comparing the full hash of each candidate in
+// constant time via subtle.ConstantTimeCompare -- so a timing attack
+// against the comparison itself can't narrow down a valid hash even
+// though the prefix index already reveals a few hex characters.
+func (s *TokenStore) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	hashHex, prefix := hashToken(token)
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, label, tenant_id, scopes, rate_limit, token_hash, created_at, last_access_at, expires_at, revoked_at
+		 FROM api_tokens WHERE token_prefix = ?`, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("token lookup failed: %w", err)
+	}
+	defer rows.Close()
+
+	wantHash := []byte(hashHex)
+
+	var match *TokenRecord
+	for rows.Next() {
+		rec, err := scanTokenRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		if subtle.ConstantTimeCompare([]byte(rec.HashHex), wantHash) == 1 {
+			match = rec
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if match == nil {
+		return nil, ErrInvalidToken
+	}
+	if match.expired(time.Now()) {
+		return nil, ErrInvalidToken
+	}
+
+	s.touchLastAccess(match.ID)
+
+	return match.principal(), nil
+}
+
+// touchLastAccess persists last_access_at for id, but at most once per
+// lastAccessCoalesce -- an in-memory write coalescer so a busy token
+// doesn't generate a write on every single request.
+func (s *TokenStore) touchLastAccess(id string) {
+	now := time.Now()
+
+	s.mu.Lock()
+	if last, ok := s.lastSeen[id]; ok && now.Sub(last) < lastAccessCoalesce {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSeen[id] = now
+	s.mu.Unlock()
+
+	// Best-effort: a lost last_access_at update doesn't affect whether
+	// the token still authenticates.
+	s.db.Exec(`UPDATE api_tokens SET last_access_at = ? WHERE id = ?`, now, id)
+}
+
+// ListTokens returns every non-revoked-or-not token belonging to
+// tenantID, most recently created first. HashHex is included since it's
+// already one-way and useful for an operator cross-checking a token by
+// its hash; the raw token itself is never stored or returned.
+func (s *TokenStore) ListTokens(ctx context.Context, tenantID string) ([]*TokenRecord, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, label, tenant_id, scopes, rate_limit, token_hash, created_at, last_access_at, expires_at, revoked_at
+		 FROM api_tokens WHERE tenant_id = ? ORDER BY created_at DESC`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*TokenRecord
+	for rows.Next() {
+		rec, err := scanTokenRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// errTokenNotFound is returned by RelabelToken/RevokeToken when id
+// doesn't belong to tenantID (or doesn't exist at all) -- the caller
+// can't distinguish the two, for the same reason service.Service.Get
+// folds a cross-tenant lookup into "not found".
+var errTokenNotFound = errors.New("token not found")
+
+// RelabelToken updates id's label, scoped to tenantID.
+func (s *TokenStore) RelabelToken(ctx context.Context, tenantID, id, label string) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE api_tokens SET label = ? WHERE id = ? AND tenant_id = ?`, label, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to relabel token: %w", err)
+	}
+	return checkRowAffected(res)
+}
+
+// RevokeToken marks id revoked, scoped to tenantID, so Authenticate
+// rejects it from then on without deleting its audit trail.
+func (s *TokenStore) RevokeToken(ctx context.Context, tenantID, id string) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE api_tokens SET revoked_at = ? WHERE id = ? AND tenant_id = ? AND revoked_at IS NULL`,
+		time.Now(), id, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return checkRowAffected(res)
+}
+
+func checkRowAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errTokenNotFound
+	}
+	return nil
+}
+
+func scanTokenRow(rows *sql.Rows) (*TokenRecord, error) {
+	var rec TokenRecord
+	var scopesJSON string
+	var lastAccessAt, expiresAt, revokedAt sql.NullTime
+
+	if err := rows.Scan(&rec.ID, &rec.Label, &rec.TenantID, &scopesJSON, &rec.RateLimit,
+		&rec.HashHex, &rec.CreatedAt, &lastAccessAt, &expiresAt, &revokedAt); err != nil {
+		return nil, fmt.Errorf("failed to scan token row: %w", err)
+	}
+	if err := json.Unmarshal([]byte(scopesJSON), &rec.Scopes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal scopes: %w", err)
+	}
+	if lastAccessAt.Valid {
+		rec.LastAccessAt = lastAccessAt.Time
+	}
+	if expiresAt.Valid {
+		rec.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		rec.RevokedAt = &revokedAt.Time
+	}
+	return &rec, nil
+}
+
+// tokenRecordView is the JSON shape ListTokens/RelabelToken hand back to
+// a caller -- HashHex is renamed "token_hash" and truncated to the prefix
+// a caller would recognize from the token they were issued, not the full
+// hash.
+type tokenRecordView struct {
+	ID           string     `json:"id"`
+	Label        string     `json:"label"`
+	Scopes       []string   `json:"scopes"`
+	TokenPrefix  string     `json:"token_prefix"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastAccessAt *time.Time `json:"last_access_at,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+}
+
+func toTokenRecordView(rec *TokenRecord) tokenRecordView {
+	view := tokenRecordView{
+		ID:          rec.ID,
+		Label:       rec.Label,
+		Scopes:      rec.Scopes,
+		TokenPrefix: rec.HashHex[:tokenPrefixLen],
+		CreatedAt:   rec.CreatedAt,
+		ExpiresAt:   rec.ExpiresAt,
+		RevokedAt:   rec.RevokedAt,
+	}
+	if !rec.LastAccessAt.IsZero() {
+		t := rec.LastAccessAt
+		view.LastAccessAt = &t
+	}
+	return view
+}
+
+// TokenHandler exposes /account/tokens CRUD over a TokenStore, scoped to
+// the caller's own Principal.TenantID the same way Handler's task
+// endpoints are (see requestTenantID) -- a caller can only see or manage
+// their own tokens.
+type TokenHandler struct {
+	store *TokenStore
+}
+
+// NewTokenHandler creates a TokenHandler backed by store.
+func NewTokenHandler(store *TokenStore) *TokenHandler {
+	return &TokenHandler{store: store}
+}
+
+// registerRoutes adds /account/tokens routes to mux, scoped with
+// ScopeAccountTokens.
+func (h *TokenHandler) registerRoutes(mux *http.ServeMux) {
+	mux.Handle("GET /account/tokens", scoped(ScopeAccountTokens, h.List))
+	mux.Handle("PATCH /account/tokens/{id}", scoped(ScopeAccountTokens, h.Relabel))
+	mux.Handle("DELETE /account/tokens/{id}", scoped(ScopeAccountTokens, h.Revoke))
+}
+
+// List handles GET /account/tokens.
+func (h *TokenHandler) List(w http.ResponseWriter, r *http.Request) {
+	records, err := h.store.ListTokens(r.Context(), requestTenantID(r))
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	views := make([]tokenRecordView, len(records))
+	for i, rec := range records {
+		views[i] = toTokenRecordView(rec)
+	}
+
+	writeJSON(w, r, http.StatusOK, Response{Success: true, Data: views, Meta: &Meta{Total: len(views)}})
+}
+
+// relabelRequest is the request body for PATCH /account/tokens/{id}.
+type relabelRequest struct {
+	Label string `json:"label"`
+}
+
+// Relabel handles PATCH /account/tokens/{id}.
+func (h *TokenHandler) Relabel(w http.ResponseWriter, r *http.Request) {
+	var req relabelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	err := h.store.RelabelToken(r.Context(), requestTenantID(r), r.PathValue("id"), req.Label)
+	h.writeTokenResult(w, r, err)
+}
+
+// Revoke handles DELETE /account/tokens/{id}.
+func (h *TokenHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	err := h.store.RevokeToken(r.Context(), requestTenantID(r), r.PathValue("id"))
+	h.writeTokenResult(w, r, err)
+}
+
+func (h *TokenHandler) writeTokenResult(w http.ResponseWriter, r *http.Request, err error) {
+	if err != nil {
+		if errors.Is(err, errTokenNotFound) {
+			writeError(w, r, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, r, http.StatusOK, Response{Success: true})
+}
@@ -0,0 +1,42 @@
+// Package logging provides the shared structured logger used by the
+// server, worker, and CLI binaries, so task lifecycle events carry
+// correlated fields (task_id, task_type, worker_id, duration_ms,
+// request_id, ...) regardless of which binary emits them.
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// New builds a *slog.Logger writing to os.Stdout, honoring level and
+// format as documented for the -log-level and -log-format flags shared
+// across the server, worker, and CLI binaries.
+func New(level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(level)}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, "json") {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	}
+
+	return slog.New(handler)
+}
+
+// ParseLevel maps a -log-level flag value to a slog.Level, defaulting to
+// Info for an empty or unrecognized string.
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
@@ -0,0 +1,250 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultGroupFlushInterval is how often the flusher goroutine started by
+// NewAggregatingQueue checks buffered groups against their GroupPolicy when
+// WithFlushInterval isn't given.
+const DefaultGroupFlushInterval = 250 * time.Millisecond
+
+// GroupPolicy controls when AggregatingQueue flushes a group's buffered
+// tasks into a single aggregated task. A group flushes as soon as any one
+// of the three conditions is met.
+type GroupPolicy struct {
+	// GracePeriod resets every time a new task is buffered into the group;
+	// the group flushes once this much time passes without a new arrival.
+	// Zero disables this condition.
+	GracePeriod time.Duration
+
+	// MaxDelay bounds how long the oldest buffered task in a group may
+	// wait, even if new arrivals keep resetting GracePeriod. Zero disables
+	// this condition.
+	MaxDelay time.Duration
+
+	// MaxSize flushes the group as soon as this many tasks are buffered,
+	// regardless of GracePeriod or MaxDelay. Zero disables this condition.
+	MaxSize int
+}
+
+// DefaultGroupPolicy returns the GroupPolicy applied to a group that has no
+// override registered via WithGroupPolicy.
+func DefaultGroupPolicy() GroupPolicy {
+	return GroupPolicy{
+		GracePeriod: 5 * time.Second,
+		MaxDelay:    30 * time.Second,
+		MaxSize:     100,
+	}
+}
+
+// Aggregator merges the tasks buffered for group into the single task
+// AggregatingQueue enqueues in their place once the group flushes. Callers
+// own how the merged payload is represented; DefaultAggregator provides a
+// simple implementation that carries the buffered tasks through unchanged.
+type Aggregator func(group string, tasks []*Task) *Task
+
+// DefaultAggregator merges tasks into a task of type group whose Payload
+// holds the original tasks verbatim under the "tasks" key. It's meant as a
+// starting point for callers that don't need a custom merge strategy.
+func DefaultAggregator(group string, tasks []*Task) *Task {
+	return &Task{
+		Type:    group,
+		Payload: map[string]interface{}{"tasks": tasks},
+	}
+}
+
+// groupBuffer holds the tasks accumulated for one (queue, group) key.
+type groupBuffer struct {
+	tasks   []*Task
+	firstAt time.Time
+	lastAt  time.Time
+}
+
+// AggregatingQueue wraps a MemoryQueue, buffering tasks enqueued with
+// WithGroup under their Group key instead of making them immediately
+// eligible for Dequeue, and periodically flushing each group's buffer
+// through an Aggregator into a single enqueued task. This lets
+// batch-friendly workloads (e.g. "send one email summarizing 20 events")
+// run on top of the same per-task Dequeue/Ack pipeline as everything else,
+// without the core Queue interface changing at all.
+type AggregatingQueue struct {
+	*MemoryQueue
+
+	aggregator    Aggregator
+	defaultPolicy GroupPolicy
+	flushInterval time.Duration
+
+	mu     sync.Mutex
+	groups map[string]*groupBuffer
+
+	stopFlusher func()
+	policies    map[string]GroupPolicy
+}
+
+// AggregatingQueueOption is a functional option for AggregatingQueue.
+type AggregatingQueueOption func(*AggregatingQueue)
+
+// WithDefaultGroupPolicy overrides the GroupPolicy applied to groups with no
+// policy registered via WithGroupPolicy (see DefaultGroupPolicy).
+func WithDefaultGroupPolicy(policy GroupPolicy) AggregatingQueueOption {
+	return func(q *AggregatingQueue) {
+		q.defaultPolicy = policy
+	}
+}
+
+// WithGroupPolicy registers a GroupPolicy for one specific group, overriding
+// the default policy for that group only.
+func WithGroupPolicy(group string, policy GroupPolicy) AggregatingQueueOption {
+	return func(q *AggregatingQueue) {
+		q.policies[group] = policy
+	}
+}
+
+// WithFlushInterval overrides how often the flusher goroutine started by
+// NewAggregatingQueue checks buffered groups against their GroupPolicy (see
+// DefaultGroupFlushInterval).
+func WithFlushInterval(interval time.Duration) AggregatingQueueOption {
+	return func(q *AggregatingQueue) {
+		q.flushInterval = interval
+	}
+}
+
+// NewAggregatingQueue wraps mq, buffering tasks enqueued with WithGroup and
+// flushing each group through aggregator per its GroupPolicy.
+func NewAggregatingQueue(mq *MemoryQueue, aggregator Aggregator, opts ...AggregatingQueueOption) *AggregatingQueue {
+	q := &AggregatingQueue{
+		MemoryQueue:   mq,
+		aggregator:    aggregator,
+		defaultPolicy: DefaultGroupPolicy(),
+		flushInterval: DefaultGroupFlushInterval,
+		groups:        make(map[string]*groupBuffer),
+		policies:      make(map[string]GroupPolicy),
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	q.stopFlusher = q.startFlusher(q.flushInterval)
+
+	return q
+}
+
+// policyFor returns the GroupPolicy registered for group, falling back to
+// q.defaultPolicy.
+func (q *AggregatingQueue) policyFor(group string) GroupPolicy {
+	if p, ok := q.policies[group]; ok {
+		return p
+	}
+	return q.defaultPolicy
+}
+
+// Enqueue buffers task under its Group instead of handing it to the
+// underlying MemoryQueue, flushing the group immediately if MaxSize is
+// reached. A task with no Group set is enqueued directly, unaffected by
+// aggregation.
+func (q *AggregatingQueue) Enqueue(ctx context.Context, task *Task) error {
+	if task == nil {
+		return ErrInvalidTask
+	}
+	if task.Group == "" {
+		return q.MemoryQueue.Enqueue(ctx, task)
+	}
+
+	now := time.Now()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	buf, ok := q.groups[task.Group]
+	if !ok {
+		buf = &groupBuffer{firstAt: now}
+		q.groups[task.Group] = buf
+	}
+	buf.tasks = append(buf.tasks, task)
+	buf.lastAt = now
+
+	if policy := q.policyFor(task.Group); policy.MaxSize > 0 && len(buf.tasks) >= policy.MaxSize {
+		return q.flushGroupLocked(ctx, task.Group)
+	}
+
+	return nil
+}
+
+// flushGroupLocked builds the aggregated task for group from its buffered
+// tasks and enqueues it on the underlying MemoryQueue, clearing the
+// buffer. q.mu must be held.
+func (q *AggregatingQueue) flushGroupLocked(ctx context.Context, group string) error {
+	buf, ok := q.groups[group]
+	if !ok || len(buf.tasks) == 0 {
+		return nil
+	}
+
+	tasks := buf.tasks
+	delete(q.groups, group)
+
+	aggregated := q.aggregator(group, tasks)
+	if aggregated == nil {
+		return nil
+	}
+
+	return q.MemoryQueue.Enqueue(ctx, aggregated)
+}
+
+// flushDue flushes every group whose GracePeriod or MaxDelay condition is
+// met as of now.
+func (q *AggregatingQueue) flushDue(now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for group, buf := range q.groups {
+		if len(buf.tasks) == 0 {
+			continue
+		}
+
+		policy := q.policyFor(group)
+		idle := policy.GracePeriod > 0 && now.Sub(buf.lastAt) >= policy.GracePeriod
+		aged := policy.MaxDelay > 0 && now.Sub(buf.firstAt) >= policy.MaxDelay
+		if idle || aged {
+			q.flushGroupLocked(context.Background(), group)
+		}
+	}
+}
+
+// startFlusher starts the goroutine that periodically checks buffered
+// groups against their GroupPolicy, mirroring MemoryQueue's own
+// startForwarder/startRecoverer goroutines.
+func (q *AggregatingQueue) startFlusher(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				q.flushDue(now)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// Close stops the flusher goroutine, then closes the underlying
+// MemoryQueue. Any tasks still buffered in a group are discarded rather
+// than flushed.
+func (q *AggregatingQueue) Close() error {
+	if q.stopFlusher != nil {
+		q.stopFlusher()
+	}
+	return q.MemoryQueue.Close()
+}
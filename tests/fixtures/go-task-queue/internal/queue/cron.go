@@ -0,0 +1,168 @@
+package queue
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CronScheduler holds a set of (cron expression, task template) entries
+// and, in a background goroutine, enqueues a materialized Task at each
+// firing time. Firing IDs are deterministic (sha256 of the entry ID and
+// the firing timestamp), so a crashed scheduler that comes back up and
+// recomputes recent firings won't double-enqueue them as long as the
+// target queue treats duplicate Task.IDs as a no-op on re-insert.
+type CronScheduler struct {
+	queue Queue
+
+	mu        sync.Mutex
+	entries   map[string]*cronEntry
+	lastFired map[string]time.Time
+
+	pollInterval time.Duration
+	stopCh       chan struct{}
+	wg           sync.WaitGroup
+}
+
+type cronEntry struct {
+	id      string
+	sched   cronSchedule
+	factory func(time.Time) *Task
+}
+
+// CatchUpMode controls what happens to firings missed while the scheduler
+// wasn't running.
+type CatchUpMode int
+
+const (
+	// SkipMissed only fires from "now" forward; anything missed while the
+	// process was down is simply never enqueued.
+	SkipMissed CatchUpMode = iota
+	// FireOnceOnRecovery enqueues a single catch-up firing for the most
+	// recent missed time, then resumes normal firing.
+	FireOnceOnRecovery
+)
+
+// NewCronScheduler creates a CronScheduler that enqueues materialized
+// tasks onto q.
+func NewCronScheduler(q Queue) *CronScheduler {
+	return &CronScheduler{
+		queue:        q,
+		entries:      make(map[string]*cronEntry),
+		lastFired:    make(map[string]time.Time),
+		pollInterval: time.Second,
+		stopCh:       make(chan struct{}),
+	}
+}
+
+// Add registers a recurring entry. taskFactory builds the Task to enqueue
+// for a given firing time; its returned Task.ID is overwritten with the
+// deterministic firing ID.
+func (s *CronScheduler) Add(id, cronExpr string, taskFactory func(time.Time) *Task) error {
+	sched, err := parseCronSchedule(cronExpr)
+	if err != nil {
+		return fmt.Errorf("invalid cron expression %q: %w", cronExpr, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[id] = &cronEntry{id: id, sched: sched, factory: taskFactory}
+	return nil
+}
+
+// Remove unregisters an entry.
+func (s *CronScheduler) Remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, id)
+	delete(s.lastFired, id)
+}
+
+// Start runs the scheduler's firing loop until ctx is cancelled or Stop is
+// called. mode governs how entries with no recorded last-fired time (i.e.
+// a cold start) treat firings in the past.
+func (s *CronScheduler) Start(ctx context.Context, mode CatchUpMode) {
+	s.wg.Add(1)
+	go s.run(ctx, mode)
+}
+
+// Stop halts the firing loop and waits for it to exit.
+func (s *CronScheduler) Stop() {
+	close(s.stopCh)
+	s.wg.Wait()
+}
+
+func (s *CronScheduler) run(ctx context.Context, mode CatchUpMode) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			s.tick(time.Now(), mode)
+		}
+	}
+}
+
+// tick checks every entry for a due firing and enqueues it.
+func (s *CronScheduler) tick(now time.Time, mode CatchUpMode) {
+	s.mu.Lock()
+	entries := make([]*cronEntry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e)
+	}
+	s.mu.Unlock()
+
+	for _, e := range entries {
+		s.fireIfDue(e, now, mode)
+	}
+}
+
+func (s *CronScheduler) fireIfDue(e *cronEntry, now time.Time, mode CatchUpMode) {
+	s.mu.Lock()
+	last, known := s.lastFired[e.id]
+	s.mu.Unlock()
+
+	if !known {
+		switch mode {
+		case SkipMissed:
+			last = now
+		case FireOnceOnRecovery:
+			last = now.Add(-e.sched.interval())
+		}
+	}
+
+	firing, ok := e.sched.next(last, now)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	s.lastFired[e.id] = firing
+	s.mu.Unlock()
+
+	task := e.factory(firing)
+	if task == nil {
+		return
+	}
+	task.ID = firingID(e.id, firing)
+
+	_ = s.queue.Enqueue(context.Background(), task)
+}
+
+// firingID computes a deterministic task ID for a given cron entry and
+// firing time, so re-deriving the same firing after a crash produces the
+// same ID (letting an idempotent Enqueue / unique-constraint skip it).
+func firingID(cronID string, firing time.Time) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", cronID, firing.Unix())))
+	return hex.EncodeToString(h[:])
+}
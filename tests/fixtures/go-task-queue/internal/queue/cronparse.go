@@ -0,0 +1,160 @@
+package queue
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed 5-field cron expression (minute hour
+// day-of-month month day-of-week), each field a set of allowed values.
+type cronSchedule struct {
+	minutes  map[int]bool
+	hours    map[int]bool
+	days     map[int]bool
+	months   map[int]bool
+	weekdays map[int]bool
+}
+
+var cronFieldRanges = []struct {
+	min, max int
+}{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 6},  // day of week (0 = Sunday)
+}
+
+// parseCronSchedule parses a standard 5-field cron expression supporting
+// "*", lists ("1,2,3"), ranges ("1-5"), steps ("*/5", "1-10/2") and plain
+// values, one field per "minute hour dom month dow".
+func parseCronSchedule(expr string) (cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return cronSchedule{}, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+
+	sets := make([]map[int]bool, 5)
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFieldRanges[i].min, cronFieldRanges[i].max)
+		if err != nil {
+			return cronSchedule{}, fmt.Errorf("field %d (%q): %w", i, field, err)
+		}
+		sets[i] = set
+	}
+
+	return cronSchedule{
+		minutes:  sets[0],
+		hours:    sets[1],
+		days:     sets[2],
+		months:   sets[3],
+		weekdays: sets[4],
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	set := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		if err := parseCronPart(part, min, max, set); err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+func parseCronPart(part string, min, max int, set map[int]bool) error {
+	step := 1
+	if idx := strings.Index(part, "/"); idx != -1 {
+		s, err := strconv.Atoi(part[idx+1:])
+		if err != nil || s <= 0 {
+			return fmt.Errorf("invalid step %q", part)
+		}
+		step = s
+		part = part[:idx]
+	}
+
+	lo, hi := min, max
+	switch {
+	case part == "*":
+		// full range already set above
+	case strings.Contains(part, "-"):
+		bounds := strings.SplitN(part, "-", 2)
+		if len(bounds) != 2 {
+			return fmt.Errorf("invalid range %q", part)
+		}
+		l, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return fmt.Errorf("invalid range start %q", bounds[0])
+		}
+		h, err := strconv.Atoi(bounds[1])
+		if err != nil {
+			return fmt.Errorf("invalid range end %q", bounds[1])
+		}
+		lo, hi = l, h
+	default:
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return fmt.Errorf("invalid value %q", part)
+		}
+		lo, hi = v, v
+	}
+
+	if lo < min || hi > max || lo > hi {
+		return fmt.Errorf("value out of range [%d-%d]", min, max)
+	}
+
+	for v := lo; v <= hi; v += step {
+		set[v] = true
+	}
+	return nil
+}
+
+// interval returns the minimum granularity this schedule can fire at,
+// used to derive a reasonable catch-up lookback when no last-fired time
+// is recorded yet.
+func (s cronSchedule) interval() time.Duration {
+	return time.Minute
+}
+
+// next returns the earliest minute-aligned time in (after, upTo] that
+// matches the schedule, if any.
+func (s cronSchedule) next(after, upTo time.Time) (time.Time, bool) {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	for !t.After(upTo) {
+		if s.matches(t) {
+			return t, true
+		}
+		t = t.Add(time.Minute)
+	}
+	return time.Time{}, false
+}
+
+func (s cronSchedule) matches(t time.Time) bool {
+	return s.minutes[t.Minute()] &&
+		s.hours[t.Hour()] &&
+		s.days[t.Day()] &&
+		s.months[int(t.Month())] &&
+		s.weekdays[int(t.Weekday())]
+}
+
+// CronSchedule is an exported handle on a parsed cron expression for
+// callers outside this package (e.g. task.Scheduler) that need to compute
+// firing times without reimplementing the parser.
+type CronSchedule struct {
+	sched cronSchedule
+}
+
+// ParseCronSchedule parses a standard 5-field cron expression; see
+// parseCronSchedule for the supported syntax.
+func ParseCronSchedule(expr string) (CronSchedule, error) {
+	sched, err := parseCronSchedule(expr)
+	return CronSchedule{sched: sched}, err
+}
+
+// NextAfter returns the earliest minute-aligned firing time after after,
+// searching up to one year out.
+func (c CronSchedule) NextAfter(after time.Time) (time.Time, bool) {
+	return c.sched.next(after, after.AddDate(1, 0, 0))
+}
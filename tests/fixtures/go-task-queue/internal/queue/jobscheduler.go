@@ -0,0 +1,370 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// JobFunc is the work a JobScheduler runs for one firing of a registered
+// job.
+type JobFunc func(ctx context.Context) error
+
+// scheduledJob is one claimed row from the scheduled_jobs table.
+type scheduledJob struct {
+	id      string
+	timeout time.Duration
+}
+
+// JobSchedulerConfig configures a JobScheduler.
+type JobSchedulerConfig struct {
+	// DSN is a database/sql data source name for a Postgres or MySQL
+	// database (whichever driver is imported by the caller) -- the
+	// scheduler relies on SELECT ... FOR UPDATE SKIP LOCKED to elect a
+	// single runner per job, which SQLite's single-writer model doesn't
+	// need and doesn't support.
+	DSN string
+
+	// Driver is the database/sql driver name to open DSN with, e.g.
+	// "postgres" or "mysql".
+	Driver string
+
+	// PollInterval bounds how long the scheduler sleeps between checking
+	// scheduled_jobs for due work.
+	PollInterval time.Duration
+
+	// Concurrency caps how many claimed jobs run at once, so a backlog of
+	// simultaneously-due jobs can't spawn an unbounded number of
+	// goroutines against a shared *sql.DB.
+	Concurrency int
+
+	// Host identifies this replica in job_runs.host. Defaults to
+	// os.Hostname().
+	Host string
+}
+
+// DefaultJobSchedulerConfig returns sane defaults for a JobScheduler
+// connecting to dsn with driver.
+func DefaultJobSchedulerConfig(driver, dsn string) JobSchedulerConfig {
+	return JobSchedulerConfig{
+		DSN:          dsn,
+		Driver:       driver,
+		PollInterval: 10 * time.Second,
+		Concurrency:  4,
+	}
+}
+
+// JobScheduler runs cron-scheduled jobs across any number of replicas,
+// with at most one replica actually executing a given firing. Job
+// definitions and their next/last run times persist in a scheduled_jobs
+// table instead of living only in process memory (see CronScheduler for
+// the in-memory, single-process equivalent used by queue.Queue's own
+// delayed/recurring tasks); every run's outcome is recorded in job_runs
+// for audit and debugging.
+//
+// Leader election is per-job, not per-process: JobScheduler.runDue claims
+// every currently-due row with SELECT ... FOR UPDATE SKIP LOCKED in the
+// same transaction it advances next_run_at, so a replica that loses the
+// race for a row simply sees zero rows returned rather than blocking on
+// it. This is the same pattern PostgresQueue.Dequeue uses to avoid
+// double-claiming a task, applied to scheduled jobs instead.
+type JobScheduler struct {
+	db   *sql.DB
+	cfg  JobSchedulerConfig
+	host string
+
+	mu   sync.RWMutex
+	jobs map[string]JobFunc
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewJobScheduler opens (migrating if necessary) the scheduler's database
+// connection.
+func NewJobScheduler(cfg JobSchedulerConfig) (*JobScheduler, error) {
+	db, err := sql.Open(cfg.Driver, cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open scheduler database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to scheduler database: %w", err)
+	}
+
+	host := cfg.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+
+	s := &JobScheduler{
+		db:     db,
+		cfg:    cfg,
+		host:   host,
+		jobs:   make(map[string]JobFunc),
+		stopCh: make(chan struct{}),
+	}
+
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *JobScheduler) migrate() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS scheduled_jobs (
+			id              TEXT PRIMARY KEY,
+			cron_expr       TEXT NOT NULL,
+			timeout_seconds INTEGER NOT NULL DEFAULT 0,
+			next_run_at     TIMESTAMPTZ NOT NULL,
+			last_run_at     TIMESTAMPTZ
+		);
+		CREATE TABLE IF NOT EXISTS job_runs (
+			id          BIGSERIAL PRIMARY KEY,
+			job_id      TEXT NOT NULL,
+			host        TEXT NOT NULL,
+			started_at  TIMESTAMPTZ NOT NULL,
+			finished_at TIMESTAMPTZ,
+			status      TEXT NOT NULL,
+			error       TEXT
+		);
+		CREATE INDEX IF NOT EXISTS idx_job_runs_job_id ON job_runs(job_id, started_at DESC);
+	`)
+	return err
+}
+
+// Register upserts a job's definition (cron expression and per-run
+// timeout) and binds fn as the handler this process runs when it claims
+// a firing. Every replica that might run id should call Register with
+// the same cronExpr/timeout -- only fn itself is local to this process.
+// A replica that hasn't called Register for a job it claims (e.g. mid
+// rolling-deploy) just lets that firing's next_run_at stand; the next
+// poll tries again.
+func (s *JobScheduler) Register(id, cronExpr string, timeout time.Duration, fn JobFunc) error {
+	sched, err := ParseCronSchedule(cronExpr)
+	if err != nil {
+		return fmt.Errorf("invalid cron expression %q: %w", cronExpr, err)
+	}
+
+	next, ok := sched.NextAfter(time.Now())
+	if !ok {
+		return fmt.Errorf("cron expression %q never fires", cronExpr)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO scheduled_jobs (id, cron_expr, timeout_seconds, next_run_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET cron_expr = EXCLUDED.cron_expr, timeout_seconds = EXCLUDED.timeout_seconds
+	`, id, cronExpr, int(timeout.Seconds()), next)
+	if err != nil {
+		return fmt.Errorf("failed to register job %q: %w", id, err)
+	}
+
+	s.mu.Lock()
+	s.jobs[id] = fn
+	s.mu.Unlock()
+	return nil
+}
+
+// Start runs the scheduler's poll loop until ctx is cancelled or Stop is
+// called.
+func (s *JobScheduler) Start(ctx context.Context) {
+	s.wg.Add(1)
+	go s.run(ctx)
+}
+
+// Stop halts the poll loop and waits for any in-flight jobs to finish.
+func (s *JobScheduler) Stop() {
+	close(s.stopCh)
+	s.wg.Wait()
+}
+
+func (s *JobScheduler) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			if err := s.runDue(ctx); err != nil {
+				log.Printf("job scheduler: poll failed: %v", err)
+			}
+		}
+	}
+}
+
+// runDue claims every currently-due job and runs them concurrently,
+// bounded by Config.Concurrency via an errgroup -- replacing the
+// unbounded per-job goroutine that would otherwise share s.db with no
+// limit on how many ran at once.
+func (s *JobScheduler) runDue(ctx context.Context) error {
+	claimed, err := s.claimDue(ctx)
+	if err != nil {
+		return err
+	}
+	if len(claimed) == 0 {
+		return nil
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	if s.cfg.Concurrency > 0 {
+		g.SetLimit(s.cfg.Concurrency)
+	}
+	for _, job := range claimed {
+		job := job
+		g.Go(func() error {
+			// A single job failing shouldn't cancel gctx for its
+			// siblings -- runOne already records the failure in
+			// job_runs, so swallow it here.
+			s.runOne(gctx, job)
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// claimDue elects this replica as the runner for every job due at or
+// before now, in one transaction: SELECT ... FOR UPDATE SKIP LOCKED
+// picks rows no other replica's concurrent claimDue already has locked,
+// and the UPDATE advancing next_run_at (still inside the transaction)
+// means a row this replica claims is no longer due the moment it commits.
+func (s *JobScheduler) claimDue(ctx context.Context) ([]scheduledJob, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, cron_expr, timeout_seconds
+		FROM scheduled_jobs
+		WHERE next_run_at <= $1
+		FOR UPDATE SKIP LOCKED
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due jobs: %w", err)
+	}
+
+	type row struct {
+		id       string
+		cronExpr string
+		timeoutS int
+	}
+	var claimedRows []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.cronExpr, &r.timeoutS); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		claimedRows = append(claimedRows, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	claimed := make([]scheduledJob, 0, len(claimedRows))
+	for _, r := range claimedRows {
+		sched, err := ParseCronSchedule(r.cronExpr)
+		if err != nil {
+			log.Printf("job scheduler: job %q has unparseable cron expression %q, skipping: %v", r.id, r.cronExpr, err)
+			continue
+		}
+		next, ok := sched.NextAfter(now)
+		if !ok {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE scheduled_jobs SET next_run_at = $1, last_run_at = $2 WHERE id = $3
+		`, next, now, r.id); err != nil {
+			return nil, fmt.Errorf("failed to advance job %q: %w", r.id, err)
+		}
+		claimed = append(claimed, scheduledJob{id: r.id, timeout: time.Duration(r.timeoutS) * time.Second})
+	}
+
+	return claimed, tx.Commit()
+}
+
+// errJobNotRegistered is recorded in job_runs when this replica claims a
+// firing it has no local handler for.
+var errJobNotRegistered = errors.New("job claimed but no handler registered on this replica")
+
+// runOne runs job's handler (if this replica has one registered) with
+// job.timeout applied, recovering a panic the same way worker.Pool does
+// for task handlers, and records the outcome in job_runs.
+func (s *JobScheduler) runOne(ctx context.Context, job scheduledJob) {
+	s.mu.RLock()
+	fn, ok := s.jobs[job.id]
+	s.mu.RUnlock()
+
+	start := time.Now()
+	if !ok {
+		s.recordRun(job.id, start, time.Now(), errJobNotRegistered)
+		return
+	}
+
+	runCtx := ctx
+	if job.timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, job.timeout)
+		defer cancel()
+	}
+
+	err := runWithRecover(runCtx, fn)
+	s.recordRun(job.id, start, time.Now(), err)
+}
+
+// runWithRecover calls fn, converting a panic into an error the same way
+// worker.Pool.recoverWorker does for task handlers -- one job's panic
+// shouldn't take the scheduler process down.
+func runWithRecover(ctx context.Context, fn JobFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+// recordRun inserts job's outcome into job_runs. A failure to record is
+// logged rather than returned -- losing an audit row shouldn't be
+// treated the same as the job itself failing.
+func (s *JobScheduler) recordRun(jobID string, start, end time.Time, runErr error) {
+	status := "success"
+	errMsg := ""
+	if runErr != nil {
+		status = "failed"
+		errMsg = runErr.Error()
+	}
+
+	if _, err := s.db.Exec(`
+		INSERT INTO job_runs (job_id, host, started_at, finished_at, status, error)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, jobID, s.host, start, end, status, errMsg); err != nil {
+		log.Printf("job scheduler: failed to record run for %q: %v", jobID, err)
+	}
+}
+
+// Close releases the scheduler's database connection. Stop should be
+// called first so no claimDue/runOne call is still using it.
+func (s *JobScheduler) Close() error {
+	return s.db.Close()
+}
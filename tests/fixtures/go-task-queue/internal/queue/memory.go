@@ -3,6 +3,9 @@ package queue
 import (
 	"container/heap"
 	"context"
+	"encoding/json"
+	"hash/fnv"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -12,26 +15,176 @@ import (
 
 // MemoryQueue is a thread-safe in-memory task queue
 type MemoryQueue struct {
-	mu       sync.RWMutex
-	tasks    []*Task
-	taskMap  map[string]*Task
-	closed   atomic.Bool
-	notify   chan struct{}
-	stats    *queueStats
-	maxSize  int
+	mu        sync.RWMutex
+	tasks     []*Task
+	taskMap   map[string]*Task
+	closed    atomic.Bool
+	notify    chan struct{}
+	stats     *queueStats
+	maxSize   int
 	onEnqueue func(*Task)
 	onDequeue func(*Task)
+
+	// scheduled holds tasks whose ScheduledAt is still in the future,
+	// ordered by ScheduledAt so the Forwarder only ever needs to look at
+	// the heap root. Enqueue routes a task here instead of onto tasks when
+	// it isn't due yet; the Forwarder moves it over once it is.
+	scheduled scheduledHeap
+
+	// forwarderInterval is how often the Forwarder goroutine started by
+	// NewMemoryQueue polls scheduled for due tasks; see WithForwarder.
+	forwarderInterval time.Duration
+
+	// completed holds tasks moved out of taskMap by MarkCompleted, keyed by
+	// ID, until the retention janitor purges them once their
+	// RetentionOrDefault() window has elapsed since CompletedAt.
+	completed map[string]*Task
+
+	// uniqueKeys maps a dedup key (see WithUnique) to the time its lock
+	// expires. Enqueue lazily expires an entry it finds stale on read;
+	// StartRetentionJanitor also sweeps it on a timer so keys for tasks
+	// that are never retried again don't linger until the next Enqueue.
+	uniqueKeys map[string]time.Time
+
+	// broadcastMu/broadcastCh implement a broadcast wakeup for AcquireJob:
+	// every enqueue closes the current channel (waking all acquirers) and
+	// installs a fresh one, unlike notify's single-slot wakeup.
+	broadcastMu sync.Mutex
+	broadcastCh chan struct{}
+
+	// archived holds tasks the Recoverer moved out of taskMap after their
+	// lease expired with no retries remaining, keyed by ID, until RunTask
+	// re-queues one or it's otherwise discarded.
+	archived map[string]*Task
+
+	// recovererInterval is how often the Recoverer goroutine polls taskMap
+	// for StateProcessing tasks whose lease has expired; see WithRecoverer.
+	// Zero (the default) disables the Recoverer.
+	recovererInterval time.Duration
+
+	// stopForwarder stops the Forwarder goroutine started by NewMemoryQueue.
+	stopForwarder func()
+
+	// stopRecoverer stops the Recoverer goroutine started by NewMemoryQueue
+	// when WithRecoverer was given. Nil if the Recoverer was never started.
+	stopRecoverer func()
+
+	// events fans out TaskEvents to Subscribe callers, e.g. the API's SSE
+	// endpoints.
+	events *taskEventBus
+}
+
+// DefaultForwarderInterval is how often the Forwarder goroutine polls the
+// scheduled heap for due tasks when WithForwarder isn't given.
+const DefaultForwarderInterval = 50 * time.Millisecond
+
+// scheduledHeap is a container/heap of tasks ordered by ScheduledAt, used
+// to hold tasks that aren't eligible for dequeue yet.
+type scheduledHeap []*Task
+
+func (h scheduledHeap) Len() int { return len(h) }
+func (h scheduledHeap) Less(i, j int) bool {
+	return h[i].ScheduledAt.Before(*h[j].ScheduledAt)
+}
+func (h scheduledHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *scheduledHeap) Push(x interface{}) {
+	*h = append(*h, x.(*Task))
+}
+
+func (h *scheduledHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
+
+// taskEventBufferSize bounds each Subscribe channel. publish drops the
+// oldest buffered event rather than blocking Enqueue/Dequeue/Update on a
+// slow or stalled subscriber.
+const taskEventBufferSize = 32
+
+// taskEventBus fans TaskEvents out to Subscribe callers, each filtered to
+// the events it asked for.
+type taskEventBus struct {
+	mu   sync.Mutex
+	subs map[int]*taskEventSub
+	next int
+}
+
+type taskEventSub struct {
+	filter EventFilter
+	ch     chan TaskEvent
+}
+
+func newTaskEventBus() *taskEventBus {
+	return &taskEventBus{subs: make(map[int]*taskEventSub)}
+}
+
+// subscribe returns a channel receiving future TaskEvents matching filter,
+// and an unsubscribe func the caller must invoke when it stops listening.
+func (b *taskEventBus) subscribe(filter EventFilter) (<-chan TaskEvent, func()) {
+	ch := make(chan TaskEvent, taskEventBufferSize)
+
+	b.mu.Lock()
+	id := b.next
+	b.next++
+	b.subs[id] = &taskEventSub{filter: filter, ch: ch}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, id)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// publish delivers ev to every subscriber whose filter matches it,
+// dropping the oldest buffered event for any subscriber whose channel is
+// full.
+func (b *taskEventBus) publish(ev TaskEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, sub := range b.subs {
+		if !sub.filter.Matches(ev) {
+			continue
+		}
+
+		select {
+		case sub.ch <- ev:
+		default:
+			select {
+			case <-sub.ch:
+			default:
+			}
+			select {
+			case sub.ch <- ev:
+			default:
+			}
+		}
+	}
 }
 
 // queueStats holds internal statistics
 type queueStats struct {
-	totalEnqueued  atomic.Int64
-	totalDequeued  atomic.Int64
-	totalFailed    atomic.Int64
-	waitTimeSum    atomic.Int64
-	waitTimeCount  atomic.Int64
-	processTimeSum atomic.Int64
+	totalEnqueued    atomic.Int64
+	totalDequeued    atomic.Int64
+	totalFailed      atomic.Int64
+	waitTimeSum      atomic.Int64
+	waitTimeCount    atomic.Int64
+	processTimeSum   atomic.Int64
 	processTimeCount atomic.Int64
+	totalRecovered   atomic.Int64
+	totalArchived    atomic.Int64
 }
 
 // MemoryQueueOption is a functional option for MemoryQueue
@@ -58,24 +211,58 @@ func WithDequeueCallback(fn func(*Task)) MemoryQueueOption {
 	}
 }
 
+// WithForwarder overrides how often the Forwarder goroutine started by
+// NewMemoryQueue polls the scheduled heap for due tasks (see
+// DefaultForwarderInterval).
+func WithForwarder(interval time.Duration) MemoryQueueOption {
+	return func(q *MemoryQueue) {
+		q.forwarderInterval = interval
+	}
+}
+
+// WithRecoverer starts a Recoverer goroutine that polls every interval for
+// StateProcessing tasks whose lease (see Task.LeaseExpiresAt) has expired,
+// re-enqueuing them with backoff if retries remain or archiving them
+// otherwise (see recover). Without this option, an abandoned task (e.g. its
+// worker crashed) stays StateProcessing forever.
+func WithRecoverer(interval time.Duration) MemoryQueueOption {
+	return func(q *MemoryQueue) {
+		q.recovererInterval = interval
+	}
+}
+
 // NewMemoryQueue creates a new in-memory queue
 func NewMemoryQueue(opts ...MemoryQueueOption) *MemoryQueue {
 	q := &MemoryQueue{
-		tasks:   make([]*Task, 0),
-		taskMap: make(map[string]*Task),
-		notify:  make(chan struct{}, 1),
-		stats:   &queueStats{},
-		maxSize: 10000, // Default max size
+		tasks:             make([]*Task, 0),
+		taskMap:           make(map[string]*Task),
+		notify:            make(chan struct{}, 1),
+		stats:             &queueStats{},
+		maxSize:           10000, // Default max size
+		broadcastCh:       make(chan struct{}),
+		completed:         make(map[string]*Task),
+		uniqueKeys:        make(map[string]time.Time),
+		archived:          make(map[string]*Task),
+		forwarderInterval: DefaultForwarderInterval,
+		events:            newTaskEventBus(),
 	}
+	heap.Init(&q.scheduled)
 
 	for _, opt := range opts {
 		opt(q)
 	}
 
+	q.stopForwarder = q.startForwarder(q.forwarderInterval)
+	if q.recovererInterval > 0 {
+		q.stopRecoverer = q.startRecoverer(q.recovererInterval)
+	}
+
 	return q
 }
 
-// Enqueue adds a task to the queue
+// Enqueue adds a task to the queue. A task whose ScheduledAt is set to a
+// future time is parked in the scheduled heap instead of the ready list;
+// the Forwarder goroutine moves it over once it becomes due.
 func (q *MemoryQueue) Enqueue(ctx context.Context, task *Task) error {
 	if q.closed.Load() {
 		return ErrQueueClosed
@@ -88,37 +275,155 @@ func (q *MemoryQueue) Enqueue(ctx context.Context, task *Task) error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
-	if q.maxSize > 0 && len(q.tasks) >= q.maxSize {
+	if q.maxSize > 0 && len(q.tasks)+q.scheduled.Len() >= q.maxSize {
 		return ErrQueueFull
 	}
 
-	// Assign ID if not set
-	if task.ID == "" {
+	if task.ID != "" {
+		if _, exists := q.taskMap[task.ID]; exists {
+			return ErrTaskIDConflict
+		}
+	} else {
 		task.ID = uuid.New().String()
 	}
 
+	if task.UniqueTTL > 0 {
+		key := uniqueKeyFor(task)
+		if exp, ok := q.uniqueKeys[key]; ok && time.Now().Before(exp) {
+			return ErrDuplicateTask
+		}
+		q.uniqueKeys[key] = time.Now().Add(task.UniqueTTL)
+		task.UniqueKey = key
+	}
+
 	task.State = StatePending
 	task.CreatedAt = time.Now()
+	task.Revision = 1
 
-	q.tasks = append(q.tasks, task)
 	q.taskMap[task.ID] = task
 	q.stats.totalEnqueued.Add(1)
 
-	// Notify waiting consumers
-	select {
-	case q.notify <- struct{}{}:
-	default:
+	if task.ScheduledAt != nil && task.ScheduledAt.After(time.Now()) {
+		heap.Push(&q.scheduled, task)
+	} else {
+		q.tasks = append(q.tasks, task)
+
+		// Notify waiting consumers
+		select {
+		case q.notify <- struct{}{}:
+		default:
+		}
+		q.wakeAcquirers()
 	}
 
 	if q.onEnqueue != nil {
 		go q.onEnqueue(task)
 	}
 
+	q.events.publish(TaskEvent{
+		TaskID:    task.ID,
+		TaskType:  task.Type,
+		State:     task.State,
+		Revision:  task.Revision,
+		Timestamp: time.Now(),
+		TenantID:  task.TenantID,
+	})
+
 	return nil
 }
 
-// Dequeue removes and returns the next task
-func (q *MemoryQueue) Dequeue(ctx context.Context) (*Task, error) {
+// forward moves tasks out of the scheduled heap whose ScheduledAt is due as
+// of now into the ready list, returning the number moved.
+func (q *MemoryQueue) forward(now time.Time) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	moved := 0
+	for q.scheduled.Len() > 0 && !q.scheduled[0].ScheduledAt.After(now) {
+		task := heap.Pop(&q.scheduled).(*Task)
+		q.tasks = append(q.tasks, task)
+		moved++
+	}
+
+	if moved > 0 {
+		select {
+		case q.notify <- struct{}{}:
+		default:
+		}
+		q.wakeAcquirers()
+	}
+
+	return moved
+}
+
+// startForwarder starts a goroutine that calls forward every interval,
+// until the returned stop func is called.
+func (q *MemoryQueue) startForwarder(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				q.forward(now)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// wakeAcquirers broadcasts to every goroutine blocked in AcquireJob.
+func (q *MemoryQueue) wakeAcquirers() {
+	q.broadcastMu.Lock()
+	close(q.broadcastCh)
+	q.broadcastCh = make(chan struct{})
+	q.broadcastMu.Unlock()
+}
+
+// acquireWaitChan returns the current broadcast channel to wait on.
+func (q *MemoryQueue) acquireWaitChan() chan struct{} {
+	q.broadcastMu.Lock()
+	defer q.broadcastMu.Unlock()
+	return q.broadcastCh
+}
+
+// AcquireJob blocks (up to ctx's deadline) for the next pending task whose
+// Type is in tags, or any task if tags is empty. It wakes immediately on
+// enqueue via the broadcast channel, falling back to a poll ticker in case
+// a wakeup is missed between the check and the wait.
+func (q *MemoryQueue) AcquireJob(ctx context.Context, tags []string) (*Task, error) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		task, err := q.dequeueMatching(tags)
+		if err == nil {
+			return task, nil
+		}
+		if err != ErrQueueEmpty {
+			return nil, err
+		}
+
+		wait := q.acquireWaitChan()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-wait:
+		case <-ticker.C:
+		}
+	}
+}
+
+// dequeueMatching claims the first pending task whose Type is in tags.
+func (q *MemoryQueue) dequeueMatching(tags []string) (*Task, error) {
 	if q.closed.Load() {
 		return nil, ErrQueueClosed
 	}
@@ -126,28 +431,88 @@ func (q *MemoryQueue) Dequeue(ctx context.Context) (*Task, error) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
-	if len(q.tasks) == 0 {
-		return nil, ErrQueueEmpty
+	for i, t := range q.tasks {
+		if len(tags) > 0 && !taskTypeInTags(t.Type, tags) {
+			continue
+		}
+
+		q.tasks = append(q.tasks[:i], q.tasks[i+1:]...)
+
+		claimedAt := time.Now()
+		t.State = StateProcessing
+		t.StartedAt = &claimedAt
+		leaseExp := claimedAt.Add(t.LeaseDurationOrDefault())
+		t.LeaseExpiresAt = &leaseExp
+		t.Revision++
+
+		waitTime := claimedAt.Sub(t.CreatedAt)
+		q.stats.waitTimeSum.Add(waitTime.Nanoseconds())
+		q.stats.waitTimeCount.Add(1)
+		q.stats.totalDequeued.Add(1)
+
+		if q.onDequeue != nil {
+			go q.onDequeue(t)
+		}
+
+		q.events.publish(TaskEvent{
+			TaskID:    t.ID,
+			TaskType:  t.Type,
+			State:     t.State,
+			Revision:  t.Revision,
+			Timestamp: claimedAt,
+			TenantID:  t.TenantID,
+		})
+
+		// Return a copy: t remains the canonical record in q.taskMap, and
+		// the claiming caller's copy only overwrites it via a CAS'd Update,
+		// instead of the two silently sharing one struct.
+		claimed := *t
+		return &claimed, nil
 	}
 
-	task := q.tasks[0]
-	q.tasks = q.tasks[1:]
+	return nil, ErrQueueEmpty
+}
 
-	now := time.Now()
-	task.State = StateProcessing
-	task.StartedAt = &now
+// uniqueKeyFor returns task's dedup key: task.UniqueKey if set, otherwise
+// task.Type plus an FNV-1a hash of its JSON-encoded Payload.
+func uniqueKeyFor(task *Task) string {
+	if task.UniqueKey != "" {
+		return task.UniqueKey
+	}
+	h := fnv.New64a()
+	if payload, err := json.Marshal(task.Payload); err == nil {
+		h.Write(payload)
+	}
+	return task.Type + ":" + strconv.FormatUint(h.Sum64(), 16)
+}
+
+func taskTypeInTags(taskType string, tags []string) bool {
+	for _, tag := range tags {
+		if tag == taskType {
+			return true
+		}
+	}
+	return false
+}
 
-	// Track wait time
-	waitTime := now.Sub(task.CreatedAt)
-	q.stats.waitTimeSum.Add(waitTime.Nanoseconds())
-	q.stats.waitTimeCount.Add(1)
-	q.stats.totalDequeued.Add(1)
+// Dequeue removes and returns the next eligible task (skipping any whose
+// ScheduledAt is still in the future).
+func (q *MemoryQueue) Dequeue(ctx context.Context) (*Task, error) {
+	return q.dequeueMatching(nil)
+}
 
-	if q.onDequeue != nil {
-		go q.onDequeue(task)
+// EnqueueAt enqueues task so it's only dequeued at or after runAt.
+func (q *MemoryQueue) EnqueueAt(ctx context.Context, task *Task, runAt time.Time) error {
+	if task == nil {
+		return ErrInvalidTask
 	}
+	task.ScheduledAt = &runAt
+	return q.Enqueue(ctx, task)
+}
 
-	return task, nil
+// EnqueueAfter enqueues task so it's only dequeued after delay elapses.
+func (q *MemoryQueue) EnqueueAfter(ctx context.Context, task *Task, delay time.Duration) error {
+	return q.EnqueueAt(ctx, task, time.Now().Add(delay))
 }
 
 // DequeueBlocking blocks until a task is available
@@ -209,7 +574,8 @@ func (q *MemoryQueue) Peek(ctx context.Context) (*Task, error) {
 		return nil, ErrQueueEmpty
 	}
 
-	return q.tasks[0], nil
+	peeked := *q.tasks[0]
+	return &peeked, nil
 }
 
 // Get retrieves a task by ID
@@ -222,10 +588,13 @@ func (q *MemoryQueue) Get(ctx context.Context, id string) (*Task, error) {
 		return nil, ErrTaskNotFound
 	}
 
-	return task, nil
+	got := *task
+	return &got, nil
 }
 
-// Update updates a task
+// Update updates a task, failing with ErrRevisionConflict if task.Revision
+// is non-zero and doesn't match the stored task's current revision -- see
+// Task.Revision.
 func (q *MemoryQueue) Update(ctx context.Context, task *Task) error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
@@ -235,16 +604,68 @@ func (q *MemoryQueue) Update(ctx context.Context, task *Task) error {
 		return ErrTaskNotFound
 	}
 
+	if task.Revision != 0 && task.Revision != existing.Revision {
+		return ErrRevisionConflict
+	}
+
+	wasPending := existing.State == StatePending
+
 	// Update fields
 	existing.State = task.State
 	existing.Error = task.Error
 	existing.Result = task.Result
 	existing.Retries = task.Retries
+	existing.StartedAt = task.StartedAt
 	existing.CompletedAt = task.CompletedAt
+	existing.Revision++
+	task.Revision = existing.Revision
+
+	// A transition back into StatePending -- Retry resetting a failed task
+	// -- needs existing back on the ready list dequeueMatching scans;
+	// Update otherwise only touches taskMap, which Get/Peek read from but
+	// dequeueMatching doesn't.
+	if existing.State == StatePending && !wasPending {
+		existing.CreatedAt = time.Now()
+		q.tasks = append(q.tasks, existing)
+
+		select {
+		case q.notify <- struct{}{}:
+		default:
+		}
+		q.wakeAcquirers()
+	}
+
+	q.events.publish(TaskEvent{
+		TaskID:    existing.ID,
+		TaskType:  existing.Type,
+		State:     existing.State,
+		Error:     existing.Error,
+		Revision:  existing.Revision,
+		Timestamp: time.Now(),
+		TenantID:  existing.TenantID,
+	})
 
 	return nil
 }
 
+// Subscribe returns a channel of TaskEvents matching filter, closed once
+// ctx is done.
+func (q *MemoryQueue) Subscribe(ctx context.Context, filter EventFilter) (<-chan TaskEvent, error) {
+	if q.closed.Load() {
+		return nil, ErrQueueClosed
+	}
+
+	ch, unsubscribe := q.events.subscribe(filter)
+	go func() {
+		<-ctx.Done()
+		unsubscribe()
+	}()
+
+	return ch, nil
+}
+
+var _ SubscribableQueue = (*MemoryQueue)(nil)
+
 // Delete removes a task
 func (q *MemoryQueue) Delete(ctx context.Context, id string) error {
 	q.mu.Lock()
@@ -256,10 +677,18 @@ func (q *MemoryQueue) Delete(ctx context.Context, id string) error {
 
 	delete(q.taskMap, id)
 
-	// Remove from slice
+	// Remove from the ready list if present.
 	for i, t := range q.tasks {
 		if t.ID == id {
 			q.tasks = append(q.tasks[:i], q.tasks[i+1:]...)
+			return nil
+		}
+	}
+
+	// Otherwise it may be parked in the scheduled heap.
+	for i, t := range q.scheduled {
+		if t.ID == id {
+			heap.Remove(&q.scheduled, i)
 			break
 		}
 	}
@@ -277,6 +706,12 @@ func (q *MemoryQueue) Len() int {
 // Close closes the queue
 func (q *MemoryQueue) Close() error {
 	q.closed.Store(true)
+	if q.stopForwarder != nil {
+		q.stopForwarder()
+	}
+	if q.stopRecoverer != nil {
+		q.stopRecoverer()
+	}
 	close(q.notify)
 	return nil
 }
@@ -302,6 +737,8 @@ func (q *MemoryQueue) Stats() QueueStats {
 		CurrentSize:    q.Len(),
 		AvgWaitTime:    avgWait,
 		AvgProcessTime: avgProcess,
+		TotalRecovered: q.stats.totalRecovered.Load(),
+		TotalArchived:  q.stats.totalArchived.Load(),
 	}
 }
 
@@ -314,6 +751,8 @@ func (q *MemoryQueue) ResetStats() {
 	q.stats.waitTimeCount.Store(0)
 	q.stats.processTimeSum.Store(0)
 	q.stats.processTimeCount.Store(0)
+	q.stats.totalRecovered.Store(0)
+	q.stats.totalArchived.Store(0)
 }
 
 // MarkFailed marks a task as failed
@@ -330,26 +769,34 @@ func (q *MemoryQueue) MarkFailed(ctx context.Context, id string, errMsg string)
 	task.Error = errMsg
 	now := time.Now()
 	task.CompletedAt = &now
+	task.LeaseExpiresAt = nil
 
 	q.stats.totalFailed.Add(1)
+	if task.UniqueKey != "" {
+		delete(q.uniqueKeys, task.UniqueKey)
+	}
 
 	return nil
 }
 
-// MarkCompleted marks a task as completed
-func (q *MemoryQueue) MarkCompleted(ctx context.Context, id string, result interface{}) error {
+// MarkCompleted marks a task as completed, moves it from taskMap into the
+// completed-task retention bucket (queryable via GetCompleted until its
+// RetentionOrDefault() window elapses), and returns a ResultWriter a worker
+// can use to append further result bytes.
+func (q *MemoryQueue) MarkCompleted(ctx context.Context, id string, result interface{}) (*ResultWriter, error) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
 	task, ok := q.taskMap[id]
 	if !ok {
-		return ErrTaskNotFound
+		return nil, ErrTaskNotFound
 	}
 
 	now := time.Now()
 	task.State = StateCompleted
 	task.Result = result
 	task.CompletedAt = &now
+	task.LeaseExpiresAt = nil
 
 	// Track process time
 	if task.StartedAt != nil {
@@ -358,10 +805,259 @@ func (q *MemoryQueue) MarkCompleted(ctx context.Context, id string, result inter
 		q.stats.processTimeCount.Add(1)
 	}
 
+	delete(q.taskMap, id)
+	q.completed[id] = task
+	if task.UniqueKey != "" {
+		delete(q.uniqueKeys, task.UniqueKey)
+	}
+
+	return task.ResultWriter(), nil
+}
+
+// GetCompleted retrieves a task from the completed-task retention bucket by
+// ID. It returns ErrTaskNotFound once the task's retention window has
+// elapsed and the janitor has purged it (or if it was never completed).
+func (q *MemoryQueue) GetCompleted(ctx context.Context, id string) (*Task, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	task, ok := q.completed[id]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+
+	return task, nil
+}
+
+// purgeExpiredCompleted removes completed tasks whose retention window has
+// elapsed as of now, returning the number purged.
+func (q *MemoryQueue) purgeExpiredCompleted(now time.Time) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	purged := 0
+	for id, task := range q.completed {
+		if task.CompletedAt == nil {
+			continue
+		}
+		if now.After(task.CompletedAt.Add(task.RetentionOrDefault())) {
+			delete(q.completed, id)
+			purged++
+		}
+	}
+	return purged
+}
+
+// purgeExpiredUniqueKeys removes unique-key locks that expired as of now,
+// returning the number purged. Enqueue also lazily expires a stale key it
+// happens to read, so this just catches keys no later Enqueue ever revisits.
+func (q *MemoryQueue) purgeExpiredUniqueKeys(now time.Time) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	purged := 0
+	for key, exp := range q.uniqueKeys {
+		if now.After(exp) {
+			delete(q.uniqueKeys, key)
+			purged++
+		}
+	}
+	return purged
+}
+
+// StartRetentionJanitor starts a goroutine that purges expired completed
+// tasks and expired unique-key locks every interval, until the returned
+// stop func is called.
+func (q *MemoryQueue) StartRetentionJanitor(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				q.purgeExpiredCompleted(now)
+				q.purgeExpiredUniqueKeys(now)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// backoffBase and backoffMax bound the exponential backoff Retry applies
+// between attempts.
+const (
+	backoffBase = 100 * time.Millisecond
+	backoffMax  = 30 * time.Second
+)
+
+// backoffFor returns the backoff delay before retry attempt n (1-indexed),
+// doubling from backoffBase and capped at backoffMax.
+func backoffFor(attempt int) time.Duration {
+	d := backoffBase << uint(attempt-1)
+	if d <= 0 || d > backoffMax {
+		return backoffMax
+	}
+	return d
+}
+
+// Heartbeat extends a claimed task's lease by LeaseDurationOrDefault() from
+// now, so a Recoverer doesn't mistake a worker still actively processing it
+// for a crashed one. It returns ErrTaskNotProcessing if the task isn't
+// currently StateProcessing.
+func (q *MemoryQueue) Heartbeat(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	task, ok := q.taskMap[id]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	if task.State != StateProcessing {
+		return ErrTaskNotProcessing
+	}
+
+	exp := time.Now().Add(task.LeaseDurationOrDefault())
+	task.LeaseExpiresAt = &exp
+
+	return nil
+}
+
+// recover scans taskMap for StateProcessing tasks whose lease has expired
+// as of now, re-queuing each with backoff if it has retries remaining (the
+// same path Retry takes) or moving it to the archived set otherwise. It
+// returns the number recovered and the number archived.
+func (q *MemoryQueue) recover(now time.Time) (recovered, archived int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for id, task := range q.taskMap {
+		if task.State != StateProcessing || task.LeaseExpiresAt == nil || !now.After(*task.LeaseExpiresAt) {
+			continue
+		}
+
+		if task.Retries < task.MaxRetries {
+			task.State = StateRetrying
+			task.Retries++
+			task.Error = "lease expired: worker presumed crashed"
+			task.StartedAt = nil
+			task.LeaseExpiresAt = nil
+
+			runAt := now.Add(backoffFor(task.Retries))
+			task.ScheduledAt = &runAt
+			heap.Push(&q.scheduled, task)
+
+			recovered++
+			continue
+		}
+
+		task.State = StateFailed
+		task.Error = "lease expired: worker presumed crashed, retries exhausted"
+		task.LeaseExpiresAt = nil
+		task.CompletedAt = &now
+
+		delete(q.taskMap, id)
+		q.archived[id] = task
+		if task.UniqueKey != "" {
+			delete(q.uniqueKeys, task.UniqueKey)
+		}
+
+		archived++
+	}
+
+	if recovered > 0 {
+		select {
+		case q.notify <- struct{}{}:
+		default:
+		}
+		q.wakeAcquirers()
+	}
+
+	return recovered, archived
+}
+
+// startRecoverer starts the Recoverer goroutine that calls recover every
+// interval, until the returned stop func is called. See WithRecoverer.
+func (q *MemoryQueue) startRecoverer(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				recovered, archived := q.recover(now)
+				q.stats.totalRecovered.Add(int64(recovered))
+				q.stats.totalArchived.Add(int64(archived))
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// ListArchived returns every task the Recoverer has moved to the archived
+// set after its lease expired with no retries remaining.
+func (q *MemoryQueue) ListArchived(ctx context.Context) ([]*Task, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	tasks := make([]*Task, 0, len(q.archived))
+	for _, task := range q.archived {
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// RunTask manually re-queues an archived task: it's moved back into
+// taskMap and the ready list as a fresh StatePending task with Retries
+// reset to 0, bypassing the backoff delay Retry would otherwise apply.
+func (q *MemoryQueue) RunTask(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	task, ok := q.archived[id]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	delete(q.archived, id)
+
+	task.State = StatePending
+	task.Error = ""
+	task.Retries = 0
+	task.StartedAt = nil
+	task.CompletedAt = nil
+	task.LeaseExpiresAt = nil
+
+	q.taskMap[id] = task
+	q.tasks = append(q.tasks, task)
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	q.wakeAcquirers()
+
 	return nil
 }
 
-// Retry re-queues a failed task
+// Retry re-queues a failed task after an exponential backoff delay, by
+// parking it in the scheduled heap with ScheduledAt set to now plus
+// backoffFor(task.Retries); the Forwarder moves it into the ready list once
+// the delay elapses.
 func (q *MemoryQueue) Retry(ctx context.Context, id string) error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
@@ -380,14 +1076,17 @@ func (q *MemoryQueue) Retry(ctx context.Context, id string) error {
 	task.Error = ""
 	task.StartedAt = nil
 
-	// Move to end of queue
+	// Remove from the ready list if it's still there.
 	for i, t := range q.tasks {
 		if t.ID == id {
 			q.tasks = append(q.tasks[:i], q.tasks[i+1:]...)
 			break
 		}
 	}
-	q.tasks = append(q.tasks, task)
+
+	runAt := time.Now().Add(backoffFor(task.Retries))
+	task.ScheduledAt = &runAt
+	heap.Push(&q.scheduled, task)
 
 	return nil
 }
@@ -420,6 +1119,26 @@ type PriorityMemoryQueue struct {
 	taskMap map[string]*priorityItem
 	closed  atomic.Bool
 	notify  chan struct{}
+
+	// scheduled holds tasks whose ScheduledAt is still in the future,
+	// ordered by ScheduledAt. The corresponding *priorityItem stays in
+	// taskMap with index == -1 (the same "parked" sentinel DequeueByPriority
+	// uses) so Get/Update/Delete still work on it; the Forwarder moves it
+	// into items once it becomes due.
+	scheduled scheduledHeap
+
+	// forwarderInterval is how often the Forwarder goroutine started by
+	// NewPriorityMemoryQueue polls scheduled for due tasks; see
+	// WithPriorityForwarder.
+	forwarderInterval time.Duration
+
+	// stopForwarder stops the Forwarder goroutine started by
+	// NewPriorityMemoryQueue.
+	stopForwarder func()
+
+	// completed mirrors MemoryQueue's completed-task retention bucket (see
+	// MemoryQueue.MarkCompleted).
+	completed map[string]*Task
 }
 
 type priorityHeap []*priorityItem
@@ -449,14 +1168,36 @@ func (h *priorityHeap) Pop() interface{} {
 	return item
 }
 
+// PriorityMemoryQueueOption is a functional option for PriorityMemoryQueue.
+type PriorityMemoryQueueOption func(*PriorityMemoryQueue)
+
+// WithPriorityForwarder overrides how often the Forwarder goroutine started
+// by NewPriorityMemoryQueue polls the scheduled heap for due tasks (see
+// DefaultForwarderInterval).
+func WithPriorityForwarder(interval time.Duration) PriorityMemoryQueueOption {
+	return func(pq *PriorityMemoryQueue) {
+		pq.forwarderInterval = interval
+	}
+}
+
 // NewPriorityMemoryQueue creates a priority queue
-func NewPriorityMemoryQueue() *PriorityMemoryQueue {
+func NewPriorityMemoryQueue(opts ...PriorityMemoryQueueOption) *PriorityMemoryQueue {
 	pq := &PriorityMemoryQueue{
-		items:   make(priorityHeap, 0),
-		taskMap: make(map[string]*priorityItem),
-		notify:  make(chan struct{}, 1),
+		items:             make(priorityHeap, 0),
+		taskMap:           make(map[string]*priorityItem),
+		notify:            make(chan struct{}, 1),
+		completed:         make(map[string]*Task),
+		forwarderInterval: DefaultForwarderInterval,
 	}
 	heap.Init(&pq.items)
+	heap.Init(&pq.scheduled)
+
+	for _, opt := range opts {
+		opt(pq)
+	}
+
+	pq.stopForwarder = pq.startForwarder(pq.forwarderInterval)
+
 	return pq
 }
 
@@ -465,7 +1206,10 @@ func (pq *PriorityMemoryQueue) Enqueue(ctx context.Context, task *Task) error {
 	return pq.EnqueueWithPriority(ctx, task, task.Priority)
 }
 
-// EnqueueWithPriority adds a task with specific priority
+// EnqueueWithPriority adds a task with specific priority. A task whose
+// ScheduledAt is set to a future time is parked in the scheduled heap
+// instead of items; the Forwarder goroutine moves it over once it becomes
+// due.
 func (pq *PriorityMemoryQueue) EnqueueWithPriority(ctx context.Context, task *Task, priority Priority) error {
 	if pq.closed.Load() {
 		return ErrQueueClosed
@@ -485,14 +1229,21 @@ func (pq *PriorityMemoryQueue) EnqueueWithPriority(ctx context.Context, task *Ta
 	task.State = StatePending
 	task.Priority = priority
 	task.CreatedAt = time.Now()
+	task.Revision = 1
 
 	item := &priorityItem{
 		task:     task,
 		priority: priority,
 	}
+	pq.taskMap[task.ID] = item
+
+	if task.ScheduledAt != nil && task.ScheduledAt.After(time.Now()) {
+		item.index = -1
+		heap.Push(&pq.scheduled, task)
+		return nil
+	}
 
 	heap.Push(&pq.items, item)
-	pq.taskMap[task.ID] = item
 
 	select {
 	case pq.notify <- struct{}{}:
@@ -502,6 +1253,58 @@ func (pq *PriorityMemoryQueue) EnqueueWithPriority(ctx context.Context, task *Ta
 	return nil
 }
 
+// forward moves tasks out of the scheduled heap whose ScheduledAt is due as
+// of now into items, returning the number moved.
+func (pq *PriorityMemoryQueue) forward(now time.Time) int {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	moved := 0
+	for pq.scheduled.Len() > 0 && !pq.scheduled[0].ScheduledAt.After(now) {
+		task := heap.Pop(&pq.scheduled).(*Task)
+		item, ok := pq.taskMap[task.ID]
+		if !ok {
+			// Deleted while parked; nothing to forward.
+			continue
+		}
+		heap.Push(&pq.items, item)
+		moved++
+	}
+
+	if moved > 0 {
+		select {
+		case pq.notify <- struct{}{}:
+		default:
+		}
+	}
+
+	return moved
+}
+
+// startForwarder starts a goroutine that calls forward every interval,
+// until the returned stop func is called.
+func (pq *PriorityMemoryQueue) startForwarder(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				pq.forward(now)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
 // Dequeue removes the next task (not priority based)
 func (pq *PriorityMemoryQueue) Dequeue(ctx context.Context) (*Task, error) {
 	return pq.DequeueByPriority(ctx)
@@ -521,11 +1324,13 @@ func (pq *PriorityMemoryQueue) DequeueByPriority(ctx context.Context) (*Task, er
 	}
 
 	item := heap.Pop(&pq.items).(*priorityItem)
-	delete(pq.taskMap, item.task.ID)
+	// item stays in taskMap (with item.index == -1, set by Pop) so
+	// MarkCompleted can still look the task up by ID while it's processing.
 
 	now := time.Now()
 	item.task.State = StateProcessing
 	item.task.StartedAt = &now
+	item.task.Revision++
 
 	return item.task, nil
 }
@@ -555,7 +1360,9 @@ func (pq *PriorityMemoryQueue) Get(ctx context.Context, id string) (*Task, error
 	return item.task, nil
 }
 
-// Update updates a task
+// Update updates a task, failing with ErrRevisionConflict if task.Revision
+// is non-zero and doesn't match the stored task's current revision -- see
+// Task.Revision.
 func (pq *PriorityMemoryQueue) Update(ctx context.Context, task *Task) error {
 	pq.mu.Lock()
 	defer pq.mu.Unlock()
@@ -565,12 +1372,19 @@ func (pq *PriorityMemoryQueue) Update(ctx context.Context, task *Task) error {
 		return ErrTaskNotFound
 	}
 
+	if task.Revision != 0 && task.Revision != item.task.Revision {
+		return ErrRevisionConflict
+	}
+
 	item.task.State = task.State
 	item.task.Error = task.Error
 	item.task.Result = task.Result
+	item.task.Revision++
+	task.Revision = item.task.Revision
 
-	// Update priority if changed
-	if item.priority != task.Priority {
+	// Update priority if changed, only while still queued (item.index is
+	// -1 once DequeueByPriority has popped it off the heap)
+	if item.index >= 0 && item.priority != task.Priority {
 		item.priority = task.Priority
 		heap.Fix(&pq.items, item.index)
 	}
@@ -588,12 +1402,97 @@ func (pq *PriorityMemoryQueue) Delete(ctx context.Context, id string) error {
 		return ErrTaskNotFound
 	}
 
-	heap.Remove(&pq.items, item.index)
+	if item.index >= 0 {
+		heap.Remove(&pq.items, item.index)
+	}
 	delete(pq.taskMap, id)
 
+	// If it was parked in the scheduled heap, leave the stale *Task entry
+	// there; forward's taskMap lookup will no longer find it and skip it.
+
 	return nil
 }
 
+// MarkCompleted marks a task as completed, moves it from taskMap into the
+// completed-task retention bucket, and returns a ResultWriter for
+// incremental result appends. See MemoryQueue.MarkCompleted.
+func (pq *PriorityMemoryQueue) MarkCompleted(ctx context.Context, id string, result interface{}) (*ResultWriter, error) {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	item, ok := pq.taskMap[id]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+
+	now := time.Now()
+	item.task.State = StateCompleted
+	item.task.Result = result
+	item.task.CompletedAt = &now
+
+	delete(pq.taskMap, id)
+	pq.completed[id] = item.task
+
+	return item.task.ResultWriter(), nil
+}
+
+// GetCompleted retrieves a task from the completed-task retention bucket by
+// ID. See MemoryQueue.GetCompleted.
+func (pq *PriorityMemoryQueue) GetCompleted(ctx context.Context, id string) (*Task, error) {
+	pq.mu.RLock()
+	defer pq.mu.RUnlock()
+
+	task, ok := pq.completed[id]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+
+	return task, nil
+}
+
+// purgeExpiredCompleted removes completed tasks whose retention window has
+// elapsed as of now, returning the number purged.
+func (pq *PriorityMemoryQueue) purgeExpiredCompleted(now time.Time) int {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	purged := 0
+	for id, task := range pq.completed {
+		if task.CompletedAt == nil {
+			continue
+		}
+		if now.After(task.CompletedAt.Add(task.RetentionOrDefault())) {
+			delete(pq.completed, id)
+			purged++
+		}
+	}
+	return purged
+}
+
+// StartRetentionJanitor starts a goroutine that purges expired completed
+// tasks every interval, until the returned stop func is called.
+func (pq *PriorityMemoryQueue) StartRetentionJanitor(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				pq.purgeExpiredCompleted(now)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
 // Len returns the queue length
 func (pq *PriorityMemoryQueue) Len() int {
 	pq.mu.RLock()
@@ -604,6 +1503,9 @@ func (pq *PriorityMemoryQueue) Len() int {
 // Close closes the queue
 func (pq *PriorityMemoryQueue) Close() error {
 	pq.closed.Store(true)
+	if pq.stopForwarder != nil {
+		pq.stopForwarder()
+	}
 	close(pq.notify)
 	return nil
 }
@@ -0,0 +1,71 @@
+package queue
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Open parses rawURL's scheme and returns a ready-to-use Queue backed by
+// the matching driver:
+//
+//   - memory:// (or no scheme at all) an in-process MemoryQueue; a
+//     maxSize query param maps to WithMaxSize.
+//   - sqlite://path/to/db.sqlite a durable SQLiteQueue; path is taken
+//     verbatim from the URL (opaque or host+path), see NewSQLiteQueue.
+//   - postgres://... or postgresql://... a durable PostgresQueue, using
+//     rawURL itself as the connection DSN, see NewPostgresQueue.
+//
+// Any other scheme is looked up in the driver registry (RegisterDriver),
+// so a package that registers itself -- by being imported, blank or
+// otherwise -- extends Open without package queue needing to know about
+// it.
+//
+// redis:// is not, and can't be, registered this way: queue/redisq's
+// Queue.Dequeue returns an explicit AckFunc for its at-least-once
+// delivery model, so it doesn't implement Queue at all, not just
+// separately from the import-cycle problem (redisq already imports
+// queue, so queue importing it back would cycle). Wrapping it behind
+// Queue's plain Dequeue would mean auto-acking somewhere Open can't see,
+// silently discarding the guarantee redisq exists for. Callers that need
+// Redis construct a redisq.Queue directly instead of calling Open; see
+// cmd/worker/main.go. redisq.ClientFor still gives those callers the
+// shared-connection-pool behavior Open gives every other scheme.
+func Open(rawURL string) (Queue, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse queue URL %q: %w", rawURL, err)
+	}
+
+	switch u.Scheme {
+	case "", "memory":
+		var opts []MemoryQueueOption
+		if s := u.Query().Get("maxSize"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid maxSize %q: %w", s, err)
+			}
+			opts = append(opts, WithMaxSize(n))
+		}
+		return NewMemoryQueue(opts...), nil
+
+	case "sqlite":
+		path := u.Opaque
+		if path == "" {
+			path = u.Host + u.Path
+		}
+		if path == "" {
+			return nil, fmt.Errorf("sqlite queue URL %q has no path", rawURL)
+		}
+		return NewSQLiteQueue(DefaultSQLiteQueueConfig(path))
+
+	case "postgres", "postgresql":
+		return NewPostgresQueue(DefaultPostgresQueueConfig(rawURL))
+
+	default:
+		if open, ok := driverFor(u.Scheme); ok {
+			return open(rawURL)
+		}
+		return nil, fmt.Errorf("unsupported queue URL scheme %q", u.Scheme)
+	}
+}
@@ -0,0 +1,457 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PostgresQueueConfig configures a PostgresQueue.
+type PostgresQueueConfig struct {
+	DSN               string
+	Channel           string
+	VisibilityTimeout time.Duration
+	ReapInterval      time.Duration
+}
+
+// DefaultPostgresQueueConfig returns sane defaults for a PostgresQueue
+// connecting to dsn.
+func DefaultPostgresQueueConfig(dsn string) PostgresQueueConfig {
+	return PostgresQueueConfig{
+		DSN:               dsn,
+		Channel:           "task_queue_ready",
+		VisibilityTimeout: 30 * time.Second,
+		ReapInterval:      10 * time.Second,
+	}
+}
+
+// PostgresQueue is a durable, at-least-once Queue backed by PostgreSQL.
+// Dequeue claims the next pending row with SELECT ... FOR UPDATE SKIP
+// LOCKED, so concurrent workers never block on or double-claim each
+// other's candidate row the way a plain row lock would. Enqueue issues a
+// NOTIFY on Config.Channel so DequeueBlocking wakes up immediately instead
+// of waiting out its poll interval; the poll loop itself stays in place as
+// a fallback for notifications missed while the listener connection is
+// reconnecting, same as SQLiteQueue's reaper is the fallback for a missed
+// ack.
+type PostgresQueue struct {
+	db       *sql.DB
+	cfg      PostgresQueueConfig
+	listener *pq.Listener
+
+	mu     sync.Mutex
+	closed bool
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewPostgresQueue opens (migrating if necessary) a PostgreSQL-backed
+// queue, starts its LISTEN connection and its background reaper.
+func NewPostgresQueue(cfg PostgresQueueConfig) (*PostgresQueue, error) {
+	db, err := sql.Open("postgres", cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open queue database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to queue database: %w", err)
+	}
+
+	q := &PostgresQueue{
+		db:     db,
+		cfg:    cfg,
+		stopCh: make(chan struct{}),
+	}
+
+	if err := q.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// minReconnectInterval/maxReconnectInterval give the listener its own
+	// exponential backoff if the connection drops; reportProblem just logs,
+	// since a dropped listener degrades DequeueBlocking to its poll
+	// interval rather than losing anything.
+	q.listener = pq.NewListener(cfg.DSN, 5*time.Second, time.Minute, q.reportListenerProblem)
+	if err := q.listener.Listen(cfg.Channel); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to listen on channel %q: %w", cfg.Channel, err)
+	}
+
+	q.wg.Add(1)
+	go q.reapLoop()
+
+	return q, nil
+}
+
+func (q *PostgresQueue) reportListenerProblem(ev pq.ListenerEventType, err error) {
+	if err != nil {
+		log.Printf("postgres queue: listener connection problem: %v", err)
+	}
+}
+
+func (q *PostgresQueue) migrate() error {
+	_, err := q.db.Exec(`
+		CREATE TABLE IF NOT EXISTS tasks (
+			id              TEXT PRIMARY KEY,
+			type            TEXT NOT NULL,
+			payload         TEXT NOT NULL,
+			priority        INTEGER NOT NULL,
+			state           TEXT NOT NULL,
+			created_at      TIMESTAMPTZ NOT NULL,
+			started_at      TIMESTAMPTZ,
+			completed_at    TIMESTAMPTZ,
+			next_visible_at TIMESTAMPTZ NOT NULL,
+			retries         INTEGER NOT NULL DEFAULT 0,
+			max_retries     INTEGER NOT NULL DEFAULT 0,
+			error           TEXT,
+			result          TEXT,
+			metadata        TEXT,
+			revision        BIGINT NOT NULL DEFAULT 1
+		);
+		CREATE INDEX IF NOT EXISTS idx_tasks_claim
+			ON tasks(state, priority DESC, next_visible_at);
+	`)
+	return err
+}
+
+// Enqueue adds a task to the queue in the pending state and notifies any
+// blocked DequeueBlocking callers via Config.Channel.
+func (q *PostgresQueue) Enqueue(ctx context.Context, task *Task) error {
+	if task == nil {
+		return ErrInvalidTask
+	}
+	if task.ID == "" {
+		task.ID = uuid.New().String()
+	}
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now()
+	}
+	task.State = StatePending
+
+	// A scheduled task simply starts with its visibility deadline in the
+	// future; the existing claim query's next_visible_at filter then
+	// naturally excludes it until due.
+	nextVisible := task.CreatedAt
+	if task.ScheduledAt != nil && task.ScheduledAt.After(nextVisible) {
+		nextVisible = *task.ScheduledAt
+	}
+
+	payload, err := json.Marshal(task.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	metadata, err := json.Marshal(task.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	task.Revision = 1
+
+	_, err = q.db.ExecContext(ctx, `
+		INSERT INTO tasks (id, type, payload, priority, state, created_at, next_visible_at, retries, max_retries, metadata, revision)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, task.ID, task.Type, string(payload), int(task.Priority), string(task.State), task.CreatedAt, nextVisible, task.Retries, task.MaxRetries, string(metadata), task.Revision)
+	if err != nil {
+		return err
+	}
+
+	if _, err := q.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, q.cfg.Channel, task.ID); err != nil {
+		// Best-effort: a missed notification only costs DequeueBlocking's
+		// poll interval, not the task itself.
+		log.Printf("postgres queue: notify failed: %v", err)
+	}
+
+	return nil
+}
+
+// EnqueueAt enqueues task so it's only claimable at or after runAt.
+func (q *PostgresQueue) EnqueueAt(ctx context.Context, task *Task, runAt time.Time) error {
+	if task == nil {
+		return ErrInvalidTask
+	}
+	task.ScheduledAt = &runAt
+	return q.Enqueue(ctx, task)
+}
+
+// EnqueueAfter enqueues task so it's only claimable after delay elapses.
+func (q *PostgresQueue) EnqueueAfter(ctx context.Context, task *Task, delay time.Duration) error {
+	return q.EnqueueAt(ctx, task, time.Now().Add(delay))
+}
+
+// EnqueueWithTimeout enqueues with a timeout.
+func (q *PostgresQueue) EnqueueWithTimeout(ctx context.Context, task *Task, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return q.Enqueue(ctx, task)
+}
+
+// Dequeue claims the next pending task with SELECT ... FOR UPDATE SKIP
+// LOCKED, setting its state to processing and its visibility deadline to
+// now + VisibilityTimeout.
+func (q *PostgresQueue) Dequeue(ctx context.Context) (*Task, error) {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, `
+		SELECT id, type, payload, priority, state, created_at, started_at, completed_at, retries, max_retries, error, result, metadata, revision
+		FROM tasks
+		WHERE state = $1 AND next_visible_at <= $2
+		ORDER BY priority DESC, created_at ASC
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`, string(StatePending), time.Now())
+
+	task, err := scanTask(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrQueueEmpty
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	visibleAt := now.Add(q.cfg.VisibilityTimeout)
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE tasks SET state = $1, started_at = $2, next_visible_at = $3, revision = revision + 1 WHERE id = $4
+	`, string(StateProcessing), now, visibleAt, task.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	task.State = StateProcessing
+	task.StartedAt = &now
+	task.Revision++
+	return task, nil
+}
+
+// DequeueBlocking waits for a claimable task, woken either by a NOTIFY on
+// Config.Channel or by its poll ticker, until one is claimed or ctx is
+// cancelled.
+func (q *PostgresQueue) DequeueBlocking(ctx context.Context) (*Task, error) {
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		task, err := q.Dequeue(ctx)
+		if err == nil {
+			return task, nil
+		}
+		if err != ErrQueueEmpty {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-q.listener.Notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Peek returns the next claimable task without claiming it.
+func (q *PostgresQueue) Peek(ctx context.Context) (*Task, error) {
+	row := q.db.QueryRowContext(ctx, `
+		SELECT id, type, payload, priority, state, created_at, started_at, completed_at, retries, max_retries, error, result, metadata, revision
+		FROM tasks
+		WHERE state = $1 AND next_visible_at <= $2
+		ORDER BY priority DESC, created_at ASC
+		LIMIT 1
+	`, string(StatePending), time.Now())
+
+	task, err := scanTask(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrQueueEmpty
+	}
+	return task, err
+}
+
+// Get retrieves a task by ID regardless of state.
+func (q *PostgresQueue) Get(ctx context.Context, id string) (*Task, error) {
+	row := q.db.QueryRowContext(ctx, `
+		SELECT id, type, payload, priority, state, created_at, started_at, completed_at, retries, max_retries, error, result, metadata, revision
+		FROM tasks WHERE id = $1
+	`, id)
+
+	task, err := scanTask(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrTaskNotFound
+	}
+	return task, err
+}
+
+// Update persists an updated task, failing with ErrRevisionConflict if
+// task.Revision is non-zero and doesn't match the stored row's current
+// revision -- see Task.Revision.
+func (q *PostgresQueue) Update(ctx context.Context, task *Task) error {
+	payload, err := json.Marshal(task.Payload)
+	if err != nil {
+		return err
+	}
+	metadata, err := json.Marshal(task.Metadata)
+	if err != nil {
+		return err
+	}
+	result, err := json.Marshal(task.Result)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE tasks SET type=$1, payload=$2, priority=$3, state=$4, started_at=$5, completed_at=$6, retries=$7, max_retries=$8, error=$9, result=$10, metadata=$11, revision=revision+1
+		WHERE id=$12
+	`
+	args := []interface{}{task.Type, string(payload), int(task.Priority), string(task.State), task.StartedAt, task.CompletedAt,
+		task.Retries, task.MaxRetries, task.Error, string(result), string(metadata), task.ID}
+
+	if task.Revision != 0 {
+		query = `
+			UPDATE tasks SET type=$1, payload=$2, priority=$3, state=$4, started_at=$5, completed_at=$6, retries=$7, max_retries=$8, error=$9, result=$10, metadata=$11, revision=revision+1
+			WHERE id=$12 AND revision=$13
+		`
+		args = append(args, task.Revision)
+	}
+
+	res, err := q.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		if task.Revision != 0 {
+			if _, getErr := q.Get(ctx, task.ID); getErr == nil {
+				return ErrRevisionConflict
+			}
+		}
+		return ErrTaskNotFound
+	}
+	task.Revision++
+	return nil
+}
+
+// Delete removes a task.
+func (q *PostgresQueue) Delete(ctx context.Context, id string) error {
+	res, err := q.db.ExecContext(ctx, `DELETE FROM tasks WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
+// Len returns the number of tasks not yet completed or cancelled.
+func (q *PostgresQueue) Len() int {
+	var n int
+	row := q.db.QueryRow(`SELECT COUNT(*) FROM tasks WHERE state NOT IN ($1, $2)`, string(StateCompleted), string(StateCancelled))
+	if err := row.Scan(&n); err != nil {
+		return 0
+	}
+	return n
+}
+
+// Ack marks a task completed, clearing its visibility deadline.
+func (q *PostgresQueue) Ack(ctx context.Context, taskID string) error {
+	now := time.Now()
+	res, err := q.db.ExecContext(ctx, `
+		UPDATE tasks SET state = $1, completed_at = $2 WHERE id = $3
+	`, string(StateCompleted), now, taskID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
+// Nack marks a task failed. If retries remain it is returned to pending
+// immediately (the caller / backoff strategy is responsible for setting
+// ScheduledAt if a delay is desired); otherwise it's marked failed.
+func (q *PostgresQueue) Nack(ctx context.Context, taskID string, cause error) error {
+	task, err := q.Get(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	task.Retries++
+	errMsg := ""
+	if cause != nil {
+		errMsg = cause.Error()
+	}
+
+	state := StatePending
+	nextVisible := time.Now()
+	if task.Retries > task.MaxRetries {
+		state = StateFailed
+	}
+
+	_, err = q.db.ExecContext(ctx, `
+		UPDATE tasks SET state = $1, retries = $2, error = $3, next_visible_at = $4 WHERE id = $5
+	`, string(state), task.Retries, errMsg, nextVisible, taskID)
+	return err
+}
+
+// reapLoop periodically returns expired in-flight tasks to pending.
+func (q *PostgresQueue) reapLoop() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.cfg.ReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.stopCh:
+			return
+		case <-ticker.C:
+			q.reapExpired()
+		}
+	}
+}
+
+// reapExpired moves tasks stuck in "processing" past their visibility
+// deadline back to "pending", incrementing Retries so crashed workers
+// don't strand jobs indefinitely.
+func (q *PostgresQueue) reapExpired() {
+	now := time.Now()
+	_, _ = q.db.Exec(`
+		UPDATE tasks
+		SET state = $1, retries = retries + 1, next_visible_at = $2
+		WHERE state = $3 AND next_visible_at <= $4
+	`, string(StatePending), now, string(StateProcessing), now)
+}
+
+// Close stops the reaper and listener and closes the underlying database.
+func (q *PostgresQueue) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	q.mu.Unlock()
+
+	close(q.stopCh)
+	q.wg.Wait()
+	_ = q.listener.Close()
+	return q.db.Close()
+}
+
+var _ BlockingQueue = (*PostgresQueue)(nil)
+var _ AckingQueue = (*PostgresQueue)(nil)
+var _ ScheduledQueue = (*PostgresQueue)(nil)
@@ -4,17 +4,47 @@ package queue
 import (
 	"context"
 	"errors"
+	"sync"
 	"time"
 )
 
+// DefaultRetention is how long a completed task is kept queryable when its
+// Retention field is left unset.
+const DefaultRetention = time.Hour
+
+// DefaultLeaseDuration is how long a claimed (StateProcessing) task may go
+// without a Heartbeat before a Recoverer considers its lease expired when
+// Task.LeaseDuration is left unset.
+const DefaultLeaseDuration = 30 * time.Second
+
 // Common queue errors
 var (
-	ErrQueueEmpty    = errors.New("queue is empty")
-	ErrQueueFull     = errors.New("queue is full")
-	ErrTaskNotFound  = errors.New("task not found")
-	ErrQueueClosed   = errors.New("queue is closed")
-	ErrInvalidTask   = errors.New("invalid task")
-	ErrTimeout       = errors.New("operation timed out")
+	ErrQueueEmpty   = errors.New("queue is empty")
+	ErrQueueFull    = errors.New("queue is full")
+	ErrTaskNotFound = errors.New("task not found")
+	ErrQueueClosed  = errors.New("queue is closed")
+	ErrInvalidTask  = errors.New("invalid task")
+	ErrTimeout      = errors.New("operation timed out")
+
+	// ErrTaskIDConflict is returned by Enqueue when an explicitly set
+	// task.ID already belongs to another task in the queue.
+	ErrTaskIDConflict = errors.New("task ID conflicts with an existing task")
+
+	// ErrDuplicateTask is returned by Enqueue when WithUnique is in effect
+	// and an unexpired task with the same dedup key is already queued.
+	ErrDuplicateTask = errors.New("duplicate task: unique key is still active")
+
+	// ErrTaskNotProcessing is returned by Heartbeat when the task isn't
+	// currently in StateProcessing, e.g. it already completed, failed, or
+	// was already reclaimed by a Recoverer.
+	ErrTaskNotProcessing = errors.New("task is not currently processing")
+
+	// ErrRevisionConflict is returned by Update when task.Revision doesn't
+	// match the stored task's current revision, meaning it was claimed,
+	// updated, or re-enqueued by someone else since the caller last read
+	// it. The caller should re-read the task and decide whether its
+	// intended change still applies.
+	ErrRevisionConflict = errors.New("task revision conflict")
 )
 
 // Priority levels for tasks
@@ -57,19 +87,225 @@ const (
 
 // Task represents a unit of work in the queue
 type Task struct {
-	ID          string                 `json:"id"`
-	Type        string                 `json:"type"`
-	Payload     map[string]interface{} `json:"payload"`
-	Priority    Priority               `json:"priority"`
-	State       TaskState              `json:"state"`
-	CreatedAt   time.Time              `json:"created_at"`
-	StartedAt   *time.Time             `json:"started_at,omitempty"`
-	CompletedAt *time.Time             `json:"completed_at,omitempty"`
-	Retries     int                    `json:"retries"`
-	MaxRetries  int                    `json:"max_retries"`
-	Error       string                 `json:"error,omitempty"`
-	Result      interface{}            `json:"result,omitempty"`
-	Metadata    map[string]string      `json:"metadata,omitempty"`
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	Payload   map[string]interface{} `json:"payload"`
+	Priority  Priority               `json:"priority"`
+	State     TaskState              `json:"state"`
+	CreatedAt time.Time              `json:"created_at"`
+
+	// Revision increases by one on every successful claim (Dequeue/
+	// AcquireJob) or Update. Update uses it as an optimistic-concurrency
+	// token: a non-zero Revision must match the stored task's current
+	// Revision or the call fails with ErrRevisionConflict instead of
+	// silently overwriting a change made by someone else. A caller that
+	// doesn't track revisions can pass 0 to skip the check.
+	Revision    uint64            `json:"revision"`
+	StartedAt   *time.Time        `json:"started_at,omitempty"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+	Retries     int               `json:"retries"`
+	MaxRetries  int               `json:"max_retries"`
+	Error       string            `json:"error,omitempty"`
+	Result      interface{}       `json:"result,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+
+	// ScheduledAt, if set, is the earliest time the task becomes eligible
+	// for dequeue. Backends that support it (see ScheduledQueue) filter it
+	// out of Dequeue/DequeueBlocking/AcquireJob until then.
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+
+	// Codec names the encoding of Body (see task.Codec.ContentType), e.g.
+	// "json", "protobuf", "msgpack", "cbor". Empty means Body is unset and
+	// Payload is the only representation of this task's arguments.
+	Codec string `json:"codec,omitempty"`
+
+	// Body is the Codec-encoded task argument, set by producers that
+	// already hold a typed value and want to skip the map[string]interface{}
+	// round trip Payload requires. Payload remains the JSON-only
+	// compatibility path for producers/backends that only deal in maps.
+	Body []byte `json:"body,omitempty"`
+
+	// Retention is how long after CompletedAt this task's state and result
+	// stay queryable in the completed-task bucket before the retention
+	// janitor purges it. Zero means DefaultRetention.
+	Retention time.Duration `json:"retention,omitempty"`
+
+	// UniqueKey, if set, is the dedup key Enqueue checks under WithUnique
+	// instead of deriving one from Type and Payload.
+	UniqueKey string `json:"unique_key,omitempty"`
+
+	// UniqueTTL, if non-zero (set via WithUnique), makes Enqueue reject this
+	// task with ErrDuplicateTask if a task with the same dedup key was
+	// enqueued within the last UniqueTTL and hasn't completed or failed yet.
+	UniqueTTL time.Duration `json:"unique_ttl,omitempty"`
+
+	// LeaseDuration is how long this task may stay in StateProcessing
+	// without a Heartbeat call before a Recoverer treats it as abandoned by
+	// a crashed worker. Zero means DefaultLeaseDuration.
+	LeaseDuration time.Duration `json:"lease_duration,omitempty"`
+
+	// LeaseExpiresAt is set when the task is claimed (Dequeue/AcquireJob)
+	// to the claim time plus LeaseDurationOrDefault(), and pushed forward
+	// by each Heartbeat call. A Recoverer reclaims any StateProcessing task
+	// whose LeaseExpiresAt has elapsed. storage.LeaseTasks repurposes the
+	// same field on a StatePending task as a "not eligible before" marker:
+	// storage.NackLease sets it to the retry time instead of clearing it,
+	// so a nacked task doesn't need a separate scheduled-at column to
+	// delay its next lease.
+	LeaseExpiresAt *time.Time `json:"lease_expires_at,omitempty"`
+
+	// LeaseToken identifies the storage.LeaseTasks batch currently holding
+	// this task, set for the duration of the lease and cleared by
+	// AckLease/NackLease/a lease-expiry sweeper. Empty outside of a lease.
+	LeaseToken string `json:"lease_token,omitempty"`
+
+	// Group, if set (see WithGroup), is the aggregation key an
+	// AggregatingQueue buffers this task under instead of enqueuing it
+	// directly; see AggregatingQueue and GroupPolicy.
+	Group string `json:"group,omitempty"`
+
+	// TenantID, if set, is the owning tenant from the Principal that
+	// enqueued this task (see internal/api.Authn). service.Service's
+	// List/Get/Delete use it to scope a caller to its own tasks; empty
+	// means the task predates tenant enforcement or was enqueued by a
+	// caller with no Principal, and is exempt from the check.
+	TenantID string `json:"tenant_id,omitempty"`
+
+	// Tag, if set, scopes this task to a storage.LeaseRequest.Tag so a
+	// caller can lease only the tasks belonging to e.g. a single article
+	// or user instead of an entire Type, the way App Engine's task queues
+	// support leasing by tag.
+	Tag string `json:"tag,omitempty"`
+
+	// Paused marks this individual task ineligible for dispatch (see
+	// storage.SQLiteStorage.PauseTask) regardless of its Type's pause
+	// state. It's independent of storage.SQLiteStorage.PauseType, which
+	// pauses every task of a Type without touching this field.
+	Paused bool `json:"paused,omitempty"`
+}
+
+// TaskOption configures optional Task fields, applied via Task.Apply before
+// the task is enqueued.
+type TaskOption func(*Task)
+
+// WithRetention sets how long a completed task's result remains queryable
+// after CompletedAt.
+func WithRetention(d time.Duration) TaskOption {
+	return func(t *Task) {
+		t.Retention = d
+	}
+}
+
+// WithScheduleAt sets ScheduledAt to runAt, deferring the task's initial
+// execution until then instead of making it immediately eligible.
+func WithScheduleAt(runAt time.Time) TaskOption {
+	return func(t *Task) {
+		t.ScheduledAt = &runAt
+	}
+}
+
+// WithDelay sets ScheduledAt to time.Now().Add(d), deferring the task's
+// initial execution by d.
+func WithDelay(d time.Duration) TaskOption {
+	return func(t *Task) {
+		runAt := time.Now().Add(d)
+		t.ScheduledAt = &runAt
+	}
+}
+
+// WithUnique makes Enqueue reject this task with ErrDuplicateTask if
+// another task with the same dedup key (UniqueKey if set, else derived from
+// Type and a hash of Payload) was enqueued within the last ttl and hasn't
+// completed or failed yet.
+func WithUnique(ttl time.Duration) TaskOption {
+	return func(t *Task) {
+		t.UniqueTTL = ttl
+	}
+}
+
+// WithLeaseDuration sets how long this task may stay in StateProcessing
+// without a Heartbeat before a Recoverer treats it as abandoned.
+func WithLeaseDuration(d time.Duration) TaskOption {
+	return func(t *Task) {
+		t.LeaseDuration = d
+	}
+}
+
+// WithGroup marks this task as belonging to the aggregation group name. An
+// AggregatingQueue buffers tasks sharing a group instead of enqueuing them
+// directly, flushing the buffer into a single task per its GroupPolicy.
+func WithGroup(name string) TaskOption {
+	return func(t *Task) {
+		t.Group = name
+	}
+}
+
+// WithTag sets the tag a storage.LeaseRequest.Tag can later scope a lease
+// to, e.g. a single article or user within a shared Type.
+func WithTag(tag string) TaskOption {
+	return func(t *Task) {
+		t.Tag = tag
+	}
+}
+
+// Apply applies opts to t and returns t, for chaining onto a Task literal:
+//
+//	task := (&queue.Task{Type: "email"}).Apply(queue.WithRetention(time.Hour))
+func (t *Task) Apply(opts ...TaskOption) *Task {
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// RetentionOrDefault returns t.Retention, falling back to DefaultRetention
+// when unset.
+func (t *Task) RetentionOrDefault() time.Duration {
+	if t.Retention <= 0 {
+		return DefaultRetention
+	}
+	return t.Retention
+}
+
+// LeaseDurationOrDefault returns t.LeaseDuration, falling back to
+// DefaultLeaseDuration when unset.
+func (t *Task) LeaseDurationOrDefault() time.Duration {
+	if t.LeaseDuration <= 0 {
+		return DefaultLeaseDuration
+	}
+	return t.LeaseDuration
+}
+
+// ResultWriter lets a worker append result bytes to a completed task
+// incrementally, for results that are streamed or produced in chunks
+// rather than available as a single value up front. MarkCompleted returns
+// one already bound to the task it just completed.
+type ResultWriter struct {
+	mu   sync.Mutex
+	task *Task
+}
+
+// Write appends p to the task's Result, which is treated as a []byte
+// buffer for the lifetime of the ResultWriter.
+func (w *ResultWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	buf, _ := w.task.Result.([]byte)
+	buf = append(buf, p...)
+	w.task.Result = buf
+
+	return len(p), nil
+}
+
+// Close is a no-op; it exists so ResultWriter satisfies io.WriteCloser.
+func (w *ResultWriter) Close() error {
+	return nil
+}
+
+// ResultWriter returns a writer for appending to this task's result.
+func (t *Task) ResultWriter() *ResultWriter {
+	return &ResultWriter{task: t}
 }
 
 // Queue defines the interface for task queue operations
@@ -110,6 +346,34 @@ type BlockingQueue interface {
 	EnqueueWithTimeout(ctx context.Context, task *Task, timeout time.Duration) error
 }
 
+// AcquiringQueue extends Queue with tag-scoped long-polling acquisition.
+// Unlike DequeueBlocking, AcquireJob only returns tasks whose Type matches
+// one of the caller's tags (or any task if tags is empty), which lets
+// heterogeneous worker pools share one queue without a task type that only
+// a few workers can handle starving everyone else behind a single channel.
+type AcquiringQueue interface {
+	Queue
+
+	// AcquireJob blocks until a task whose Type is in tags becomes
+	// available or ctx is cancelled.
+	AcquireJob(ctx context.Context, tags []string) (*Task, error)
+}
+
+// ScheduledQueue extends Queue with delayed/scheduled enqueue. A backend
+// implementing this composes naturally with the visibility-timeout claim
+// query: both simply add a "not yet due" filter on the next candidate row.
+type ScheduledQueue interface {
+	Queue
+
+	// EnqueueAt enqueues task so it only becomes eligible for dequeue at
+	// or after runAt.
+	EnqueueAt(ctx context.Context, task *Task, runAt time.Time) error
+
+	// EnqueueAfter enqueues task so it only becomes eligible for dequeue
+	// after delay has elapsed.
+	EnqueueAfter(ctx context.Context, task *Task, delay time.Duration) error
+}
+
 // PriorityQueue extends Queue with priority-based operations
 type PriorityQueue interface {
 	Queue
@@ -121,6 +385,62 @@ type PriorityQueue interface {
 	DequeueByPriority(ctx context.Context) (*Task, error)
 }
 
+// TaskEvent is a single task state transition, published whenever a
+// SubscribableQueue backend enqueues, claims, updates, or deletes a task.
+type TaskEvent struct {
+	TaskID    string    `json:"task_id"`
+	TaskType  string    `json:"task_type"`
+	State     TaskState `json:"state"`
+	Error     string    `json:"error,omitempty"`
+	Revision  uint64    `json:"revision"`
+	Timestamp time.Time `json:"timestamp"`
+
+	// TenantID is the publishing task's Task.TenantID, empty for a task
+	// enqueued with none. It's carried on the event (rather than looked up
+	// later) so EventFilter.Matches can scope a subscription without a
+	// round trip back to storage.
+	TenantID string `json:"tenant_id,omitempty"`
+}
+
+// EventFilter narrows a Subscribe call to the TaskEvents a caller cares
+// about. A zero-valued EventFilter matches every event.
+type EventFilter struct {
+	TaskID   string    // empty matches any task
+	Type     string    // empty matches any task type
+	State    TaskState // empty matches any state
+	TenantID string    // empty matches any tenant
+}
+
+// Matches reports whether ev satisfies every non-empty field of f.
+func (f EventFilter) Matches(ev TaskEvent) bool {
+	if f.TaskID != "" && f.TaskID != ev.TaskID {
+		return false
+	}
+	if f.Type != "" && f.Type != ev.TaskType {
+		return false
+	}
+	if f.State != "" && f.State != ev.State {
+		return false
+	}
+	if f.TenantID != "" && f.TenantID != ev.TenantID {
+		return false
+	}
+	return true
+}
+
+// SubscribableQueue extends Queue with a live feed of task state
+// transitions, letting callers (e.g. the API's SSE endpoints) watch tasks
+// without polling Get/List. A backend implementing this publishes a
+// TaskEvent on every Enqueue, successful Dequeue/AcquireJob, and Update.
+type SubscribableQueue interface {
+	Queue
+
+	// Subscribe returns a channel of TaskEvents matching filter. The
+	// channel is closed once ctx is done; the caller doesn't need to drain
+	// it first.
+	Subscribe(ctx context.Context, filter EventFilter) (<-chan TaskEvent, error)
+}
+
 // QueueStats holds queue statistics
 type QueueStats struct {
 	TotalEnqueued  int64         `json:"total_enqueued"`
@@ -129,6 +449,14 @@ type QueueStats struct {
 	CurrentSize    int           `json:"current_size"`
 	AvgWaitTime    time.Duration `json:"avg_wait_time"`
 	AvgProcessTime time.Duration `json:"avg_process_time"`
+
+	// TotalRecovered counts tasks a Recoverer re-enqueued after finding
+	// their lease expired while they still had retries remaining.
+	TotalRecovered int64 `json:"total_recovered"`
+
+	// TotalArchived counts tasks a Recoverer moved to the archived set
+	// after finding their lease expired with no retries remaining.
+	TotalArchived int64 `json:"total_archived"`
 }
 
 // StatsCollector interface for queue statistics
@@ -0,0 +1,33 @@
+package redisq
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	clientsMu sync.Mutex
+	clients   = make(map[string]*redis.Client)
+)
+
+// ClientFor returns a *redis.Client connected per opts, reusing one
+// already opened for an equal opts.Addr+opts.DB instead of dialing a new
+// pool -- so e.g. multiple worker.Processors pointed at the same Redis
+// instance share one underlying connection pool rather than each opening
+// their own, mirroring how queue.Open's sqlite/postgres drivers are
+// expected to be handed one already-open backing store by their caller.
+func ClientFor(opts *redis.Options) *redis.Client {
+	key := fmt.Sprintf("%s/%d", opts.Addr, opts.DB)
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	if c, ok := clients[key]; ok {
+		return c
+	}
+	c := redis.NewClient(opts)
+	clients[key] = c
+	return c
+}
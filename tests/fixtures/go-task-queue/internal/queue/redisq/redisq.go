@@ -0,0 +1,231 @@
+// Package redisq provides a Redis-backed durable task queue with
+// at-least-once delivery. Unlike queue.SQLiteQueue's transactional claim,
+// in-flight tracking here is structural: BLMOVE atomically hands a task
+// from the pending list to a per-worker list, and a heartbeat-driven
+// reaper reclaims it if that worker disappears.
+package redisq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/example/task-queue/internal/queue"
+	"github.com/example/task-queue/internal/task"
+	"github.com/redis/go-redis/v9"
+)
+
+// AckFunc acknowledges successful processing of the task it was returned
+// alongside, removing it from its worker's in-flight list.
+type AckFunc func(ctx context.Context) error
+
+// Config configures a Queue.
+type Config struct {
+	// Pending is the Redis key holding the shared pending list.
+	Pending string
+
+	// WorkerID scopes this worker's in-flight list and heartbeat key, so
+	// concurrent workers don't reap each other's claims.
+	WorkerID string
+
+	// HeartbeatInterval is how often Run refreshes this worker's
+	// heartbeat key while it holds a claimed task.
+	HeartbeatInterval time.Duration
+
+	// HeartbeatTTL is the expiry set on the heartbeat key; Reap treats an
+	// in-flight list whose heartbeat key has expired as orphaned.
+	HeartbeatTTL time.Duration
+
+	// ReapInterval is how often Reap scans in-flight lists for orphans.
+	ReapInterval time.Duration
+
+	// BlockTimeout bounds each BLMOVE call so Dequeue can periodically
+	// re-check ctx cancellation.
+	BlockTimeout time.Duration
+}
+
+// DefaultConfig returns sane defaults for a Queue claiming as workerID.
+func DefaultConfig(workerID string) Config {
+	return Config{
+		Pending:           "redisq:pending",
+		WorkerID:          workerID,
+		HeartbeatInterval: 10 * time.Second,
+		HeartbeatTTL:      30 * time.Second,
+		ReapInterval:      15 * time.Second,
+		BlockTimeout:      5 * time.Second,
+	}
+}
+
+func (c Config) inflightKey() string {
+	return fmt.Sprintf("redisq:inflight:%s", c.WorkerID)
+}
+
+func (c Config) heartbeatKey() string {
+	return fmt.Sprintf("redisq:heartbeat:%s", c.WorkerID)
+}
+
+// Queue is a durable, at-least-once task queue backed by Redis lists.
+// Enqueue LPUSHes a JSON-encoded queue.Task onto the shared pending list;
+// Dequeue BLMOVEs it onto this worker's in-flight list so a crash between
+// claim and ack leaves the task recoverable instead of lost.
+type Queue struct {
+	client *redis.Client
+	cfg    Config
+}
+
+// New creates a Queue against an already-connected client.
+func New(client *redis.Client, cfg Config) *Queue {
+	return &Queue{client: client, cfg: cfg}
+}
+
+// Enqueue adds task to the pending list.
+func (q *Queue) Enqueue(ctx context.Context, t *queue.Task) error {
+	if t == nil {
+		return queue.ErrInvalidTask
+	}
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	t.State = queue.StatePending
+
+	payload, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+
+	if err := q.client.LPush(ctx, q.cfg.Pending, payload).Err(); err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+	return nil
+}
+
+// Dequeue blocks for up to BlockTimeout waiting for a task, atomically
+// moving it from the pending list to this worker's in-flight list. It
+// returns queue.ErrQueueEmpty if nothing arrived within BlockTimeout so
+// callers (notably Run) can loop and re-check ctx cancellation.
+func (q *Queue) Dequeue(ctx context.Context) (*queue.Task, AckFunc, error) {
+	payload, err := q.client.BLMove(ctx, q.cfg.Pending, q.cfg.inflightKey(), "RIGHT", "LEFT", q.cfg.BlockTimeout).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil, queue.ErrQueueEmpty
+		}
+		return nil, nil, fmt.Errorf("failed to dequeue task: %w", err)
+	}
+
+	var t queue.Task
+	if err := json.Unmarshal([]byte(payload), &t); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal task: %w", err)
+	}
+	t.State = queue.StateProcessing
+
+	ack := func(ctx context.Context) error {
+		return q.client.LRem(ctx, q.cfg.inflightKey(), 1, payload).Err()
+	}
+	return &t, ack, nil
+}
+
+// heartbeat refreshes this worker's heartbeat key so Reap (run by any
+// worker) knows it's still alive.
+func (q *Queue) heartbeat(ctx context.Context) error {
+	return q.client.Set(ctx, q.cfg.heartbeatKey(), 1, q.cfg.HeartbeatTTL).Err()
+}
+
+// Reap scans in-flight lists whose owning worker's heartbeat key has
+// expired and moves their tasks back onto the pending list, so a crashed
+// worker's claims are eventually retried by someone else.
+func (q *Queue) Reap(ctx context.Context) error {
+	var cursor uint64
+	pattern := "redisq:inflight:*"
+	for {
+		keys, next, err := q.client.Scan(ctx, cursor, pattern, 100).Result()
+		if err != nil {
+			return fmt.Errorf("failed to scan in-flight lists: %w", err)
+		}
+
+		for _, inflightKey := range keys {
+			workerID := inflightKey[len("redisq:inflight:"):]
+			heartbeatKey := fmt.Sprintf("redisq:heartbeat:%s", workerID)
+
+			exists, err := q.client.Exists(ctx, heartbeatKey).Result()
+			if err != nil {
+				return fmt.Errorf("failed to check heartbeat: %w", err)
+			}
+			if exists > 0 {
+				continue
+			}
+
+			// Worker is gone; drain its in-flight list back to pending.
+			for {
+				payload, err := q.client.RPopLPush(ctx, inflightKey, q.cfg.Pending).Result()
+				if err == redis.Nil {
+					break
+				}
+				if err != nil {
+					return fmt.Errorf("failed to reap orphaned task: %w", err)
+				}
+				_ = payload
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+	return nil
+}
+
+// ReapLoop runs Reap every ReapInterval until ctx is cancelled.
+func (q *Queue) ReapLoop(ctx context.Context) {
+	ticker := time.NewTicker(q.cfg.ReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = q.Reap(ctx)
+		}
+	}
+}
+
+// Run dequeues tasks and dispatches them to registry.Execute in a loop,
+// acking on success and leaving failures in-flight for Reap to recover,
+// until ctx is cancelled. It refreshes this worker's heartbeat on every
+// iteration so Reap doesn't treat an active worker as orphaned.
+func (q *Queue) Run(ctx context.Context, registry *task.Registry) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if err := q.heartbeat(ctx); err != nil {
+			return fmt.Errorf("failed to set heartbeat: %w", err)
+		}
+
+		t, ack, err := q.Dequeue(ctx)
+		if err != nil {
+			if err == queue.ErrQueueEmpty {
+				continue
+			}
+			return err
+		}
+
+		if _, execErr := registry.Execute(ctx, t); execErr != nil {
+			// Leave the task on the in-flight list; Reap returns it to
+			// pending once this worker's heartbeat lapses or a retry
+			// loop elsewhere re-dequeues it after an explicit Nack-style
+			// removal. We don't ack on failure, matching the package's
+			// at-least-once contract.
+			continue
+		}
+
+		if err := ack(ctx); err != nil {
+			return fmt.Errorf("failed to ack task: %w", err)
+		}
+	}
+}
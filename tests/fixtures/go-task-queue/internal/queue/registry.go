@@ -0,0 +1,46 @@
+package queue
+
+import "sync"
+
+// DriverOpener opens a Queue for a URI whose scheme it was registered
+// under.
+type DriverOpener func(rawURL string) (Queue, error)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]DriverOpener)
+)
+
+// RegisterDriver registers open under scheme so a later Open call for a
+// matching URI dispatches to it, the same way database/sql drivers
+// register themselves in an init(). It exists for drivers that can't be
+// built into package queue itself -- redisq already imports queue, so
+// queue importing redisq back would cycle -- and is a no-op for
+// memory/sqlite/postgres, which Open still dispatches to directly.
+//
+// RegisterDriver is meant to be called from a driver package's init(),
+// so importing that package (blank import or otherwise) is enough to
+// make Open recognize its scheme; it panics on a nil opener or a
+// duplicate scheme, the same way database/sql.Register does, since both
+// are programmer errors caught at init time rather than something a
+// caller should handle.
+func RegisterDriver(scheme string, open DriverOpener) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if open == nil {
+		panic("queue: RegisterDriver open is nil")
+	}
+	if _, dup := drivers[scheme]; dup {
+		panic("queue: RegisterDriver called twice for scheme " + scheme)
+	}
+	drivers[scheme] = open
+}
+
+// driverFor looks up a previously-registered opener for scheme.
+func driverFor(scheme string) (DriverOpener, bool) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	open, ok := drivers[scheme]
+	return open, ok
+}
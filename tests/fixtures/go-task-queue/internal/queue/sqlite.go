@@ -0,0 +1,467 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// AckingQueue extends Queue with explicit acknowledgement semantics for
+// backends that track an in-flight "processing" state durably. Pool uses
+// this instead of Update when a queue implements it, so a crash between
+// dequeue and completion doesn't strand the task.
+type AckingQueue interface {
+	Queue
+
+	// Ack marks a task as successfully completed.
+	Ack(ctx context.Context, taskID string) error
+
+	// Nack marks a task as failed, optionally scheduling a retry.
+	Nack(ctx context.Context, taskID string, cause error) error
+}
+
+// SQLiteQueueConfig configures a SQLiteQueue.
+type SQLiteQueueConfig struct {
+	Path               string
+	VisibilityTimeout  time.Duration
+	ReapInterval       time.Duration
+}
+
+// DefaultSQLiteQueueConfig returns sane defaults for a SQLiteQueue.
+func DefaultSQLiteQueueConfig(path string) SQLiteQueueConfig {
+	return SQLiteQueueConfig{
+		Path:              path,
+		VisibilityTimeout: 30 * time.Second,
+		ReapInterval:      10 * time.Second,
+	}
+}
+
+// SQLiteQueue is a durable, at-least-once Queue backed by SQLite. Unlike
+// MemoryQueue, a dequeued task survives a process crash: it is claimed
+// transactionally with a visibility timeout, and a background reaper
+// returns tasks to "pending" if they're never acked.
+type SQLiteQueue struct {
+	db     *sql.DB
+	cfg    SQLiteQueueConfig
+	mu     sync.Mutex
+	closed bool
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewSQLiteQueue opens (creating if necessary) a SQLite-backed queue and
+// starts its background reaper.
+func NewSQLiteQueue(cfg SQLiteQueueConfig) (*SQLiteQueue, error) {
+	db, err := sql.Open("sqlite3", cfg.Path+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open queue database: %w", err)
+	}
+
+	q := &SQLiteQueue{
+		db:     db,
+		cfg:    cfg,
+		stopCh: make(chan struct{}),
+	}
+
+	if err := q.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	q.wg.Add(1)
+	go q.reapLoop()
+
+	return q, nil
+}
+
+func (q *SQLiteQueue) migrate() error {
+	_, err := q.db.Exec(`
+		CREATE TABLE IF NOT EXISTS tasks (
+			id            TEXT PRIMARY KEY,
+			type          TEXT NOT NULL,
+			payload       TEXT NOT NULL,
+			priority      INTEGER NOT NULL,
+			state         TEXT NOT NULL,
+			created_at    DATETIME NOT NULL,
+			started_at    DATETIME,
+			completed_at  DATETIME,
+			next_visible_at DATETIME NOT NULL,
+			retries       INTEGER NOT NULL DEFAULT 0,
+			max_retries   INTEGER NOT NULL DEFAULT 0,
+			error         TEXT,
+			result        TEXT,
+			metadata      TEXT,
+			revision      INTEGER NOT NULL DEFAULT 1
+		);
+		CREATE INDEX IF NOT EXISTS idx_tasks_claim
+			ON tasks(state, priority DESC, next_visible_at);
+	`)
+	return err
+}
+
+// Enqueue adds a task to the queue in the pending state.
+func (q *SQLiteQueue) Enqueue(ctx context.Context, task *Task) error {
+	if task == nil {
+		return ErrInvalidTask
+	}
+	if task.ID == "" {
+		task.ID = uuid.New().String()
+	}
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now()
+	}
+	task.State = StatePending
+
+	// A scheduled task simply starts with its visibility deadline in the
+	// future; the existing claim query's next_visible_at filter then
+	// naturally excludes it until due.
+	nextVisible := task.CreatedAt
+	if task.ScheduledAt != nil && task.ScheduledAt.After(nextVisible) {
+		nextVisible = *task.ScheduledAt
+	}
+
+	payload, err := json.Marshal(task.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	metadata, err := json.Marshal(task.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	task.Revision = 1
+
+	_, err = q.db.ExecContext(ctx, `
+		INSERT INTO tasks (id, type, payload, priority, state, created_at, next_visible_at, retries, max_retries, metadata, revision)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, task.ID, task.Type, string(payload), int(task.Priority), string(task.State), task.CreatedAt, nextVisible, task.Retries, task.MaxRetries, string(metadata), task.Revision)
+	return err
+}
+
+// EnqueueAt enqueues task so it's only claimable at or after runAt.
+func (q *SQLiteQueue) EnqueueAt(ctx context.Context, task *Task, runAt time.Time) error {
+	if task == nil {
+		return ErrInvalidTask
+	}
+	task.ScheduledAt = &runAt
+	return q.Enqueue(ctx, task)
+}
+
+// EnqueueAfter enqueues task so it's only claimable after delay elapses.
+func (q *SQLiteQueue) EnqueueAfter(ctx context.Context, task *Task, delay time.Duration) error {
+	return q.EnqueueAt(ctx, task, time.Now().Add(delay))
+}
+
+// Dequeue claims the next pending task transactionally, setting its state
+// to processing and its visibility deadline to now + VisibilityTimeout.
+func (q *SQLiteQueue) Dequeue(ctx context.Context) (*Task, error) {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, `
+		SELECT id, type, payload, priority, state, created_at, started_at, completed_at, retries, max_retries, error, result, metadata, revision
+		FROM tasks
+		WHERE state = ? AND next_visible_at <= ?
+		ORDER BY priority DESC, created_at ASC
+		LIMIT 1
+	`, string(StatePending), time.Now())
+
+	task, err := scanTask(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrQueueEmpty
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	visibleAt := now.Add(q.cfg.VisibilityTimeout)
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE tasks SET state = ?, started_at = ?, next_visible_at = ?, revision = revision + 1 WHERE id = ?
+	`, string(StateProcessing), now, visibleAt, task.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	task.State = StateProcessing
+	task.StartedAt = &now
+	task.Revision++
+	return task, nil
+}
+
+// DequeueBlocking polls until a task becomes claimable or ctx is cancelled.
+func (q *SQLiteQueue) DequeueBlocking(ctx context.Context) (*Task, error) {
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		task, err := q.Dequeue(ctx)
+		if err == nil {
+			return task, nil
+		}
+		if err != ErrQueueEmpty {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// EnqueueWithTimeout enqueues with a timeout.
+func (q *SQLiteQueue) EnqueueWithTimeout(ctx context.Context, task *Task, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return q.Enqueue(ctx, task)
+}
+
+// Peek returns the next claimable task without claiming it.
+func (q *SQLiteQueue) Peek(ctx context.Context) (*Task, error) {
+	row := q.db.QueryRowContext(ctx, `
+		SELECT id, type, payload, priority, state, created_at, started_at, completed_at, retries, max_retries, error, result, metadata, revision
+		FROM tasks
+		WHERE state = ? AND next_visible_at <= ?
+		ORDER BY priority DESC, created_at ASC
+		LIMIT 1
+	`, string(StatePending), time.Now())
+
+	task, err := scanTask(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrQueueEmpty
+	}
+	return task, err
+}
+
+// Get retrieves a task by ID regardless of state.
+func (q *SQLiteQueue) Get(ctx context.Context, id string) (*Task, error) {
+	row := q.db.QueryRowContext(ctx, `
+		SELECT id, type, payload, priority, state, created_at, started_at, completed_at, retries, max_retries, error, result, metadata, revision
+		FROM tasks WHERE id = ?
+	`, id)
+
+	task, err := scanTask(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrTaskNotFound
+	}
+	return task, err
+}
+
+// Update persists an updated task, failing with ErrRevisionConflict if
+// task.Revision is non-zero and doesn't match the stored row's current
+// revision -- see Task.Revision.
+func (q *SQLiteQueue) Update(ctx context.Context, task *Task) error {
+	payload, err := json.Marshal(task.Payload)
+	if err != nil {
+		return err
+	}
+	metadata, err := json.Marshal(task.Metadata)
+	if err != nil {
+		return err
+	}
+	result, err := json.Marshal(task.Result)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE tasks SET type=?, payload=?, priority=?, state=?, started_at=?, completed_at=?, retries=?, max_retries=?, error=?, result=?, metadata=?, revision=revision+1
+		WHERE id=?
+	`
+	args := []interface{}{task.Type, string(payload), int(task.Priority), string(task.State), task.StartedAt, task.CompletedAt,
+		task.Retries, task.MaxRetries, task.Error, string(result), string(metadata), task.ID}
+
+	if task.Revision != 0 {
+		query = `
+			UPDATE tasks SET type=?, payload=?, priority=?, state=?, started_at=?, completed_at=?, retries=?, max_retries=?, error=?, result=?, metadata=?, revision=revision+1
+			WHERE id=? AND revision=?
+		`
+		args = append(args, task.Revision)
+	}
+
+	res, err := q.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		if task.Revision != 0 {
+			if _, getErr := q.Get(ctx, task.ID); getErr == nil {
+				return ErrRevisionConflict
+			}
+		}
+		return ErrTaskNotFound
+	}
+	task.Revision++
+	return nil
+}
+
+// Delete removes a task.
+func (q *SQLiteQueue) Delete(ctx context.Context, id string) error {
+	res, err := q.db.ExecContext(ctx, `DELETE FROM tasks WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
+// Len returns the number of tasks not yet completed or cancelled.
+func (q *SQLiteQueue) Len() int {
+	var n int
+	row := q.db.QueryRow(`SELECT COUNT(*) FROM tasks WHERE state NOT IN (?, ?)`, string(StateCompleted), string(StateCancelled))
+	if err := row.Scan(&n); err != nil {
+		return 0
+	}
+	return n
+}
+
+// Ack marks a task completed, clearing its visibility deadline.
+func (q *SQLiteQueue) Ack(ctx context.Context, taskID string) error {
+	now := time.Now()
+	res, err := q.db.ExecContext(ctx, `
+		UPDATE tasks SET state = ?, completed_at = ? WHERE id = ?
+	`, string(StateCompleted), now, taskID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
+// Nack marks a task failed. If retries remain it is returned to pending
+// immediately (the caller / backoff strategy is responsible for setting
+// ScheduledAt if a delay is desired); otherwise it's marked failed.
+func (q *SQLiteQueue) Nack(ctx context.Context, taskID string, cause error) error {
+	task, err := q.Get(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	task.Retries++
+	errMsg := ""
+	if cause != nil {
+		errMsg = cause.Error()
+	}
+
+	state := StatePending
+	nextVisible := time.Now()
+	if task.Retries > task.MaxRetries {
+		state = StateFailed
+	}
+
+	_, err = q.db.ExecContext(ctx, `
+		UPDATE tasks SET state = ?, retries = ?, error = ?, next_visible_at = ? WHERE id = ?
+	`, string(state), task.Retries, errMsg, nextVisible, taskID)
+	return err
+}
+
+// reapLoop periodically returns expired in-flight tasks to pending.
+func (q *SQLiteQueue) reapLoop() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.cfg.ReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.stopCh:
+			return
+		case <-ticker.C:
+			q.reapExpired()
+		}
+	}
+}
+
+// reapExpired moves tasks stuck in "processing" past their visibility
+// deadline back to "pending", incrementing Retries so crashed workers
+// don't strand jobs indefinitely.
+func (q *SQLiteQueue) reapExpired() {
+	now := time.Now()
+	_, _ = q.db.Exec(`
+		UPDATE tasks
+		SET state = ?, retries = retries + 1, next_visible_at = ?
+		WHERE state = ? AND next_visible_at <= ?
+	`, string(StatePending), now, string(StateProcessing), now)
+}
+
+// Close stops the reaper and closes the underlying database.
+func (q *SQLiteQueue) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	q.mu.Unlock()
+
+	close(q.stopCh)
+	q.wg.Wait()
+	return q.db.Close()
+}
+
+// rowScanner abstracts over *sql.Row and *sql.Rows for scanTask.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTask(row rowScanner) (*Task, error) {
+	var (
+		t                        Task
+		payload, metadata, result sql.NullString
+		state                    string
+		priority                 int
+		startedAt, completedAt   sql.NullTime
+		errStr                   sql.NullString
+	)
+
+	if err := row.Scan(&t.ID, &t.Type, &payload, &priority, &state, &t.CreatedAt, &startedAt, &completedAt,
+		&t.Retries, &t.MaxRetries, &errStr, &result, &metadata, &t.Revision); err != nil {
+		return nil, err
+	}
+
+	t.Priority = Priority(priority)
+	t.State = TaskState(state)
+	t.Error = errStr.String
+	if startedAt.Valid {
+		v := startedAt.Time
+		t.StartedAt = &v
+	}
+	if completedAt.Valid {
+		v := completedAt.Time
+		t.CompletedAt = &v
+	}
+	if payload.Valid && payload.String != "" {
+		_ = json.Unmarshal([]byte(payload.String), &t.Payload)
+	}
+	if metadata.Valid && metadata.String != "" {
+		_ = json.Unmarshal([]byte(metadata.String), &t.Metadata)
+	}
+	if result.Valid && result.String != "" && result.String != "null" {
+		_ = json.Unmarshal([]byte(result.String), &t.Result)
+	}
+
+	return &t, nil
+}
+
+var _ BlockingQueue = (*SQLiteQueue)(nil)
+var _ AckingQueue = (*SQLiteQueue)(nil)
+var _ ScheduledQueue = (*SQLiteQueue)(nil)
@@ -0,0 +1,47 @@
+package storage
+
+import "fmt"
+
+// Type selects which Storage implementation Config.New constructs.
+type Type string
+
+const (
+	TypeSQLite   Type = "sqlite"
+	TypePostgres Type = "postgres"
+	TypeMemory   Type = "memory"
+)
+
+// Config selects and configures one Storage backend. Exactly one of its
+// nested configs is read, chosen by Type; the others are ignored, the
+// same way worker.Config only reads the nested config matching its own
+// mode.
+type Config struct {
+	Type Type
+
+	SQLite   SQLiteConfig
+	Postgres PostgresConfig
+}
+
+// New validates cfg and constructs the Storage backend it selects,
+// failing fast with a descriptive error instead of constructing a
+// backend that will only fail on its first query -- e.g. TypeSQLite with
+// no Path set is rejected here rather than surfacing as a confusing
+// "unable to open database file" from SQLite itself.
+func New(cfg Config) (Storage, error) {
+	switch cfg.Type {
+	case TypeSQLite:
+		if cfg.SQLite.Path == "" {
+			return nil, fmt.Errorf("storage: sqlite config requires Path")
+		}
+		return NewSQLiteStorage(cfg.SQLite)
+	case TypePostgres:
+		if cfg.Postgres.DSN == "" {
+			return nil, fmt.Errorf("storage: postgres config requires DSN")
+		}
+		return NewPostgresStorage(cfg.Postgres)
+	case TypeMemory:
+		return NewInMemoryStorage(), nil
+	default:
+		return nil, fmt.Errorf("storage: unknown type %q (want %q, %q or %q)", cfg.Type, TypeSQLite, TypePostgres, TypeMemory)
+	}
+}
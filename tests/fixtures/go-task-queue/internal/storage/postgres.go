@@ -0,0 +1,976 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/example/task-queue/internal/queue"
+)
+
+// PostgresStorage implements Storage using PostgreSQL, for callers that
+// need the task table shared across multiple server processes instead of
+// living in one SQLiteStorage's file. Its schema, queries and lease
+// semantics mirror SQLiteStorage's as closely as the two dialects allow
+// (see buildFilterConditions, shared by both); the main divergence is
+// LeaseTasks, which claims rows with SELECT ... FOR UPDATE SKIP LOCKED
+// the same way queue.PostgresQueue.Dequeue does, instead of relying on
+// SQLite's single-writer transaction isolation.
+type PostgresStorage struct {
+	db         *sql.DB
+	serializer Serializer
+
+	stopSweep chan struct{}
+	sweepDone chan struct{}
+
+	stopLeaseSweep chan struct{}
+	leaseSweepDone chan struct{}
+}
+
+// PostgresConfig holds PostgreSQL configuration.
+type PostgresConfig struct {
+	DSN             string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
+	// RetentionSweepInterval and LeaseSweepInterval behave exactly as
+	// their SQLiteConfig counterparts do; see SQLiteConfig for the full
+	// rationale.
+	RetentionSweepInterval time.Duration
+	LeaseSweepInterval     time.Duration
+}
+
+// DefaultPostgresConfig returns default PostgreSQL configuration for dsn.
+func DefaultPostgresConfig(dsn string) PostgresConfig {
+	return PostgresConfig{
+		DSN:                    dsn,
+		MaxOpenConns:           10,
+		MaxIdleConns:           5,
+		ConnMaxLifetime:        time.Hour,
+		RetentionSweepInterval: 5 * time.Minute,
+		LeaseSweepInterval:     15 * time.Second,
+	}
+}
+
+// NewPostgresStorage opens (migrating if necessary) a PostgreSQL-backed
+// Storage.
+func NewPostgresStorage(cfg PostgresConfig) (*PostgresStorage, error) {
+	db, err := sql.Open("postgres", cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	s := &PostgresStorage{
+		db:         db,
+		serializer: &JSONSerializer{},
+	}
+
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	if cfg.RetentionSweepInterval > 0 {
+		s.stopSweep = make(chan struct{})
+		s.sweepDone = make(chan struct{})
+		go s.sweepRetention(cfg.RetentionSweepInterval)
+	}
+
+	if cfg.LeaseSweepInterval > 0 {
+		s.stopLeaseSweep = make(chan struct{})
+		s.leaseSweepDone = make(chan struct{})
+		go s.sweepLeases(cfg.LeaseSweepInterval)
+	}
+
+	return s, nil
+}
+
+func (s *PostgresStorage) migrate() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS tasks (
+			id                TEXT PRIMARY KEY,
+			type              TEXT NOT NULL,
+			payload           TEXT NOT NULL,
+			priority          INTEGER NOT NULL DEFAULT 0,
+			state             TEXT NOT NULL DEFAULT 'pending',
+			created_at        TIMESTAMPTZ NOT NULL,
+			started_at        TIMESTAMPTZ,
+			completed_at      TIMESTAMPTZ,
+			retries           INTEGER NOT NULL DEFAULT 0,
+			max_retries       INTEGER NOT NULL DEFAULT 3,
+			error             TEXT,
+			result            TEXT,
+			metadata          TEXT,
+			retention_ns      BIGINT NOT NULL DEFAULT 0,
+			lease_duration_ns BIGINT NOT NULL DEFAULT 0,
+			lease_expires_at  TIMESTAMPTZ,
+			lease_token       TEXT NOT NULL DEFAULT '',
+			tenant_id         TEXT NOT NULL DEFAULT '',
+			tag               TEXT NOT NULL DEFAULT ''
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_tasks_state ON tasks(state);
+		CREATE INDEX IF NOT EXISTS idx_tasks_type ON tasks(type);
+		CREATE INDEX IF NOT EXISTS idx_tasks_priority ON tasks(priority);
+		CREATE INDEX IF NOT EXISTS idx_tasks_created_at ON tasks(created_at);
+		CREATE INDEX IF NOT EXISTS idx_tasks_tenant_id ON tasks(tenant_id);
+		CREATE INDEX IF NOT EXISTS idx_tasks_tag ON tasks(tag);
+		CREATE INDEX IF NOT EXISTS idx_tasks_lease_token ON tasks(lease_token);
+
+		CREATE TABLE IF NOT EXISTS unique_locks (
+			key        TEXT PRIMARY KEY,
+			expires_at TIMESTAMPTZ NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS task_results (
+			task_id TEXT NOT NULL,
+			seq     INTEGER NOT NULL,
+			data    BYTEA NOT NULL,
+			PRIMARY KEY (task_id, seq)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_task_results_task_id ON task_results(task_id);
+	`)
+	return err
+}
+
+// sweepRetention mirrors SQLiteStorage.sweepRetention.
+func (s *PostgresStorage) sweepRetention(interval time.Duration) {
+	defer close(s.sweepDone)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopSweep:
+			return
+		case <-ticker.C:
+			s.sweepExpiredTasks(context.Background())
+		}
+	}
+}
+
+// sweepExpiredTasks deletes every completed or failed task whose
+// retention window has elapsed, along with its task_results rows -- see
+// SQLiteStorage.sweepExpiredTasks for the full rationale. The expiry
+// window is computed in Go rather than SQL's INTERVAL arithmetic, since
+// it varies per row (queue.Task.RetentionOrDefault).
+func (s *PostgresStorage) sweepExpiredTasks(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	const expired = `
+		completed_at IS NOT NULL
+		AND state IN ($1, $2)
+		AND completed_at <= $3 - (CASE WHEN retention_ns > 0 THEN retention_ns ELSE $4 END) * interval '1 nanosecond'
+	`
+	args := []interface{}{queue.StateCompleted, queue.StateFailed, time.Now(), int64(queue.DefaultRetention)}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM task_results WHERE task_id IN (SELECT id FROM tasks WHERE "+expired+")", args...); err != nil {
+		return fmt.Errorf("failed to sweep task results: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tasks WHERE "+expired, args...); err != nil {
+		return fmt.Errorf("failed to sweep tasks: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// sweepLeases mirrors SQLiteStorage.sweepLeases.
+func (s *PostgresStorage) sweepLeases(interval time.Duration) {
+	defer close(s.leaseSweepDone)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopLeaseSweep:
+			return
+		case <-ticker.C:
+			s.sweepExpiredLeases(context.Background())
+		}
+	}
+}
+
+func (s *PostgresStorage) sweepExpiredLeases(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE tasks
+		SET state = $1, retries = retries + 1, lease_token = '', lease_expires_at = NULL
+		WHERE state = $2 AND lease_expires_at IS NOT NULL AND lease_expires_at <= $3
+	`, queue.StatePending, queue.StateProcessing, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to sweep expired leases: %w", err)
+	}
+	return nil
+}
+
+// SaveTask saves a task to the database.
+func (s *PostgresStorage) SaveTask(ctx context.Context, task *queue.Task) error {
+	payload, err := json.Marshal(task.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	metadata, err := json.Marshal(task.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO tasks (id, type, payload, priority, state, created_at, retries, max_retries, metadata, retention_ns, lease_duration_ns, lease_expires_at, lease_token, tenant_id, tag)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		ON CONFLICT (id) DO UPDATE SET
+			state = excluded.state,
+			started_at = excluded.started_at,
+			completed_at = excluded.completed_at,
+			retries = excluded.retries,
+			error = excluded.error,
+			result = excluded.result,
+			retention_ns = excluded.retention_ns,
+			lease_duration_ns = excluded.lease_duration_ns,
+			lease_expires_at = excluded.lease_expires_at,
+			lease_token = excluded.lease_token
+	`,
+		task.ID,
+		task.Type,
+		string(payload),
+		task.Priority,
+		task.State,
+		task.CreatedAt,
+		task.Retries,
+		task.MaxRetries,
+		string(metadata),
+		int64(task.Retention),
+		int64(task.LeaseDuration),
+		task.LeaseExpiresAt,
+		task.LeaseToken,
+		task.TenantID,
+		task.Tag,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save task: %w", err)
+	}
+	return nil
+}
+
+// GetTask retrieves a task by ID.
+func (s *PostgresStorage) GetTask(ctx context.Context, id string) (*queue.Task, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, type, payload, priority, state, created_at, started_at, completed_at,
+		       retries, max_retries, error, result, metadata, retention_ns, lease_duration_ns, lease_expires_at,
+		       lease_token, tenant_id, tag
+		FROM tasks WHERE id = $1
+	`, id)
+	return s.scanTask(row)
+}
+
+// GetCompletedTask retrieves a completed task by ID if it's still within
+// its retention window.
+func (s *PostgresStorage) GetCompletedTask(ctx context.Context, id string) (*queue.Task, error) {
+	task, err := s.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if task.State != queue.StateCompleted || task.CompletedAt == nil {
+		return nil, ErrNotFound
+	}
+	if time.Now().After(task.CompletedAt.Add(task.RetentionOrDefault())) {
+		return nil, ErrNotFound
+	}
+	return task, nil
+}
+
+// rowScanner is the subset of *sql.Row and *sql.Rows that scanTask needs.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (s *PostgresStorage) scanTask(row rowScanner) (*queue.Task, error) {
+	return scanTaskRow(row)
+}
+
+func (s *PostgresStorage) scanTaskRows(rows *sql.Rows) (*queue.Task, error) {
+	return scanTaskRow(rows)
+}
+
+// scanTaskRow scans either a *sql.Row or a *sql.Rows into a Task -- the
+// column order and null-handling mirror SQLiteStorage's scanTask /
+// scanTaskRows exactly, since the two backends select the same columns.
+func scanTaskRow(row rowScanner) (*queue.Task, error) {
+	var task queue.Task
+	var payload, metadata, result sql.NullString
+	var startedAt, completedAt, leaseExpiresAt sql.NullTime
+	var errorStr sql.NullString
+	var retentionNs, leaseDurationNs int64
+
+	err := row.Scan(
+		&task.ID,
+		&task.Type,
+		&payload,
+		&task.Priority,
+		&task.State,
+		&task.CreatedAt,
+		&startedAt,
+		&completedAt,
+		&task.Retries,
+		&task.MaxRetries,
+		&errorStr,
+		&result,
+		&metadata,
+		&retentionNs,
+		&leaseDurationNs,
+		&leaseExpiresAt,
+		&task.LeaseToken,
+		&task.TenantID,
+		&task.Tag,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan task: %w", err)
+	}
+
+	task.Retention = time.Duration(retentionNs)
+	task.LeaseDuration = time.Duration(leaseDurationNs)
+	if leaseExpiresAt.Valid {
+		task.LeaseExpiresAt = &leaseExpiresAt.Time
+	}
+
+	if payload.Valid {
+		if err := json.Unmarshal([]byte(payload.String), &task.Payload); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+		}
+	}
+	if metadata.Valid {
+		if err := json.Unmarshal([]byte(metadata.String), &task.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	}
+	if result.Valid {
+		if err := json.Unmarshal([]byte(result.String), &task.Result); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal result: %w", err)
+		}
+	}
+	if startedAt.Valid {
+		task.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		task.CompletedAt = &completedAt.Time
+	}
+	if errorStr.Valid {
+		task.Error = errorStr.String
+	}
+
+	return &task, nil
+}
+
+// UpdateTask updates a task.
+func (s *PostgresStorage) UpdateTask(ctx context.Context, task *queue.Task) error {
+	result, err := json.Marshal(task.Result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE tasks SET
+			state = $1,
+			started_at = $2,
+			completed_at = $3,
+			retries = $4,
+			error = $5,
+			result = $6,
+			retention_ns = $7,
+			lease_duration_ns = $8,
+			lease_expires_at = $9,
+			lease_token = $10
+		WHERE id = $11
+	`,
+		task.State,
+		task.StartedAt,
+		task.CompletedAt,
+		task.Retries,
+		task.Error,
+		string(result),
+		int64(task.Retention),
+		int64(task.LeaseDuration),
+		task.LeaseExpiresAt,
+		task.LeaseToken,
+		task.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteTask deletes a task.
+func (s *PostgresStorage) DeleteTask(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM tasks WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListTasks lists tasks with filters.
+func (s *PostgresStorage) ListTasks(ctx context.Context, filter TaskFilter) ([]*queue.Task, error) {
+	conditions, args := buildFilterConditions(filter, dollarPlaceholder)
+
+	query := `
+		SELECT id, type, payload, priority, state, created_at, started_at, completed_at,
+		       retries, max_retries, error, result, metadata, retention_ns, lease_duration_ns, lease_expires_at,
+		       lease_token, tenant_id, tag
+		FROM tasks
+	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	orderBy := "created_at"
+	if filter.OrderBy != "" {
+		orderBy = filter.OrderBy
+	}
+	order := "ASC"
+	if filter.OrderDesc {
+		order = "DESC"
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s", orderBy, order)
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET %d", filter.Offset)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []*queue.Task
+	for rows.Next() {
+		task, err := s.scanTaskRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// SaveTasks saves multiple tasks in a transaction.
+func (s *PostgresStorage) SaveTasks(ctx context.Context, tasks []*queue.Task) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO tasks (id, type, payload, priority, state, created_at, retries, max_retries, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, task := range tasks {
+		payload, _ := json.Marshal(task.Payload)
+		metadata, _ := json.Marshal(task.Metadata)
+
+		_, err := stmt.ExecContext(ctx,
+			task.ID,
+			task.Type,
+			string(payload),
+			task.Priority,
+			task.State,
+			task.CreatedAt,
+			task.Retries,
+			task.MaxRetries,
+			string(metadata),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert task %s: %w", task.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteTasks deletes multiple tasks.
+func (s *PostgresStorage) DeleteTasks(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = dollarPlaceholder(i + 1)
+		args[i] = id
+	}
+
+	query := fmt.Sprintf("DELETE FROM tasks WHERE id IN (%s)", strings.Join(placeholders, ","))
+	_, err := s.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// CountTasks counts tasks matching filter.
+func (s *PostgresStorage) CountTasks(ctx context.Context, filter TaskFilter) (int, error) {
+	conditions, args := buildFilterConditions(filter, dollarPlaceholder)
+
+	query := "SELECT COUNT(*) FROM tasks"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var count int
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	return count, err
+}
+
+// GetTasksByState gets all tasks in a specific state.
+func (s *PostgresStorage) GetTasksByState(ctx context.Context, state queue.TaskState) ([]*queue.Task, error) {
+	return s.ListTasks(ctx, TaskFilter{State: state})
+}
+
+// GetStaleTasks gets tasks older than duration.
+func (s *PostgresStorage) GetStaleTasks(ctx context.Context, olderThan time.Duration) ([]*queue.Task, error) {
+	return s.ListTasks(ctx, TaskFilter{CreatedBefore: time.Now().Add(-olderThan)})
+}
+
+// AcquireUniqueLock acquires the dedup lock for key, mirroring
+// SQLiteStorage.AcquireUniqueLock's upsert-with-expiry-guard.
+func (s *PostgresStorage) AcquireUniqueLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO unique_locks (key, expires_at) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET expires_at = excluded.expires_at
+		WHERE unique_locks.expires_at <= $3
+	`, key, now.Add(ttl), now)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire unique lock: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// ReleaseUniqueLock releases the dedup lock for key.
+func (s *PostgresStorage) ReleaseUniqueLock(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM unique_locks WHERE key = $1", key)
+	return err
+}
+
+// Heartbeat extends a StateProcessing task's lease.
+func (s *PostgresStorage) Heartbeat(ctx context.Context, id string) error {
+	task, err := s.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+	if task.State != queue.StateProcessing {
+		return queue.ErrTaskNotProcessing
+	}
+
+	exp := time.Now().Add(task.LeaseDurationOrDefault())
+	_, err = s.db.ExecContext(ctx, "UPDATE tasks SET lease_expires_at = $1 WHERE id = $2", exp, id)
+	if err != nil {
+		return fmt.Errorf("failed to heartbeat task: %w", err)
+	}
+	return nil
+}
+
+// pgQueryExecer is the subset of *sql.DB and *sql.Tx that leasePostgresTasks
+// needs.
+type pgQueryExecer interface {
+	pgExecer
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// pgExecer is the subset of *sql.DB and *sql.Tx that the lease and
+// result-writer helpers need.
+type pgExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// leasePostgresTasks claims up to req.Max pending, eligible tasks in one
+// round trip: a CTE selects candidate ids with SELECT ... FOR UPDATE SKIP
+// LOCKED -- the same claim primitive queue.PostgresQueue.Dequeue uses --
+// so concurrent callers never block on or double-claim each other's rows,
+// then the outer UPDATE marks exactly those ids leased and RETURNING
+// hands back the full rows in one statement.
+func leasePostgresTasks(ctx context.Context, q pgQueryExecer, req LeaseRequest) ([]*queue.Task, LeaseToken, error) {
+	conditions := []string{"state = $1", "(lease_expires_at IS NULL OR lease_expires_at <= $2)"}
+	args := []interface{}{queue.StatePending, time.Now()}
+
+	if req.Type != "" {
+		args = append(args, req.Type)
+		conditions = append(conditions, fmt.Sprintf("type = $%d", len(args)))
+	}
+	if req.Tag != "" {
+		args = append(args, req.Tag)
+		conditions = append(conditions, fmt.Sprintf("tag = $%d", len(args)))
+	}
+	if req.Priority != 0 {
+		args = append(args, req.Priority)
+		conditions = append(conditions, fmt.Sprintf("priority = $%d", len(args)))
+	}
+
+	limit := ""
+	if req.Max > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Max)
+	}
+
+	leaseFor := req.LeaseFor
+	if leaseFor <= 0 {
+		leaseFor = queue.DefaultLeaseDuration
+	}
+	token := newLeaseToken()
+	expiresAt := time.Now().Add(leaseFor)
+	args = append(args, queue.StateProcessing, string(token), expiresAt)
+	stateArg := fmt.Sprintf("$%d", len(args)-2)
+	tokenArg := fmt.Sprintf("$%d", len(args)-1)
+	expiresArg := fmt.Sprintf("$%d", len(args))
+
+	query := fmt.Sprintf(`
+		WITH candidates AS (
+			SELECT id FROM tasks WHERE %s
+			ORDER BY priority DESC, created_at ASC%s
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE tasks SET state = %s, lease_token = %s, lease_expires_at = %s
+		WHERE id IN (SELECT id FROM candidates)
+		RETURNING id, type, payload, priority, state, created_at, started_at, completed_at,
+		          retries, max_retries, error, result, metadata, retention_ns, lease_duration_ns, lease_expires_at,
+		          lease_token, tenant_id, tag
+	`, strings.Join(conditions, " AND "), limit, stateArg, tokenArg, expiresArg)
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to lease tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []*queue.Task
+	for rows.Next() {
+		task, err := scanTaskRow(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+	if len(tasks) == 0 {
+		return nil, "", nil
+	}
+
+	return tasks, token, nil
+}
+
+func extendPostgresLease(ctx context.Context, q pgExecer, token LeaseToken, extra time.Duration) error {
+	if token == "" {
+		return ErrLeaseNotFound
+	}
+	res, err := q.ExecContext(ctx, `
+		UPDATE tasks SET lease_expires_at = $1 WHERE lease_token = $2 AND state = $3
+	`, time.Now().Add(extra), string(token), queue.StateProcessing)
+	if err != nil {
+		return fmt.Errorf("failed to extend lease: %w", err)
+	}
+	return requireLeaseRowsAffected(res)
+}
+
+func ackPostgresLease(ctx context.Context, q pgExecer, token LeaseToken) error {
+	if token == "" {
+		return ErrLeaseNotFound
+	}
+	res, err := q.ExecContext(ctx, `
+		UPDATE tasks SET state = $1, completed_at = $2, lease_token = '', lease_expires_at = NULL
+		WHERE lease_token = $3 AND state = $4
+	`, queue.StateCompleted, time.Now(), string(token), queue.StateProcessing)
+	if err != nil {
+		return fmt.Errorf("failed to ack lease: %w", err)
+	}
+	return requireLeaseRowsAffected(res)
+}
+
+func nackPostgresLease(ctx context.Context, q pgExecer, token LeaseToken, retryAfter time.Duration) error {
+	if token == "" {
+		return ErrLeaseNotFound
+	}
+	res, err := q.ExecContext(ctx, `
+		UPDATE tasks SET state = $1, retries = retries + 1, lease_token = '', lease_expires_at = $2
+		WHERE lease_token = $3 AND state = $4
+	`, queue.StatePending, time.Now().Add(retryAfter), string(token), queue.StateProcessing)
+	if err != nil {
+		return fmt.Errorf("failed to nack lease: %w", err)
+	}
+	return requireLeaseRowsAffected(res)
+}
+
+// LeaseTasks atomically selects and claims up to req.Max pending tasks.
+func (s *PostgresStorage) LeaseTasks(ctx context.Context, req LeaseRequest) ([]*queue.Task, LeaseToken, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer tx.Rollback()
+
+	tasks, token, err := leasePostgresTasks(ctx, tx, req)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, "", err
+	}
+	return tasks, token, nil
+}
+
+// ExtendLease pushes every task leased under token forward by extra.
+func (s *PostgresStorage) ExtendLease(ctx context.Context, token LeaseToken, extra time.Duration) error {
+	return extendPostgresLease(ctx, s.db, token, extra)
+}
+
+// AckLease marks every task leased under token completed.
+func (s *PostgresStorage) AckLease(ctx context.Context, token LeaseToken) error {
+	return ackPostgresLease(ctx, s.db, token)
+}
+
+// NackLease returns every task leased under token to pending.
+func (s *PostgresStorage) NackLease(ctx context.Context, token LeaseToken, retryAfter time.Duration) error {
+	return nackPostgresLease(ctx, s.db, token, retryAfter)
+}
+
+// Close closes the database connection, first stopping the retention and
+// lease sweepers (if running).
+func (s *PostgresStorage) Close() error {
+	if s.stopSweep != nil {
+		close(s.stopSweep)
+		<-s.sweepDone
+	}
+	if s.stopLeaseSweep != nil {
+		close(s.stopLeaseSweep)
+		<-s.leaseSweepDone
+	}
+	return s.db.Close()
+}
+
+// postgresResultWriter is PostgresStorage's equivalent of
+// sqliteResultWriter.
+type postgresResultWriter struct {
+	execer pgExecer
+	taskID string
+	seq    int
+}
+
+func (w *postgresResultWriter) Write(p []byte) (int, error) {
+	w.seq++
+	if _, err := w.execer.ExecContext(context.Background(), `
+		INSERT INTO task_results (task_id, seq, data) VALUES ($1, $2, $3)
+	`, w.taskID, w.seq, p); err != nil {
+		return 0, fmt.Errorf("failed to write result chunk: %w", err)
+	}
+	return len(p), nil
+}
+
+// ResultWriter returns a writer that appends result chunks for taskID to
+// the task_results table.
+func (s *PostgresStorage) ResultWriter(taskID string) io.Writer {
+	return &postgresResultWriter{execer: s.db, taskID: taskID}
+}
+
+// DB returns the underlying *sql.DB.
+func (s *PostgresStorage) DB() *sql.DB {
+	return s.db
+}
+
+// Ping checks database health.
+func (s *PostgresStorage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Begin starts a transaction.
+func (s *PostgresStorage) Begin(ctx context.Context) (Transaction, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &PostgresTransaction{tx: tx, storage: s}, nil
+}
+
+// PostgresTransaction implements Transaction, delegating to
+// PostgresStorage exactly as SQLiteTransaction delegates to
+// SQLiteStorage.
+type PostgresTransaction struct {
+	tx      *sql.Tx
+	storage *PostgresStorage
+}
+
+// SaveTask saves a task in the transaction.
+func (t *PostgresTransaction) SaveTask(ctx context.Context, task *queue.Task) error {
+	payload, _ := json.Marshal(task.Payload)
+	metadata, _ := json.Marshal(task.Metadata)
+
+	_, err := t.tx.ExecContext(ctx, `
+		INSERT INTO tasks (id, type, payload, priority, state, created_at, retries, max_retries, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`,
+		task.ID,
+		task.Type,
+		string(payload),
+		task.Priority,
+		task.State,
+		task.CreatedAt,
+		task.Retries,
+		task.MaxRetries,
+		string(metadata),
+	)
+	return err
+}
+
+func (t *PostgresTransaction) GetTask(ctx context.Context, id string) (*queue.Task, error) {
+	return t.storage.GetTask(ctx, id)
+}
+
+func (t *PostgresTransaction) UpdateTask(ctx context.Context, task *queue.Task) error {
+	return t.storage.UpdateTask(ctx, task)
+}
+
+func (t *PostgresTransaction) DeleteTask(ctx context.Context, id string) error {
+	_, err := t.tx.ExecContext(ctx, "DELETE FROM tasks WHERE id = $1", id)
+	return err
+}
+
+func (t *PostgresTransaction) ListTasks(ctx context.Context, filter TaskFilter) ([]*queue.Task, error) {
+	return t.storage.ListTasks(ctx, filter)
+}
+
+func (t *PostgresTransaction) SaveTasks(ctx context.Context, tasks []*queue.Task) error {
+	for _, task := range tasks {
+		if err := t.SaveTask(ctx, task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *PostgresTransaction) DeleteTasks(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		if err := t.DeleteTask(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *PostgresTransaction) CountTasks(ctx context.Context, filter TaskFilter) (int, error) {
+	return t.storage.CountTasks(ctx, filter)
+}
+
+func (t *PostgresTransaction) GetTasksByState(ctx context.Context, state queue.TaskState) ([]*queue.Task, error) {
+	return t.storage.GetTasksByState(ctx, state)
+}
+
+func (t *PostgresTransaction) GetStaleTasks(ctx context.Context, olderThan time.Duration) ([]*queue.Task, error) {
+	return t.storage.GetStaleTasks(ctx, olderThan)
+}
+
+func (t *PostgresTransaction) GetCompletedTask(ctx context.Context, id string) (*queue.Task, error) {
+	return t.storage.GetCompletedTask(ctx, id)
+}
+
+func (t *PostgresTransaction) AcquireUniqueLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return t.storage.AcquireUniqueLock(ctx, key, ttl)
+}
+
+func (t *PostgresTransaction) ReleaseUniqueLock(ctx context.Context, key string) error {
+	return t.storage.ReleaseUniqueLock(ctx, key)
+}
+
+func (t *PostgresTransaction) Heartbeat(ctx context.Context, id string) error {
+	return t.storage.Heartbeat(ctx, id)
+}
+
+// LeaseTasks leases within this transaction, so the claim commits
+// atomically alongside other writes.
+func (t *PostgresTransaction) LeaseTasks(ctx context.Context, req LeaseRequest) ([]*queue.Task, LeaseToken, error) {
+	return leasePostgresTasks(ctx, t.tx, req)
+}
+
+func (t *PostgresTransaction) ExtendLease(ctx context.Context, token LeaseToken, extra time.Duration) error {
+	return extendPostgresLease(ctx, t.tx, token, extra)
+}
+
+func (t *PostgresTransaction) AckLease(ctx context.Context, token LeaseToken) error {
+	return ackPostgresLease(ctx, t.tx, token)
+}
+
+func (t *PostgresTransaction) NackLease(ctx context.Context, token LeaseToken, retryAfter time.Duration) error {
+	return nackPostgresLease(ctx, t.tx, token, retryAfter)
+}
+
+// ResultWriter returns a writer that appends result chunks for taskID to
+// the task_results table within this transaction.
+func (t *PostgresTransaction) ResultWriter(taskID string) io.Writer {
+	return &postgresResultWriter{execer: t.tx, taskID: taskID}
+}
+
+func (t *PostgresTransaction) Close() error {
+	return nil
+}
+
+func (t *PostgresTransaction) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (t *PostgresTransaction) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *PostgresTransaction) Rollback() error {
+	return t.tx.Rollback()
+}
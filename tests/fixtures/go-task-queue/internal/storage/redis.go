@@ -0,0 +1,1015 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/example/task-queue/internal/queue"
+)
+
+// Redis key layout, mirroring queue.SQLiteQueue's visibility-timeout model
+// but expressed as per-state containers instead of a single table with a
+// "state" column:
+//
+//	queue:{name}:t:<id>      hash: msg, state, timeout, deadline, result, retention
+//	queue:{name}:pending     list, FIFO (RPOPLPUSH'd onto active by Dequeue)
+//	queue:{name}:active      list, in-flight tasks claimed by a worker
+//	queue:{name}:scheduled   zset, scored by the Unix time the task becomes due
+//	queue:{name}:retry       zset, scored by the Unix time of the next retry
+//	queue:{name}:completed   list, most-recently-completed first
+//	queue:{name}:archived    list, dead-lettered tasks that exhausted retries
+//	queue:{name}:unique:<k>  string, SET NX PX dedup lock (see AcquireUniqueLock)
+//	queues                   set of registered queue names
+//
+// A scheduled task is stored with state "pending" but lives in the
+// scheduled zset rather than the pending list until its score elapses;
+// this mirrors queue.Task.ScheduledAt gating dequeue rather than being a
+// TaskState of its own.
+const (
+	containerList = "list"
+	containerZSet = "zset"
+	containerNone = "none"
+)
+
+// container names a Redis key and the data structure (list or zset) IDs
+// are stored in at that key.
+type container struct {
+	key  string
+	kind string
+}
+
+// RedisStorage implements Storage (and TransactionalStorage) against a
+// single named queue's keyspace in Redis. All state transitions are run as
+// Lua scripts that read the current state, verify the task is actually in
+// the container that state implies, and move it to the new container in
+// one round trip — two workers racing to, say, mark the same task both
+// completed and failed will have one call observe the mismatch and fail
+// instead of silently losing the task from every container.
+type RedisStorage struct {
+	client *redis.Client
+	name   string
+
+	// VisibilityTimeout bounds how long a dequeued task may stay in the
+	// active container before a reaper would need to consider it stuck.
+	// RedisStorage itself doesn't run a reaper; Dequeue only sets the
+	// "deadline" hash field so one built on top of it has something to
+	// compare against.
+	VisibilityTimeout time.Duration
+
+	transitionScript *redis.Script
+	forwardDueScript *redis.Script
+	dequeueScript    *redis.Script
+}
+
+// NewRedisStorage returns a RedisStorage scoped to the queue named name,
+// using client for all Redis operations.
+func NewRedisStorage(client *redis.Client, name string) *RedisStorage {
+	return &RedisStorage{
+		client:            client,
+		name:              name,
+		VisibilityTimeout: 30 * time.Second,
+		transitionScript:  redis.NewScript(transitionScriptSrc),
+		forwardDueScript:  redis.NewScript(forwardDueScriptSrc),
+		dequeueScript:     redis.NewScript(dequeueScriptSrc),
+	}
+}
+
+func (s *RedisStorage) taskKey(id string) string  { return fmt.Sprintf("queue:%s:t:%s", s.name, id) }
+func (s *RedisStorage) pendingKey() string        { return fmt.Sprintf("queue:%s:pending", s.name) }
+func (s *RedisStorage) activeKey() string         { return fmt.Sprintf("queue:%s:active", s.name) }
+func (s *RedisStorage) scheduledKey() string      { return fmt.Sprintf("queue:%s:scheduled", s.name) }
+func (s *RedisStorage) retryKey() string          { return fmt.Sprintf("queue:%s:retry", s.name) }
+func (s *RedisStorage) completedKey() string      { return fmt.Sprintf("queue:%s:completed", s.name) }
+func (s *RedisStorage) archivedKey() string       { return fmt.Sprintf("queue:%s:archived", s.name) }
+func (s *RedisStorage) queuesKey() string         { return "queues" }
+func (s *RedisStorage) uniqueLockKey(key string) string {
+	return fmt.Sprintf("queue:%s:unique:%s", s.name, key)
+}
+
+// containerFor returns the container holding IDs in the given state. It
+// doesn't distinguish pending-and-due from pending-and-scheduled; callers
+// that need the scheduled container (SaveTask, UpdateTask) compute it
+// directly since that's a function of ScheduledAt, not State alone.
+func (s *RedisStorage) containerFor(state queue.TaskState) container {
+	switch state {
+	case queue.StatePending:
+		return container{s.pendingKey(), containerList}
+	case queue.StateProcessing:
+		return container{s.activeKey(), containerList}
+	case queue.StateRetrying:
+		return container{s.retryKey(), containerZSet}
+	case queue.StateCompleted:
+		return container{s.completedKey(), containerList}
+	case queue.StateFailed, queue.StateCancelled:
+		return container{s.archivedKey(), containerList}
+	default:
+		return container{}
+	}
+}
+
+// transitionScriptSrc atomically moves a task between containers and
+// updates its hash fields, failing the whole round trip if the task isn't
+// in the expected source container. Passing "none" for the source or
+// destination kind performs a plain HSET with no container move, which
+// SaveTask uses for a brand-new task (no source container yet) and
+// UpdateTask uses when the task's container isn't changing.
+const transitionScriptSrc = `
+local taskKey = KEYS[1]
+local fromKey = KEYS[2]
+local toKey = KEYS[3]
+
+local id = ARGV[1]
+local fromKind = ARGV[2]
+local toKind = ARGV[3]
+local score = ARGV[4]
+
+if fromKind == "list" then
+	if redis.call("LREM", fromKey, 1, id) == 0 then
+		return redis.error_reply("task not in expected source container")
+	end
+elseif fromKind == "zset" then
+	if redis.call("ZREM", fromKey, id) == 0 then
+		return redis.error_reply("task not in expected source container")
+	end
+end
+
+if toKind == "list" then
+	redis.call("LPUSH", toKey, id)
+elseif toKind == "zset" then
+	redis.call("ZADD", toKey, score, id)
+end
+
+local hsetArgs = {taskKey}
+for i = 5, #ARGV do
+	table.insert(hsetArgs, ARGV[i])
+end
+if #hsetArgs > 1 then
+	redis.call("HSET", unpack(hsetArgs))
+end
+
+return 1
+`
+
+// forwardDueScriptSrc moves every member of a score-ordered container
+// (scheduled or retry) whose score has elapsed onto the pending list,
+// setting its state to pending. It's the Redis analogue of
+// queue.SQLiteQueue's reaper sweep, driven by score instead of a deadline
+// column scan.
+const forwardDueScriptSrc = `
+local dueKey = KEYS[1]
+local pendingKey = KEYS[2]
+local taskKeyPrefix = ARGV[1]
+local now = ARGV[2]
+
+local ids = redis.call("ZRANGEBYSCORE", dueKey, "-inf", now)
+for _, id in ipairs(ids) do
+	redis.call("ZREM", dueKey, id)
+	redis.call("LPUSH", pendingKey, id)
+	redis.call("HSET", taskKeyPrefix .. id, "state", "pending")
+end
+
+return #ids
+`
+
+// dequeueScriptSrc claims the oldest pending task in a single round trip:
+// RPOPLPUSH already does the container move atomically, so unlike
+// transitionScriptSrc this doesn't need a separate membership check —
+// there's no way to pop an ID that wasn't actually in the list.
+const dequeueScriptSrc = `
+local pendingKey = KEYS[1]
+local activeKey = KEYS[2]
+local taskKeyPrefix = ARGV[1]
+local deadline = ARGV[2]
+
+local id = redis.call("RPOPLPUSH", pendingKey, activeKey)
+if not id then
+	return false
+end
+
+redis.call("HSET", taskKeyPrefix .. id, "state", "processing", "deadline", deadline)
+return id
+`
+
+// hash renders task as the field/value pairs stored in its hash, excluding
+// "state" and "deadline", which transition's callers set explicitly since
+// they depend on which transition is being made rather than on the task
+// alone.
+func (s *RedisStorage) hash(task *queue.Task) ([]interface{}, error) {
+	msg, err := json.Marshal(task)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal task: %w", err)
+	}
+	result, err := json.Marshal(task.Result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return []interface{}{
+		"msg", string(msg),
+		"timeout", int64(s.VisibilityTimeout / time.Second),
+		"result", string(result),
+		"retention", int64(task.Retention),
+	}, nil
+}
+
+// transition runs transitionScriptSrc moving id from "from" to "to",
+// stamping extra onto the task's hash alongside "state": newState.
+func (s *RedisStorage) transition(ctx context.Context, id string, from, to container, newState queue.TaskState, score float64, extra []interface{}) error {
+	fromKind, toKind := containerNone, containerNone
+	if from.kind != "" {
+		fromKind = from.kind
+	}
+	if to.kind != "" {
+		toKind = to.kind
+	}
+
+	args := []interface{}{id, fromKind, toKind, score, "state", string(newState)}
+	args = append(args, extra...)
+
+	return s.transitionScript.Run(ctx, s.client, []string{s.taskKey(id), from.key, to.key}, args...).Err()
+}
+
+// destinationFor picks the container a task belongs in given its state and
+// (for pending tasks) whether ScheduledAt is still in the future.
+func (s *RedisStorage) destinationFor(task *queue.Task) (container, float64) {
+	if task.State == queue.StatePending && task.ScheduledAt != nil && task.ScheduledAt.After(time.Now()) {
+		return container{s.scheduledKey(), containerZSet}, float64(task.ScheduledAt.Unix())
+	}
+	return s.containerFor(task.State), 0
+}
+
+// SaveTask creates task in the container its state (and, for pending
+// tasks, ScheduledAt) implies.
+func (s *RedisStorage) SaveTask(ctx context.Context, task *queue.Task) error {
+	if task.State == "" {
+		task.State = queue.StatePending
+	}
+
+	extra, err := s.hash(task)
+	if err != nil {
+		return err
+	}
+	to, score := s.destinationFor(task)
+
+	if err := s.transition(ctx, task.ID, container{}, to, task.State, score, extra); err != nil {
+		return fmt.Errorf("failed to save task: %w", err)
+	}
+	return s.client.SAdd(ctx, s.queuesKey(), s.name).Err()
+}
+
+// GetTask retrieves a task by ID, decoding it from the "msg" hash field.
+func (s *RedisStorage) GetTask(ctx context.Context, id string) (*queue.Task, error) {
+	fields, err := s.client.HGetAll(ctx, s.taskKey(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, ErrNotFound
+	}
+
+	var task queue.Task
+	if err := json.Unmarshal([]byte(fields["msg"]), &task); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal task: %w", err)
+	}
+	if state, ok := fields["state"]; ok {
+		task.State = queue.TaskState(state)
+	}
+	return &task, nil
+}
+
+// GetCompletedTask retrieves a completed task by ID if it's still within
+// its retention window.
+func (s *RedisStorage) GetCompletedTask(ctx context.Context, id string) (*queue.Task, error) {
+	task, err := s.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if task.State != queue.StateCompleted || task.CompletedAt == nil {
+		return nil, ErrNotFound
+	}
+	if time.Now().After(task.CompletedAt.Add(task.RetentionOrDefault())) {
+		return nil, ErrNotFound
+	}
+	return task, nil
+}
+
+// UpdateTask persists task's fields and, if its container would change
+// (its State or, for a pending task, its ScheduledAt due-ness), moves it
+// there in the same round trip.
+func (s *RedisStorage) UpdateTask(ctx context.Context, task *queue.Task) error {
+	current, err := s.GetTask(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+
+	extra, err := s.hash(task)
+	if err != nil {
+		return err
+	}
+
+	currentContainer, _ := s.destinationFor(current)
+	to, score := s.destinationFor(task)
+
+	from := container{}
+	if currentContainer != to {
+		from = currentContainer
+	}
+
+	if err := s.transition(ctx, task.ID, from, to, task.State, score, extra); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+	return nil
+}
+
+// DeleteTask removes a task's hash and its entry in whichever container it
+// currently occupies.
+func (s *RedisStorage) DeleteTask(ctx context.Context, id string) error {
+	task, err := s.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	c, _ := s.destinationFor(task)
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, s.taskKey(id))
+	switch c.kind {
+	case containerList:
+		pipe.LRem(ctx, c.key, 1, id)
+	case containerZSet:
+		pipe.ZRem(ctx, c.key, id)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+	return nil
+}
+
+// ListTasks lists tasks matching filter by scanning the container implied
+// by filter.State (or every known container if filter.State is empty).
+func (s *RedisStorage) ListTasks(ctx context.Context, filter TaskFilter) ([]*queue.Task, error) {
+	containers := []container{
+		{s.pendingKey(), containerList},
+		{s.activeKey(), containerList},
+		{s.scheduledKey(), containerZSet},
+		{s.retryKey(), containerZSet},
+		{s.completedKey(), containerList},
+		{s.archivedKey(), containerList},
+	}
+	if filter.State != "" {
+		containers = nil
+		if filter.State == queue.StatePending {
+			// Pending tasks whose ScheduledAt hasn't arrived yet live in
+			// the scheduled zset rather than the pending list.
+			containers = append(containers, container{s.scheduledKey(), containerZSet})
+		}
+		if c := s.containerFor(filter.State); c.kind != "" {
+			containers = append(containers, c)
+		}
+	}
+
+	var ids []string
+	seen := make(map[string]bool)
+	for _, c := range containers {
+		var members []string
+		var err error
+		switch c.kind {
+		case containerList:
+			members, err = s.client.LRange(ctx, c.key, 0, -1).Result()
+		case containerZSet:
+			members, err = s.client.ZRange(ctx, c.key, 0, -1).Result()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", c.key, err)
+		}
+		for _, id := range members {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	if filter.Offset > 0 && filter.Offset < len(ids) {
+		ids = ids[filter.Offset:]
+	}
+	if filter.Limit > 0 && filter.Limit < len(ids) {
+		ids = ids[:filter.Limit]
+	}
+
+	tasks := make([]*queue.Task, 0, len(ids))
+	for _, id := range ids {
+		task, err := s.GetTask(ctx, id)
+		if err != nil {
+			if err == ErrNotFound {
+				continue
+			}
+			return nil, err
+		}
+		if filter.Type != "" && task.Type != filter.Type {
+			continue
+		}
+		if filter.State != "" && task.State != filter.State {
+			continue
+		}
+		if filter.TenantID != "" && task.TenantID != filter.TenantID {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// SaveTasks saves multiple tasks. Each is its own atomic transition; a
+// failure partway through leaves earlier tasks saved, matching
+// InMemoryStorage's and SQLiteStorage's non-transactional bulk semantics.
+func (s *RedisStorage) SaveTasks(ctx context.Context, tasks []*queue.Task) error {
+	for _, task := range tasks {
+		if err := s.SaveTask(ctx, task); err != nil {
+			return fmt.Errorf("failed to save task %s: %w", task.ID, err)
+		}
+	}
+	return nil
+}
+
+// DeleteTasks deletes multiple tasks by ID.
+func (s *RedisStorage) DeleteTasks(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		if err := s.DeleteTask(ctx, id); err != nil && err != ErrNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountTasks counts tasks matching filter.
+func (s *RedisStorage) CountTasks(ctx context.Context, filter TaskFilter) (int, error) {
+	tasks, err := s.ListTasks(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return len(tasks), nil
+}
+
+// GetTasksByState gets all tasks in a specific state.
+func (s *RedisStorage) GetTasksByState(ctx context.Context, state queue.TaskState) ([]*queue.Task, error) {
+	return s.ListTasks(ctx, TaskFilter{State: state})
+}
+
+// GetStaleTasks gets tasks created before olderThan.
+func (s *RedisStorage) GetStaleTasks(ctx context.Context, olderThan time.Duration) ([]*queue.Task, error) {
+	threshold := time.Now().Add(-olderThan)
+	all, err := s.ListTasks(ctx, TaskFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	stale := make([]*queue.Task, 0, len(all))
+	for _, task := range all {
+		if task.CreatedAt.Before(threshold) {
+			stale = append(stale, task)
+		}
+	}
+	return stale, nil
+}
+
+// Dequeue atomically claims the oldest pending task, moving it to the
+// active container and stamping its visibility deadline.
+func (s *RedisStorage) Dequeue(ctx context.Context) (*queue.Task, error) {
+	deadline := time.Now().Add(s.VisibilityTimeout)
+	res, err := s.dequeueScript.Run(ctx, s.client, []string{s.pendingKey(), s.activeKey()}, s.taskKey(""), deadline.Unix()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to dequeue task: %w", err)
+	}
+	if res == nil {
+		return nil, queue.ErrQueueEmpty
+	}
+
+	id, _ := res.(string)
+	return s.GetTask(ctx, id)
+}
+
+// MarkCompleted moves an active task to the completed container, storing
+// result and completedAt.
+func (s *RedisStorage) MarkCompleted(ctx context.Context, id string, result interface{}, completedAt time.Time) error {
+	task, err := s.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+	task.Result = result
+	task.CompletedAt = &completedAt
+	task.State = queue.StateCompleted
+
+	extra, err := s.hash(task)
+	if err != nil {
+		return err
+	}
+	from := container{s.activeKey(), containerList}
+	to := container{s.completedKey(), containerList}
+	if err := s.transition(ctx, id, from, to, queue.StateCompleted, 0, extra); err != nil {
+		return fmt.Errorf("failed to mark task completed: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed moves an active task to the retry container with
+// nextRetryAt as its due score, or to the archived (dead-letter) container
+// if retries has reached task.MaxRetries.
+func (s *RedisStorage) MarkFailed(ctx context.Context, id string, taskErr string, nextRetryAt time.Time) error {
+	task, err := s.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+	task.Error = taskErr
+	task.Retries++
+
+	to := container{s.retryKey(), containerZSet}
+	newState := queue.StateRetrying
+	score := float64(nextRetryAt.Unix())
+	if task.Retries >= task.MaxRetries {
+		to = container{s.archivedKey(), containerList}
+		newState = queue.StateFailed
+		score = 0
+	}
+	task.State = newState
+
+	extra, err := s.hash(task)
+	if err != nil {
+		return err
+	}
+	from := container{s.activeKey(), containerList}
+	if err := s.transition(ctx, id, from, to, newState, score, extra); err != nil {
+		return fmt.Errorf("failed to mark task failed: %w", err)
+	}
+	return nil
+}
+
+// Retry forwards every task in the retry container whose due score has
+// elapsed back onto the pending list. It returns the number forwarded.
+func (s *RedisStorage) Retry(ctx context.Context) (int, error) {
+	return s.forwardDue(ctx, s.retryKey())
+}
+
+// ForwardScheduled forwards every scheduled task whose due score has
+// elapsed back onto the pending list. It returns the number forwarded.
+func (s *RedisStorage) ForwardScheduled(ctx context.Context) (int, error) {
+	return s.forwardDue(ctx, s.scheduledKey())
+}
+
+func (s *RedisStorage) forwardDue(ctx context.Context, dueKey string) (int, error) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	n, err := s.forwardDueScript.Run(ctx, s.client, []string{dueKey, s.pendingKey()}, s.taskKey(""), now).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to forward due tasks: %w", err)
+	}
+	return n, nil
+}
+
+// AcquireUniqueLock acquires the dedup lock for key using SET NX PX, the
+// same idiom asynq and similar Redis-backed queues use for distributed
+// locks: the SET only succeeds if the key doesn't already exist, and the
+// TTL reclaims it automatically if it's never released.
+func (s *RedisStorage) AcquireUniqueLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ok, err := s.client.SetNX(ctx, s.uniqueLockKey(key), 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire unique lock: %w", err)
+	}
+	return ok, nil
+}
+
+// ReleaseUniqueLock releases the dedup lock for key.
+func (s *RedisStorage) ReleaseUniqueLock(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.uniqueLockKey(key)).Err()
+}
+
+// Heartbeat extends a StateProcessing task's lease to now plus its
+// LeaseDurationOrDefault(), persisted by rewriting the task's "msg" hash
+// field (LeaseExpiresAt rides along with the rest of the task's JSON, so no
+// separate hash field or container move is needed).
+func (s *RedisStorage) Heartbeat(ctx context.Context, id string) error {
+	task, err := s.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+	if task.State != queue.StateProcessing {
+		return queue.ErrTaskNotProcessing
+	}
+
+	exp := time.Now().Add(task.LeaseDurationOrDefault())
+	task.LeaseExpiresAt = &exp
+
+	msg, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+	if err := s.client.HSet(ctx, s.taskKey(id), "msg", string(msg)).Err(); err != nil {
+		return fmt.Errorf("failed to heartbeat task: %w", err)
+	}
+	return nil
+}
+
+// activeTasksByToken returns every task in the active container whose
+// LeaseToken matches token, the same "scan container, filter in Go" idiom
+// ListTasks and GetStaleTasks use -- RedisStorage has no secondary index
+// by lease token.
+func (s *RedisStorage) activeTasksByToken(ctx context.Context, token LeaseToken) ([]*queue.Task, error) {
+	ids, err := s.client.LRange(ctx, s.activeKey(), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active tasks: %w", err)
+	}
+
+	var matched []*queue.Task
+	for _, id := range ids {
+		task, err := s.GetTask(ctx, id)
+		if err != nil {
+			if err == ErrNotFound {
+				continue
+			}
+			return nil, err
+		}
+		if task.LeaseToken == string(token) {
+			matched = append(matched, task)
+		}
+	}
+	return matched, nil
+}
+
+// LeaseTasks selects up to req.Max pending tasks matching
+// req.Type/req.Tag/req.Priority and eligible for lease (LeaseExpiresAt
+// unset or already past, see NackLease), claiming each with its own
+// atomic transition the same way Dequeue claims one -- matching
+// SaveTasks/DeleteTasks' per-item-loop bulk semantics rather than one
+// script covering the whole batch. A task another caller claims first is
+// skipped rather than failing the whole lease.
+func (s *RedisStorage) LeaseTasks(ctx context.Context, req LeaseRequest) ([]*queue.Task, LeaseToken, error) {
+	ids, err := s.client.LRange(ctx, s.pendingKey(), 0, -1).Result()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list pending tasks: %w", err)
+	}
+
+	leaseFor := req.LeaseFor
+	if leaseFor <= 0 {
+		leaseFor = queue.DefaultLeaseDuration
+	}
+	token := newLeaseToken()
+	now := time.Now()
+	expiresAt := now.Add(leaseFor)
+
+	var leased []*queue.Task
+	for _, id := range ids {
+		if req.Max > 0 && len(leased) >= req.Max {
+			break
+		}
+
+		task, err := s.GetTask(ctx, id)
+		if err != nil {
+			if err == ErrNotFound {
+				continue
+			}
+			return leased, token, err
+		}
+		if task.State != queue.StatePending {
+			continue
+		}
+		if task.LeaseExpiresAt != nil && task.LeaseExpiresAt.After(now) {
+			continue
+		}
+		if req.Type != "" && task.Type != req.Type {
+			continue
+		}
+		if req.Tag != "" && task.Tag != req.Tag {
+			continue
+		}
+		if req.Priority != 0 && task.Priority != req.Priority {
+			continue
+		}
+
+		task.State = queue.StateProcessing
+		task.LeaseToken = string(token)
+		task.LeaseExpiresAt = &expiresAt
+
+		extra, err := s.hash(task)
+		if err != nil {
+			return leased, token, err
+		}
+		from := container{s.pendingKey(), containerList}
+		to := container{s.activeKey(), containerList}
+		if err := s.transition(ctx, id, from, to, queue.StateProcessing, 0, extra); err != nil {
+			continue
+		}
+		leased = append(leased, task)
+	}
+
+	if len(leased) == 0 {
+		return nil, "", nil
+	}
+	return leased, token, nil
+}
+
+// ExtendLease pushes every task leased under token forward by extra.
+func (s *RedisStorage) ExtendLease(ctx context.Context, token LeaseToken, extra time.Duration) error {
+	if token == "" {
+		return ErrLeaseNotFound
+	}
+	tasks, err := s.activeTasksByToken(ctx, token)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return ErrLeaseNotFound
+	}
+
+	exp := time.Now().Add(extra)
+	for _, task := range tasks {
+		task.LeaseExpiresAt = &exp
+		msg, err := json.Marshal(task)
+		if err != nil {
+			return fmt.Errorf("failed to marshal task: %w", err)
+		}
+		if err := s.client.HSet(ctx, s.taskKey(task.ID), "msg", string(msg)).Err(); err != nil {
+			return fmt.Errorf("failed to extend lease: %w", err)
+		}
+	}
+	return nil
+}
+
+// AckLease marks every task leased under token completed, releasing the
+// lease.
+func (s *RedisStorage) AckLease(ctx context.Context, token LeaseToken) error {
+	if token == "" {
+		return ErrLeaseNotFound
+	}
+	tasks, err := s.activeTasksByToken(ctx, token)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return ErrLeaseNotFound
+	}
+
+	now := time.Now()
+	for _, task := range tasks {
+		task.State = queue.StateCompleted
+		task.CompletedAt = &now
+		task.LeaseToken = ""
+		task.LeaseExpiresAt = nil
+
+		extra, err := s.hash(task)
+		if err != nil {
+			return err
+		}
+		from := container{s.activeKey(), containerList}
+		to := container{s.completedKey(), containerList}
+		if err := s.transition(ctx, task.ID, from, to, queue.StateCompleted, 0, extra); err != nil {
+			return fmt.Errorf("failed to ack lease: %w", err)
+		}
+	}
+	return nil
+}
+
+// NackLease returns every task leased under token to pending, bumps
+// Retries, and sets LeaseExpiresAt to retryAfter from now so LeaseTasks
+// skips it until then -- reusing LeaseExpiresAt as a "not eligible
+// before" marker instead of ScheduledAt, since a nacked task should
+// become leasable again rather than wait on a Dequeue-style due check.
+func (s *RedisStorage) NackLease(ctx context.Context, token LeaseToken, retryAfter time.Duration) error {
+	if token == "" {
+		return ErrLeaseNotFound
+	}
+	tasks, err := s.activeTasksByToken(ctx, token)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return ErrLeaseNotFound
+	}
+
+	notBefore := time.Now().Add(retryAfter)
+	for _, task := range tasks {
+		task.State = queue.StatePending
+		task.Retries++
+		task.LeaseToken = ""
+		task.LeaseExpiresAt = &notBefore
+
+		extra, err := s.hash(task)
+		if err != nil {
+			return err
+		}
+		from := container{s.activeKey(), containerList}
+		to := container{s.pendingKey(), containerList}
+		if err := s.transition(ctx, task.ID, from, to, queue.StatePending, 0, extra); err != nil {
+			return fmt.Errorf("failed to nack lease: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close is a no-op; RedisStorage doesn't own the *redis.Client's lifecycle.
+func (s *RedisStorage) Close() error {
+	return nil
+}
+
+// Ping checks Redis connectivity.
+func (s *RedisStorage) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// RedisMigrator manages the version of RedisStorage's key layout, stored in
+// a single string key so a rolling deploy can detect and refuse to run
+// against an incompatible layout instead of corrupting it.
+type RedisMigrator struct {
+	client  *redis.Client
+	name    string
+	version int
+}
+
+// NewRedisMigrator returns a RedisMigrator for the named queue's keyspace,
+// targeting version as the current layout version.
+func NewRedisMigrator(client *redis.Client, name string, version int) *RedisMigrator {
+	return &RedisMigrator{client: client, name: name, version: version}
+}
+
+func (m *RedisMigrator) versionKey() string {
+	return fmt.Sprintf("queue:%s:layout_version", m.name)
+}
+
+// Up records m.version as the keyspace's layout version if it isn't
+// already set to something newer.
+func (m *RedisMigrator) Up(ctx context.Context) error {
+	current, err := m.versionAt(ctx)
+	if err != nil {
+		return err
+	}
+	if current >= m.version {
+		return nil
+	}
+	return m.client.Set(ctx, m.versionKey(), m.version, 0).Err()
+}
+
+// Down has nothing to revert; the key layout has no destructive migration
+// path, so it just leaves the version key as-is.
+func (m *RedisMigrator) Down(ctx context.Context) error {
+	return nil
+}
+
+// Version returns the keyspace's current layout version, or 0 if it has
+// never been set (a brand-new queue).
+func (m *RedisMigrator) Version() (int, error) {
+	return m.versionAt(context.Background())
+}
+
+func (m *RedisMigrator) versionAt(ctx context.Context) (int, error) {
+	val, err := m.client.Get(ctx, m.versionKey()).Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read layout version: %w", err)
+	}
+	return strconv.Atoi(val)
+}
+
+// Begin starts a pipelined transaction. Redis has no multi-statement
+// rollback, so RedisTransaction buffers writes in a TxPipeline and only
+// sends them to the server on Commit; Rollback simply discards the
+// pipeline without sending anything.
+func (s *RedisStorage) Begin(ctx context.Context) (Transaction, error) {
+	return &RedisTransaction{storage: s, pipe: s.client.TxPipeline()}, nil
+}
+
+// RedisTransaction implements Transaction by delegating reads straight to
+// the underlying RedisStorage (so a transaction observes committed state,
+// not its own uncommitted writes) while queuing writes on a pipeline that
+// Commit flushes atomically. Unlike RedisStorage's own methods, queued
+// writes don't run the transition Lua scripts, so they skip the
+// source-container membership check; callers that need that guarantee
+// inside a transaction should use RedisStorage directly instead.
+type RedisTransaction struct {
+	storage *RedisStorage
+	pipe    redis.Pipeliner
+}
+
+func (t *RedisTransaction) SaveTask(ctx context.Context, task *queue.Task) error {
+	if task.State == "" {
+		task.State = queue.StatePending
+	}
+	extra, err := t.storage.hash(task)
+	if err != nil {
+		return err
+	}
+	to, _ := t.storage.destinationFor(task)
+
+	extra = append(extra, "state", string(task.State))
+	t.pipe.HSet(ctx, t.storage.taskKey(task.ID), extra...)
+	if to.kind == containerList {
+		t.pipe.LPush(ctx, to.key, task.ID)
+	}
+	return nil
+}
+
+func (t *RedisTransaction) GetTask(ctx context.Context, id string) (*queue.Task, error) {
+	return t.storage.GetTask(ctx, id)
+}
+
+func (t *RedisTransaction) UpdateTask(ctx context.Context, task *queue.Task) error {
+	extra, err := t.storage.hash(task)
+	if err != nil {
+		return err
+	}
+	extra = append(extra, "state", string(task.State))
+	t.pipe.HSet(ctx, t.storage.taskKey(task.ID), extra...)
+	return nil
+}
+
+func (t *RedisTransaction) DeleteTask(ctx context.Context, id string) error {
+	t.pipe.Del(ctx, t.storage.taskKey(id))
+	return nil
+}
+
+func (t *RedisTransaction) ListTasks(ctx context.Context, filter TaskFilter) ([]*queue.Task, error) {
+	return t.storage.ListTasks(ctx, filter)
+}
+
+func (t *RedisTransaction) SaveTasks(ctx context.Context, tasks []*queue.Task) error {
+	for _, task := range tasks {
+		if err := t.SaveTask(ctx, task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *RedisTransaction) DeleteTasks(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		if err := t.DeleteTask(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *RedisTransaction) CountTasks(ctx context.Context, filter TaskFilter) (int, error) {
+	return t.storage.CountTasks(ctx, filter)
+}
+
+func (t *RedisTransaction) GetTasksByState(ctx context.Context, state queue.TaskState) ([]*queue.Task, error) {
+	return t.storage.GetTasksByState(ctx, state)
+}
+
+func (t *RedisTransaction) GetStaleTasks(ctx context.Context, olderThan time.Duration) ([]*queue.Task, error) {
+	return t.storage.GetStaleTasks(ctx, olderThan)
+}
+
+func (t *RedisTransaction) GetCompletedTask(ctx context.Context, id string) (*queue.Task, error) {
+	return t.storage.GetCompletedTask(ctx, id)
+}
+
+func (t *RedisTransaction) AcquireUniqueLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return t.storage.AcquireUniqueLock(ctx, key, ttl)
+}
+
+func (t *RedisTransaction) ReleaseUniqueLock(ctx context.Context, key string) error {
+	return t.storage.ReleaseUniqueLock(ctx, key)
+}
+
+func (t *RedisTransaction) Heartbeat(ctx context.Context, id string) error {
+	return t.storage.Heartbeat(ctx, id)
+}
+
+func (t *RedisTransaction) LeaseTasks(ctx context.Context, req LeaseRequest) ([]*queue.Task, LeaseToken, error) {
+	return t.storage.LeaseTasks(ctx, req)
+}
+
+func (t *RedisTransaction) ExtendLease(ctx context.Context, token LeaseToken, extra time.Duration) error {
+	return t.storage.ExtendLease(ctx, token, extra)
+}
+
+func (t *RedisTransaction) AckLease(ctx context.Context, token LeaseToken) error {
+	return t.storage.AckLease(ctx, token)
+}
+
+func (t *RedisTransaction) NackLease(ctx context.Context, token LeaseToken, retryAfter time.Duration) error {
+	return t.storage.NackLease(ctx, token, retryAfter)
+}
+
+func (t *RedisTransaction) Close() error {
+	return nil
+}
+
+func (t *RedisTransaction) Ping(ctx context.Context) error {
+	return t.storage.Ping(ctx)
+}
+
+func (t *RedisTransaction) Commit() error {
+	_, err := t.pipe.Exec(context.Background())
+	return err
+}
+
+func (t *RedisTransaction) Rollback() error {
+	t.pipe.Discard()
+	return nil
+}
@@ -0,0 +1,49 @@
+package storage
+
+import "fmt"
+
+// placeholderFunc renders the nth (1-indexed) bind placeholder for a SQL
+// dialect -- "?" for SQLite, "$1"/"$2"/... for Postgres -- so
+// buildFilterConditions can produce a WHERE clause usable by both
+// SQLiteStorage and PostgresStorage instead of each hand-duplicating the
+// same condition list.
+type placeholderFunc func(n int) string
+
+func questionPlaceholder(n int) string { return "?" }
+
+func dollarPlaceholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+// buildFilterConditions turns a TaskFilter into a list of "col op
+// placeholder" conditions (ANDed together by the caller) and their bind
+// args, in a fixed field order shared by every backend's ListTasks and
+// CountTasks so a given filter selects the same rows regardless of which
+// Storage implementation runs it. Columns are qualified with "tasks." so
+// the conditions stay unambiguous when the caller joins in tasks_fts
+// (SQLiteStorage.SearchTasks), which has its own same-named columns.
+func buildFilterConditions(filter TaskFilter, ph placeholderFunc) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	add := func(column, op string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, "tasks."+column+" "+op+" "+ph(len(args)))
+	}
+
+	if filter.State != "" {
+		add("state", "=", filter.State)
+	}
+	if filter.Type != "" {
+		add("type", "=", filter.Type)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		add("created_at", ">", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		add("created_at", "<", filter.CreatedBefore)
+	}
+	if filter.TenantID != "" {
+		add("tenant_id", "=", filter.TenantID)
+	}
+
+	return conditions, args
+}
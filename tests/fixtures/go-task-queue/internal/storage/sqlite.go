@@ -1,10 +1,12 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -17,6 +19,19 @@ import (
 type SQLiteStorage struct {
 	db         *sql.DB
 	serializer Serializer
+
+	stopSweep chan struct{}
+	sweepDone chan struct{}
+
+	stopLeaseSweep chan struct{}
+	leaseSweepDone chan struct{}
+
+	// ftsAvailable is set by migrate once it's confirmed the tasks_fts
+	// virtual table exists -- false if go-sqlite3 wasn't built with the
+	// sqlite_fts5 tag, in which case SearchTasks and ListTasks/CountTasks
+	// with TaskFilter.Search set fail with a clear error instead of the
+	// raw "no such module: fts5" sqlite3 returns.
+	ftsAvailable bool
 }
 
 // SQLiteConfig holds SQLite configuration
@@ -25,19 +40,43 @@ type SQLiteConfig struct {
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
+
+	// RetentionSweepInterval controls how often a background goroutine
+	// scans for and deletes completed/failed tasks whose retention
+	// window (queue.Task.RetentionOrDefault, measured from CompletedAt)
+	// has elapsed, along with their task_results rows. Zero disables the
+	// sweeper, leaving GetCompletedTask's lazy expiry check as the only
+	// enforcement -- rows then accumulate until something else deletes
+	// them.
+	RetentionSweepInterval time.Duration
+
+	// LeaseSweepInterval controls how often a background goroutine
+	// reclaims tasks whose lease (see LeaseTasks, queue.Task.
+	// LeaseExpiresAt) has expired back to pending, bumping Retries so
+	// they're retried rather than silently reprocessed as if for the
+	// first time. Zero disables the sweeper -- a task whose worker
+	// crashed mid-lease then stays leased until something else notices.
+	LeaseSweepInterval time.Duration
 }
 
 // DefaultSQLiteConfig returns default SQLite configuration
 func DefaultSQLiteConfig() SQLiteConfig {
 	return SQLiteConfig{
-		Path:            "tasks.db",
-		MaxOpenConns:    10,
-		MaxIdleConns:    5,
-		ConnMaxLifetime: time.Hour,
+		Path:                   "tasks.db",
+		MaxOpenConns:           10,
+		MaxIdleConns:           5,
+		ConnMaxLifetime:        time.Hour,
+		RetentionSweepInterval: 5 * time.Minute,
+		LeaseSweepInterval:     15 * time.Second,
 	}
 }
 
-// NewSQLiteStorage creates a new SQLite storage
+// NewSQLiteStorage creates a new SQLite storage. Full-text search
+// (SearchTasks, TaskFilter.Search) needs go-sqlite3 built with
+// SQLite's FTS5 extension compiled in, which requires building this
+// binary with `-tags sqlite_fts5`; without that tag migrate leaves
+// ftsAvailable false and search calls fail with a clear error rather
+// than panicking or silently returning no results.
 func NewSQLiteStorage(cfg SQLiteConfig) (*SQLiteStorage, error) {
 	dsn := fmt.Sprintf("file:%s?cache=shared&mode=rwc", cfg.Path)
 
@@ -60,9 +99,106 @@ func NewSQLiteStorage(cfg SQLiteConfig) (*SQLiteStorage, error) {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	if cfg.RetentionSweepInterval > 0 {
+		s.stopSweep = make(chan struct{})
+		s.sweepDone = make(chan struct{})
+		go s.sweepRetention(cfg.RetentionSweepInterval)
+	}
+
+	if cfg.LeaseSweepInterval > 0 {
+		s.stopLeaseSweep = make(chan struct{})
+		s.leaseSweepDone = make(chan struct{})
+		go s.sweepLeases(cfg.LeaseSweepInterval)
+	}
+
 	return s, nil
 }
 
+// sweepRetention periodically calls sweepExpiredTasks until stopSweep is
+// closed (see Close).
+func (s *SQLiteStorage) sweepRetention(interval time.Duration) {
+	defer close(s.sweepDone)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopSweep:
+			return
+		case <-ticker.C:
+			s.sweepExpiredTasks(context.Background())
+		}
+	}
+}
+
+// sweepExpiredTasks deletes every completed or failed task whose
+// retention window has elapsed, along with its task_results and
+// task_logs rows, in one transaction so a swept task never leaves
+// orphaned result chunks or log lines behind. The expiry is evaluated in
+// SQL, since each row's retention (queue.Task.RetentionOrDefault) can
+// differ.
+func (s *SQLiteStorage) sweepExpiredTasks(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	const expired = `
+		completed_at IS NOT NULL
+		AND state IN (?, ?)
+		AND completed_at <= datetime('now', '-' || (CASE WHEN retention_ns > 0 THEN retention_ns ELSE ? END / 1000000000) || ' seconds')
+	`
+	args := []interface{}{queue.StateCompleted, queue.StateFailed, int64(queue.DefaultRetention)}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM task_results WHERE task_id IN (SELECT id FROM tasks WHERE "+expired+")", args...); err != nil {
+		return fmt.Errorf("failed to sweep task results: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM task_logs WHERE task_id IN (SELECT id FROM tasks WHERE "+expired+")", args...); err != nil {
+		return fmt.Errorf("failed to sweep task logs: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tasks WHERE "+expired, args...); err != nil {
+		return fmt.Errorf("failed to sweep tasks: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// sweepLeases periodically calls sweepExpiredLeases until stopLeaseSweep
+// is closed (see Close).
+func (s *SQLiteStorage) sweepLeases(interval time.Duration) {
+	defer close(s.leaseSweepDone)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopLeaseSweep:
+			return
+		case <-ticker.C:
+			s.sweepExpiredLeases(context.Background())
+		}
+	}
+}
+
+// sweepExpiredLeases reclaims every StateProcessing task whose
+// lease_expires_at has elapsed back to pending, clearing its lease_token
+// and bumping Retries -- LeaseTasks's crash-recovery path for a worker
+// that claimed a task and then died before AckLease/NackLease.
+func (s *SQLiteStorage) sweepExpiredLeases(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE tasks
+		SET state = ?, retries = retries + 1, lease_token = '', lease_expires_at = NULL
+		WHERE state = ? AND lease_expires_at IS NOT NULL AND lease_expires_at <= ?
+	`, queue.StatePending, queue.StateProcessing, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to sweep expired leases: %w", err)
+	}
+	return nil
+}
+
 // migrate runs database migrations
 func (s *SQLiteStorage) migrate() error {
 	schema := `
@@ -79,17 +215,107 @@ func (s *SQLiteStorage) migrate() error {
 			max_retries INTEGER DEFAULT 3,
 			error TEXT,
 			result TEXT,
-			metadata TEXT
+			metadata TEXT,
+			retention_ns INTEGER DEFAULT 0,
+			lease_duration_ns INTEGER DEFAULT 0,
+			lease_expires_at DATETIME,
+			lease_token TEXT DEFAULT '',
+			tenant_id TEXT DEFAULT '',
+			tag TEXT DEFAULT '',
+			paused INTEGER NOT NULL DEFAULT 0
 		);
 
 		CREATE INDEX IF NOT EXISTS idx_tasks_state ON tasks(state);
 		CREATE INDEX IF NOT EXISTS idx_tasks_type ON tasks(type);
 		CREATE INDEX IF NOT EXISTS idx_tasks_priority ON tasks(priority);
 		CREATE INDEX IF NOT EXISTS idx_tasks_created_at ON tasks(created_at);
+		CREATE INDEX IF NOT EXISTS idx_tasks_tenant_id ON tasks(tenant_id);
+		CREATE INDEX IF NOT EXISTS idx_tasks_tag ON tasks(tag);
+		CREATE INDEX IF NOT EXISTS idx_tasks_lease_token ON tasks(lease_token);
+		CREATE INDEX IF NOT EXISTS idx_tasks_paused ON tasks(paused);
+
+		CREATE TABLE IF NOT EXISTS unique_locks (
+			key TEXT PRIMARY KEY,
+			expires_at DATETIME NOT NULL
+		);
+
+		-- task_type_pauses holds one row per Type currently paused via
+		-- PauseType, independent of the per-task "paused" column; a Type
+		-- in this table is paused for every task of that Type regardless
+		-- of their individual paused flag.
+		CREATE TABLE IF NOT EXISTS task_type_pauses (
+			type TEXT PRIMARY KEY,
+			paused_at DATETIME NOT NULL,
+			reason TEXT
+		);
+
+		CREATE TABLE IF NOT EXISTS task_results (
+			task_id TEXT NOT NULL,
+			seq INTEGER NOT NULL,
+			data BLOB NOT NULL,
+			PRIMARY KEY (task_id, seq)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_task_results_task_id ON task_results(task_id);
+
+		CREATE TABLE IF NOT EXISTS task_logs (
+			task_id TEXT NOT NULL,
+			seq INTEGER NOT NULL,
+			ts DATETIME NOT NULL,
+			level TEXT NOT NULL DEFAULT 'info',
+			message TEXT NOT NULL,
+			PRIMARY KEY (task_id, seq)
+		);
 	`
 
-	_, err := s.db.Exec(schema)
-	return err
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+
+	return s.migrateFTS()
+}
+
+// migrateFTS creates the tasks_fts external-content FTS5 table (indexing
+// type, payload and metadata) and the triggers that keep it synchronized
+// with tasks on insert/update/delete, using the standard external-content
+// trigger pattern from SQLite's FTS5 documentation. If the sqlite3 driver
+// wasn't built with FTS5 support, CREATE VIRTUAL TABLE fails with "no such
+// module: fts5"; migrateFTS treats that one error as expected and leaves
+// ftsAvailable false instead of failing NewSQLiteStorage outright, since a
+// binary built without FTS5 should still work for everything but search.
+func (s *SQLiteStorage) migrateFTS() error {
+	_, err := s.db.Exec(`
+		CREATE VIRTUAL TABLE IF NOT EXISTS tasks_fts USING fts5(
+			type, payload, metadata, content='tasks', content_rowid='rowid'
+		);
+	`)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such module: fts5") {
+			return nil
+		}
+		return fmt.Errorf("failed to create tasks_fts: %w", err)
+	}
+	s.ftsAvailable = true
+
+	_, err = s.db.Exec(`
+		CREATE TRIGGER IF NOT EXISTS tasks_fts_ai AFTER INSERT ON tasks BEGIN
+			INSERT INTO tasks_fts(rowid, type, payload, metadata) VALUES (new.rowid, new.type, new.payload, new.metadata);
+		END;
+
+		CREATE TRIGGER IF NOT EXISTS tasks_fts_ad AFTER DELETE ON tasks BEGIN
+			INSERT INTO tasks_fts(tasks_fts, rowid, type, payload, metadata) VALUES ('delete', old.rowid, old.type, old.payload, old.metadata);
+		END;
+
+		CREATE TRIGGER IF NOT EXISTS tasks_fts_au AFTER UPDATE ON tasks BEGIN
+			INSERT INTO tasks_fts(tasks_fts, rowid, type, payload, metadata) VALUES ('delete', old.rowid, old.type, old.payload, old.metadata);
+			INSERT INTO tasks_fts(rowid, type, payload, metadata) VALUES (new.rowid, new.type, new.payload, new.metadata);
+		END;
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create tasks_fts triggers: %w", err)
+	}
+
+	return nil
 }
 
 // SaveTask saves a task to the database
@@ -105,15 +331,20 @@ func (s *SQLiteStorage) SaveTask(ctx context.Context, task *queue.Task) error {
 	}
 
 	query := `
-		INSERT INTO tasks (id, type, payload, priority, state, created_at, retries, max_retries, metadata)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO tasks (id, type, payload, priority, state, created_at, retries, max_retries, metadata, retention_ns, lease_duration_ns, lease_expires_at, lease_token, tenant_id, tag, paused)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			state = excluded.state,
 			started_at = excluded.started_at,
 			completed_at = excluded.completed_at,
 			retries = excluded.retries,
 			error = excluded.error,
-			result = excluded.result
+			result = excluded.result,
+			retention_ns = excluded.retention_ns,
+			lease_duration_ns = excluded.lease_duration_ns,
+			lease_expires_at = excluded.lease_expires_at,
+			lease_token = excluded.lease_token,
+			paused = excluded.paused
 	`
 
 	_, err = s.db.ExecContext(ctx, query,
@@ -126,6 +357,13 @@ func (s *SQLiteStorage) SaveTask(ctx context.Context, task *queue.Task) error {
 		task.Retries,
 		task.MaxRetries,
 		string(metadata),
+		int64(task.Retention),
+		int64(task.LeaseDuration),
+		task.LeaseExpiresAt,
+		task.LeaseToken,
+		task.TenantID,
+		task.Tag,
+		task.Paused,
 	)
 
 	if err != nil {
@@ -139,7 +377,8 @@ func (s *SQLiteStorage) SaveTask(ctx context.Context, task *queue.Task) error {
 func (s *SQLiteStorage) GetTask(ctx context.Context, id string) (*queue.Task, error) {
 	query := `
 		SELECT id, type, payload, priority, state, created_at, started_at, completed_at,
-		       retries, max_retries, error, result, metadata
+		       retries, max_retries, error, result, metadata, retention_ns, lease_duration_ns, lease_expires_at,
+		       lease_token, tenant_id, tag, paused
 		FROM tasks WHERE id = ?
 	`
 
@@ -147,12 +386,33 @@ func (s *SQLiteStorage) GetTask(ctx context.Context, id string) (*queue.Task, er
 	return s.scanTask(row)
 }
 
+// GetCompletedTask retrieves a completed task by ID if it's still within
+// its retention window.
+func (s *SQLiteStorage) GetCompletedTask(ctx context.Context, id string) (*queue.Task, error) {
+	task, err := s.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.State != queue.StateCompleted || task.CompletedAt == nil {
+		return nil, ErrNotFound
+	}
+
+	retention := task.RetentionOrDefault()
+	if time.Now().After(task.CompletedAt.Add(retention)) {
+		return nil, ErrNotFound
+	}
+
+	return task, nil
+}
+
 // scanTask scans a row into a Task
 func (s *SQLiteStorage) scanTask(row *sql.Row) (*queue.Task, error) {
 	var task queue.Task
 	var payload, metadata, result sql.NullString
-	var startedAt, completedAt sql.NullTime
+	var startedAt, completedAt, leaseExpiresAt sql.NullTime
 	var errorStr sql.NullString
+	var retentionNs, leaseDurationNs int64
 
 	err := row.Scan(
 		&task.ID,
@@ -168,6 +428,13 @@ func (s *SQLiteStorage) scanTask(row *sql.Row) (*queue.Task, error) {
 		&errorStr,
 		&result,
 		&metadata,
+		&retentionNs,
+		&leaseDurationNs,
+		&leaseExpiresAt,
+		&task.LeaseToken,
+		&task.TenantID,
+		&task.Tag,
+		&task.Paused,
 	)
 
 	if err == sql.ErrNoRows {
@@ -176,6 +443,11 @@ func (s *SQLiteStorage) scanTask(row *sql.Row) (*queue.Task, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan task: %w", err)
 	}
+	task.Retention = time.Duration(retentionNs)
+	task.LeaseDuration = time.Duration(leaseDurationNs)
+	if leaseExpiresAt.Valid {
+		task.LeaseExpiresAt = &leaseExpiresAt.Time
+	}
 
 	// Parse JSON fields
 	if payload.Valid {
@@ -223,7 +495,12 @@ func (s *SQLiteStorage) UpdateTask(ctx context.Context, task *queue.Task) error
 			completed_at = ?,
 			retries = ?,
 			error = ?,
-			result = ?
+			result = ?,
+			retention_ns = ?,
+			lease_duration_ns = ?,
+			lease_expires_at = ?,
+			lease_token = ?,
+			paused = ?
 		WHERE id = ?
 	`
 
@@ -234,6 +511,11 @@ func (s *SQLiteStorage) UpdateTask(ctx context.Context, task *queue.Task) error
 		task.Retries,
 		task.Error,
 		string(result),
+		int64(task.Retention),
+		int64(task.LeaseDuration),
+		task.LeaseExpiresAt,
+		task.LeaseToken,
+		task.Paused,
 		task.ID,
 	)
 
@@ -272,34 +554,58 @@ func (s *SQLiteStorage) DeleteTask(ctx context.Context, id string) error {
 	return nil
 }
 
-// ListTasks lists tasks with filters
+// pausedFragment is true for a task that is effectively paused: paused
+// itself, or belonging to a type paused via PauseType. ListTasks and
+// CountTasks use it to include or exclude paused tasks per filter.Paused
+// / filter.IncludePaused; leaseTasks uses its negation unconditionally,
+// since a lease request never wants paused work.
+const pausedFragment = "(tasks.paused = 1 OR EXISTS (SELECT 1 FROM task_type_pauses tp WHERE tp.type = tasks.type))"
+
+// appendPauseCondition adds a pause-related condition to conditions per
+// filter.Paused/filter.IncludePaused: Paused (if non-nil) overrides
+// IncludePaused and restricts to exactly that effective-paused state;
+// otherwise paused tasks are included only if IncludePaused is set.
+func appendPauseCondition(conditions []string, filter TaskFilter) []string {
+	switch {
+	case filter.Paused != nil && *filter.Paused:
+		return append(conditions, pausedFragment)
+	case filter.Paused != nil && !*filter.Paused:
+		return append(conditions, "NOT "+pausedFragment)
+	case !filter.IncludePaused:
+		return append(conditions, "NOT "+pausedFragment)
+	default:
+		return conditions
+	}
+}
+
+// ListTasks lists tasks with filters. If filter.Search is set it joins
+// against tasks_fts and MATCHes it, failing with a clear error if this
+// SQLiteStorage wasn't built with FTS5 support (see migrateFTS).
 func (s *SQLiteStorage) ListTasks(ctx context.Context, filter TaskFilter) ([]*queue.Task, error) {
-	var conditions []string
-	var args []interface{}
+	conditions, args := buildFilterConditions(filter, questionPlaceholder)
 
-	if filter.State != "" {
-		conditions = append(conditions, "state = ?")
-		args = append(args, filter.State)
-	}
-	if filter.Type != "" {
-		conditions = append(conditions, "type = ?")
-		args = append(args, filter.Type)
-	}
-	if !filter.CreatedAfter.IsZero() {
-		conditions = append(conditions, "created_at > ?")
-		args = append(args, filter.CreatedAfter)
-	}
-	if !filter.CreatedBefore.IsZero() {
-		conditions = append(conditions, "created_at < ?")
-		args = append(args, filter.CreatedBefore)
+	from := "FROM tasks"
+	if filter.Search != "" {
+		if !s.ftsAvailable {
+			return nil, fmt.Errorf("full-text search unavailable: go-sqlite3 was not built with the sqlite_fts5 tag")
+		}
+		from = "FROM tasks JOIN tasks_fts ON tasks_fts.rowid = tasks.rowid"
+		conditions = append([]string{"tasks_fts MATCH ?"}, conditions...)
+		args = append([]interface{}{filter.Search}, args...)
 	}
 
+	// Columns are qualified with "tasks." since the FTS join above adds a
+	// second table (tasks_fts) with its own type/payload/metadata
+	// columns, which would otherwise make an unqualified SELECT ambiguous.
 	query := `
-		SELECT id, type, payload, priority, state, created_at, started_at, completed_at,
-		       retries, max_retries, error, result, metadata
-		FROM tasks
+		SELECT tasks.id, tasks.type, tasks.payload, tasks.priority, tasks.state, tasks.created_at, tasks.started_at, tasks.completed_at,
+		       tasks.retries, tasks.max_retries, tasks.error, tasks.result, tasks.metadata, tasks.retention_ns, tasks.lease_duration_ns, tasks.lease_expires_at,
+		       tasks.lease_token, tasks.tenant_id, tasks.tag, tasks.paused
+		` + from + `
 	`
 
+	conditions = appendPauseCondition(conditions, filter)
+
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
@@ -345,8 +651,9 @@ func (s *SQLiteStorage) ListTasks(ctx context.Context, filter TaskFilter) ([]*qu
 func (s *SQLiteStorage) scanTaskRows(rows *sql.Rows) (*queue.Task, error) {
 	var task queue.Task
 	var payload, metadata, result sql.NullString
-	var startedAt, completedAt sql.NullTime
+	var startedAt, completedAt, leaseExpiresAt sql.NullTime
 	var errorStr sql.NullString
+	var retentionNs, leaseDurationNs int64
 
 	err := rows.Scan(
 		&task.ID,
@@ -362,11 +669,23 @@ func (s *SQLiteStorage) scanTaskRows(rows *sql.Rows) (*queue.Task, error) {
 		&errorStr,
 		&result,
 		&metadata,
+		&retentionNs,
+		&leaseDurationNs,
+		&leaseExpiresAt,
+		&task.LeaseToken,
+		&task.TenantID,
+		&task.Tag,
+		&task.Paused,
 	)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan task: %w", err)
 	}
+	task.Retention = time.Duration(retentionNs)
+	task.LeaseDuration = time.Duration(leaseDurationNs)
+	if leaseExpiresAt.Valid {
+		task.LeaseExpiresAt = &leaseExpiresAt.Time
+	}
 
 	// Parse JSON fields
 	if payload.Valid {
@@ -452,19 +771,21 @@ func (s *SQLiteStorage) DeleteTasks(ctx context.Context, ids []string) error {
 
 // CountTasks counts tasks matching filter
 func (s *SQLiteStorage) CountTasks(ctx context.Context, filter TaskFilter) (int, error) {
-	var conditions []string
-	var args []interface{}
+	conditions, args := buildFilterConditions(filter, questionPlaceholder)
 
-	if filter.State != "" {
-		conditions = append(conditions, "state = ?")
-		args = append(args, filter.State)
-	}
-	if filter.Type != "" {
-		conditions = append(conditions, "type = ?")
-		args = append(args, filter.Type)
+	from := "FROM tasks"
+	if filter.Search != "" {
+		if !s.ftsAvailable {
+			return 0, fmt.Errorf("full-text search unavailable: go-sqlite3 was not built with the sqlite_fts5 tag")
+		}
+		from = "FROM tasks JOIN tasks_fts ON tasks_fts.rowid = tasks.rowid"
+		conditions = append([]string{"tasks_fts MATCH ?"}, conditions...)
+		args = append([]interface{}{filter.Search}, args...)
 	}
 
-	query := "SELECT COUNT(*) FROM tasks"
+	conditions = appendPauseCondition(conditions, filter)
+
+	query := "SELECT COUNT(*) " + from
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
@@ -474,6 +795,131 @@ func (s *SQLiteStorage) CountTasks(ctx context.Context, filter TaskFilter) (int,
 	return count, err
 }
 
+// SearchTasks finds tasks whose type, payload or metadata match query,
+// optionally narrowed further by filter, using the tasks_fts FTS5 index
+// instead of scanning the tasks table -- the entry point dashboards
+// should use for "all tasks whose payload mentions X" instead of
+// ListTasks with a LIKE condition. query is FTS5 query syntax, not a
+// plain substring -- operators like AND/OR/NOT, "column:", and a bare
+// "-" prefix are significant, so a caller passing through untrusted
+// user input should quote it (e.g. `"invoice-12345"`) to search it as a
+// literal phrase. It returns the same "unavailable" error as
+// ListTasks/CountTasks if this SQLiteStorage wasn't built with FTS5
+// support.
+func (s *SQLiteStorage) SearchTasks(ctx context.Context, query string, filter TaskFilter) ([]*queue.Task, error) {
+	filter.Search = query
+	return s.ListTasks(ctx, filter)
+}
+
+// PauseTask marks id paused so leaseTasks skips it; it otherwise remains
+// visible exactly where it was (ListTasks/CountTasks hide it from callers
+// that don't set filter.IncludePaused or filter.Paused).
+func (s *SQLiteStorage) PauseTask(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE tasks SET paused = 1 WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to pause task: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to pause task: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ResumeTask clears a pause set by PauseTask. It does not affect a pause
+// on the task's type -- use ResumeType for that.
+func (s *SQLiteStorage) ResumeTask(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE tasks SET paused = 0 WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to resume task: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to resume task: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// TypePause records that every task of Type is, for the time being,
+// excluded from leaseTasks regardless of its own paused column.
+type TypePause struct {
+	Type     string
+	PausedAt time.Time
+	Reason   string
+}
+
+// PauseType pauses every task of taskType, present and future, until
+// ResumeType is called; reason is freeform and purely informational. It
+// is idempotent: pausing an already-paused type just updates PausedAt
+// and Reason.
+func (s *SQLiteStorage) PauseType(ctx context.Context, taskType, reason string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO task_type_pauses (type, paused_at, reason) VALUES (?, ?, ?)
+		ON CONFLICT(type) DO UPDATE SET paused_at = excluded.paused_at, reason = excluded.reason
+	`, taskType, time.Now(), reason)
+	if err != nil {
+		return fmt.Errorf("failed to pause type: %w", err)
+	}
+	return nil
+}
+
+// ResumeType undoes PauseType for taskType. It does not touch any
+// individual task's own paused column set via PauseTask.
+func (s *SQLiteStorage) ResumeType(ctx context.Context, taskType string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM task_type_pauses WHERE type = ?`, taskType)
+	if err != nil {
+		return fmt.Errorf("failed to resume type: %w", err)
+	}
+	return nil
+}
+
+// IsPaused reports whether id is effectively paused right now -- paused
+// individually via PauseTask, or via its Type via PauseType -- the same
+// condition pausedFragment excludes from LeaseTasks. It satisfies
+// worker.PauseChecker, the live-dispatch hook for a Pool whose
+// queue.Queue doesn't itself consult task_type_pauses/tasks.paused.
+func (s *SQLiteStorage) IsPaused(ctx context.Context, id, taskType string) (bool, error) {
+	var paused bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM tasks WHERE id = ? AND paused = 1
+		) OR EXISTS (
+			SELECT 1 FROM task_type_pauses WHERE type = ?
+		)
+	`, id, taskType).Scan(&paused)
+	if err != nil {
+		return false, fmt.Errorf("failed to check pause state: %w", err)
+	}
+	return paused, nil
+}
+
+// ListPausedTypes lists every type currently paused via PauseType.
+func (s *SQLiteStorage) ListPausedTypes(ctx context.Context) ([]TypePause, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT type, paused_at, reason FROM task_type_pauses ORDER BY paused_at ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list paused types: %w", err)
+	}
+	defer rows.Close()
+
+	var pauses []TypePause
+	for rows.Next() {
+		var p TypePause
+		var reason sql.NullString
+		if err := rows.Scan(&p.Type, &p.PausedAt, &reason); err != nil {
+			return nil, fmt.Errorf("failed to scan type pause: %w", err)
+		}
+		p.Reason = reason.String
+		pauses = append(pauses, p)
+	}
+	return pauses, rows.Err()
+}
+
 // GetTasksByState gets all tasks in a specific state
 func (s *SQLiteStorage) GetTasksByState(ctx context.Context, state queue.TaskState) ([]*queue.Task, error) {
 	return s.ListTasks(ctx, TaskFilter{State: state})
@@ -487,11 +933,448 @@ func (s *SQLiteStorage) GetStaleTasks(ctx context.Context, olderThan time.Durati
 	})
 }
 
-// Close closes the database connection
+// AcquireUniqueLock acquires the dedup lock for key if it's absent or
+// expired, via an upsert that only overwrites an existing row once its
+// expires_at has passed.
+func (s *SQLiteStorage) AcquireUniqueLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO unique_locks (key, expires_at) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET expires_at = excluded.expires_at
+		WHERE unique_locks.expires_at <= ?
+	`, key, now.Add(ttl), now)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire unique lock: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// ReleaseUniqueLock releases the dedup lock for key.
+func (s *SQLiteStorage) ReleaseUniqueLock(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM unique_locks WHERE key = ?", key)
+	return err
+}
+
+// Heartbeat extends a StateProcessing task's lease to now plus its
+// LeaseDurationOrDefault().
+func (s *SQLiteStorage) Heartbeat(ctx context.Context, id string) error {
+	task, err := s.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+	if task.State != queue.StateProcessing {
+		return queue.ErrTaskNotProcessing
+	}
+
+	exp := time.Now().Add(task.LeaseDurationOrDefault())
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE tasks SET lease_expires_at = ? WHERE id = ?
+	`, exp, id)
+	if err != nil {
+		return fmt.Errorf("failed to heartbeat task: %w", err)
+	}
+	return nil
+}
+
+// queryExecer is the subset of *sql.DB and *sql.Tx that leaseTasks needs,
+// so the same lease-selection logic backs both SQLiteStorage.LeaseTasks
+// (its own transaction) and SQLiteTransaction.LeaseTasks (the caller's).
+type queryExecer interface {
+	execer
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// leaseTasks selects up to req.Max pending tasks matching
+// req.Type/req.Tag/req.Priority and eligible for lease (lease_expires_at
+// unset or already past, see NackLease), marks them processing under a
+// fresh shared token, and returns them highest-priority-first. scanner
+// resolves rows the same way GetTask/ListTasks do.
+func leaseTasks(ctx context.Context, q queryExecer, scanner *SQLiteStorage, req LeaseRequest) ([]*queue.Task, LeaseToken, error) {
+	conditions := []string{"state = ?", "(lease_expires_at IS NULL OR lease_expires_at <= ?)", "NOT " + pausedFragment}
+	args := []interface{}{queue.StatePending, time.Now()}
+
+	if req.Type != "" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, req.Type)
+	}
+	if req.Tag != "" {
+		conditions = append(conditions, "tag = ?")
+		args = append(args, req.Tag)
+	}
+	if req.Priority != 0 {
+		conditions = append(conditions, "priority = ?")
+		args = append(args, req.Priority)
+	}
+
+	selectQuery := `
+		SELECT id, type, payload, priority, state, created_at, started_at, completed_at,
+		       retries, max_retries, error, result, metadata, retention_ns, lease_duration_ns, lease_expires_at,
+		       lease_token, tenant_id, tag, paused
+		FROM tasks WHERE ` + strings.Join(conditions, " AND ") + " ORDER BY priority DESC, created_at ASC"
+	if req.Max > 0 {
+		selectQuery += fmt.Sprintf(" LIMIT %d", req.Max)
+	}
+
+	rows, err := q.QueryContext(ctx, selectQuery, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to select leasable tasks: %w", err)
+	}
+
+	var tasks []*queue.Task
+	for rows.Next() {
+		task, err := scanner.scanTaskRows(rows)
+		if err != nil {
+			rows.Close()
+			return nil, "", err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, "", err
+	}
+	rows.Close()
+
+	if len(tasks) == 0 {
+		return nil, "", nil
+	}
+
+	leaseFor := req.LeaseFor
+	if leaseFor <= 0 {
+		leaseFor = queue.DefaultLeaseDuration
+	}
+	token := newLeaseToken()
+	expiresAt := time.Now().Add(leaseFor)
+
+	placeholders := make([]string, len(tasks))
+	updateArgs := make([]interface{}, 0, len(tasks)+3)
+	updateArgs = append(updateArgs, queue.StateProcessing, string(token), expiresAt)
+	for i, task := range tasks {
+		placeholders[i] = "?"
+		updateArgs = append(updateArgs, task.ID)
+	}
+
+	updateQuery := fmt.Sprintf(`
+		UPDATE tasks SET state = ?, lease_token = ?, lease_expires_at = ?
+		WHERE id IN (%s)
+	`, strings.Join(placeholders, ","))
+	if _, err := q.ExecContext(ctx, updateQuery, updateArgs...); err != nil {
+		return nil, "", fmt.Errorf("failed to lease tasks: %w", err)
+	}
+
+	for _, task := range tasks {
+		task.State = queue.StateProcessing
+		task.LeaseToken = string(token)
+		task.LeaseExpiresAt = &expiresAt
+	}
+
+	return tasks, token, nil
+}
+
+// extendLease pushes every task leased under token forward by extra.
+func extendLease(ctx context.Context, q execer, token LeaseToken, extra time.Duration) error {
+	if token == "" {
+		return ErrLeaseNotFound
+	}
+	res, err := q.ExecContext(ctx, `
+		UPDATE tasks SET lease_expires_at = ? WHERE lease_token = ? AND state = ?
+	`, time.Now().Add(extra), string(token), queue.StateProcessing)
+	if err != nil {
+		return fmt.Errorf("failed to extend lease: %w", err)
+	}
+	return requireLeaseRowsAffected(res)
+}
+
+// ackLease marks every task leased under token completed, releasing the
+// lease.
+func ackLease(ctx context.Context, q execer, token LeaseToken) error {
+	if token == "" {
+		return ErrLeaseNotFound
+	}
+	res, err := q.ExecContext(ctx, `
+		UPDATE tasks SET state = ?, completed_at = ?, lease_token = '', lease_expires_at = NULL
+		WHERE lease_token = ? AND state = ?
+	`, queue.StateCompleted, time.Now(), string(token), queue.StateProcessing)
+	if err != nil {
+		return fmt.Errorf("failed to ack lease: %w", err)
+	}
+	return requireLeaseRowsAffected(res)
+}
+
+// nackLease returns every task leased under token to pending, bumping
+// Retries, and sets lease_expires_at to retryAfter from now so leaseTasks
+// skips it until then -- reusing lease_expires_at as a "not eligible
+// before" marker instead of adding a separate scheduled-at column.
+func nackLease(ctx context.Context, q execer, token LeaseToken, retryAfter time.Duration) error {
+	if token == "" {
+		return ErrLeaseNotFound
+	}
+	res, err := q.ExecContext(ctx, `
+		UPDATE tasks SET state = ?, retries = retries + 1, lease_token = '', lease_expires_at = ?
+		WHERE lease_token = ? AND state = ?
+	`, queue.StatePending, time.Now().Add(retryAfter), string(token), queue.StateProcessing)
+	if err != nil {
+		return fmt.Errorf("failed to nack lease: %w", err)
+	}
+	return requireLeaseRowsAffected(res)
+}
+
+func requireLeaseRowsAffected(res sql.Result) error {
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrLeaseNotFound
+	}
+	return nil
+}
+
+// LeaseTasks atomically selects and claims up to req.Max pending tasks.
+func (s *SQLiteStorage) LeaseTasks(ctx context.Context, req LeaseRequest) ([]*queue.Task, LeaseToken, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer tx.Rollback()
+
+	tasks, token, err := leaseTasks(ctx, tx, s, req)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, "", err
+	}
+	return tasks, token, nil
+}
+
+// ExtendLease pushes every task leased under token forward by extra.
+func (s *SQLiteStorage) ExtendLease(ctx context.Context, token LeaseToken, extra time.Duration) error {
+	return extendLease(ctx, s.db, token, extra)
+}
+
+// AckLease marks every task leased under token completed.
+func (s *SQLiteStorage) AckLease(ctx context.Context, token LeaseToken) error {
+	return ackLease(ctx, s.db, token)
+}
+
+// NackLease returns every task leased under token to pending.
+func (s *SQLiteStorage) NackLease(ctx context.Context, token LeaseToken, retryAfter time.Duration) error {
+	return nackLease(ctx, s.db, token, retryAfter)
+}
+
+// Close closes the database connection, first stopping the retention and
+// lease sweepers (if running) so neither queries db after it closes.
 func (s *SQLiteStorage) Close() error {
+	if s.stopSweep != nil {
+		close(s.stopSweep)
+		<-s.sweepDone
+	}
+	if s.stopLeaseSweep != nil {
+		close(s.stopLeaseSweep)
+		<-s.leaseSweepDone
+	}
 	return s.db.Close()
 }
 
+// execer is the subset of *sql.DB and *sql.Tx that sqliteResultWriter
+// needs, so the same writer implementation backs both
+// SQLiteStorage.ResultWriter and SQLiteTransaction.ResultWriter.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// sqliteResultWriter streams chunked result bytes for one task into the
+// task_results table, one row per Write -- the SQLite-backed equivalent
+// of queue.ResultWriter's in-memory append, for a worker whose result is
+// too large or too incremental to reserialize into tasks.result on every
+// call. Chunks are ordered by seq, assigned sequentially starting at 1.
+type sqliteResultWriter struct {
+	execer execer
+	taskID string
+	seq    int
+}
+
+func (w *sqliteResultWriter) Write(p []byte) (int, error) {
+	w.seq++
+	if _, err := w.execer.ExecContext(context.Background(), `
+		INSERT INTO task_results (task_id, seq, data) VALUES (?, ?, ?)
+	`, w.taskID, w.seq, p); err != nil {
+		return 0, fmt.Errorf("failed to write result chunk: %w", err)
+	}
+	return len(p), nil
+}
+
+// ResultWriter returns a writer that appends result chunks for taskID to
+// the task_results table.
+func (s *SQLiteStorage) ResultWriter(taskID string) io.Writer {
+	return &sqliteResultWriter{execer: s.db, taskID: taskID}
+}
+
+// LogLine is one row read back from task_logs.
+type LogLine struct {
+	TaskID  string
+	Seq     int
+	Time    time.Time
+	Level   string
+	Message string
+}
+
+// TaskLogWriter is an io.Writer that splits whatever it's given on
+// newlines and appends each complete line to task_logs as its own row,
+// the structured-log equivalent of sqliteResultWriter for task results.
+// A trailing partial line is buffered and prefixed onto the next Write
+// rather than discarded, so callers can write in arbitrary chunks rather
+// than one Write per line. Level tags every line written from this point
+// on (default "info"); set it between writes to change the level of
+// subsequent lines, e.g. before writing a line pulled from stderr.
+type TaskLogWriter struct {
+	execer execer
+	taskID string
+	Level  string
+
+	seq int
+	buf []byte
+}
+
+func (w *TaskLogWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.writeLine(string(w.buf[:i])); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+func (w *TaskLogWriter) writeLine(line string) error {
+	w.seq++
+	level := w.Level
+	if level == "" {
+		level = "info"
+	}
+	_, err := w.execer.ExecContext(context.Background(), `
+		INSERT INTO task_logs (task_id, seq, ts, level, message) VALUES (?, ?, ?, ?, ?)
+	`, w.taskID, w.seq, time.Now(), level, line)
+	if err != nil {
+		return fmt.Errorf("failed to write log line: %w", err)
+	}
+	return nil
+}
+
+// Close flushes a final line left in the buffer with no trailing
+// newline. It's a no-op if Write always ended on a newline.
+func (w *TaskLogWriter) Close() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := string(w.buf)
+	w.buf = nil
+	return w.writeLine(line)
+}
+
+// LogWriter returns a writer that appends log lines for taskID to the
+// task_logs table; see TaskLogWriter.
+func (s *SQLiteStorage) LogWriter(taskID string) *TaskLogWriter {
+	return &TaskLogWriter{execer: s.db, taskID: taskID}
+}
+
+// ReadLogs returns up to limit log lines for taskID with seq > fromSeq,
+// oldest first -- pass the last seq seen to page forward, or 0 to start
+// from the beginning. limit <= 0 means no limit.
+func (s *SQLiteStorage) ReadLogs(ctx context.Context, taskID string, fromSeq, limit int) ([]LogLine, error) {
+	query := `
+		SELECT task_id, seq, ts, level, message FROM task_logs
+		WHERE task_id = ? AND seq > ?
+		ORDER BY seq ASC
+	`
+	args := []interface{}{taskID, fromSeq}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read logs: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []LogLine
+	for rows.Next() {
+		var l LogLine
+		if err := rows.Scan(&l.TaskID, &l.Seq, &l.Time, &l.Level, &l.Message); err != nil {
+			return nil, fmt.Errorf("failed to scan log line: %w", err)
+		}
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
+
+// logFollowPollInterval is how often FollowLogs re-checks task_logs for
+// rows past the caller's last-seen seq. database/sql has no portable
+// equivalent of go-sqlite3's per-connection update hook, so FollowLogs
+// polls instead of subscribing to one.
+const logFollowPollInterval = 500 * time.Millisecond
+
+// FollowLogs streams log lines for taskID with seq > fromSeq as they're
+// written, polling task_logs every logFollowPollInterval. The returned
+// channel is closed when ctx is canceled; callers should range over it
+// rather than checking ctx separately.
+func (s *SQLiteStorage) FollowLogs(ctx context.Context, taskID string, fromSeq int) (<-chan LogLine, error) {
+	ch := make(chan LogLine)
+
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(logFollowPollInterval)
+		defer ticker.Stop()
+
+		last := fromSeq
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			lines, err := s.ReadLogs(ctx, taskID, last, 0)
+			if err != nil {
+				return
+			}
+			for _, l := range lines {
+				select {
+				case ch <- l:
+					last = l.Seq
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// DB returns the underlying *sql.DB, for callers that need to manage
+// their own tables alongside tasks' in the same database file -- e.g.
+// api.NewTokenStore's api_tokens table.
+func (s *SQLiteStorage) DB() *sql.DB {
+	return s.db
+}
+
 // Ping checks database health
 func (s *SQLiteStorage) Ping(ctx context.Context) error {
 	return s.db.PingContext(ctx)
@@ -585,6 +1468,52 @@ func (t *SQLiteTransaction) GetStaleTasks(ctx context.Context, olderThan time.Du
 	return t.storage.GetStaleTasks(ctx, olderThan)
 }
 
+func (t *SQLiteTransaction) GetCompletedTask(ctx context.Context, id string) (*queue.Task, error) {
+	return t.storage.GetCompletedTask(ctx, id)
+}
+
+func (t *SQLiteTransaction) AcquireUniqueLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return t.storage.AcquireUniqueLock(ctx, key, ttl)
+}
+
+func (t *SQLiteTransaction) ReleaseUniqueLock(ctx context.Context, key string) error {
+	return t.storage.ReleaseUniqueLock(ctx, key)
+}
+
+func (t *SQLiteTransaction) Heartbeat(ctx context.Context, id string) error {
+	return t.storage.Heartbeat(ctx, id)
+}
+
+// LeaseTasks leases within this transaction, so a caller that wants the
+// lease committed atomically alongside other writes can do so.
+func (t *SQLiteTransaction) LeaseTasks(ctx context.Context, req LeaseRequest) ([]*queue.Task, LeaseToken, error) {
+	return leaseTasks(ctx, t.tx, t.storage, req)
+}
+
+func (t *SQLiteTransaction) ExtendLease(ctx context.Context, token LeaseToken, extra time.Duration) error {
+	return extendLease(ctx, t.tx, token, extra)
+}
+
+func (t *SQLiteTransaction) AckLease(ctx context.Context, token LeaseToken) error {
+	return ackLease(ctx, t.tx, token)
+}
+
+func (t *SQLiteTransaction) NackLease(ctx context.Context, token LeaseToken, retryAfter time.Duration) error {
+	return nackLease(ctx, t.tx, token, retryAfter)
+}
+
+// ResultWriter returns a writer that appends result chunks for taskID to
+// the task_results table within this transaction.
+func (t *SQLiteTransaction) ResultWriter(taskID string) io.Writer {
+	return &sqliteResultWriter{execer: t.tx, taskID: taskID}
+}
+
+// LogWriter returns a writer that appends log lines for taskID to the
+// task_logs table within this transaction.
+func (t *SQLiteTransaction) LogWriter(taskID string) *TaskLogWriter {
+	return &TaskLogWriter{execer: t.tx, taskID: taskID}
+}
+
 func (t *SQLiteTransaction) Close() error {
 	return nil
 }
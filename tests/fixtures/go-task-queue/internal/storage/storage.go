@@ -7,6 +7,8 @@ import (
 	"errors"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/example/task-queue/internal/queue"
 )
 
@@ -15,6 +17,11 @@ var (
 	ErrNotFound      = errors.New("record not found")
 	ErrDuplicateKey  = errors.New("duplicate key")
 	ErrStorageClosed = errors.New("storage is closed")
+
+	// ErrLeaseNotFound is returned by ExtendLease, AckLease, and NackLease
+	// when no task is currently leased under the given token, e.g. because
+	// the lease already expired and a sweeper reclaimed it.
+	ErrLeaseNotFound = errors.New("lease not found or already resolved")
 )
 
 // Storage defines the interface for task persistence
@@ -35,31 +42,142 @@ type Storage interface {
 	GetTasksByState(ctx context.Context, state queue.TaskState) ([]*queue.Task, error)
 	GetStaleTasks(ctx context.Context, olderThan time.Duration) ([]*queue.Task, error)
 
+	// GetCompletedTask retrieves a completed task by ID, as long as it's
+	// still within its retention window (task.Retention, or
+	// queue.DefaultRetention if unset, measured from CompletedAt). It
+	// returns ErrNotFound once that window has elapsed.
+	GetCompletedTask(ctx context.Context, id string) (*queue.Task, error)
+
+	// AcquireUniqueLock acquires the dedup lock for key, used to back
+	// queue.WithUnique across distributed backends, returning true if this
+	// call acquired it and false if an unexpired lock already existed. The
+	// lock expires automatically after ttl if never released.
+	AcquireUniqueLock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// ReleaseUniqueLock releases a dedup lock early, e.g. on task
+	// completion or failure, instead of waiting for it to expire.
+	ReleaseUniqueLock(ctx context.Context, key string) error
+
+	// Heartbeat extends a StateProcessing task's lease (see
+	// queue.Task.LeaseExpiresAt) by its LeaseDurationOrDefault() from now,
+	// so a reaper built on GetStaleTasks doesn't reclaim work a worker is
+	// still actively making progress on.
+	Heartbeat(ctx context.Context, id string) error
+
+	// LeaseTasks atomically selects up to req.Max pending tasks matching
+	// req.Type/req.Tag/req.Priority, marks them StateProcessing under a
+	// single shared LeaseToken with a lease_expires_at req.LeaseFor (or
+	// queue.DefaultLeaseDuration) from now, and returns them with that
+	// token. It's crash-safe at-least-once delivery for multiple workers
+	// pulling from the same backend without double-processing, in place
+	// of "pick by state" Dequeue for a caller that wants tag-scoped
+	// batches and an explicit Ack/Nack instead of Update.
+	LeaseTasks(ctx context.Context, req LeaseRequest) ([]*queue.Task, LeaseToken, error)
+
+	// ExtendLease pushes every task leased under token forward by extra
+	// from now, the batch equivalent of Heartbeat. It returns
+	// ErrLeaseNotFound if token names no currently-leased tasks.
+	ExtendLease(ctx context.Context, token LeaseToken, extra time.Duration) error
+
+	// AckLease marks every task leased under token StateCompleted,
+	// releasing the lease. It returns ErrLeaseNotFound if token names no
+	// currently-leased tasks.
+	AckLease(ctx context.Context, token LeaseToken) error
+
+	// NackLease returns every task leased under token to StatePending,
+	// bumping Retries, and delays it from being leased again until
+	// retryAfter has elapsed. It returns ErrLeaseNotFound if token names
+	// no currently-leased tasks.
+	NackLease(ctx context.Context, token LeaseToken, retryAfter time.Duration) error
+
 	// Lifecycle
 	Close() error
 	Ping(ctx context.Context) error
 }
 
+// LeaseToken identifies one batch of tasks claimed together by a single
+// LeaseTasks call, letting a caller extend, acknowledge, or
+// negatively-acknowledge the whole batch without threading individual task
+// IDs back through ExtendLease/AckLease/NackLease.
+type LeaseToken string
+
+// LeaseRequest narrows a LeaseTasks call.
+type LeaseRequest struct {
+	// Type, if set, restricts the lease to pending tasks of that type.
+	Type string
+
+	// Tag, if set, restricts the lease to pending tasks carrying that tag
+	// (see queue.Task.Tag) -- e.g. leasing only one article's or user's
+	// tasks out of a shared Type, matching the LeaseByTag pattern used by
+	// App Engine task queues.
+	Tag string
+
+	// Priority, if non-zero, restricts the lease to tasks at exactly that
+	// priority. Zero means any priority, the same "zero value means no
+	// filter" convention TaskFilter.Priority uses.
+	Priority queue.Priority
+
+	// Max is the maximum number of tasks to lease in this call. Fewer may
+	// be returned if fewer are eligible.
+	Max int
+
+	// LeaseFor is how long the leased tasks are reserved before a sweeper
+	// reclaims them back to pending. Zero means queue.DefaultLeaseDuration.
+	LeaseFor time.Duration
+}
+
 // TaskFilter defines filtering options for task queries
 type TaskFilter struct {
-	State      queue.TaskState
-	Type       string
-	Priority   queue.Priority
+	State         queue.TaskState
+	Type          string
+	Priority      queue.Priority
 	CreatedAfter  time.Time
 	CreatedBefore time.Time
-	Limit      int
-	Offset     int
-	OrderBy    string
-	OrderDesc  bool
+	Limit         int
+	Offset        int
+	OrderBy       string
+	OrderDesc     bool
+
+	// TenantID, if set, restricts the result to tasks owned by that
+	// tenant. See queue.Task.TenantID.
+	TenantID string
+
+	// Search, if set, restricts the result to tasks whose type, payload
+	// or metadata match this SQLite FTS5 query (see
+	// SQLiteStorage.SearchTasks). Only SQLiteStorage honors it; other
+	// backends ignore it.
+	Search string
+
+	// IncludePaused, if true, includes tasks that are paused (either
+	// individually, see queue.Task.Paused, or by Type, see
+	// SQLiteStorage.PauseType) in the result. By default ListTasks/
+	// CountTasks hide paused tasks the same way a dispatcher would skip
+	// them, so a dashboard has to opt in to see them. Ignored if Paused
+	// is set.
+	IncludePaused bool
+
+	// Paused, if non-nil, restricts the result to tasks whose effective
+	// paused state (individually paused, or Type-paused) matches *Paused,
+	// overriding IncludePaused.
+	Paused *bool
+}
+
+// newLeaseToken generates an opaque LeaseToken, shared by every backend's
+// LeaseTasks so one batch of tasks claimed together gets one token --
+// uuid.New() the same way Task.ID itself is generated, rather than the
+// crypto/rand-based token api.tokenstore reserves for security-sensitive
+// bearer credentials.
+func newLeaseToken() LeaseToken {
+	return LeaseToken(uuid.New().String())
 }
 
 // ResultSet represents a paginated result
 type ResultSet[T any] struct {
-	Items      []T   `json:"items"`
-	Total      int   `json:"total"`
-	Page       int   `json:"page"`
-	PerPage    int   `json:"per_page"`
-	TotalPages int   `json:"total_pages"`
+	Items      []T `json:"items"`
+	Total      int `json:"total"`
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	TotalPages int `json:"total_pages"`
 }
 
 // Transaction represents a storage transaction
@@ -107,13 +225,15 @@ func (s *JSONSerializer) Deserialize(data []byte) (*queue.Task, error) {
 
 // InMemoryStorage is a simple in-memory storage for testing
 type InMemoryStorage struct {
-	tasks map[string]*queue.Task
+	tasks       map[string]*queue.Task
+	uniqueLocks map[string]time.Time
 }
 
 // NewInMemoryStorage creates a new in-memory storage
 func NewInMemoryStorage() *InMemoryStorage {
 	return &InMemoryStorage{
-		tasks: make(map[string]*queue.Task),
+		tasks:       make(map[string]*queue.Task),
+		uniqueLocks: make(map[string]time.Time),
 	}
 }
 
@@ -158,6 +278,18 @@ func (s *InMemoryStorage) ListTasks(ctx context.Context, filter TaskFilter) ([]*
 		if filter.Type != "" && task.Type != filter.Type {
 			continue
 		}
+		if filter.TenantID != "" && task.TenantID != filter.TenantID {
+			continue
+		}
+		// InMemoryStorage has no task_type_pauses equivalent, so only
+		// queue.Task.Paused (not a Type-wide pause) is considered here.
+		if filter.Paused != nil {
+			if task.Paused != *filter.Paused {
+				continue
+			}
+		} else if !filter.IncludePaused && task.Paused {
+			continue
+		}
 		result = append(result, task)
 	}
 
@@ -216,6 +348,162 @@ func (s *InMemoryStorage) GetStaleTasks(ctx context.Context, olderThan time.Dura
 	return result, nil
 }
 
+// GetCompletedTask retrieves a completed task by ID if it's still within
+// its retention window.
+func (s *InMemoryStorage) GetCompletedTask(ctx context.Context, id string) (*queue.Task, error) {
+	task, ok := s.tasks[id]
+	if !ok || task.State != queue.StateCompleted || task.CompletedAt == nil {
+		return nil, ErrNotFound
+	}
+
+	retention := task.Retention
+	if retention <= 0 {
+		retention = queue.DefaultRetention
+	}
+	if time.Now().After(task.CompletedAt.Add(retention)) {
+		return nil, ErrNotFound
+	}
+
+	return task, nil
+}
+
+// AcquireUniqueLock acquires the dedup lock for key if it's absent or
+// expired, returning false without error if an unexpired lock already
+// exists.
+func (s *InMemoryStorage) AcquireUniqueLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if exp, ok := s.uniqueLocks[key]; ok && time.Now().Before(exp) {
+		return false, nil
+	}
+	s.uniqueLocks[key] = time.Now().Add(ttl)
+	return true, nil
+}
+
+// ReleaseUniqueLock releases the dedup lock for key.
+func (s *InMemoryStorage) ReleaseUniqueLock(ctx context.Context, key string) error {
+	delete(s.uniqueLocks, key)
+	return nil
+}
+
+// Heartbeat extends a StateProcessing task's lease by its
+// LeaseDurationOrDefault() from now.
+func (s *InMemoryStorage) Heartbeat(ctx context.Context, id string) error {
+	task, ok := s.tasks[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if task.State != queue.StateProcessing {
+		return queue.ErrTaskNotProcessing
+	}
+
+	exp := time.Now().Add(task.LeaseDurationOrDefault())
+	task.LeaseExpiresAt = &exp
+
+	return nil
+}
+
+// LeaseTasks selects up to req.Max pending tasks matching
+// req.Type/req.Tag/req.Priority and eligible (LeaseExpiresAt unset or
+// already past, see NackLease), marks them StateProcessing under a fresh
+// shared token, and returns them.
+func (s *InMemoryStorage) LeaseTasks(ctx context.Context, req LeaseRequest) ([]*queue.Task, LeaseToken, error) {
+	leaseFor := req.LeaseFor
+	if leaseFor <= 0 {
+		leaseFor = queue.DefaultLeaseDuration
+	}
+
+	token := newLeaseToken()
+	now := time.Now()
+	expiresAt := now.Add(leaseFor)
+
+	var leased []*queue.Task
+	for _, task := range s.tasks {
+		if req.Max > 0 && len(leased) >= req.Max {
+			break
+		}
+		if task.State != queue.StatePending {
+			continue
+		}
+		if task.LeaseExpiresAt != nil && task.LeaseExpiresAt.After(now) {
+			continue
+		}
+		if req.Type != "" && task.Type != req.Type {
+			continue
+		}
+		if req.Tag != "" && task.Tag != req.Tag {
+			continue
+		}
+		if req.Priority != 0 && task.Priority != req.Priority {
+			continue
+		}
+
+		task.State = queue.StateProcessing
+		task.LeaseToken = string(token)
+		task.LeaseExpiresAt = &expiresAt
+		leased = append(leased, task)
+	}
+
+	return leased, token, nil
+}
+
+// ExtendLease pushes every task leased under token forward by extra.
+func (s *InMemoryStorage) ExtendLease(ctx context.Context, token LeaseToken, extra time.Duration) error {
+	found := false
+	expiresAt := time.Now().Add(extra)
+	for _, task := range s.tasks {
+		if task.LeaseToken != string(token) {
+			continue
+		}
+		task.LeaseExpiresAt = &expiresAt
+		found = true
+	}
+	if !found {
+		return ErrLeaseNotFound
+	}
+	return nil
+}
+
+// AckLease marks every task leased under token StateCompleted.
+func (s *InMemoryStorage) AckLease(ctx context.Context, token LeaseToken) error {
+	found := false
+	now := time.Now()
+	for _, task := range s.tasks {
+		if task.LeaseToken != string(token) {
+			continue
+		}
+		task.State = queue.StateCompleted
+		task.CompletedAt = &now
+		task.LeaseToken = ""
+		task.LeaseExpiresAt = nil
+		found = true
+	}
+	if !found {
+		return ErrLeaseNotFound
+	}
+	return nil
+}
+
+// NackLease returns every task leased under token to StatePending, bumps
+// Retries, and sets LeaseExpiresAt to retryAfter from now so LeaseTasks
+// skips it until then.
+func (s *InMemoryStorage) NackLease(ctx context.Context, token LeaseToken, retryAfter time.Duration) error {
+	found := false
+	notBefore := time.Now().Add(retryAfter)
+	for _, task := range s.tasks {
+		if task.LeaseToken != string(token) {
+			continue
+		}
+		task.State = queue.StatePending
+		task.Retries++
+		task.LeaseToken = ""
+		task.LeaseExpiresAt = &notBefore
+		found = true
+	}
+	if !found {
+		return ErrLeaseNotFound
+	}
+	return nil
+}
+
 // Close closes the storage
 func (s *InMemoryStorage) Close() error {
 	s.tasks = nil
@@ -0,0 +1,86 @@
+// Package store provides Redis-backed persistence for task outcomes that
+// need to outlive the process, starting with dead-lettered tasks.
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/example/task-queue/internal/task"
+	"github.com/redis/go-redis/v9"
+)
+
+// Config holds Redis configuration for a DeadLetterStore.
+type Config struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+// DeadLetterStore is a task.DeadLetterSink backed by a Redis list. Each
+// dead-lettered task is JSON-encoded and LPUSHed onto a single key so an
+// operator (or a replay tool) can LRANGE/BRPOP it later.
+type DeadLetterStore struct {
+	client *redis.Client
+	key    string
+}
+
+// NewDeadLetterStore creates a DeadLetterStore and verifies connectivity.
+func NewDeadLetterStore(cfg Config) (*DeadLetterStore, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	return &DeadLetterStore{
+		client: client,
+		key:    "taskqueue:deadletter",
+	}, nil
+}
+
+// Send implements task.DeadLetterSink by LPUSHing the JSON-encoded
+// DeadLetter onto the store's list.
+func (s *DeadLetterStore) Send(ctx context.Context, dl task.DeadLetter) error {
+	data, err := json.Marshal(dl)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter: %w", err)
+	}
+
+	if err := s.client.LPush(ctx, s.key, data).Err(); err != nil {
+		return fmt.Errorf("failed to save dead letter: %w", err)
+	}
+	return nil
+}
+
+// List returns up to limit dead letters, most recently sent first.
+func (s *DeadLetterStore) List(ctx context.Context, limit int) ([]task.DeadLetter, error) {
+	raw, err := s.client.LRange(ctx, s.key, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	letters := make([]task.DeadLetter, 0, len(raw))
+	for _, item := range raw {
+		var dl task.DeadLetter
+		if err := json.Unmarshal([]byte(item), &dl); err != nil {
+			continue // Skip malformed entries
+		}
+		letters = append(letters, dl)
+	}
+	return letters, nil
+}
+
+// Close closes the underlying Redis client.
+func (s *DeadLetterStore) Close() error {
+	return s.client.Close()
+}
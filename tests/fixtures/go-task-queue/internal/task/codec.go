@@ -0,0 +1,91 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec encodes and decodes task arguments to and from queue.Task.Body,
+// replacing TypedHandler's hard-coded marshal-then-unmarshal-through-JSON
+// hop for producers and handlers that already agree on a wire format.
+type Codec interface {
+	// Marshal encodes v to its wire representation.
+	Marshal(v interface{}) ([]byte, error)
+
+	// Unmarshal decodes data into v, which must be a pointer.
+	Unmarshal(data []byte, v interface{}) error
+
+	// ContentType names the encoding, stored on queue.Task.Codec so a
+	// consumer can pick the matching Codec without out-of-band agreement.
+	ContentType() string
+}
+
+// JSONCodec encodes with encoding/json. It's the default and the only
+// codec the map-based Payload compatibility path understands.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (JSONCodec) ContentType() string                        { return "json" }
+
+// ProtobufCodec encodes with google.golang.org/protobuf/proto. v must
+// implement proto.Message; anything else is an error rather than a silent
+// fallback, since there's no reasonable wire format to produce otherwise.
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T does not implement proto.Message", ErrPayloadDecode, v)
+	}
+	return proto.Marshal(msg)
+}
+
+func (ProtobufCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%w: %T does not implement proto.Message", ErrPayloadDecode, v)
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+func (ProtobufCodec) ContentType() string { return "protobuf" }
+
+// MsgpackCodec encodes with the vmihailenco/msgpack implementation,
+// preserving integer width and binary fields that JSON's text encoding
+// doesn't distinguish.
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) Marshal(v interface{}) ([]byte, error)      { return msgpack.Marshal(v) }
+func (MsgpackCodec) Unmarshal(data []byte, v interface{}) error { return msgpack.Unmarshal(data, v) }
+func (MsgpackCodec) ContentType() string                        { return "msgpack" }
+
+// CBORCodec encodes with the fxamacker/cbor implementation.
+type CBORCodec struct{}
+
+func (CBORCodec) Marshal(v interface{}) ([]byte, error)      { return cbor.Marshal(v) }
+func (CBORCodec) Unmarshal(data []byte, v interface{}) error { return cbor.Unmarshal(data, v) }
+func (CBORCodec) ContentType() string                        { return "cbor" }
+
+// codecsByContentType maps a queue.Task.Codec identifier back to the Codec
+// that produced it, for consumers that only have the identifier on hand
+// (e.g. a generic dispatcher that hasn't been wired with the producer's
+// Codec instance).
+var codecsByContentType = map[string]Codec{
+	JSONCodec{}.ContentType():     JSONCodec{},
+	ProtobufCodec{}.ContentType(): ProtobufCodec{},
+	MsgpackCodec{}.ContentType():  MsgpackCodec{},
+	CBORCodec{}.ContentType():     CBORCodec{},
+}
+
+// CodecByContentType looks up one of the built-in codecs by the identifier
+// stored in queue.Task.Codec. It returns false for unknown identifiers,
+// including those naming a custom Codec the caller must supply itself.
+func CodecByContentType(contentType string) (Codec, bool) {
+	c, ok := codecsByContentType[contentType]
+	return c, ok
+}
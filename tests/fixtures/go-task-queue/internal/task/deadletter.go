@@ -0,0 +1,75 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DeadLetter captures everything needed to inspect or replay a task that
+// failed permanently: its original payload and type, the full error
+// chain, how many attempts were made, and when it first ran and finally
+// gave up.
+type DeadLetter struct {
+	TaskType  string                 `json:"task_type"`
+	Payload   map[string]interface{} `json:"payload"`
+	Error     string                 `json:"error"`
+	Attempts  int                    `json:"attempts"`
+	FirstSeen time.Time              `json:"first_seen"`
+	FailedAt  time.Time              `json:"failed_at"`
+}
+
+// DeadLetterSink receives tasks that a handler gave up on permanently.
+type DeadLetterSink interface {
+	Send(ctx context.Context, dl DeadLetter) error
+}
+
+// WithDeadLetter wraps a handler so that, on final failure, the original
+// payload, task type, error chain, attempt count, and timestamps are
+// handed to sink as a DeadLetter instead of just being returned to the
+// caller. Pair it with WithRetryPolicy (outer) so Attempts reflects the
+// retry count rather than always 1; without a RetryPolicy in the chain,
+// RetryExhaustedError is absent and Attempts defaults to 1.
+func WithDeadLetter(sink DeadLetterSink) Middleware {
+	return func(next RawHandler) RawHandler {
+		return &deadLetterHandler{next: next, sink: sink}
+	}
+}
+
+type deadLetterHandler struct {
+	next RawHandler
+	sink DeadLetterSink
+}
+
+func (d *deadLetterHandler) HandleRaw(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	firstSeen := time.Now()
+
+	result, err := d.next.HandleRaw(ctx, payload)
+	if err == nil {
+		return result, nil
+	}
+
+	attempts := 1
+	var exhausted *RetryExhaustedError
+	if errors.As(err, &exhausted) {
+		attempts = exhausted.Attempts
+	}
+
+	dl := DeadLetter{
+		TaskType:  d.next.TaskType(),
+		Payload:   payload,
+		Error:     err.Error(),
+		Attempts:  attempts,
+		FirstSeen: firstSeen,
+		FailedAt:  time.Now(),
+	}
+
+	// Dead-lettering is best-effort: a sink failure shouldn't mask the
+	// original handler error from the caller.
+	_ = d.sink.Send(ctx, dl)
+
+	return nil, err
+}
+
+func (d *deadLetterHandler) TaskType() string       { return d.next.TaskType() }
+func (d *deadLetterHandler) Timeout() time.Duration { return d.next.Timeout() }
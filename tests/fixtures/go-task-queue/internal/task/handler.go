@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/example/task-queue/internal/queue"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Common handler errors
@@ -106,36 +107,118 @@ func (th *TypedHandler[T, R]) Timeout() time.Duration {
 	return th.handler.Timeout()
 }
 
+// dispatch runs handler against task, preferring HandleBody over HandleRaw
+// whenever handler implements RawBodyHandler and task carries a Body, so
+// typed producers and handlers skip the map[string]interface{} round trip.
+func dispatch(ctx context.Context, handler RawHandler, task *queue.Task) (interface{}, error) {
+	if rb, ok := handler.(RawBodyHandler); ok && len(task.Body) > 0 {
+		return rb.HandleBody(ctx, task.Body)
+	}
+	return handler.HandleRaw(ctx, task.Payload)
+}
+
+// RawBodyHandler is implemented by handlers that decode a task's
+// Codec-encoded queue.Task.Body directly, instead of TypedHandler's
+// map[string]interface{}-via-JSON path. Execute and ExecuteWithPool prefer
+// HandleBody over HandleRaw whenever both the handler and the task support
+// it, so typed producers and handlers skip the map round trip entirely.
+type RawBodyHandler interface {
+	RawHandler
+	HandleBody(ctx context.Context, body []byte) (interface{}, error)
+}
+
+// CodecTypedHandler wraps a generic handler with a pluggable Codec. It
+// still implements HandleRaw (decoding via JSON, like TypedHandler) so it
+// keeps working against the map-based Payload compatibility path, and
+// additionally implements HandleBody for producers that hand it a Codec
+// encoded queue.Task.Body.
+type CodecTypedHandler[T any, R any] struct {
+	handler Handler[T, R]
+	codec   Codec
+}
+
+// WrapHandlerWithCodec wraps a generic handler into a RawHandler (and
+// RawBodyHandler) that decodes with codec instead of hard-coded JSON.
+func WrapHandlerWithCodec[T any, R any](h Handler[T, R], codec Codec) RawHandler {
+	return &CodecTypedHandler[T, R]{handler: h, codec: codec}
+}
+
+// HandleRaw implements RawHandler by decoding payload through JSON, the
+// same compatibility path TypedHandler uses, regardless of codec.
+func (ch *CodecTypedHandler[T, R]) HandleRaw(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	jsonBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPayloadDecode, err)
+	}
+
+	var typed T
+	if err := json.Unmarshal(jsonBytes, &typed); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPayloadDecode, err)
+	}
+
+	return ch.handler.Handle(ctx, typed)
+}
+
+// HandleBody implements RawBodyHandler, decoding body with ch.codec.
+func (ch *CodecTypedHandler[T, R]) HandleBody(ctx context.Context, body []byte) (interface{}, error) {
+	var typed T
+	if err := ch.codec.Unmarshal(body, &typed); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPayloadDecode, err)
+	}
+
+	return ch.handler.Handle(ctx, typed)
+}
+
+// TaskType implements RawHandler
+func (ch *CodecTypedHandler[T, R]) TaskType() string {
+	return ch.handler.TaskType()
+}
+
+// Timeout implements RawHandler
+func (ch *CodecTypedHandler[T, R]) Timeout() time.Duration {
+	return ch.handler.Timeout()
+}
+
 // Registry maintains a map of task types to handlers
 type Registry struct {
 	handlers map[string]RawHandler
 	hooks    []Hook
 }
 
+// HookContext carries per-invocation state that Execute/ExecuteWithPool
+// already compute so every Hook doesn't have to rebuild it: when the
+// invocation started, which attempt this is, and (when WithTracing is in
+// the handler chain) the span covering it.
+type HookContext struct {
+	StartTime time.Time
+	Attempt   int
+	Span      trace.Span
+}
+
 // Hook is called during task lifecycle
 type Hook interface {
-	BeforeExecute(ctx context.Context, task *queue.Task) error
-	AfterExecute(ctx context.Context, task *queue.Task, result interface{}, err error)
+	BeforeExecute(ctx context.Context, task *queue.Task, hc HookContext) error
+	AfterExecute(ctx context.Context, task *queue.Task, hc HookContext, result interface{}, err error)
 }
 
 // HookFunc is a function adapter for hooks
 type HookFunc struct {
-	before func(context.Context, *queue.Task) error
-	after  func(context.Context, *queue.Task, interface{}, error)
+	before func(context.Context, *queue.Task, HookContext) error
+	after  func(context.Context, *queue.Task, HookContext, interface{}, error)
 }
 
 // BeforeExecute implements Hook
-func (h *HookFunc) BeforeExecute(ctx context.Context, task *queue.Task) error {
+func (h *HookFunc) BeforeExecute(ctx context.Context, task *queue.Task, hc HookContext) error {
 	if h.before != nil {
-		return h.before(ctx, task)
+		return h.before(ctx, task, hc)
 	}
 	return nil
 }
 
 // AfterExecute implements Hook
-func (h *HookFunc) AfterExecute(ctx context.Context, task *queue.Task, result interface{}, err error) {
+func (h *HookFunc) AfterExecute(ctx context.Context, task *queue.Task, hc HookContext, result interface{}, err error) {
 	if h.after != nil {
-		h.after(ctx, task, result, err)
+		h.after(ctx, task, hc, result, err)
 	}
 }
 
@@ -169,16 +252,26 @@ func (r *Registry) AddHook(h Hook) {
 	r.hooks = append(r.hooks, h)
 }
 
-// Execute runs a task with the appropriate handler
+// Execute runs a task with the appropriate handler.
+//
+// Deprecated: Execute spawns an unbounded goroutine per call and abandons
+// it on timeout, so a slow handler keeps writing to result/execErr after
+// the caller has already moved on - a data race. Use ExecuteWithPool with
+// a shared WorkerPool instead.
 func (r *Registry) Execute(ctx context.Context, task *queue.Task) (interface{}, error) {
 	handler, ok := r.handlers[task.Type]
 	if !ok {
 		return nil, fmt.Errorf("%w: %s", ErrHandlerNotFound, task.Type)
 	}
 
+	hc := HookContext{StartTime: time.Now(), Attempt: task.Retries + 1}
+	ctx = WithTaskContext(ctx, TaskContext{ID: task.ID, Type: task.Type, Attempt: hc.Attempt})
+	var box *spanBox
+	ctx, box = withSpanBox(ctx)
+
 	// Run before hooks
 	for _, hook := range r.hooks {
-		if err := hook.BeforeExecute(ctx, task); err != nil {
+		if err := hook.BeforeExecute(ctx, task, hc); err != nil {
 			return nil, err
 		}
 	}
@@ -203,7 +296,7 @@ func (r *Registry) Execute(ctx context.Context, task *queue.Task) (interface{},
 			}
 			close(done)
 		}()
-		result, execErr = handler.HandleRaw(ctx, task.Payload)
+		result, execErr = dispatch(ctx, handler, task)
 	}()
 
 	select {
@@ -214,8 +307,9 @@ func (r *Registry) Execute(ctx context.Context, task *queue.Task) (interface{},
 	}
 
 	// Run after hooks
+	hc.Span = box.get()
 	for _, hook := range r.hooks {
-		hook.AfterExecute(ctx, task, result, execErr)
+		hook.AfterExecute(ctx, task, hc, result, execErr)
 	}
 
 	return result, execErr
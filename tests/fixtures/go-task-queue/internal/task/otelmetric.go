@@ -0,0 +1,68 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// NewOTelMetricsMiddleware builds a Middleware that records task.duration,
+// task.retries, and task.in_flight against meter, complementing WithTracing
+// for deployments that export via an OTel MeterProvider. It returns an
+// error rather than panicking because instrument creation can fail (e.g.
+// a duplicate name registered against the same meter).
+func NewOTelMetricsMiddleware(meter metric.Meter) (Middleware, error) {
+	duration, err := meter.Float64Histogram("task.duration",
+		metric.WithDescription("Task handler execution duration, in seconds."))
+	if err != nil {
+		return nil, err
+	}
+	retries, err := meter.Int64Counter("task.retries",
+		metric.WithDescription("Number of task invocations that were a retry (attempt > 1)."))
+	if err != nil {
+		return nil, err
+	}
+	inFlight, err := meter.Int64UpDownCounter("task.in_flight",
+		metric.WithDescription("Number of task handler invocations currently executing."))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next RawHandler) RawHandler {
+		return &otelMetricsHandler{
+			next:     next,
+			duration: duration,
+			retries:  retries,
+			inFlight: inFlight,
+		}
+	}, nil
+}
+
+type otelMetricsHandler struct {
+	next     RawHandler
+	duration metric.Float64Histogram
+	retries  metric.Int64Counter
+	inFlight metric.Int64UpDownCounter
+}
+
+func (o *otelMetricsHandler) HandleRaw(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	attrs := metric.WithAttributes(attribute.String("task_type", o.next.TaskType()))
+
+	o.inFlight.Add(ctx, 1, attrs)
+	defer o.inFlight.Add(ctx, -1, attrs)
+
+	if tc, ok := TaskContextFrom(ctx); ok && tc.Attempt > 1 {
+		o.retries.Add(ctx, 1, attrs)
+	}
+
+	start := time.Now()
+	result, err := o.next.HandleRaw(ctx, payload)
+	o.duration.Record(ctx, time.Since(start).Seconds(), attrs)
+
+	return result, err
+}
+
+func (o *otelMetricsHandler) TaskType() string       { return o.next.TaskType() }
+func (o *otelMetricsHandler) Timeout() time.Duration { return o.next.Timeout() }
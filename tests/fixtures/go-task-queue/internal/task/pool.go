@@ -0,0 +1,227 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/example/task-queue/internal/queue"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PanicError wraps a recovered handler panic with the stack trace at the
+// point of the panic, and unwraps to ErrHandlerPanic so existing
+// errors.Is(err, ErrHandlerPanic) checks keep working.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error implements error.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%s: %v\n%s", ErrHandlerPanic, e.Value, e.Stack)
+}
+
+// Unwrap exposes ErrHandlerPanic for errors.Is.
+func (e *PanicError) Unwrap() error {
+	return ErrHandlerPanic
+}
+
+var (
+	poolInFlightDesc = prometheus.NewDesc(
+		"taskqueue_pool_in_flight", "Number of tasks currently executing.", nil, nil)
+	poolQueuedDesc = prometheus.NewDesc(
+		"taskqueue_pool_queued", "Number of tasks waiting for an admission slot.", nil, nil)
+	poolCompletedDesc = prometheus.NewDesc(
+		"taskqueue_pool_completed_total", "Total number of tasks that finished, successfully or not.", nil, nil)
+	poolPanickedDesc = prometheus.NewDesc(
+		"taskqueue_pool_panicked_total", "Total number of tasks whose handler panicked.", nil, nil)
+)
+
+// PoolMetrics holds a WorkerPool's counters and implements
+// prometheus.Collector directly so it can be registered as-is.
+type PoolMetrics struct {
+	inFlight  atomic.Int64
+	queued    atomic.Int64
+	completed atomic.Int64
+	panicked  atomic.Int64
+}
+
+// Describe implements prometheus.Collector.
+func (m *PoolMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolInFlightDesc
+	ch <- poolQueuedDesc
+	ch <- poolCompletedDesc
+	ch <- poolPanickedDesc
+}
+
+// Collect implements prometheus.Collector.
+func (m *PoolMetrics) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(poolInFlightDesc, prometheus.GaugeValue, float64(m.inFlight.Load()))
+	ch <- prometheus.MustNewConstMetric(poolQueuedDesc, prometheus.GaugeValue, float64(m.queued.Load()))
+	ch <- prometheus.MustNewConstMetric(poolCompletedDesc, prometheus.CounterValue, float64(m.completed.Load()))
+	ch <- prometheus.MustNewConstMetric(poolPanickedDesc, prometheus.CounterValue, float64(m.panicked.Load()))
+}
+
+// Snapshot returns a point-in-time read of the metrics.
+func (m *PoolMetrics) Snapshot() PoolMetricsSnapshot {
+	return PoolMetricsSnapshot{
+		InFlight:  m.inFlight.Load(),
+		Queued:    m.queued.Load(),
+		Completed: m.completed.Load(),
+		Panicked:  m.panicked.Load(),
+	}
+}
+
+// PoolMetricsSnapshot is a point-in-time read of PoolMetrics.
+type PoolMetricsSnapshot struct {
+	InFlight  int64
+	Queued    int64
+	Completed int64
+	Panicked  int64
+}
+
+// WorkerPool bounds the number of task handlers executing concurrently
+// via a semaphore, replacing Registry.Execute's unbounded goroutine per
+// call. Share one WorkerPool across every Registry.ExecuteWithPool call
+// that should count against the same concurrency limit.
+type WorkerPool struct {
+	sem     chan struct{}
+	metrics *PoolMetrics
+}
+
+// NewWorkerPool creates a WorkerPool admitting at most concurrency tasks
+// at a time. concurrency <= 0 is treated as 1.
+func NewWorkerPool(concurrency int) *WorkerPool {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &WorkerPool{
+		sem:     make(chan struct{}, concurrency),
+		metrics: &PoolMetrics{},
+	}
+}
+
+// Metrics returns the pool's metrics collector.
+func (p *WorkerPool) Metrics() *PoolMetrics {
+	return p.metrics
+}
+
+// acquire blocks until an admission slot is free or ctx is cancelled,
+// returning a release func that must be called to free the slot.
+func (p *WorkerPool) acquire(ctx context.Context) (func(), error) {
+	p.metrics.queued.Add(1)
+	select {
+	case p.sem <- struct{}{}:
+		p.metrics.queued.Add(-1)
+		p.metrics.inFlight.Add(1)
+		return func() {
+			<-p.sem
+			p.metrics.inFlight.Add(-1)
+		}, nil
+	case <-ctx.Done():
+		p.metrics.queued.Add(-1)
+		return nil, ctx.Err()
+	}
+}
+
+type execOutcome struct {
+	result interface{}
+	err    error
+}
+
+// ExecuteWithPool runs t through pool's admission control instead of
+// Execute's unbounded goroutine spawn. The handler still runs in its own
+// goroutine (HandleRaw has no way to be preempted), but its result is
+// delivered over a buffered channel rather than written to shared
+// variables: if ctx is done first, ExecuteWithPool returns immediately
+// without touching anything the now-abandoned handler goroutine later
+// writes, so there's no data race, and context.AfterFunc finishes
+// accounting (Completed/Panicked) once that goroutine actually exits.
+func (r *Registry) ExecuteWithPool(ctx context.Context, pool *WorkerPool, t *queue.Task) (interface{}, error) {
+	handler, ok := r.handlers[t.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrHandlerNotFound, t.Type)
+	}
+
+	hc := HookContext{StartTime: time.Now(), Attempt: t.Retries + 1}
+	ctx = WithTaskContext(ctx, TaskContext{ID: t.ID, Type: t.Type, Attempt: hc.Attempt})
+	var box *spanBox
+	ctx, box = withSpanBox(ctx)
+
+	for _, hook := range r.hooks {
+		if err := hook.BeforeExecute(ctx, t, hc); err != nil {
+			return nil, err
+		}
+	}
+
+	release, err := pool.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := handler.Timeout()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	outcomeCh := make(chan execOutcome, 1)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				outcomeCh <- execOutcome{err: &PanicError{Value: rec, Stack: debug.Stack()}}
+				return
+			}
+		}()
+		result, err := dispatch(ctx, handler, t)
+		outcomeCh <- execOutcome{result: result, err: err}
+	}()
+
+	// account finishes the pool's bookkeeping for one outcome, however it
+	// arrives: inline below on the fast path, or later via AfterFunc if
+	// the handler outlives ctx.
+	account := func(outcome execOutcome) {
+		release()
+		pool.metrics.completed.Add(1)
+		var panicErr *PanicError
+		if asPanicError(outcome.err, &panicErr) {
+			pool.metrics.panicked.Add(1)
+		}
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		account(<-outcomeCh)
+	})
+
+	var result interface{}
+	var execErr error
+	select {
+	case <-ctx.Done():
+		execErr = ErrHandlerTimeout
+	case outcome := <-outcomeCh:
+		if stop() {
+			// We won the race to consume outcomeCh; AfterFunc never ran.
+			account(outcome)
+		}
+		result, execErr = outcome.result, outcome.err
+	}
+
+	hc.Span = box.get()
+	for _, hook := range r.hooks {
+		hook.AfterExecute(ctx, t, hc, result, execErr)
+	}
+
+	return result, execErr
+}
+
+func asPanicError(err error, target **PanicError) bool {
+	pe, ok := err.(*PanicError)
+	if ok {
+		*target = pe
+	}
+	return ok
+}
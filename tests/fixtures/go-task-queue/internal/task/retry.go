@@ -0,0 +1,131 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryExhaustedError wraps the last error from a RetryPolicy-governed
+// handler once ShouldRetry returns false, recording how many attempts
+// were made. WithDeadLetter unwraps this (via errors.As) to populate
+// DeadLetter.Attempts without the two middlewares needing any other way
+// to share state across the call.
+type RetryExhaustedError struct {
+	Attempts int
+	Err      error
+}
+
+// Error implements error.
+func (e *RetryExhaustedError) Error() string {
+	return fmt.Sprintf("exhausted %d attempt(s): %v", e.Attempts, e.Err)
+}
+
+// Unwrap exposes the underlying error for errors.Is/As.
+func (e *RetryExhaustedError) Unwrap() error {
+	return e.Err
+}
+
+// RetryPolicy decides, for a given error and 1-indexed attempt number,
+// whether HandleRaw should be retried and how long to wait first. Unlike
+// WithRetry's fixed maxRetries/backoff pair, a RetryPolicy can vary the
+// delay per error or attempt and is shared across concurrent invocations
+// of the handler it wraps, so implementations must be stateless (or
+// internally synchronized) with respect to attempt.
+type RetryPolicy interface {
+	ShouldRetry(err error, attempt int) (retry bool, delay time.Duration)
+}
+
+// FullJitterPolicy retries up to MaxRetries times with the "full jitter"
+// exponential backoff from the AWS architecture blog: each delay is a
+// uniform random duration in [0, min(Max, Base*2^(attempt-1))).
+type FullJitterPolicy struct {
+	MaxRetries int
+	Base       time.Duration
+	Max        time.Duration
+}
+
+// ShouldRetry implements RetryPolicy.
+func (p FullJitterPolicy) ShouldRetry(err error, attempt int) (bool, time.Duration) {
+	if attempt > p.MaxRetries {
+		return false, 0
+	}
+	cap := float64(p.Base) * math.Pow(2, float64(attempt-1))
+	if cap > float64(p.Max) {
+		cap = float64(p.Max)
+	}
+	return true, time.Duration(rand.Float64() * cap)
+}
+
+// DecorrelatedJitterPolicy retries up to MaxRetries times with the
+// AWS-recommended "decorrelated jitter" formula. Because RetryPolicy must
+// be safe to share across concurrently retrying tasks, this approximates
+// the recursive sleep_i = random(Base, sleep_{i-1}*3) definition from
+// attempt alone (sleep_{i-1} ~= Base*3^(attempt-2)) rather than carrying
+// per-task state.
+type DecorrelatedJitterPolicy struct {
+	MaxRetries int
+	Base       time.Duration
+	Max        time.Duration
+}
+
+// ShouldRetry implements RetryPolicy.
+func (p DecorrelatedJitterPolicy) ShouldRetry(err error, attempt int) (bool, time.Duration) {
+	if attempt > p.MaxRetries {
+		return false, 0
+	}
+	prev := float64(p.Base)
+	if attempt > 1 {
+		prev = float64(p.Base) * math.Pow(3, float64(attempt-2))
+	}
+	delay := float64(p.Base) + rand.Float64()*(prev*3-float64(p.Base))
+	if delay > float64(p.Max) {
+		delay = float64(p.Max)
+	}
+	return true, time.Duration(delay)
+}
+
+// WithRetryPolicy wraps a handler with retry logic governed by policy,
+// replacing the fixed-backoff WithRetry for callers that need per-error
+// retry decisions or jittered delays. Unlike WithRetry, the wait between
+// attempts respects ctx.Done so a cancelled task stops retrying promptly
+// instead of sleeping out a fixed backoff.
+func WithRetryPolicy(policy RetryPolicy) Middleware {
+	return func(next RawHandler) RawHandler {
+		return &retryPolicyHandler{next: next, policy: policy}
+	}
+}
+
+type retryPolicyHandler struct {
+	next   RawHandler
+	policy RetryPolicy
+}
+
+func (r *retryPolicyHandler) HandleRaw(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		result, err := r.next.HandleRaw(ctx, payload)
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+
+		retry, delay := r.policy.ShouldRetry(err, attempt)
+		if !retry {
+			return nil, &RetryExhaustedError{Attempts: attempt, Err: lastErr}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (r *retryPolicyHandler) TaskType() string       { return r.next.TaskType() }
+func (r *retryPolicyHandler) Timeout() time.Duration { return r.next.Timeout() }
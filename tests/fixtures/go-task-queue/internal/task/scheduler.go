@@ -0,0 +1,329 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/example/task-queue/internal/queue"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// renewLockScript extends lockKey's TTL only if this instance still holds
+// it, so a scheduler that lost leadership (e.g. after a long GC pause)
+// can't resurrect its own expired lock out from under a new leader.
+var renewLockScript = redis.NewScript(`
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	end
+	return 0
+`)
+
+// moveDueScript atomically removes member from the scheduled ZSET and, if
+// it was actually still present, pushes it onto the pending list. The ZREM
+// result gates the LPUSH so two schedulers racing on the same member (e.g.
+// a brief leadership overlap) can't both dispatch it.
+var moveDueScript = redis.NewScript(`
+	local removed = redis.call("ZREM", KEYS[1], ARGV[1])
+	if removed == 1 then
+		redis.call("LPUSH", KEYS[2], ARGV[1])
+	end
+	return removed
+`)
+
+// SchedulerConfig configures a Scheduler.
+type SchedulerConfig struct {
+	// ScheduledKey is the ZSET holding pending scheduled/cron tasks,
+	// scored by their unix-nano due time.
+	ScheduledKey string
+
+	// PendingKey is the list due tasks are moved onto; it should match
+	// the redisq.Config.Pending of the workers that will consume them.
+	PendingKey string
+
+	// LockKey is the well-known key used for leader election.
+	LockKey string
+
+	// InstanceID identifies this process in the leader lock. Defaults to
+	// a random UUID if empty.
+	InstanceID string
+
+	// LockTTL is how long a held leader lock survives without renewal.
+	LockTTL time.Duration
+
+	// HeartbeatInterval is how often the leader renews LockKey.
+	HeartbeatInterval time.Duration
+
+	// PollInterval bounds how long the dispatch loop sleeps when the
+	// scheduled set is empty, so it still notices new entries promptly.
+	PollInterval time.Duration
+
+	// BatchSize caps how many due tasks are moved per dispatch tick.
+	BatchSize int64
+}
+
+// DefaultSchedulerConfig returns sane defaults for a Scheduler sharing
+// pendingKey with a redisq worker pool.
+func DefaultSchedulerConfig(pendingKey string) SchedulerConfig {
+	return SchedulerConfig{
+		ScheduledKey:      "redisq:scheduled",
+		PendingKey:        pendingKey,
+		LockKey:           "redisq:scheduler:leader",
+		InstanceID:        uuid.New().String(),
+		LockTTL:           15 * time.Second,
+		HeartbeatInterval: 5 * time.Second,
+		PollInterval:      time.Second,
+		BatchSize:         100,
+	}
+}
+
+type cronEntry struct {
+	id       string
+	schedule queue.CronSchedule
+	taskType string
+	payload  map[string]interface{}
+}
+
+// Scheduler dispatches delayed and cron-triggered tasks through a Redis
+// ZSET. Only the elected leader (see tryAcquireLeader) actually moves due
+// tasks to the pending list; EnqueueAt/EnqueueIn/RegisterCron may be
+// called from any process since ZADD is itself concurrency-safe.
+type Scheduler struct {
+	client *redis.Client
+	cfg    SchedulerConfig
+
+	mu          sync.Mutex
+	cronEntries map[string]*cronEntry
+
+	isLeader bool
+}
+
+// NewScheduler creates a Scheduler against an already-connected client.
+func NewScheduler(client *redis.Client, cfg SchedulerConfig) *Scheduler {
+	if cfg.InstanceID == "" {
+		cfg.InstanceID = uuid.New().String()
+	}
+	return &Scheduler{
+		client:      client,
+		cfg:         cfg,
+		cronEntries: make(map[string]*cronEntry),
+	}
+}
+
+// EnqueueAt schedules task for dispatch at or after at.
+func (s *Scheduler) EnqueueAt(ctx context.Context, t *queue.Task, at time.Time) error {
+	if t == nil {
+		return queue.ErrInvalidTask
+	}
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	t.State = queue.StatePending
+
+	payload, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+
+	return s.client.ZAdd(ctx, s.cfg.ScheduledKey, redis.Z{
+		Score:  float64(at.UnixNano()),
+		Member: payload,
+	}).Err()
+}
+
+// EnqueueIn schedules task for dispatch after delay elapses.
+func (s *Scheduler) EnqueueIn(ctx context.Context, t *queue.Task, delay time.Duration) error {
+	return s.EnqueueAt(ctx, t, time.Now().Add(delay))
+}
+
+// RegisterCron registers a recurring task materialized from taskType and
+// payload at each firing of the given 5-field cron spec, and schedules
+// its first firing. Only the leader's Run loop re-chains subsequent
+// firings, so RegisterCron should be called identically by every process
+// that might become leader.
+func (s *Scheduler) RegisterCron(id, spec, taskType string, payload map[string]interface{}) error {
+	sched, err := queue.ParseCronSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+
+	s.mu.Lock()
+	s.cronEntries[id] = &cronEntry{id: id, schedule: sched, taskType: taskType, payload: payload}
+	s.mu.Unlock()
+
+	return s.scheduleNextCronFiring(context.Background(), id, time.Now())
+}
+
+func (s *Scheduler) scheduleNextCronFiring(ctx context.Context, id string, after time.Time) error {
+	s.mu.Lock()
+	entry, ok := s.cronEntries[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	next, ok := entry.schedule.NextAfter(after)
+	if !ok {
+		return nil
+	}
+
+	t := &queue.Task{
+		ID:      fmt.Sprintf("cron:%s:%d", id, next.UnixNano()),
+		Type:    entry.taskType,
+		Payload: entry.payload,
+	}
+	return s.EnqueueAt(ctx, t, next)
+}
+
+// tryAcquireLeader attempts to claim or renew the leader lock, returning
+// whether this instance holds it afterward.
+func (s *Scheduler) tryAcquireLeader(ctx context.Context) bool {
+	if s.holdsLeadership() {
+		renewed, err := renewLockScript.Run(ctx, s.client, []string{s.cfg.LockKey}, s.cfg.InstanceID, s.cfg.LockTTL.Milliseconds()).Int()
+		if err == nil && renewed == 1 {
+			return true
+		}
+		s.setLeader(false)
+	}
+
+	ok, err := s.client.SetNX(ctx, s.cfg.LockKey, s.cfg.InstanceID, s.cfg.LockTTL).Result()
+	leader := err == nil && ok
+	s.setLeader(leader)
+	return leader
+}
+
+func (s *Scheduler) setLeader(leader bool) {
+	s.mu.Lock()
+	s.isLeader = leader
+	s.mu.Unlock()
+}
+
+// Run is the scheduler's main loop: it contends for leadership in the
+// background and, while leading, moves due entries from the scheduled
+// ZSET to the pending list and re-chains any cron entries that just
+// fired. It returns when ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.electionLoop(ctx)
+	}()
+	defer wg.Wait()
+
+	for {
+		if !s.holdsLeadership() {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(s.cfg.PollInterval):
+			}
+			continue
+		}
+
+		sleep, err := s.dispatchDue(ctx)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sleep):
+		}
+	}
+}
+
+// electionLoop periodically attempts to claim or renew leadership until
+// ctx is cancelled.
+func (s *Scheduler) electionLoop(ctx context.Context) {
+	s.tryAcquireLeader(ctx)
+
+	ticker := time.NewTicker(s.cfg.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.tryAcquireLeader(ctx)
+		}
+	}
+}
+
+// holdsLeadership reports whether this instance currently holds the
+// leader lock, without attempting to acquire it.
+func (s *Scheduler) holdsLeadership() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isLeader
+}
+
+// dispatchDue moves all currently-due entries to the pending list and
+// returns how long the caller should sleep before checking again: either
+// until the next entry's due time, or PollInterval if the set is empty.
+func (s *Scheduler) dispatchDue(ctx context.Context) (time.Duration, error) {
+	now := time.Now()
+
+	due, err := s.client.ZRangeByScore(ctx, s.cfg.ScheduledKey, &redis.ZRangeBy{
+		Min:    "-inf",
+		Max:    fmt.Sprintf("%d", now.UnixNano()),
+		Offset: 0,
+		Count:  s.cfg.BatchSize,
+	}).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan scheduled tasks: %w", err)
+	}
+
+	for _, member := range due {
+		if _, err := moveDueScript.Run(ctx, s.client, []string{s.cfg.ScheduledKey, s.cfg.PendingKey}, member).Result(); err != nil {
+			return 0, fmt.Errorf("failed to dispatch scheduled task: %w", err)
+		}
+		s.rechainIfCron(ctx, member, now)
+	}
+
+	next, err := s.client.ZRangeWithScores(ctx, s.cfg.ScheduledKey, 0, 0).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to peek next scheduled task: %w", err)
+	}
+	if len(next) == 0 {
+		return s.cfg.PollInterval, nil
+	}
+
+	nextAt := time.Unix(0, int64(next[0].Score))
+	if wait := time.Until(nextAt); wait > 0 {
+		return wait, nil
+	}
+	return 0, nil
+}
+
+// rechainIfCron re-registers a cron entry's next firing once its current
+// one has been dispatched. member is the just-dispatched task's JSON.
+func (s *Scheduler) rechainIfCron(ctx context.Context, member string, firedAt time.Time) {
+	var t queue.Task
+	if err := json.Unmarshal([]byte(member), &t); err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	var id string
+	for entryID, entry := range s.cronEntries {
+		if entry.taskType == t.Type {
+			id = entryID
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if id == "" {
+		return
+	}
+	_ = s.scheduleNextCronFiring(ctx, id, firedAt)
+}
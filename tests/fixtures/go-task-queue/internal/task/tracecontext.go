@@ -0,0 +1,169 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/example/task-queue/internal/queue"
+)
+
+// otelPayloadKey is the queue.Task.Payload key InjectTraceContext writes
+// to and WithTracing reads from, so a trace started by the producer
+// continues across the queue hop instead of starting a new root span.
+const otelPayloadKey = "_otel"
+
+// TaskContext carries the fields of a queue.Task that middlewares need
+// but HandleRaw's (ctx, payload) signature doesn't expose. Execute and
+// ExecuteWithPool populate it before running the handler chain.
+type TaskContext struct {
+	ID      string
+	Type    string
+	Attempt int
+}
+
+type taskCtxKey struct{}
+
+// WithTaskContext attaches tc to ctx.
+func WithTaskContext(ctx context.Context, tc TaskContext) context.Context {
+	return context.WithValue(ctx, taskCtxKey{}, tc)
+}
+
+// TaskContextFrom retrieves the TaskContext attached by Execute or
+// ExecuteWithPool, if any.
+func TaskContextFrom(ctx context.Context) (TaskContext, bool) {
+	tc, ok := ctx.Value(taskCtxKey{}).(TaskContext)
+	return tc, ok
+}
+
+// spanBox lets WithTracing publish the span it starts back up to
+// Execute/ExecuteWithPool for HookContext.Span, without the one-way
+// context-value propagation getting in the way: Execute installs the box
+// into ctx before calling the handler chain, and since that's the same
+// ctx (or a value-preserving derivative) the chain is invoked with,
+// tracingHandler can fill in the pointer it already has access to.
+type spanBox struct {
+	mu   sync.Mutex
+	span trace.Span
+}
+
+func (b *spanBox) set(s trace.Span) {
+	b.mu.Lock()
+	b.span = s
+	b.mu.Unlock()
+}
+
+func (b *spanBox) get() trace.Span {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.span
+}
+
+type spanBoxKey struct{}
+
+// withSpanBox installs a fresh spanBox into ctx, returning the derived
+// ctx and the box so the caller can read back whatever span (if any)
+// WithTracing records during the call.
+func withSpanBox(ctx context.Context) (context.Context, *spanBox) {
+	box := &spanBox{}
+	return context.WithValue(ctx, spanBoxKey{}, box), box
+}
+
+// InjectTraceContext serializes ctx's span context into t.Payload so a
+// consumer on the other side of the queue can continue the same trace.
+// Call this on the producer side before Enqueue.
+func InjectTraceContext(ctx context.Context, t *queue.Task) {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	if t.Payload == nil {
+		t.Payload = make(map[string]interface{})
+	}
+	otelData := make(map[string]interface{}, len(carrier))
+	for k, v := range carrier {
+		otelData[k] = v
+	}
+	t.Payload[otelPayloadKey] = otelData
+}
+
+// extractTraceContext recovers the span context InjectTraceContext stored
+// in payload, if present, returning ctx unchanged otherwise.
+func extractTraceContext(ctx context.Context, payload map[string]interface{}) context.Context {
+	raw, ok := payload[otelPayloadKey]
+	if !ok {
+		return ctx
+	}
+
+	carrier := propagation.MapCarrier{}
+	switch m := raw.(type) {
+	case map[string]interface{}:
+		for k, v := range m {
+			if s, ok := v.(string); ok {
+				carrier[k] = s
+			}
+		}
+	case map[string]string:
+		for k, v := range m {
+			carrier[k] = v
+		}
+	default:
+		return ctx
+	}
+
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// WithTracing wraps a handler with a span per invocation, continuing the
+// producer's trace (via extractTraceContext) when present. The span is
+// attached to ctx so downstream handlers and hooks can add children or
+// events, and is exposed on HookContext.Span for hooks that need it
+// directly.
+func WithTracing(tracer trace.Tracer) Middleware {
+	return func(next RawHandler) RawHandler {
+		return &tracingHandler{next: next, tracer: tracer}
+	}
+}
+
+type tracingHandler struct {
+	next   RawHandler
+	tracer trace.Tracer
+}
+
+func (t *tracingHandler) HandleRaw(ctx context.Context, payload map[string]interface{}) (interface{}, error) {
+	ctx = extractTraceContext(ctx, payload)
+	tc, _ := TaskContextFrom(ctx)
+
+	payloadSize := 0
+	if data, err := json.Marshal(payload); err == nil {
+		payloadSize = len(data)
+	}
+
+	ctx, span := t.tracer.Start(ctx, "task."+t.next.TaskType(), trace.WithAttributes(
+		attribute.String("task.type", t.next.TaskType()),
+		attribute.String("task.id", tc.ID),
+		attribute.Int("task.attempt", tc.Attempt),
+		attribute.Int("task.payload_size", payloadSize),
+	))
+	defer span.End()
+
+	if box, ok := ctx.Value(spanBoxKey{}).(*spanBox); ok {
+		box.set(span)
+	}
+
+	result, err := t.next.HandleRaw(ctx, payload)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return result, err
+}
+
+func (t *tracingHandler) TaskType() string       { return t.next.TaskType() }
+func (t *tracingHandler) Timeout() time.Duration { return t.next.Timeout() }
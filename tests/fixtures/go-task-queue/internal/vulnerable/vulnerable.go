@@ -13,16 +13,21 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 
 	"github.com/example/task-queue/internal/queue"
 )
 
+// identifierPattern allows only SQL-identifier-safe characters.
+var identifierPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
 // ============================================================================
 // SQL INJECTION PATTERNS
 // ============================================================================
 
 // SQLInjectionDirect demonstrates direct SQL injection via string concatenation.
 // TAINT: req.URL.Query().Get("id") -> fmt.Sprintf -> db.Query (SQL sink)
+//taint:expect sql-injection source=r.URL.Query sink=db.Query
 func SQLInjectionDirect(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	// SOURCE: User input from query parameter
 	userID := r.URL.Query().Get("id")
@@ -41,6 +46,7 @@ func SQLInjectionDirect(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 
 // SQLInjectionInterpolated demonstrates SQL injection with string interpolation.
 // TAINT: r.FormValue("name") -> query string -> db.Exec (SQL sink)
+//taint:expect sql-injection source=r.FormValue sink=db.Exec
 func SQLInjectionInterpolated(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	// SOURCE: Form value
 	name := r.FormValue("name")
@@ -59,6 +65,7 @@ func SQLInjectionInterpolated(db *sql.DB, w http.ResponseWriter, r *http.Request
 
 // SQLInjectionViaVariable demonstrates SQL injection through intermediate variable.
 // TAINT: r.Header.Get("X-Filter") -> filter -> query -> db.Query
+//taint:expect sql-injection source=r.Header.Get sink=db.Query
 func SQLInjectionViaVariable(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	// SOURCE: HTTP header
 	filter := r.Header.Get("X-Filter")
@@ -76,6 +83,7 @@ func SQLInjectionViaVariable(db *sql.DB, w http.ResponseWriter, r *http.Request)
 
 // SQLInjectionOrderBy demonstrates SQL injection in ORDER BY clause.
 // TAINT: r.URL.Query().Get("sort") -> ORDER BY -> db.Query
+//taint:expect sql-injection source=r.URL.Query sink=db.Query
 func SQLInjectionOrderBy(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	sortField := r.URL.Query().Get("sort")
 	sortOrder := r.URL.Query().Get("order")
@@ -91,6 +99,7 @@ func SQLInjectionOrderBy(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 
 // CommandInjectionDirect demonstrates direct command injection.
 // TAINT: r.URL.Query().Get("file") -> exec.Command (command sink)
+//taint:expect command-injection source=r.URL.Query sink=exec.Command
 func CommandInjectionDirect(w http.ResponseWriter, r *http.Request) {
 	// SOURCE: Query parameter
 	filename := r.URL.Query().Get("file")
@@ -108,6 +117,7 @@ func CommandInjectionDirect(w http.ResponseWriter, r *http.Request) {
 
 // CommandInjectionViaBody demonstrates command injection via request body.
 // TAINT: json.Decode(r.Body) -> payload.Command -> exec.Command
+//taint:expect command-injection source=r.Body sink=exec.Command
 func CommandInjectionViaBody(w http.ResponseWriter, r *http.Request) {
 	var payload struct {
 		Command string `json:"command"`
@@ -127,6 +137,7 @@ func CommandInjectionViaBody(w http.ResponseWriter, r *http.Request) {
 
 // CommandInjectionPipelined demonstrates command through pipe.
 // TAINT: r.PostFormValue("script") -> exec.Command with pipe
+//taint:expect command-injection source=r.PostFormValue sink=exec.Command
 func CommandInjectionPipelined(w http.ResponseWriter, r *http.Request) {
 	script := r.PostFormValue("script")
 
@@ -146,6 +157,7 @@ func CommandInjectionPipelined(w http.ResponseWriter, r *http.Request) {
 
 // PathTraversalDirect demonstrates direct path traversal.
 // TAINT: r.URL.Query().Get("path") -> filepath.Join -> os.ReadFile
+//taint:expect path-traversal source=r.URL.Query sink=os.ReadFile
 func PathTraversalDirect(w http.ResponseWriter, r *http.Request) {
 	// SOURCE: Query parameter
 	userPath := r.URL.Query().Get("path")
@@ -163,6 +175,7 @@ func PathTraversalDirect(w http.ResponseWriter, r *http.Request) {
 
 // PathTraversalWrite demonstrates path traversal in file write.
 // TAINT: r.FormValue("filename") -> os.Create (file write sink)
+//taint:expect path-traversal source=r.FormValue sink=os.Create
 func PathTraversalWrite(w http.ResponseWriter, r *http.Request) {
 	filename := r.FormValue("filename")
 	content := r.FormValue("content")
@@ -185,6 +198,7 @@ func PathTraversalWrite(w http.ResponseWriter, r *http.Request) {
 
 // TemplateInjectionHTML demonstrates HTML template injection.
 // TAINT: r.FormValue("content") -> template.HTML (XSS sink)
+//taint:expect template-injection source=r.FormValue sink=template.HTML
 func TemplateInjectionHTML(w http.ResponseWriter, r *http.Request) {
 	// SOURCE: Form value
 	userContent := r.FormValue("content")
@@ -198,6 +212,7 @@ func TemplateInjectionHTML(w http.ResponseWriter, r *http.Request) {
 
 // TemplateInjectionJS demonstrates JS template injection.
 // TAINT: r.URL.Query().Get("callback") -> template.JS (XSS sink)
+//taint:expect template-injection source=r.URL.Query sink=template.JS
 func TemplateInjectionJS(w http.ResponseWriter, r *http.Request) {
 	callback := r.URL.Query().Get("callback")
 
@@ -214,6 +229,7 @@ func TemplateInjectionJS(w http.ResponseWriter, r *http.Request) {
 
 // TaintThroughChannel demonstrates taint flowing through channels.
 // TAINT: r.Body -> channel -> db.Exec (cross-goroutine flow)
+//taint:expect sql-injection source=r.Body sink=db.Exec hops=cross-goroutine
 func TaintThroughChannel(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	// Channel for passing tainted data
 	dataChan := make(chan string, 1)
@@ -238,6 +254,7 @@ func TaintThroughChannel(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 
 // TaintThroughBufferedChannel demonstrates buffered channel taint flow.
 // TAINT: query params -> buffered channel -> multiple sinks
+//taint:expect sql-injection source=r.URL.Query sink=db.Exec hops=cross-goroutine
 func TaintThroughBufferedChannel(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	queries := make(chan string, 10)
 
@@ -258,6 +275,36 @@ func TaintThroughBufferedChannel(db *sql.DB, w http.ResponseWriter, r *http.Requ
 	}
 }
 
+// UnifiedQueryExecuteHandler demonstrates a single route that dispatches to
+// either a read (SELECT) or write (UPDATE) SQL path based on the HTTP
+// method, the way a unified endpoint would. The two branches should be
+// tracked as separate taint contexts so a tainted value reaching the
+// SELECT branch isn't also reported as reaching the UPDATE sink.
+// TAINT: r.URL.Query (query context) -> db.Query; r.FormValue (execute context) -> db.Exec
+//taint:unified discriminator=method query=GET execute=POST
+//taint:expect sql-injection source=r.URL.Query sink=db.Query context=query
+//taint:expect sql-injection source=r.FormValue sink=db.Exec context=execute
+func UnifiedQueryExecuteHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		// SOURCE: Query parameter, read-only context
+		filter := r.URL.Query().Get("filter")
+
+		// SINK: SQL injection in the query context
+		query := "SELECT * FROM tasks WHERE status = '" + filter + "'"
+		rows, _ := db.Query(query)
+		defer rows.Close()
+		return
+	}
+
+	// SOURCE: Form value, write context
+	status := r.FormValue("status")
+	id := r.FormValue("id")
+
+	// SINK: SQL injection in the execute context
+	query := fmt.Sprintf("UPDATE tasks SET status = '%s' WHERE id = '%s'", status, id)
+	db.Exec(query)
+}
+
 // ============================================================================
 // INTERFACE-BASED TAINT PROPAGATION
 // ============================================================================
@@ -279,6 +326,7 @@ func (s *HTTPSource) GetData() string {
 
 // ProcessSource demonstrates taint through interface dispatch.
 // TAINT: DataSource.GetData() -> db.Query (interface method dispatch)
+//taint:expect sql-injection source=DataSource.GetData sink=db.Query hops=interface
 func ProcessSource(db *sql.DB, source DataSource) {
 	// Taint flows through interface method
 	data := source.GetData()
@@ -294,6 +342,7 @@ func ProcessSource(db *sql.DB, source DataSource) {
 
 // TaintInClosure demonstrates taint captured by closure.
 // TAINT: r.FormValue -> closure capture -> db.Exec
+//taint:expect sql-injection source=r.FormValue sink=db.Exec hops=closure
 func TaintInClosure(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	// SOURCE: Form value
 	userInput := r.FormValue("input")
@@ -311,6 +360,7 @@ func TaintInClosure(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 
 // TaintInDeferredClosure demonstrates taint in deferred closure.
 // TAINT: r.Header -> deferred closure -> os.WriteFile
+//taint:expect path-traversal source=r.Header.Get sink=os.WriteFile hops=closure
 func TaintInDeferredClosure(w http.ResponseWriter, r *http.Request) {
 	// SOURCE: HTTP header
 	logData := r.Header.Get("X-Log-Data")
@@ -328,8 +378,68 @@ func TaintInDeferredClosure(w http.ResponseWriter, r *http.Request) {
 // TASK QUEUE SPECIFIC TAINT PATTERNS
 // ============================================================================
 
+// VulnerableTaskProducer enqueues a task whose payload carries tainted HTTP
+// input. The "target" and "action" keys set here are consumed, untrusted,
+// by VulnerableTaskHandler below — potentially from a separate process.
+// TAINT: r.URL.Query -> task.Payload["target"], task.Payload["action"]
+//taint:queue-producer keys=target,action
+func VulnerableTaskProducer(q queue.Queue, w http.ResponseWriter, r *http.Request) {
+	// SOURCE: Query parameters
+	target := r.URL.Query().Get("target")
+	action := r.URL.Query().Get("action")
+
+	task := &queue.Task{
+		Type: "resource.update",
+		Payload: map[string]interface{}{
+			"target": target,
+			"action": action,
+		},
+	}
+
+	if err := q.Enqueue(r.Context(), task); err != nil {
+		http.Error(w, err.Error(), 500)
+	}
+}
+
+// TaskPayload is a typed payload struct, enqueued via json.Marshal instead
+// of a bare map[string]interface{}.
+type TaskPayload struct {
+	Command string `json:"command"`
+	Args    string `json:"args"`
+}
+
+// VulnerableTypedTaskProducer enqueues a task with a typed, JSON-marshaled
+// payload carrying tainted HTTP input.
+// TAINT: r.FormValue -> TaskPayload -> json.Marshal -> task.Payload
+//taint:queue-producer keys=command,args typed=TaskPayload
+func VulnerableTypedTaskProducer(q queue.Queue, w http.ResponseWriter, r *http.Request) {
+	// SOURCE: Form values
+	payload := TaskPayload{
+		Command: r.FormValue("command"),
+		Args:    r.FormValue("args"),
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	task := &queue.Task{
+		Type: "shell.exec",
+		Payload: map[string]interface{}{
+			"data": string(encoded),
+		},
+	}
+
+	if err := q.Enqueue(r.Context(), task); err != nil {
+		http.Error(w, err.Error(), 500)
+	}
+}
+
 // VulnerableTaskHandler demonstrates taint through task queue payload.
 // TAINT: task.Payload -> SQL sink (simulates real task processing)
+//taint:expect sql-injection source=task.Payload sink=db.Exec hops=cross-process
 func VulnerableTaskHandler(db *sql.DB, task *queue.Task) error {
 	// SOURCE: Task payload from queue (originally from HTTP)
 	target := task.Payload["target"].(string)
@@ -343,6 +453,7 @@ func VulnerableTaskHandler(db *sql.DB, task *queue.Task) error {
 
 // VulnerableTaskWithCommand demonstrates command injection in task.
 // TAINT: task.Payload["command"] -> exec.Command
+//taint:expect command-injection source=task.Payload sink=exec.Command hops=cross-process
 func VulnerableTaskWithCommand(task *queue.Task) error {
 	command := task.Payload["command"].(string)
 	args := task.Payload["args"].(string)
@@ -369,6 +480,7 @@ func formatForQuery(data string) string {
 
 // MultiHopTaint demonstrates taint through multiple function calls.
 // TAINT: r.FormValue -> processInput -> formatForQuery -> db.Query
+//taint:expect sql-injection source=r.FormValue sink=db.Query hops=multi-hop
 func MultiHopTaint(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	// SOURCE: Form input
 	raw := r.FormValue("data")
@@ -397,6 +509,7 @@ type UserInput struct {
 
 // StructFieldTaint demonstrates taint through struct fields.
 // TAINT: json.Decode -> struct fields -> SQL sink
+//taint:expect sql-injection source=r.Body sink=db.Exec hops=struct-field
 func StructFieldTaint(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	var input UserInput
 
@@ -417,6 +530,7 @@ type Request struct {
 }
 
 // TAINT: json body -> nested struct -> map access -> SQL
+//taint:expect sql-injection source=r.Body sink=db.Query hops=struct-field
 func NestedStructTaint(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	var req Request
 	json.NewDecoder(r.Body).Decode(&req)
@@ -438,6 +552,7 @@ func NestedStructTaint(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 
 // SafeParameterizedQuery demonstrates proper parameterized query.
 // This should NOT be flagged as SQL injection.
+//taint:expect safe sink=db.Query reason=parameterized
 func SafeParameterizedQuery(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("id")
 
@@ -448,6 +563,7 @@ func SafeParameterizedQuery(db *sql.DB, w http.ResponseWriter, r *http.Request)
 
 // SafeCommandWithLiteral demonstrates safe command with literal.
 // This should NOT be flagged as command injection.
+//taint:expect safe sink=exec.Command reason=literal-command
 func SafeCommandWithLiteral(w http.ResponseWriter, r *http.Request) {
 	// SAFE: Literal command, user input only as argument to safe command
 	userID := r.URL.Query().Get("id")
@@ -457,6 +573,7 @@ func SafeCommandWithLiteral(w http.ResponseWriter, r *http.Request) {
 
 // SafePathWithValidation demonstrates path with validation.
 // This should NOT be flagged if sanitization is detected.
+//taint:expect safe sink=os.ReadFile reason=path-validated
 func SafePathWithValidation(w http.ResponseWriter, r *http.Request) {
 	filename := r.URL.Query().Get("file")
 
@@ -473,3 +590,63 @@ func SafePathWithValidation(w http.ResponseWriter, r *http.Request) {
 	path := filepath.Join("/var/data", cleaned)
 	os.ReadFile(path)
 }
+
+// SafeFilenameWithAllowList demonstrates sanitization via an allow-list
+// lookup rather than a pattern check. This should NOT be flagged if the
+// allow-list guard is detected as a sanitizer.
+//taint:expect safe sink=os.ReadFile reason=allowlist-validated
+func SafeFilenameWithAllowList(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+
+	allowed := map[string]bool{"report.csv": true, "summary.csv": true}
+
+	// SANITIZER: Allow-list lookup
+	if !allowed[name] {
+		http.Error(w, "invalid filename", 400)
+		return
+	}
+
+	path := filepath.Join("/var/reports", name)
+	os.ReadFile(path)
+}
+
+// SafeIdentifierWithRegexp demonstrates sanitization via regexp match
+// against an allow-pattern before the value reaches a SQL sink.
+//taint:expect safe sink=db.Query reason=regexp-validated
+func SafeIdentifierWithRegexp(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	column := r.URL.Query().Get("sort")
+
+	// SANITIZER: Regexp allow-list for identifier-safe characters
+	if !identifierPattern.MatchString(column) {
+		http.Error(w, "invalid sort column", 400)
+		return
+	}
+
+	query := fmt.Sprintf("SELECT * FROM tasks ORDER BY %s", column)
+	db.Query(query)
+}
+
+// PartiallySanitizedClosureTaint demonstrates a sanitizer applied on one
+// of two closure-borne paths: the length-checked path should score higher
+// confidence of safety than the unchecked one reaching the same sink.
+// TAINT: r.FormValue -> closure (one path length-checked, one not) -> db.Exec
+//taint:expect sql-injection source=r.FormValue sink=db.Exec hops=closure confidence=medium
+func PartiallySanitizedClosureTaint(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	comment := r.FormValue("comment")
+
+	validate := func(s string) bool {
+		// SANITIZER: Length check only; does not reject quote characters
+		return len(s) <= 280
+	}
+
+	process := func() {
+		if !validate(comment) {
+			return
+		}
+		// SINK: Still vulnerable - length check doesn't strip quotes
+		query := "INSERT INTO comments (body) VALUES ('" + comment + "')"
+		db.Exec(query)
+	}
+
+	process()
+}
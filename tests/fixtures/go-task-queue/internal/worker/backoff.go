@@ -0,0 +1,99 @@
+package worker
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff computes the delay before the next retry attempt, given the
+// number of attempts made so far (1 for the first retry).
+type Backoff interface {
+	Next(attempt int) time.Duration
+}
+
+// LinearBackoff grows the delay linearly with the attempt number, matching
+// the pool's original "RetryDelay * Retries" behavior.
+type LinearBackoff struct {
+	Delay time.Duration
+}
+
+// Next returns Delay * attempt.
+func (b LinearBackoff) Next(attempt int) time.Duration {
+	return b.Delay * time.Duration(attempt)
+}
+
+// ExponentialBackoff grows the delay by Multiplier per attempt, starting
+// at Base and capped at Max.
+type ExponentialBackoff struct {
+	Base       time.Duration
+	Max        time.Duration
+	Multiplier float64
+}
+
+// Next returns min(Max, Base * Multiplier^(attempt-1)).
+func (b ExponentialBackoff) Next(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := float64(b.Base) * math.Pow(b.Multiplier, float64(attempt-1))
+	if d > float64(b.Max) {
+		return b.Max
+	}
+	return time.Duration(d)
+}
+
+// FullJitterBackoff implements the "full jitter" formula from AWS's
+// exponential backoff guidance: delay = random_between(0, min(Max, Base *
+// 2^attempt)). Unlike DecorrelatedJitter it is stateless across calls.
+type FullJitterBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Next returns min(Max, Base*2^attempt) scaled by a uniform random factor
+// in [0, 1).
+func (b FullJitterBackoff) Next(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	ceiling := float64(b.Base) * math.Pow(2, float64(attempt))
+	if ceiling > float64(b.Max) {
+		ceiling = float64(b.Max)
+	}
+
+	return time.Duration(ceiling * rand.Float64())
+}
+
+// DecorrelatedJitter implements the AWS-recommended "decorrelated jitter"
+// formula: sleep = min(Max, random_between(Base, prev*3)). It is stateful
+// across calls for a given task, so callers should keep one instance per
+// retrying task rather than sharing it.
+type DecorrelatedJitter struct {
+	Base time.Duration
+	Max  time.Duration
+
+	prev time.Duration
+}
+
+// Next returns the next jittered delay and advances the internal state.
+func (b *DecorrelatedJitter) Next(attempt int) time.Duration {
+	prev := b.prev
+	if prev == 0 {
+		prev = b.Base
+	}
+
+	upper := prev * 3
+	if upper < b.Base {
+		upper = b.Base
+	}
+
+	d := b.Base + time.Duration(rand.Int63n(int64(upper-b.Base)+1))
+	if d > b.Max {
+		d = b.Max
+	}
+
+	b.prev = d
+	return d
+}
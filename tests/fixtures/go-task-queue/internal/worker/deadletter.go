@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/example/task-queue/internal/queue"
+)
+
+// DrainDLQ moves every task currently sitting in dlq back onto primary,
+// resetting each task's retry count and clearing its error the same way
+// Pool.ReplayDeadLetter does for a single task -- the package-level
+// equivalent for operators requeuing a whole dead-letter queue after
+// fixing whatever caused the failures, rather than one task ID at a
+// time. It stops at the first task dlq reports as not found (queue.
+// ErrQueueEmpty) and returns how many were requeued. A task that fails
+// to re-enqueue onto primary is left on dlq rather than being dropped,
+// and its error is recorded as the first returned error so the caller
+// knows draining didn't fully succeed; draining continues past it so one
+// bad task doesn't strand the rest.
+func DrainDLQ(ctx context.Context, dlq, primary queue.Queue) (int, error) {
+	var drained int
+	var firstErr error
+
+	for {
+		t, err := dlq.Dequeue(ctx)
+		if err != nil {
+			if errors.Is(err, queue.ErrQueueEmpty) {
+				break
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("dequeuing from dead-letter queue: %w", err)
+			}
+			break
+		}
+
+		t.Retries = 0
+		t.State = queue.StatePending
+		t.Error = ""
+		t.ScheduledAt = nil
+
+		if err := primary.Enqueue(ctx, t); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("re-enqueueing task %s: %w", t.ID, err)
+			}
+			continue
+		}
+		drained++
+	}
+
+	return drained, firstErr
+}
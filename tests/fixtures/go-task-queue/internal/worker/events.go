@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType identifies a task lifecycle transition broadcast to subscribers.
+type EventType string
+
+const (
+	EventStarted   EventType = "started"
+	EventRetrying  EventType = "retrying"
+	EventCompleted EventType = "completed"
+	EventFailed    EventType = "failed"
+)
+
+// Event is a single task lifecycle transition, delivered to whoever is
+// subscribed to its TaskID via Pool.Subscribe. It's the payload the API's
+// SSE endpoint marshals straight to JSON for each stream line.
+type Event struct {
+	TaskID    string      `json:"task_id"`
+	TaskType  string      `json:"task_type"`
+	Type      EventType   `json:"type"`
+	Attempt   int         `json:"attempt,omitempty"`
+	WorkerID  int         `json:"worker_id,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Output    interface{} `json:"output,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// eventBufferSize bounds each subscriber's channel. Publish drops the
+// oldest buffered event rather than blocking a worker goroutine on a slow
+// or stalled reader.
+const eventBufferSize = 32
+
+// eventBus fans out per-task Events to subscribers with bounded,
+// drop-oldest channels.
+type eventBus struct {
+	mu   sync.Mutex
+	subs map[string]map[chan Event]struct{}
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subs: make(map[string]map[chan Event]struct{})}
+}
+
+// subscribe returns a channel receiving every future Event for taskID, and
+// an unsubscribe func the caller must invoke when it stops reading.
+func (b *eventBus) subscribe(taskID string) (<-chan Event, func()) {
+	ch := make(chan Event, eventBufferSize)
+
+	b.mu.Lock()
+	if b.subs[taskID] == nil {
+		b.subs[taskID] = make(map[chan Event]struct{})
+	}
+	b.subs[taskID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs[taskID], ch)
+			if len(b.subs[taskID]) == 0 {
+				delete(b.subs, taskID)
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// publish delivers ev to every current subscriber of ev.TaskID, dropping
+// the oldest buffered event for any subscriber whose channel is full.
+func (b *eventBus) publish(ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs[ev.TaskID] {
+		select {
+		case ch <- ev:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- ev:
+			default:
+			}
+		}
+	}
+}
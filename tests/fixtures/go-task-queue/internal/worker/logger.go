@@ -0,0 +1,30 @@
+package worker
+
+// Logger is the structured logging interface used throughout Pool. Every
+// call site attaches contextual key-value pairs (worker_id, task_id,
+// task_type, attempt, ...) so operators can trace a task through its full
+// lifecycle in whatever backend the adapter writes to.
+type Logger interface {
+	Debugf(msg string, kv ...interface{})
+	Infof(msg string, kv ...interface{})
+	Warnf(msg string, kv ...interface{})
+	Errorf(msg string, kv ...interface{})
+}
+
+// noopLogger discards everything; it's the default so existing callers
+// that don't configure a Logger see no behavior change.
+type noopLogger struct{}
+
+func (noopLogger) Debugf(string, ...interface{}) {}
+func (noopLogger) Infof(string, ...interface{})  {}
+func (noopLogger) Warnf(string, ...interface{})  {}
+func (noopLogger) Errorf(string, ...interface{}) {}
+
+// WithLogger sets the pool's structured logger.
+func WithLogger(l Logger) PoolOption {
+	return func(p *Pool) {
+		if l != nil {
+			p.logger = l
+		}
+	}
+}
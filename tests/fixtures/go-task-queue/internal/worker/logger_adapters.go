@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"log/slog"
+
+	"go.uber.org/zap"
+
+	"github.com/rs/zerolog"
+)
+
+// SlogLogger adapts *slog.Logger to the worker.Logger interface.
+type SlogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlogLogger wraps an existing *slog.Logger.
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	return &SlogLogger{l: l}
+}
+
+func (s *SlogLogger) Debugf(msg string, kv ...interface{}) { s.l.Debug(msg, kv...) }
+func (s *SlogLogger) Infof(msg string, kv ...interface{})  { s.l.Info(msg, kv...) }
+func (s *SlogLogger) Warnf(msg string, kv ...interface{})  { s.l.Warn(msg, kv...) }
+func (s *SlogLogger) Errorf(msg string, kv ...interface{}) { s.l.Error(msg, kv...) }
+
+// ZapLogger adapts *zap.SugaredLogger to the worker.Logger interface.
+type ZapLogger struct {
+	l *zap.SugaredLogger
+}
+
+// NewZapLogger wraps an existing *zap.SugaredLogger.
+func NewZapLogger(l *zap.SugaredLogger) *ZapLogger {
+	return &ZapLogger{l: l}
+}
+
+func (z *ZapLogger) Debugf(msg string, kv ...interface{}) { z.l.Debugw(msg, kv...) }
+func (z *ZapLogger) Infof(msg string, kv ...interface{})  { z.l.Infow(msg, kv...) }
+func (z *ZapLogger) Warnf(msg string, kv ...interface{})  { z.l.Warnw(msg, kv...) }
+func (z *ZapLogger) Errorf(msg string, kv ...interface{}) { z.l.Errorw(msg, kv...) }
+
+// ZerologLogger adapts zerolog.Logger to the worker.Logger interface.
+type ZerologLogger struct {
+	l zerolog.Logger
+}
+
+// NewZerologLogger wraps an existing zerolog.Logger.
+func NewZerologLogger(l zerolog.Logger) *ZerologLogger {
+	return &ZerologLogger{l: l}
+}
+
+func (z *ZerologLogger) Debugf(msg string, kv ...interface{}) { z.event(z.l.Debug(), msg, kv) }
+func (z *ZerologLogger) Infof(msg string, kv ...interface{})  { z.event(z.l.Info(), msg, kv) }
+func (z *ZerologLogger) Warnf(msg string, kv ...interface{})  { z.event(z.l.Warn(), msg, kv) }
+func (z *ZerologLogger) Errorf(msg string, kv ...interface{}) { z.event(z.l.Error(), msg, kv) }
+
+// event applies the key-value pairs to a zerolog event before firing it.
+func (z *ZerologLogger) event(e *zerolog.Event, msg string, kv []interface{}) {
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			continue
+		}
+		e = e.Interface(key, kv[i+1])
+	}
+	e.Msg(msg)
+}
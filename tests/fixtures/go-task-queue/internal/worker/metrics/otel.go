@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+func taskTypeAttr(taskType string) attribute.KeyValue {
+	return attribute.String("task_type", taskType)
+}
+
+// OTelAdapter mirrors Collectors' counters/gauges/histograms as
+// OpenTelemetry instruments, for pools that export via an OTel
+// MeterProvider instead of (or alongside) Prometheus.
+type OTelAdapter struct {
+	tasksProcessed metric.Int64Counter
+	tasksFailed    metric.Int64Counter
+	tasksRetried   metric.Int64Counter
+	taskDuration   metric.Float64Histogram
+	queueWaitTime  metric.Float64Histogram
+}
+
+// NewOTelAdapter builds an adapter using instruments created from meter.
+func NewOTelAdapter(meter metric.Meter) (*OTelAdapter, error) {
+	tasksProcessed, err := meter.Int64Counter("taskqueue.pool.tasks_processed")
+	if err != nil {
+		return nil, err
+	}
+	tasksFailed, err := meter.Int64Counter("taskqueue.pool.tasks_failed")
+	if err != nil {
+		return nil, err
+	}
+	tasksRetried, err := meter.Int64Counter("taskqueue.pool.tasks_retried")
+	if err != nil {
+		return nil, err
+	}
+	taskDuration, err := meter.Float64Histogram("taskqueue.pool.task_duration")
+	if err != nil {
+		return nil, err
+	}
+	queueWaitTime, err := meter.Float64Histogram("taskqueue.pool.queue_wait")
+	if err != nil {
+		return nil, err
+	}
+
+	return &OTelAdapter{
+		tasksProcessed: tasksProcessed,
+		tasksFailed:    tasksFailed,
+		tasksRetried:   tasksRetried,
+		taskDuration:   taskDuration,
+		queueWaitTime:  queueWaitTime,
+	}, nil
+}
+
+func (o *OTelAdapter) RecordProcessed(ctx context.Context, taskType string) {
+	o.tasksProcessed.Add(ctx, 1, metric.WithAttributes(taskTypeAttr(taskType)))
+}
+
+func (o *OTelAdapter) RecordFailed(ctx context.Context, taskType string) {
+	o.tasksFailed.Add(ctx, 1, metric.WithAttributes(taskTypeAttr(taskType)))
+}
+
+func (o *OTelAdapter) RecordRetried(ctx context.Context, taskType string) {
+	o.tasksRetried.Add(ctx, 1, metric.WithAttributes(taskTypeAttr(taskType)))
+}
+
+func (o *OTelAdapter) RecordTaskDuration(ctx context.Context, taskType string, seconds float64) {
+	o.taskDuration.Record(ctx, seconds, metric.WithAttributes(taskTypeAttr(taskType)))
+}
+
+func (o *OTelAdapter) RecordQueueWait(ctx context.Context, taskType string, seconds float64) {
+	o.queueWaitTime.Record(ctx, seconds, metric.WithAttributes(taskTypeAttr(taskType)))
+}
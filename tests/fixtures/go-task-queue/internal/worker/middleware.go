@@ -0,0 +1,262 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/example/task-queue/internal/queue"
+	"github.com/example/task-queue/internal/worker/metrics"
+)
+
+// Middleware wraps a ProcessFunc with cross-cutting behavior, the same
+// way api.Middleware wraps an http.Handler. Processor.Register and
+// NewFanOutProcessor each accept a chain of these instead of requiring
+// every cross-cutting concern -- timeout, retry, logging, panic
+// recovery, metrics -- to be forked into the caller's own handler. See
+// StageMiddleware for the equivalent over Pipeline's differently-shaped
+// stage handlers.
+type Middleware func(ProcessFunc) ProcessFunc
+
+// Chain applies middlewares to fn with middlewares[0] wrapping
+// outermost, matching api.Chain's ordering for HTTP middleware.
+func Chain(fn ProcessFunc, middlewares ...Middleware) ProcessFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		fn = middlewares[i](fn)
+	}
+	return fn
+}
+
+// recoverErr runs fn, converting a panic into an error instead of
+// letting it escape -- shared by WithRecover and WithStageRecover so the
+// panic-to-error conversion itself isn't duplicated between them.
+func recoverErr(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return fn()
+}
+
+// WithTimeout derives a per-task context bounded by d before calling the
+// wrapped handler, so a handler that ignores cancellation is still cut
+// off instead of running against its task's ambient context forever.
+func WithTimeout(d time.Duration) Middleware {
+	return func(next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, t *queue.Task) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next(ctx, t)
+		}
+	}
+}
+
+// WithRecover turns a handler panic into an error -- the same protection
+// process's inlined defer/recover gave before this middleware chain
+// replaced it -- so a panicking task handler no longer takes its
+// processor's loop goroutine down with it.
+func WithRecover() Middleware {
+	return func(next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, t *queue.Task) error {
+			return recoverErr(func() error { return next(ctx, t) })
+		}
+	}
+}
+
+// WithLogging logs each task's outcome and duration through logger,
+// correlated by task ID and type the same way Pool's own task-complete/
+// task-fail logging is.
+func WithLogging(logger Logger) Middleware {
+	return func(next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, t *queue.Task) error {
+			start := time.Now()
+			err := next(ctx, t)
+			logTaskOutcome(logger, t, time.Since(start), err)
+			return err
+		}
+	}
+}
+
+func logTaskOutcome(logger Logger, t *queue.Task, d time.Duration, err error) {
+	if err != nil {
+		logger.Errorf("task failed", "task_id", t.ID, "task_type", t.Type, "duration_ms", d.Milliseconds(), "error", err)
+	} else {
+		logger.Infof("task completed", "task_id", t.ID, "task_type", t.Type, "duration_ms", d.Milliseconds())
+	}
+}
+
+// WithMetrics records each task's outcome and duration against c -- the
+// same *metrics.Collectors type Pool registers via metrics.New -- so a
+// Processor's tasks show up in the same task_type-labeled dashboards a
+// Pool's do.
+func WithMetrics(c *metrics.Collectors) Middleware {
+	return func(next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, t *queue.Task) error {
+			start := time.Now()
+			err := next(ctx, t)
+			observeTaskMetrics(c, t, time.Since(start), err)
+			return err
+		}
+	}
+}
+
+func observeTaskMetrics(c *metrics.Collectors, t *queue.Task, d time.Duration, err error) {
+	c.ObserveTaskDuration(t.Type, d)
+	if err != nil {
+		c.TasksFailed.WithLabelValues(t.Type).Inc()
+	} else {
+		c.TasksProcessed.WithLabelValues(t.Type).Inc()
+	}
+}
+
+// ErrRetryScheduled is returned by a WithRetry-wrapped handler in place
+// of the handler's own error when a failed task was re-enqueued for a
+// later attempt, so process/fanOut treat it as handled rather than
+// marking the task permanently failed.
+var ErrRetryScheduled = errors.New("worker: task retry scheduled")
+
+// ErrDeadLettered wraps the handler's original error when WithRetry
+// exhausts maxRetries and routes the task to its dead-letter queue
+// instead of marking it failed in place. It's mainly informational --
+// process/fanOut treat a wrapped ErrDeadLettered the same as any other
+// failure -- but a caller that wants to tell "ran out of retries" apart
+// from "handler itself returned a dlq-worthy error" can check for it with
+// errors.Is.
+var ErrDeadLettered = errors.New("worker: task moved to dead-letter queue")
+
+// WithRetry retries a failing task up to maxRetries times by
+// re-enqueueing it onto q with backoff.Next's delay, rather than looping
+// the handler call in-process -- mirroring Pool.handleFailedTask, down
+// to preferring queue.ScheduledQueue.EnqueueAt over ScheduledAt+Enqueue
+// when the backend supports it, so a crash between attempts doesn't
+// silently drop the retry. Every failed attempt, including the final
+// one, is recorded onto Task.Metadata via appendErrorHistory, the same
+// history Pool's own retries build up. dlq may be nil to skip dead-letter
+// routing and just return the handler's error once retries are
+// exhausted, matching WithRetry's behavior before dead-lettering existed.
+// logger may be nil.
+func WithRetry(q queue.Queue, maxRetries int, backoff Backoff, dlq queue.Queue, logger Logger) Middleware {
+	if logger == nil {
+		logger = noopLogger{}
+	}
+	return func(next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, t *queue.Task) error {
+			err := next(ctx, t)
+			if err == nil {
+				return nil
+			}
+
+			appendErrorHistory(t, t.Retries+1, err, "")
+
+			if t.Retries >= maxRetries {
+				if dlq == nil {
+					return err
+				}
+				if dlqErr := dlq.Enqueue(ctx, t); dlqErr != nil {
+					logger.Errorf("failed to route task to dead-letter queue", "task_id", t.ID, "task_type", t.Type, "attempt", t.Retries, "error", dlqErr)
+					return err
+				}
+				logger.Infof("task moved to dead-letter queue", "task_id", t.ID, "task_type", t.Type, "attempt", t.Retries, "error", err)
+				return fmt.Errorf("%w: %s", ErrDeadLettered, err)
+			}
+
+			t.Retries++
+			t.State = queue.StateRetrying
+			t.Error = err.Error()
+
+			logger.Infof("retrying task", "task_id", t.ID, "task_type", t.Type, "attempt", t.Retries, "error", err)
+
+			runAt := time.Now().Add(backoff.Next(t.Retries))
+			if scheduledQueue, ok := q.(queue.ScheduledQueue); ok {
+				if enqueueErr := scheduledQueue.EnqueueAt(ctx, t, runAt); enqueueErr != nil {
+					logger.Errorf("failed to re-enqueue retried task", "task_id", t.ID, "task_type", t.Type, "attempt", t.Retries, "error", enqueueErr)
+					return err
+				}
+				return ErrRetryScheduled
+			}
+
+			t.ScheduledAt = &runAt
+			if enqueueErr := q.Enqueue(ctx, t); enqueueErr != nil {
+				logger.Errorf("failed to re-enqueue retried task", "task_id", t.ID, "task_type", t.Type, "attempt", t.Retries, "error", enqueueErr)
+				return err
+			}
+			return ErrRetryScheduled
+		}
+	}
+}
+
+// StageFunc is the function signature a Pipeline stage handler
+// implements: given a task, return the (possibly transformed) task to
+// pass to the next stage.
+type StageFunc func(context.Context, *queue.Task) (*queue.Task, error)
+
+// StageMiddleware wraps a StageFunc, mirroring Middleware -- Pipeline
+// stages return a transformed task rather than just an error, so they
+// need their own middleware shape. Retry isn't offered at this level:
+// re-enqueueing a task that's already partway through a multi-stage
+// pipeline would need to capture which stage to resume from, which
+// PipelineStage doesn't model; processPipeline's existing
+// whole-task failure handling is what retry at the Processor/
+// FanOutProcessor level builds on instead.
+type StageMiddleware func(StageFunc) StageFunc
+
+// ChainStage applies middlewares to fn with middlewares[0] wrapping
+// outermost, matching Chain's ordering.
+func ChainStage(fn StageFunc, middlewares ...StageMiddleware) StageFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		fn = middlewares[i](fn)
+	}
+	return fn
+}
+
+// WithStageTimeout is WithTimeout for a Pipeline stage.
+func WithStageTimeout(d time.Duration) StageMiddleware {
+	return func(next StageFunc) StageFunc {
+		return func(ctx context.Context, t *queue.Task) (*queue.Task, error) {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next(ctx, t)
+		}
+	}
+}
+
+// WithStageRecover is WithRecover for a Pipeline stage.
+func WithStageRecover() StageMiddleware {
+	return func(next StageFunc) StageFunc {
+		return func(ctx context.Context, t *queue.Task) (result *queue.Task, err error) {
+			err = recoverErr(func() error {
+				var innerErr error
+				result, innerErr = next(ctx, t)
+				return innerErr
+			})
+			return result, err
+		}
+	}
+}
+
+// WithStageLogging is WithLogging for a Pipeline stage.
+func WithStageLogging(logger Logger) StageMiddleware {
+	return func(next StageFunc) StageFunc {
+		return func(ctx context.Context, t *queue.Task) (*queue.Task, error) {
+			start := time.Now()
+			result, err := next(ctx, t)
+			logTaskOutcome(logger, t, time.Since(start), err)
+			return result, err
+		}
+	}
+}
+
+// WithStageMetrics is WithMetrics for a Pipeline stage.
+func WithStageMetrics(c *metrics.Collectors) StageMiddleware {
+	return func(next StageFunc) StageFunc {
+		return func(ctx context.Context, t *queue.Task) (*queue.Task, error) {
+			start := time.Now()
+			result, err := next(ctx, t)
+			observeTaskMetrics(c, t, time.Since(start), err)
+			return result, err
+		}
+	}
+}
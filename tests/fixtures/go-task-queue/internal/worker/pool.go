@@ -3,6 +3,7 @@ package worker
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sync"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/example/task-queue/internal/queue"
 	"github.com/example/task-queue/internal/task"
+	"github.com/example/task-queue/internal/worker/metrics"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // Common worker errors
@@ -54,6 +57,23 @@ type PoolConfig struct {
 	ShutdownTimeout time.Duration
 	RetryDelay      time.Duration
 	MaxRetries      int
+
+	// WorkerTags restricts worker i to acquiring tasks whose Type is in
+	// WorkerTags[i] (empty or missing entries accept any type). Only
+	// honored when the queue implements queue.AcquiringQueue.
+	WorkerTags [][]string
+
+	// AcquireLongPollDuration bounds how long a worker blocks in a single
+	// AcquireJob call before looping to re-check shutdown.
+	AcquireLongPollDuration time.Duration
+
+	// Backoff computes the delay before each retry. Defaults to
+	// LinearBackoff{Delay: RetryDelay}, matching the pre-existing behavior.
+	Backoff Backoff
+
+	// DeadLetterQueue receives tasks that exhaust MaxRetries, instead of
+	// just being marked StateFailed in place. Nil disables dead-lettering.
+	DeadLetterQueue queue.Queue
 }
 
 // DefaultPoolConfig returns default pool configuration
@@ -65,7 +85,27 @@ func DefaultPoolConfig() PoolConfig {
 		ShutdownTimeout: 10 * time.Second,
 		RetryDelay:      time.Second,
 		MaxRetries:      3,
+		AcquireLongPollDuration: 5 * time.Second,
+		Backoff:         LinearBackoff{Delay: time.Second},
+	}
+}
+
+// backoff returns the configured Backoff strategy, falling back to
+// LinearBackoff{Delay: RetryDelay} for pools built without one.
+func (c PoolConfig) backoff() Backoff {
+	if c.Backoff != nil {
+		return c.Backoff
+	}
+	return LinearBackoff{Delay: c.RetryDelay}
+}
+
+// workerTags returns the acquisition tags for worker id, defaulting to "any
+// type" when PoolConfig.WorkerTags doesn't cover it.
+func (c PoolConfig) workerTags(id int) []string {
+	if id < len(c.WorkerTags) {
+		return c.WorkerTags[id]
 	}
+	return nil
 }
 
 // Pool manages a pool of workers for task processing
@@ -78,6 +118,14 @@ type Pool struct {
 	cancel   context.CancelFunc
 	ctx      context.Context
 
+	// acceptCtx is a child of ctx that Shutdown cancels on its own, without
+	// touching ctx. Workers/dispatcher stop pulling new tasks when it's
+	// done, while in-flight task contexts (derived from ctx) keep running
+	// until they finish or ctx itself is cancelled (Stop, or a forced
+	// Shutdown).
+	acceptCtx    context.Context
+	acceptCancel context.CancelFunc
+
 	// Channels for communication
 	jobs       chan *queue.Task
 	results    chan *Result
@@ -87,12 +135,43 @@ type Pool struct {
 	// Metrics
 	metrics *PoolMetrics
 
+	// logger receives structured events for every swallowed-error site;
+	// defaults to a no-op so existing callers see no behavior change.
+	logger Logger
+
+	// events fans lifecycle transitions out to per-task subscribers, used
+	// by the API's SSE endpoint so clients can watch a task instead of
+	// polling GetTask.
+	events *eventBus
+
+	// promMetrics exports PoolMetrics' counters/gauges/histograms to
+	// Prometheus, with per-task-type labels. Nil unless WithMetricsRegisterer
+	// is used.
+	promMetrics *metrics.Collectors
+
 	// Callbacks
 	onTaskComplete func(*Result)
 	onTaskFail     func(*queue.Task, error)
 	onWorkerPanic  func(workerID int, recovered interface{})
+
+	pauseChecker PauseChecker
+}
+
+// PauseChecker reports whether dispatch of a task is currently paused,
+// either because the task itself was paused (e.g. storage.Storage.
+// PauseTask) or its Type was (storage.Storage.PauseType). It's the live
+// counterpart to those calls: without one configured via
+// WithPauseChecker, Pool has no way to observe either kind of pause and
+// dispatches every task it's handed.
+type PauseChecker interface {
+	IsPaused(ctx context.Context, taskID, taskType string) (bool, error)
 }
 
+// pauseRecheckDelay is how long processTask defers a paused task before
+// it becomes eligible for dequeue again, so a paused type doesn't spin a
+// worker in a tight requeue loop while it waits to be resumed.
+const pauseRecheckDelay = 5 * time.Second
+
 // Result represents the result of processing a task
 type Result struct {
 	Task      *queue.Task   `json:"task"`
@@ -170,21 +249,53 @@ func WithPanicHandler(fn func(int, interface{})) PoolOption {
 	}
 }
 
+// WithPauseChecker configures checker as the Pool's PauseChecker. A task
+// processTask is about to run is re-deferred instead of executed when
+// checker reports it paused -- see PauseChecker.
+func WithPauseChecker(checker PauseChecker) PoolOption {
+	return func(p *Pool) {
+		p.pauseChecker = checker
+	}
+}
+
+// WithMetricsRegisterer registers the pool's Prometheus collectors against
+// reg and enables per-task-type histogram observations in processTask and
+// dispatcher.
+func WithMetricsRegisterer(reg prometheus.Registerer) PoolOption {
+	return func(p *Pool) {
+		p.promMetrics = metrics.New(reg)
+	}
+}
+
+// Collectors returns the pool's Prometheus collectors, for callers that
+// want to register them manually instead of via WithMetricsRegisterer.
+func (p *Pool) Collectors() []prometheus.Collector {
+	if p.promMetrics == nil {
+		return nil
+	}
+	return p.promMetrics.Collectors()
+}
+
 // NewPool creates a new worker pool
 func NewPool(q queue.BlockingQueue, registry *task.Registry, config PoolConfig, opts ...PoolOption) *Pool {
 	ctx, cancel := context.WithCancel(context.Background())
+	acceptCtx, acceptCancel := context.WithCancel(ctx)
 
 	p := &Pool{
-		config:     config,
-		queue:      q,
-		registry:   registry,
-		ctx:        ctx,
-		cancel:     cancel,
-		jobs:       make(chan *queue.Task, config.MaxQueueSize),
-		results:    make(chan *Result, config.MaxQueueSize),
-		done:       make(chan struct{}),
-		workerDone: make(chan int, config.NumWorkers),
-		metrics:    &PoolMetrics{},
+		config:       config,
+		queue:        q,
+		registry:     registry,
+		ctx:          ctx,
+		cancel:       cancel,
+		acceptCtx:    acceptCtx,
+		acceptCancel: acceptCancel,
+		jobs:         make(chan *queue.Task, config.MaxQueueSize),
+		results:      make(chan *Result, config.MaxQueueSize),
+		done:         make(chan struct{}),
+		workerDone:   make(chan int, config.NumWorkers),
+		metrics:      &PoolMetrics{},
+		logger:       noopLogger{},
+		events:       newEventBus(),
 	}
 
 	p.state.Store(int32(StateIdle))
@@ -202,46 +313,50 @@ func (p *Pool) Start() error {
 		return ErrPoolShutdown
 	}
 
+	// When the queue supports tag-scoped acquisition, each worker acquires
+	// its own jobs directly so a task type only some workers handle can't
+	// head-of-line-block everyone else behind a single dispatcher channel.
+	_, acquiring := p.queue.(queue.AcquiringQueue)
+
 	// Start workers
 	for i := 0; i < p.config.NumWorkers; i++ {
 		p.wg.Add(1)
-		go p.worker(i)
+		if acquiring {
+			go p.acquireWorker(i)
+		} else {
+			go p.worker(i)
+		}
 	}
 
 	// Start result processor
 	go p.processResults()
 
-	// Start dispatcher
-	go p.dispatcher()
+	// Start dispatcher only for queues without native acquisition support
+	if !acquiring {
+		go p.dispatcher()
+	}
 
 	return nil
 }
 
-// worker is the main worker goroutine
+// worker is the main worker goroutine for non-acquiring queues: it pulls
+// pre-dispatched tasks off the shared jobs channel.
 func (p *Pool) worker(id int) {
-	defer func() {
-		if r := recover(); r != nil {
-			p.metrics.WorkerRestarts.Add(1)
-			if p.onWorkerPanic != nil {
-				p.onWorkerPanic(id, r)
-			}
-			// Restart worker if pool is still running
-			if PoolState(p.state.Load()) == StateRunning {
-				go p.worker(id)
-				return
-			}
-		}
-		p.wg.Done()
-		p.workerDone <- id
-	}()
+	defer p.recoverWorker(id, p.worker)
 
 	p.metrics.ActiveWorkers.Add(1)
-	defer p.metrics.ActiveWorkers.Add(-1)
+	p.syncActiveWorkersGauge()
+	defer func() {
+		p.metrics.ActiveWorkers.Add(-1)
+		p.syncActiveWorkersGauge()
+	}()
 
 	for {
 		select {
 		case <-p.ctx.Done():
 			return
+		case <-p.acceptCtx.Done():
+			return
 		case task, ok := <-p.jobs:
 			if !ok {
 				return
@@ -251,10 +366,100 @@ func (p *Pool) worker(id int) {
 	}
 }
 
+// acquireWorker is the Acquirer-pattern worker goroutine: it independently
+// long-polls the queue for tasks matching its own worker tags instead of
+// waiting on a shared dispatcher channel.
+func (p *Pool) acquireWorker(id int) {
+	defer p.recoverWorker(id, p.acquireWorker)
+
+	p.metrics.ActiveWorkers.Add(1)
+	p.syncActiveWorkersGauge()
+	defer func() {
+		p.metrics.ActiveWorkers.Add(-1)
+		p.syncActiveWorkersGauge()
+	}()
+
+	acquirer := p.queue.(queue.AcquiringQueue)
+	tags := p.config.workerTags(id)
+
+	for {
+		if p.ctx.Err() != nil || p.acceptCtx.Err() != nil {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(p.acceptCtx, p.config.AcquireLongPollDuration)
+		t, err := acquirer.AcquireJob(ctx, tags)
+		cancel()
+		if err != nil {
+			continue
+		}
+
+		p.metrics.QueueDepth.Add(1)
+		p.processTask(id, t)
+	}
+}
+
+// syncActiveWorkersGauge mirrors PoolMetrics.ActiveWorkers onto the
+// Prometheus gauge, if metrics are enabled.
+func (p *Pool) syncActiveWorkersGauge() {
+	if p.promMetrics != nil {
+		p.promMetrics.ActiveWorkers.Set(float64(p.metrics.ActiveWorkers.Load()))
+	}
+}
+
+// recoverWorker restarts worker id via runFn after a panic, matching the
+// pool's existing self-healing behavior.
+func (p *Pool) recoverWorker(id int, runFn func(int)) {
+	if r := recover(); r != nil {
+		p.metrics.WorkerRestarts.Add(1)
+		if p.promMetrics != nil {
+			p.promMetrics.WorkerRestarts.Inc()
+		}
+		p.logger.Errorf("worker panicked, restarting", "worker_id", id, "panic", r)
+		if p.onWorkerPanic != nil {
+			p.onWorkerPanic(id, r)
+		}
+		// Restart worker if pool is still running
+		if PoolState(p.state.Load()) == StateRunning {
+			go runFn(id)
+			return
+		}
+	}
+	p.wg.Done()
+	p.workerDone <- id
+}
+
+// Subscribe returns a channel of lifecycle Events (started, retrying,
+// completed, failed) for taskID, and an unsubscribe func the caller must
+// invoke when done listening. The channel is bounded and drop-oldest, so a
+// slow or disconnected subscriber never blocks task processing.
+func (p *Pool) Subscribe(taskID string) (<-chan Event, func()) {
+	return p.events.subscribe(taskID)
+}
+
 // processTask processes a single task
 func (p *Pool) processTask(workerID int, t *queue.Task) {
+	if p.pauseChecker != nil {
+		paused, err := p.pauseChecker.IsPaused(p.ctx, t.ID, t.Type)
+		if err != nil {
+			p.logger.Warnf("pause check failed, dispatching anyway", "task_id", t.ID, "task_type", t.Type, "error", err)
+		} else if paused {
+			p.requeuePaused(t)
+			return
+		}
+	}
+
 	start := time.Now()
 
+	p.events.publish(Event{
+		TaskID:    t.ID,
+		TaskType:  t.Type,
+		Type:      EventStarted,
+		Attempt:   t.Retries,
+		WorkerID:  workerID,
+		Timestamp: start,
+	})
+
 	// Create task context with timeout
 	ctx, cancel := context.WithTimeout(p.ctx, p.config.TaskTimeout)
 	defer cancel()
@@ -264,6 +469,10 @@ func (p *Pool) processTask(workerID int, t *queue.Task) {
 
 	duration := time.Since(start)
 
+	if p.promMetrics != nil {
+		p.promMetrics.ObserveTaskDuration(t.Type, duration)
+	}
+
 	result := &Result{
 		Task:      t,
 		Output:    output,
@@ -288,30 +497,67 @@ func (p *Pool) dispatcher() {
 		case <-p.ctx.Done():
 			close(p.jobs)
 			return
+		case <-p.acceptCtx.Done():
+			close(p.jobs)
+			return
 		default:
 		}
 
-		// Blocking dequeue
-		t, err := p.queue.DequeueBlocking(p.ctx)
+		// Blocking dequeue, bound to acceptCtx so Shutdown unblocks it
+		// immediately without having to cancel in-flight task contexts.
+		t, err := p.queue.DequeueBlocking(p.acceptCtx)
 		if err != nil {
-			if p.ctx.Err() != nil {
+			if p.ctx.Err() != nil || p.acceptCtx.Err() != nil {
 				close(p.jobs)
 				return
 			}
+			p.logger.Warnf("dequeue failed, retrying", "error", err)
 			continue
 		}
 
 		p.metrics.QueueDepth.Add(1)
+		if p.promMetrics != nil {
+			p.promMetrics.ObserveQueueWait(t.Type, time.Since(t.CreatedAt))
+		}
 
 		select {
 		case p.jobs <- t:
 		case <-p.ctx.Done():
+			p.requeueTask(t)
+			close(p.jobs)
+			return
+		case <-p.acceptCtx.Done():
+			p.requeueTask(t)
 			close(p.jobs)
 			return
 		}
 	}
 }
 
+// requeueTask re-enqueues t, used when shutdown interrupts the dispatcher
+// between dequeuing a task and handing it to a worker so it isn't lost.
+func (p *Pool) requeueTask(t *queue.Task) {
+	if err := p.queue.Enqueue(context.Background(), t); err != nil {
+		p.logger.Errorf("failed to requeue task during shutdown", "task_id", t.ID, "task_type", t.Type, "error", err)
+	}
+}
+
+// requeuePaused re-enqueues t after pauseRecheckDelay instead of running
+// it, used when a PauseChecker reports t paused. It prefers
+// queue.ScheduledQueue.EnqueueAt so t isn't eligible for dequeue again
+// until the delay elapses; backends without ScheduledQueue support fall
+// back to an immediate Enqueue, which re-checks the pause on every
+// dequeue instead of waiting it out.
+func (p *Pool) requeuePaused(t *queue.Task) {
+	if scheduled, ok := p.queue.(queue.ScheduledQueue); ok {
+		if err := scheduled.EnqueueAt(context.Background(), t, time.Now().Add(pauseRecheckDelay)); err != nil {
+			p.logger.Errorf("failed to requeue paused task", "task_id", t.ID, "task_type", t.Type, "error", err)
+		}
+		return
+	}
+	p.requeueTask(t)
+}
+
 // processResults handles task results
 func (p *Pool) processResults() {
 	for result := range p.results {
@@ -320,9 +566,15 @@ func (p *Pool) processResults() {
 
 		if result.Error != nil {
 			p.metrics.TasksFailed.Add(1)
+			if p.promMetrics != nil {
+				p.promMetrics.TasksFailed.WithLabelValues(result.Task.Type).Inc()
+			}
 			p.handleFailedTask(result)
 		} else {
 			p.metrics.TasksProcessed.Add(1)
+			if p.promMetrics != nil {
+				p.promMetrics.TasksProcessed.WithLabelValues(result.Task.Type).Inc()
+			}
 			p.handleCompletedTask(result)
 		}
 	}
@@ -335,18 +587,70 @@ func (p *Pool) handleCompletedTask(result *Result) {
 	result.Task.CompletedAt = &now
 	result.Task.Result = result.Output
 
-	if err := p.queue.Update(p.ctx, result.Task); err != nil {
-		// Log error but continue
+	if ackQueue, ok := p.queue.(queue.AckingQueue); ok {
+		if err := ackQueue.Ack(p.ctx, result.Task.ID); err != nil {
+			p.logger.Errorf("failed to ack completed task", "task_id", result.Task.ID, "task_type", result.Task.Type, "worker_id", result.WorkerID, "error", err)
+		}
+	} else if err := p.queue.Update(p.ctx, result.Task); err != nil {
+		p.logger.Errorf("failed to persist completed task", "task_id", result.Task.ID, "task_type", result.Task.Type, "worker_id", result.WorkerID, "error", err)
 	}
 
+	p.events.publish(Event{
+		TaskID:    result.Task.ID,
+		TaskType:  result.Task.Type,
+		Type:      EventCompleted,
+		WorkerID:  result.WorkerID,
+		Output:    result.Output,
+		Timestamp: now,
+	})
+
 	if p.onTaskComplete != nil {
 		p.onTaskComplete(result)
 	}
 }
 
+// errorHistoryEntry records a single failed attempt for a task, persisted
+// as a JSON array in Task.Metadata["error_history"] so dead-lettered tasks
+// can be inspected without losing earlier attempts' errors. Stage is only
+// set for a Pipeline task, recording which PipelineStage the attempt
+// failed in.
+type errorHistoryEntry struct {
+	Attempt   int       `json:"attempt"`
+	Error     string    `json:"error"`
+	Timestamp time.Time `json:"timestamp"`
+	Stage     string    `json:"stage,omitempty"`
+}
+
+// appendErrorHistory records attempt's error onto t.Metadata["error_history"].
+// stage is empty outside of Pipeline.
+func appendErrorHistory(t *queue.Task, attempt int, err error, stage string) {
+	var history []errorHistoryEntry
+	if raw, ok := t.Metadata["error_history"]; ok {
+		_ = json.Unmarshal([]byte(raw), &history)
+	}
+
+	history = append(history, errorHistoryEntry{
+		Attempt:   attempt,
+		Error:     err.Error(),
+		Timestamp: time.Now(),
+		Stage:     stage,
+	})
+
+	encoded, marshalErr := json.Marshal(history)
+	if marshalErr != nil {
+		return
+	}
+
+	if t.Metadata == nil {
+		t.Metadata = make(map[string]string)
+	}
+	t.Metadata["error_history"] = string(encoded)
+}
+
 // handleFailedTask handles failed task with retry logic
 func (p *Pool) handleFailedTask(result *Result) {
 	task := result.Task
+	appendErrorHistory(task, task.Retries+1, result.Error, "")
 
 	if task.Retries < p.config.MaxRetries {
 		// Retry
@@ -355,13 +659,35 @@ func (p *Pool) handleFailedTask(result *Result) {
 		task.Error = result.Error.Error()
 		p.metrics.TasksRetried.Add(1)
 
-		// Re-enqueue after delay
-		go func() {
-			time.Sleep(p.config.RetryDelay * time.Duration(task.Retries))
-			if err := p.queue.Enqueue(p.ctx, task); err != nil {
+		p.logger.Infof("retrying task", "task_id", task.ID, "task_type", task.Type, "attempt", task.Retries, "worker_id", result.WorkerID, "error", result.Error)
+
+		p.events.publish(Event{
+			TaskID:    task.ID,
+			TaskType:  task.Type,
+			Type:      EventRetrying,
+			Attempt:   task.Retries,
+			WorkerID:  result.WorkerID,
+			Error:     result.Error.Error(),
+			Timestamp: time.Now(),
+		})
+
+		// Schedule the retry durably instead of sleeping in a detached
+		// goroutine, so a crash between failure and re-enqueue doesn't
+		// silently drop the retry.
+		runAt := time.Now().Add(p.config.backoff().Next(task.Retries))
+		if scheduledQueue, ok := p.queue.(queue.ScheduledQueue); ok {
+			if err := scheduledQueue.EnqueueAt(p.ctx, task, runAt); err != nil {
+				p.logger.Errorf("failed to re-enqueue retried task", "task_id", task.ID, "task_type", task.Type, "attempt", task.Retries, "error", err)
 				p.handlePermanentFailure(task, result.Error)
 			}
-		}()
+			return
+		}
+
+		task.ScheduledAt = &runAt
+		if err := p.queue.Enqueue(p.ctx, task); err != nil {
+			p.logger.Errorf("failed to re-enqueue retried task", "task_id", task.ID, "task_type", task.Type, "attempt", task.Retries, "error", err)
+			p.handlePermanentFailure(task, result.Error)
+		}
 	} else {
 		p.handlePermanentFailure(task, result.Error)
 	}
@@ -374,15 +700,116 @@ func (p *Pool) handlePermanentFailure(t *queue.Task, err error) {
 	t.CompletedAt = &now
 	t.Error = err.Error()
 
-	if updateErr := p.queue.Update(p.ctx, t); updateErr != nil {
-		// Log error but continue
+	p.logger.Errorf("task permanently failed", "task_id", t.ID, "task_type", t.Type, "attempt", t.Retries, "error", err)
+
+	if p.config.DeadLetterQueue != nil {
+		if dlqErr := p.config.DeadLetterQueue.Enqueue(p.ctx, t); dlqErr != nil {
+			p.logger.Errorf("failed to route task to dead-letter queue", "task_id", t.ID, "task_type", t.Type, "error", dlqErr)
+		}
+	}
+
+	if ackQueue, ok := p.queue.(queue.AckingQueue); ok {
+		if nackErr := ackQueue.Nack(p.ctx, t.ID, err); nackErr != nil {
+			p.logger.Errorf("failed to nack permanently failed task", "task_id", t.ID, "task_type", t.Type, "error", nackErr)
+		}
+	} else if updateErr := p.queue.Update(p.ctx, t); updateErr != nil {
+		p.logger.Errorf("failed to persist permanently failed task", "task_id", t.ID, "task_type", t.Type, "error", updateErr)
 	}
 
+	p.events.publish(Event{
+		TaskID:    t.ID,
+		TaskType:  t.Type,
+		Type:      EventFailed,
+		Attempt:   t.Retries,
+		Error:     err.Error(),
+		Timestamp: now,
+	})
+
 	if p.onTaskFail != nil {
 		p.onTaskFail(t, err)
 	}
 }
 
+// ReplayDeadLetter moves a task out of the dead-letter queue and back onto
+// the pool's primary queue for another attempt, resetting its retry count.
+func (p *Pool) ReplayDeadLetter(ctx context.Context, taskID string) error {
+	if p.config.DeadLetterQueue == nil {
+		return fmt.Errorf("pool has no dead-letter queue configured")
+	}
+
+	t, err := p.config.DeadLetterQueue.Get(ctx, taskID)
+	if err != nil {
+		return fmt.Errorf("fetching dead-lettered task: %w", err)
+	}
+
+	t.Retries = 0
+	t.State = queue.StatePending
+	t.Error = ""
+	t.ScheduledAt = nil
+
+	if err := p.queue.Enqueue(ctx, t); err != nil {
+		return fmt.Errorf("re-enqueueing replayed task: %w", err)
+	}
+
+	return p.config.DeadLetterQueue.Delete(ctx, taskID)
+}
+
+// Shutdown stops the pool from accepting new tasks and waits for in-flight
+// handlers to return, honoring each task's TaskTimeout, then requeues
+// anything that was pulled off the queue but never started so another
+// worker can pick it up. Unlike Stop, it doesn't cancel in-flight task
+// contexts immediately: those keep running against ctx until they finish
+// naturally or ctx's deadline elapses.
+//
+// If ctx is done before draining completes, Shutdown force-cancels all
+// in-flight task contexts (the same abort Stop performs), waits for workers
+// to unwind, and returns ctx.Err(). Calling it a second time, with a
+// context already cancelled by a second interrupt signal, is the intended
+// way to escalate a stuck drain into an immediate abort.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	if !p.state.CompareAndSwap(int32(StateRunning), int32(StateShuttingDown)) {
+		return ErrPoolNotStarted
+	}
+
+	p.acceptCancel()
+	defer p.requeuePending()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		close(p.results)
+		p.state.Store(int32(StateStopped))
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		close(p.results)
+		p.state.Store(int32(StateStopped))
+		return ctx.Err()
+	}
+}
+
+// requeuePending drains any tasks the dispatcher handed to p.jobs but that
+// no worker had started processing yet when shutdown began.
+func (p *Pool) requeuePending() {
+	for {
+		select {
+		case t, ok := <-p.jobs:
+			if !ok {
+				return
+			}
+			p.requeueTask(t)
+		default:
+			return
+		}
+	}
+}
+
 // Stop gracefully stops the worker pool
 func (p *Pool) Stop() error {
 	if !p.state.CompareAndSwap(int32(StateRunning), int32(StateShuttingDown)) {
@@ -2,10 +2,15 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/example/task-queue/internal/queue"
 )
 
@@ -34,11 +39,27 @@ func NewProcessor(q queue.Queue) *Processor {
 	}
 }
 
-// Register registers a handler for a task type
-func (p *Processor) Register(taskType string, fn ProcessFunc) {
+// NewProcessorFromURI opens uri via queue.Open and wraps the result the
+// same way NewProcessor wraps an already-open Queue -- so a config-driven
+// caller can wire a Processor from a backend string without importing
+// any driver package itself.
+func NewProcessorFromURI(uri string) (*Processor, error) {
+	q, err := queue.Open(uri)
+	if err != nil {
+		return nil, err
+	}
+	return NewProcessor(q), nil
+}
+
+// Register registers a handler for a task type, wrapped by middlewares
+// (see WithTimeout, WithRetry, WithLogging, WithMetrics) plus a default
+// WithRecover so a handler registered with no middleware at all keeps
+// process's old panic-safety guarantee. middlewares[0] wraps outermost,
+// matching Chain.
+func (p *Processor) Register(taskType string, fn ProcessFunc, middlewares ...Middleware) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.handlers[taskType] = fn
+	p.handlers[taskType] = Chain(fn, append([]Middleware{WithRecover()}, middlewares...)...)
 }
 
 // Start starts processing tasks
@@ -84,7 +105,9 @@ func (p *Processor) loop() {
 	}
 }
 
-// process handles a single task
+// process handles a single task. Panic recovery is Register's default
+// WithRecover middleware now, not an inline defer here -- the handler
+// stored in p.handlers is already the fully wrapped chain.
 func (p *Processor) process(task *queue.Task) {
 	p.mu.RLock()
 	handler, ok := p.handlers[task.Type]
@@ -97,26 +120,22 @@ func (p *Processor) process(task *queue.Task) {
 		return
 	}
 
-	// Execute handler with panic recovery
-	func() {
-		defer func() {
-			if r := recover(); r != nil {
-				task.State = queue.StateFailed
-				task.Error = fmt.Sprintf("handler panic: %v", r)
-				p.queue.Update(p.ctx, task)
-			}
-		}()
+	err := handler(p.ctx, task)
+	if errors.Is(err, ErrRetryScheduled) {
+		// A WithRetry middleware in the chain already re-enqueued task;
+		// nothing left to record here.
+		return
+	}
 
-		if err := handler(p.ctx, task); err != nil {
-			task.State = queue.StateFailed
-			task.Error = err.Error()
-		} else {
-			task.State = queue.StateCompleted
-		}
-		now := time.Now()
-		task.CompletedAt = &now
-		p.queue.Update(p.ctx, task)
-	}()
+	if err != nil {
+		task.State = queue.StateFailed
+		task.Error = err.Error()
+	} else {
+		task.State = queue.StateCompleted
+	}
+	now := time.Now()
+	task.CompletedAt = &now
+	p.queue.Update(p.ctx, task)
 }
 
 // Stop stops the processor
@@ -137,15 +156,33 @@ type BatchProcessor struct {
 	ctx       context.Context
 	cancel    context.CancelFunc
 	wg        sync.WaitGroup
+	tracer    trace.Tracer
+	traceRate float64
 }
 
 // BatchProcessFunc processes a batch of tasks
 type BatchProcessFunc func(ctx context.Context, tasks []*queue.Task) []error
 
+// BatchProcessorOption is a functional option for BatchProcessor, matching
+// Pool's PoolOption pattern.
+type BatchProcessorOption func(*BatchProcessor)
+
+// WithBatchTracer starts a "worker.process_batch" span around each batch
+// this processor runs, the BatchProcessor equivalent of WithTracer (batch
+// processing has no per-handler middleware chain to hang a Middleware
+// off of, so it's a constructor option instead). rate controls what
+// fraction of batches get a span; pass 1 to trace every batch.
+func WithBatchTracer(tp trace.TracerProvider, rate float64) BatchProcessorOption {
+	return func(bp *BatchProcessor) {
+		bp.tracer = tp.Tracer("github.com/example/task-queue/internal/worker")
+		bp.traceRate = rate
+	}
+}
+
 // NewBatchProcessor creates a batch processor
-func NewBatchProcessor(q queue.Queue, batchSize int, interval time.Duration, handler BatchProcessFunc) *BatchProcessor {
+func NewBatchProcessor(q queue.Queue, batchSize int, interval time.Duration, handler BatchProcessFunc, opts ...BatchProcessorOption) *BatchProcessor {
 	ctx, cancel := context.WithCancel(context.Background())
-	return &BatchProcessor{
+	bp := &BatchProcessor{
 		queue:     q,
 		batchSize: batchSize,
 		interval:  interval,
@@ -153,6 +190,20 @@ func NewBatchProcessor(q queue.Queue, batchSize int, interval time.Duration, han
 		ctx:       ctx,
 		cancel:    cancel,
 	}
+	for _, opt := range opts {
+		opt(bp)
+	}
+	return bp
+}
+
+// NewBatchProcessorFromURI opens uri via queue.Open and wraps the result
+// the same way NewBatchProcessor wraps an already-open Queue.
+func NewBatchProcessorFromURI(uri string, batchSize int, interval time.Duration, handler BatchProcessFunc, opts ...BatchProcessorOption) (*BatchProcessor, error) {
+	q, err := queue.Open(uri)
+	if err != nil {
+		return nil, err
+	}
+	return NewBatchProcessor(q, batchSize, interval, handler, opts...), nil
 }
 
 // Start starts batch processing
@@ -195,8 +246,17 @@ func (bp *BatchProcessor) processBatch() {
 		return
 	}
 
+	ctx := bp.ctx
+	if bp.tracer != nil && sampled(bp.traceRate) {
+		var span trace.Span
+		ctx, span = bp.tracer.Start(ctx, "worker.process_batch", trace.WithAttributes(
+			attribute.Int("batch.size", len(batch)),
+		))
+		defer span.End()
+	}
+
 	// Process batch
-	errs := bp.handler(bp.ctx, batch)
+	errs := bp.handler(ctx, batch)
 
 	// Update task states
 	for i, task := range batch {
@@ -229,17 +289,33 @@ type FanOutProcessor struct {
 	wg       sync.WaitGroup
 }
 
-// NewFanOutProcessor creates a fan-out processor
-func NewFanOutProcessor(q queue.Queue, handlers ...ProcessFunc) *FanOutProcessor {
+// NewFanOutProcessor creates a fan-out processor. Each handler is wrapped
+// with middlewares plus a default WithRecover, the same as Register, so a
+// panic in one handler can't take the others' goroutines down with it.
+func NewFanOutProcessor(q queue.Queue, middlewares []Middleware, handlers ...ProcessFunc) *FanOutProcessor {
 	ctx, cancel := context.WithCancel(context.Background())
+	wrapped := make([]ProcessFunc, len(handlers))
+	for i, h := range handlers {
+		wrapped[i] = Chain(h, append([]Middleware{WithRecover()}, middlewares...)...)
+	}
 	return &FanOutProcessor{
 		queue:    q,
-		handlers: handlers,
+		handlers: wrapped,
 		ctx:      ctx,
 		cancel:   cancel,
 	}
 }
 
+// NewFanOutProcessorFromURI opens uri via queue.Open and wraps the
+// result the same way NewFanOutProcessor wraps an already-open Queue.
+func NewFanOutProcessorFromURI(uri string, middlewares []Middleware, handlers ...ProcessFunc) (*FanOutProcessor, error) {
+	q, err := queue.Open(uri)
+	if err != nil {
+		return nil, err
+	}
+	return NewFanOutProcessor(q, middlewares, handlers...), nil
+}
+
 // Start starts fan-out processing
 func (fp *FanOutProcessor) Start() {
 	fp.wg.Add(1)
@@ -285,14 +361,16 @@ func (fp *FanOutProcessor) fanOut(task *queue.Task) {
 
 	wg.Wait()
 
-	// Aggregate errors
+	// Aggregate errors, treating a WithRetry middleware's
+	// ErrRetryScheduled as handled rather than a real failure.
 	var hasError bool
 	var errMsg string
 	for _, err := range errs {
-		if err != nil {
-			hasError = true
-			errMsg += err.Error() + "; "
+		if err == nil || errors.Is(err, ErrRetryScheduled) {
+			continue
 		}
+		hasError = true
+		errMsg += err.Error() + "; "
 	}
 
 	now := time.Now()
@@ -314,50 +392,243 @@ func (fp *FanOutProcessor) Stop() {
 	fp.wg.Wait()
 }
 
-// PipelineStage is a stage in a processing pipeline
+// PipelineStage is a stage in a processing pipeline. Workers controls how
+// many goroutines run this stage concurrently (fan-out over a single
+// inbound channel); BufferSize controls how many tasks that inbound
+// channel holds before a sender -- the previous stage, or the pipeline's
+// own dequeue loop for the first stage -- blocks. Both default to 1 when
+// left zero, reproducing the old single-goroutine, unbuffered-feeling
+// behavior, so AddStage callers that never set them see no change.
 type PipelineStage struct {
-	Name    string
-	Handler func(context.Context, *queue.Task) (*queue.Task, error)
+	Name       string
+	Handler    StageFunc
+	Workers    int
+	BufferSize int
 }
 
-// Pipeline processes tasks through sequential stages
+func (s PipelineStage) workers() int {
+	if s.Workers <= 0 {
+		return 1
+	}
+	return s.Workers
+}
+
+func (s PipelineStage) bufferSize() int {
+	if s.BufferSize <= 0 {
+		return 1
+	}
+	return s.BufferSize
+}
+
+// pipelineItem carries a task through the pipeline's stage channels. task
+// is the original, stable across every stage -- it's what failStage and
+// finishPipeline report the outcome against. current is the (possibly
+// replaced) task a stage handler returned, threaded into the next stage's
+// call. seq is assigned once per task by feed, in dequeue order, and is
+// what a stage's reorderer (see newReorderer) uses to hand tasks to the
+// next stage in the same order they arrived here, even when Workers > 1
+// finishes them out of order.
+type pipelineItem struct {
+	seq     uint64
+	task    *queue.Task
+	current *queue.Task
+}
+
+// reorderer buffers out-of-order sends from a stage's concurrent workers
+// and releases them to out strictly in seq order, so a downstream stage
+// (or finishPipeline) sees tasks in the same order they entered the
+// pipeline despite any amount of upstream concurrency. Used only when a
+// Pipeline has Ordered(true) set; unordered mode calls out directly from
+// whichever worker finishes first.
+type reorderer struct {
+	mu      sync.Mutex
+	next    uint64
+	pending map[uint64]pipelineItem
+	out     func(pipelineItem)
+}
+
+func newReorderer(out func(pipelineItem)) *reorderer {
+	return &reorderer{pending: make(map[uint64]pipelineItem), out: out}
+}
+
+func (r *reorderer) emit(item pipelineItem) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pending[item.seq] = item
+	for {
+		next, ok := r.pending[r.next]
+		if !ok {
+			break
+		}
+		delete(r.pending, r.next)
+		r.next++
+		r.out(next)
+	}
+}
+
+// stageRuntime holds the live counters behind Pipeline.Metrics for one
+// stage. in is kept alongside the counters so Metrics can read its
+// current length without threading the channel through StageMetrics
+// itself.
+type stageRuntime struct {
+	in        chan pipelineItem
+	inFlight  atomic.Int32
+	processed atomic.Int64
+}
+
+// StageMetrics reports one Pipeline stage's current load, returned by
+// Pipeline.Metrics.
+type StageMetrics struct {
+	Name       string
+	QueueDepth int   // tasks buffered in the stage's inbound channel
+	InFlight   int   // tasks a worker is actively running Handler on
+	Processed  int64 // tasks this stage has finished, success or failure, since Start
+}
+
+// Pipeline processes tasks through a sequence of stages. Each stage runs
+// its own pool of Workers goroutines reading from a BufferSize-deep
+// inbound channel, so a slow stage applies backpressure -- its channel
+// fills, stalling the stage before it rather than the whole pipeline --
+// instead of one slow stage blocking every task behind it one at a time.
 type Pipeline struct {
-	stages []PipelineStage
-	queue  queue.Queue
-	ctx    context.Context
-	cancel context.CancelFunc
-	wg     sync.WaitGroup
+	stages          []PipelineStage
+	queue           queue.Queue
+	deadLetterQueue queue.Queue
+	ordered         bool
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+	runtimes        []*stageRuntime
+}
+
+// WithDeadLetterQueue sets the queue a task is moved to when a stage
+// fails, preserving its payload and error history instead of just
+// leaving it StateFailed on the primary queue. Pipeline has no per-stage
+// retry (see StageMiddleware's doc comment), so unlike WithRetry's dlq
+// parameter this is the only failure outcome once a stage errors.
+// Chainable, matching RateLimiter.ForRoute and Metrics.ForRoute.
+func (p *Pipeline) WithDeadLetterQueue(q queue.Queue) *Pipeline {
+	p.deadLetterQueue = q
+	return p
 }
 
-// NewPipeline creates a processing pipeline
+// Ordered sets whether tasks must cross each stage boundary in the same
+// order they entered the pipeline. Off by default: with every stage's
+// Workers left at 1 this makes no observable difference (there's nothing
+// to reorder), but a concurrent stage gives faster-finishing tasks no
+// reason to wait for slower ones unless a caller opts in here, since that
+// waiting is itself a source of the head-of-line blocking this pipeline
+// redesign exists to avoid. Chainable, matching WithDeadLetterQueue.
+func (p *Pipeline) Ordered(ordered bool) *Pipeline {
+	p.ordered = ordered
+	return p
+}
+
+// NewPipeline creates a processing pipeline. Every stage is wrapped with
+// WithStageRecover, the same panic-safety AddStage applies to stages
+// added after construction, so a stage handler passed in directly here
+// gets it too. A stage's Workers/BufferSize pass through unchanged.
 func NewPipeline(q queue.Queue, stages ...PipelineStage) *Pipeline {
 	ctx, cancel := context.WithCancel(context.Background())
+	wrapped := make([]PipelineStage, len(stages))
+	for i, s := range stages {
+		wrapped[i] = PipelineStage{
+			Name:       s.Name,
+			Handler:    ChainStage(s.Handler, WithStageRecover()),
+			Workers:    s.Workers,
+			BufferSize: s.BufferSize,
+		}
+	}
 	return &Pipeline{
-		stages: stages,
+		stages: wrapped,
 		queue:  q,
 		ctx:    ctx,
 		cancel: cancel,
 	}
 }
 
-// AddStage adds a stage to the pipeline
-func (p *Pipeline) AddStage(name string, handler func(context.Context, *queue.Task) (*queue.Task, error)) {
+// NewPipelineFromURI opens uri via queue.Open and wraps the result the
+// same way NewPipeline wraps an already-open Queue.
+func NewPipelineFromURI(uri string, stages ...PipelineStage) (*Pipeline, error) {
+	q, err := queue.Open(uri)
+	if err != nil {
+		return nil, err
+	}
+	return NewPipeline(q, stages...), nil
+}
+
+// AddStage adds a single-worker stage to the pipeline, wrapped by
+// middlewares (see WithStageTimeout, WithStageLogging, WithStageMetrics)
+// plus a default WithStageRecover, matching Register's panic-safety
+// guarantee. Equivalent to AddConcurrentStage with Workers=1,
+// BufferSize=1 -- use AddConcurrentStage directly for a stage whose
+// handler is slow enough to want more of either.
+func (p *Pipeline) AddStage(name string, handler StageFunc, middlewares ...StageMiddleware) {
+	p.AddConcurrentStage(name, handler, 1, 1, middlewares...)
+}
+
+// AddConcurrentStage is AddStage with an explicit worker pool size and
+// inbound channel depth for this stage.
+func (p *Pipeline) AddConcurrentStage(name string, handler StageFunc, workers, bufferSize int, middlewares ...StageMiddleware) {
 	p.stages = append(p.stages, PipelineStage{
-		Name:    name,
-		Handler: handler,
+		Name:       name,
+		Handler:    ChainStage(handler, append([]StageMiddleware{WithStageRecover()}, middlewares...)...),
+		Workers:    workers,
+		BufferSize: bufferSize,
 	})
 }
 
-// Start starts the pipeline
+// Start starts the pipeline: one feed goroutine dequeuing into the first
+// stage, Workers goroutines per stage reading that stage's inbound
+// channel and sending into the next stage's (or into finishPipeline, for
+// the last stage), wired up front so every stage's channel and worker
+// pool exist before any task flows.
 func (p *Pipeline) Start() {
+	n := len(p.stages)
+	p.runtimes = make([]*stageRuntime, n)
+	for i, stage := range p.stages {
+		p.runtimes[i] = &stageRuntime{in: make(chan pipelineItem, stage.bufferSize())}
+	}
+
+	// Built back-to-front so each stage's sink already knows whether it
+	// feeds the next stage's channel or finishes the task.
+	sinks := make([]func(pipelineItem), n)
+	for i := n - 1; i >= 0; i-- {
+		var raw func(pipelineItem)
+		if i == n-1 {
+			raw = p.finishPipeline
+		} else {
+			next := p.runtimes[i+1].in
+			raw = func(item pipelineItem) { next <- item }
+		}
+		if p.ordered {
+			raw = newReorderer(raw).emit
+		}
+		sinks[i] = raw
+	}
+
+	for i, stage := range p.stages {
+		rt := p.runtimes[i]
+		sink := sinks[i]
+		for w := 0; w < stage.workers(); w++ {
+			p.wg.Add(1)
+			go p.runStage(stage, rt, sink)
+		}
+	}
+
 	p.wg.Add(1)
-	go p.loop()
+	go p.feed(p.runtimes[0].in)
 }
 
-// loop is the pipeline processing loop
-func (p *Pipeline) loop() {
+// feed dequeues tasks from the primary queue and hands them to the first
+// stage's inbound channel, one seq number per task. A full first-stage
+// channel blocks the send (and so blocks dequeuing further tasks), which
+// is the pipeline's backpressure all the way back to the queue.
+func (p *Pipeline) feed(in chan<- pipelineItem) {
 	defer p.wg.Done()
 
+	var seq uint64
 	for {
 		select {
 		case <-p.ctx.Done():
@@ -374,34 +645,94 @@ func (p *Pipeline) loop() {
 			continue
 		}
 
-		p.processPipeline(task)
+		select {
+		case in <- pipelineItem{seq: seq, task: task, current: task}:
+			seq++
+		case <-p.ctx.Done():
+			return
+		}
 	}
 }
 
-// processPipeline runs task through all stages
-func (p *Pipeline) processPipeline(task *queue.Task) {
-	current := task
+// runStage is one worker goroutine for a stage: pull an item off rt.in,
+// run stage.Handler, and either hand the result to sink or fail the task
+// out via failStage. Any number of these run concurrently per stage,
+// each pulling from the same channel, which is what turns stage.Workers
+// into fan-out.
+func (p *Pipeline) runStage(stage PipelineStage, rt *stageRuntime, sink func(pipelineItem)) {
+	defer p.wg.Done()
 
-	for _, stage := range p.stages {
-		result, err := stage.Handler(p.ctx, current)
-		if err != nil {
-			now := time.Now()
-			task.State = queue.StateFailed
-			task.Error = fmt.Sprintf("stage %s failed: %v", stage.Name, err)
-			task.CompletedAt = &now
-			p.queue.Update(p.ctx, task)
+	for {
+		select {
+		case <-p.ctx.Done():
 			return
+		case item := <-rt.in:
+			rt.inFlight.Add(1)
+			result, err := stage.Handler(p.ctx, item.current)
+			rt.inFlight.Add(-1)
+			rt.processed.Add(1)
+
+			if err != nil {
+				p.failStage(item.task, stage.Name, err)
+				continue
+			}
+
+			item.current = result
+			sink(item)
 		}
-		current = result
 	}
+}
+
+// failStage marks task permanently failed after stageName's handler
+// returned err, routing it to the dead-letter queue first if one is
+// configured -- the same outcome processPipeline used to apply inline
+// for every stage, now shared since runStage calls it from whichever
+// stage's workers hit the error.
+func (p *Pipeline) failStage(task *queue.Task, stageName string, err error) {
+	appendErrorHistory(task, task.Retries+1, err, stageName)
 
 	now := time.Now()
-	task.State = queue.StateCompleted
+	task.State = queue.StateFailed
+	task.Error = fmt.Sprintf("stage %s failed: %v", stageName, err)
 	task.CompletedAt = &now
-	task.Result = current.Payload
+
+	if p.deadLetterQueue != nil {
+		if dlqErr := p.deadLetterQueue.Enqueue(p.ctx, task); dlqErr != nil {
+			task.Error = fmt.Sprintf("stage %s failed: %v (dead-letter enqueue also failed: %v)", stageName, err, dlqErr)
+		}
+	}
+
 	p.queue.Update(p.ctx, task)
 }
 
+// finishPipeline marks a task that made it through every stage as
+// completed, storing the last stage's output as its result.
+func (p *Pipeline) finishPipeline(item pipelineItem) {
+	now := time.Now()
+	item.task.State = queue.StateCompleted
+	item.task.CompletedAt = &now
+	item.task.Result = item.current.Payload
+	p.queue.Update(p.ctx, item.task)
+}
+
+// Metrics reports each stage's current queue depth, in-flight count, and
+// cumulative processed count, in stage order. Processed is a running
+// total rather than a rate; a caller wanting throughput samples it
+// periodically and divides by the elapsed time itself.
+func (p *Pipeline) Metrics() []StageMetrics {
+	out := make([]StageMetrics, len(p.stages))
+	for i, stage := range p.stages {
+		rt := p.runtimes[i]
+		out[i] = StageMetrics{
+			Name:       stage.Name,
+			QueueDepth: len(rt.in),
+			InFlight:   int(rt.inFlight.Load()),
+			Processed:  rt.processed.Load(),
+		}
+	}
+	return out
+}
+
 // Stop stops the pipeline
 func (p *Pipeline) Stop() {
 	p.cancel()
@@ -0,0 +1,124 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/example/task-queue/internal/queue"
+)
+
+// tracePayloadKey is the queue.Task.Payload key trace context travels
+// under, matching internal/task's own carrier key so a trace started by
+// task.InjectTraceContext on the producer side continues into a worker
+// processor too, even though the two packages don't share the constant.
+const tracePayloadKey = "_otel"
+
+// extractTraceContext recovers the span context a producer stashed in
+// payload (see task.InjectTraceContext), returning ctx unchanged if none
+// is present.
+func extractTraceContext(ctx context.Context, payload map[string]interface{}) context.Context {
+	raw, ok := payload[tracePayloadKey]
+	if !ok {
+		return ctx
+	}
+
+	carrier := propagation.MapCarrier{}
+	switch m := raw.(type) {
+	case map[string]interface{}:
+		for k, v := range m {
+			if s, ok := v.(string); ok {
+				carrier[k] = s
+			}
+		}
+	case map[string]string:
+		for k, v := range m {
+			carrier[k] = v
+		}
+	default:
+		return ctx
+	}
+
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// sampled reports whether a span should be started, letting a
+// high-throughput processor down-sample tracing overhead the same way
+// it would down-sample at the SDK's own sampler. rate <= 0 never starts
+// a span; rate >= 1 always does.
+func sampled(rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	return rand.Float64() < rate
+}
+
+// WithTracer starts a span named "worker.process <task type>" around the
+// wrapped handler, continuing the producer's trace when task.Payload
+// carries one (see extractTraceContext). tp is usually
+// otel.GetTracerProvider() unless the caller wired up its own. rate
+// controls what fraction of tasks get a span at all; pass 1 to trace
+// every task.
+func WithTracer(tp trace.TracerProvider, rate float64) Middleware {
+	tracer := tp.Tracer("github.com/example/task-queue/internal/worker")
+	return func(next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, t *queue.Task) error {
+			if !sampled(rate) {
+				return next(ctx, t)
+			}
+
+			ctx = extractTraceContext(ctx, t.Payload)
+			ctx, span := tracer.Start(ctx, "worker.process "+t.Type, trace.WithAttributes(
+				attribute.String("task.type", t.Type),
+				attribute.String("task.id", t.ID),
+				attribute.Int("task.attempt", t.Retries),
+			))
+			defer span.End()
+
+			err := next(ctx, t)
+			if err != nil && !errors.Is(err, ErrRetryScheduled) {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+	}
+}
+
+// WithStageTracer is WithTracer for a Pipeline stage, naming the span
+// after the stage ("worker.stage <name>") rather than the processor so a
+// multi-stage pipeline's trace shows one child span per stage.
+func WithStageTracer(tp trace.TracerProvider, stageName string, rate float64) StageMiddleware {
+	tracer := tp.Tracer("github.com/example/task-queue/internal/worker")
+	return func(next StageFunc) StageFunc {
+		return func(ctx context.Context, t *queue.Task) (*queue.Task, error) {
+			if !sampled(rate) {
+				return next(ctx, t)
+			}
+
+			ctx = extractTraceContext(ctx, t.Payload)
+			ctx, span := tracer.Start(ctx, "worker.stage "+stageName, trace.WithAttributes(
+				attribute.String("task.type", t.Type),
+				attribute.String("task.id", t.ID),
+				attribute.String("stage.name", stageName),
+			))
+			defer span.End()
+
+			result, err := next(ctx, t)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return result, err
+		}
+	}
+}
@@ -2,6 +2,7 @@
 package client
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -9,6 +10,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -36,6 +38,16 @@ func WithAPIKey(key string) Option {
 	}
 }
 
+// WithBearerToken sets the Authorization header to "Bearer <token>", for
+// servers using api.Authn instead of the bare-value scheme WithAPIKey
+// sends. It shares apiKey's storage with WithAPIKey -- whichever option is
+// applied last wins.
+func WithBearerToken(token string) Option {
+	return func(c *Client) {
+		c.apiKey = "Bearer " + token
+	}
+}
+
 // WithHTTPClient sets a custom HTTP client
 func WithHTTPClient(hc *http.Client) Option {
 	return func(c *Client) {
@@ -67,6 +79,7 @@ type Task struct {
 	Priority    int                    `json:"priority"`
 	State       string                 `json:"state"`
 	CreatedAt   time.Time              `json:"created_at"`
+	Revision    uint64                 `json:"revision"`
 	StartedAt   *time.Time             `json:"started_at,omitempty"`
 	CompletedAt *time.Time             `json:"completed_at,omitempty"`
 	Retries     int                    `json:"retries"`
@@ -83,6 +96,12 @@ type EnqueueRequest struct {
 	Priority   int                    `json:"priority,omitempty"`
 	MaxRetries int                    `json:"max_retries,omitempty"`
 	Metadata   map[string]string      `json:"metadata,omitempty"`
+
+	// IdempotencyKey, if set, is sent as the Idempotency-Key header rather
+	// than in the body. A retried call with the same key replays the
+	// original response instead of enqueueing the task again -- see
+	// api.IdempotencyStore.
+	IdempotencyKey string `json:"-"`
 }
 
 // EnqueueResponse is the response from enqueueing a task
@@ -133,8 +152,10 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("API error (%d): %s", e.StatusCode, e.Message)
 }
 
-// do performs an HTTP request
-func (c *Client) do(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
+// do performs an HTTP request. extraHeaders is optional and set after the
+// default headers, so a caller (e.g. Enqueue's Idempotency-Key) can
+// override them if it ever needs to.
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, extraHeaders ...http.Header) ([]byte, error) {
 	var bodyReader io.Reader
 	if body != nil {
 		data, err := json.Marshal(body)
@@ -157,6 +178,14 @@ func (c *Client) do(ctx context.Context, method, path string, body interface{})
 		req.Header.Set("Authorization", c.apiKey)
 	}
 
+	for _, h := range extraHeaders {
+		for k, values := range h {
+			for _, v := range values {
+				req.Header.Set(k, v)
+			}
+		}
+	}
+
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
@@ -195,7 +224,7 @@ func parseResponse[T any](data []byte) (T, error) {
 
 // Enqueue enqueues a new task
 func (c *Client) Enqueue(ctx context.Context, req EnqueueRequest) (*EnqueueResponse, error) {
-	data, err := c.do(ctx, http.MethodPost, "/tasks", req)
+	data, err := c.do(ctx, http.MethodPost, "/tasks", req, idempotencyHeader(req.IdempotencyKey))
 	if err != nil {
 		return nil, err
 	}
@@ -299,9 +328,17 @@ func (c *Client) Delete(ctx context.Context, id string) error {
 	return err
 }
 
-// BulkEnqueue enqueues multiple tasks
+// BulkEnqueue enqueues multiple tasks. The Idempotency-Key header, if any,
+// is taken from the first request's IdempotencyKey -- it applies to the
+// chunk as a whole, the same way a producer resubmitting a whole chunk
+// after a network error would set it.
 func (c *Client) BulkEnqueue(ctx context.Context, reqs []EnqueueRequest) ([]EnqueueResponse, []string, error) {
-	data, err := c.do(ctx, http.MethodPost, "/tasks/bulk", reqs)
+	var key string
+	if len(reqs) > 0 {
+		key = reqs[0].IdempotencyKey
+	}
+
+	data, err := c.do(ctx, http.MethodPost, "/tasks/bulk", reqs, idempotencyHeader(key))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -353,25 +390,147 @@ func (c *Client) Health(ctx context.Context) (*HealthStatus, error) {
 	return &result, nil
 }
 
-// Wait waits for a task to complete
-func (c *Client) Wait(ctx context.Context, id string, pollInterval time.Duration) (*Task, error) {
-	ticker := time.NewTicker(pollInterval)
-	defer ticker.Stop()
+// Watch streams task id's state over a single long-lived connection to
+// GET /tasks/{id}/watch, replacing the polling loop Wait used to run
+// against GetTask. Each server-sent event re-fetches the full Task (the
+// stream itself only carries the lighter queue.TaskEvent shape) and sends
+// it on the returned channel. Both channels are closed once the task
+// reaches a terminal state, the stream ends, or ctx is done; the error
+// channel carries at most one value.
+func (c *Client) Watch(ctx context.Context, id string) (<-chan *Task, <-chan error) {
+	tasks := make(chan *Task)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(tasks)
+		defer close(errs)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/tasks/"+id+"/watch", nil)
+		if err != nil {
+			errs <- fmt.Errorf("failed to create request: %w", err)
+			return
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		if c.apiKey != "" {
+			req.Header.Set("Authorization", c.apiKey)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			errs <- fmt.Errorf("request failed: %w", err)
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			errs <- &Error{StatusCode: resp.StatusCode, Message: string(body)}
+			return
+		}
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" || strings.HasPrefix(line, ":") {
+				continue
+			}
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+
+			var ev struct {
+				State string `json:"state"`
+			}
+			if json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev) != nil {
+				continue
+			}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-ticker.C:
 			task, err := c.Get(ctx, id)
 			if err != nil {
-				return nil, err
+				errs <- err
+				return
+			}
+
+			select {
+			case tasks <- task:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
 			}
 
-			switch task.State {
+			switch ev.State {
 			case "completed", "failed", "cancelled":
-				return task, nil
+				return
 			}
 		}
+
+		if err := scanner.Err(); err != nil {
+			errs <- err
+		}
+	}()
+
+	return tasks, errs
+}
+
+// waitPollTimeout is how long a single GET .../wait request blocks
+// server-side before Wait issues another one. Wait itself keeps long
+// polling -- each call picking up from the last revision it saw -- until
+// the task reaches a terminal state or ctx is done.
+const waitPollTimeout = 30 * time.Second
+
+// Wait blocks until a task reaches a terminal state, long-polling the
+// server's GET /tasks/{id}/wait endpoint rather than opening an SSE
+// stream (Watch) just to observe it resolve.
+func (c *Client) Wait(ctx context.Context, id string) (*Task, error) {
+	task, err := c.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for !taskIsTerminal(task.State) {
+		task, err = c.wait(ctx, id, task.Revision, waitPollTimeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return task, nil
+}
+
+// idempotencyHeader returns an http.Header carrying key as Idempotency-Key,
+// or nil if key is empty -- do's extraHeaders ranges over it either way.
+func idempotencyHeader(key string) http.Header {
+	if key == "" {
+		return nil
+	}
+	return http.Header{"Idempotency-Key": []string{key}}
+}
+
+func taskIsTerminal(state string) bool {
+	switch state {
+	case "completed", "failed", "cancelled":
+		return true
+	default:
+		return false
 	}
 }
+
+// wait issues a single GET /tasks/{id}/wait call, blocking server-side
+// until the task's revision advances past since or timeout elapses.
+func (c *Client) wait(ctx context.Context, id string, since uint64, timeout time.Duration) (*Task, error) {
+	params := url.Values{}
+	params.Set("revision", fmt.Sprintf("%d", since))
+	params.Set("timeout", timeout.String())
+
+	data, err := c.do(ctx, http.MethodGet, "/tasks/"+id+"/wait?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := parseResponse[Task](data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
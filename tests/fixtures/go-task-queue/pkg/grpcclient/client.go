@@ -0,0 +1,384 @@
+// Package grpcclient provides a Go client library for the task queue's
+// gRPC API -- the sibling of pkg/client, which talks to the REST API. Use
+// this when a producer needs the streaming BulkEnqueue/Watch RPCs the REST
+// API can only approximate with chunked requests and SSE.
+package grpcclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/example/task-queue/internal/api/grpc/taskqueuepb"
+	"github.com/example/task-queue/internal/storage/taskpb"
+)
+
+// Client is a task queue gRPC API client.
+type Client struct {
+	conn   *grpc.ClientConn
+	client taskqueuepb.TaskQueueServiceClient
+}
+
+// Option configures dialing in New.
+type Option func(*dialConfig)
+
+type dialConfig struct {
+	dialOpts []grpc.DialOption
+}
+
+// WithDialOption appends a grpc.DialOption, e.g. to swap in TLS transport
+// credentials in place of the insecure default.
+func WithDialOption(opt grpc.DialOption) Option {
+	return func(c *dialConfig) {
+		c.dialOpts = append(c.dialOpts, opt)
+	}
+}
+
+// New dials target (host:port) and returns a Client. It dials with
+// insecure transport credentials by default, matching the plaintext
+// grpc.Server cmd/server starts on -grpc-port.
+func New(target string, opts ...Option) (*Client, error) {
+	cfg := &dialConfig{
+		dialOpts: []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	conn, err := grpc.NewClient(target, cfg.dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", target, err)
+	}
+
+	return &Client{conn: conn, client: taskqueuepb.NewTaskQueueServiceClient(conn)}, nil
+}
+
+// Close closes the underlying connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Task mirrors pkg/client.Task, decoded from the wire taskpb.Task.
+type Task struct {
+	ID          string
+	Type        string
+	Payload     map[string]interface{}
+	Priority    int32
+	State       string
+	CreatedAt   time.Time
+	Revision    uint64
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+	Retries     int
+	MaxRetries  int
+	Error       string
+	Metadata    map[string]string
+}
+
+// EnqueueRequest is the request to enqueue a task.
+type EnqueueRequest struct {
+	Type       string
+	Payload    map[string]interface{}
+	Priority   int32
+	MaxRetries int
+	Metadata   map[string]string
+}
+
+// ListOptions specifies options for listing tasks.
+type ListOptions struct {
+	State    string
+	Type     string
+	Priority int32
+	Limit    int
+	Offset   int
+}
+
+func (r EnqueueRequest) toProto() (*taskqueuepb.EnqueueRequest, error) {
+	payload, err := json.Marshal(r.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	return &taskqueuepb.EnqueueRequest{
+		Type:       r.Type,
+		Payload:    payload,
+		Priority:   taskpb.Priority(r.Priority),
+		MaxRetries: int32(r.MaxRetries),
+		Metadata:   r.Metadata,
+	}, nil
+}
+
+func taskFromProto(t *taskpb.Task) (*Task, error) {
+	var payload map[string]interface{}
+	if len(t.Payload) > 0 {
+		if err := json.Unmarshal(t.Payload, &payload); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+		}
+	}
+
+	task := &Task{
+		ID:         t.Id,
+		Type:       t.Type,
+		Payload:    payload,
+		Priority:   int32(t.Priority),
+		State:      t.State.String(),
+		CreatedAt:  t.CreatedAt.AsTime(),
+		Revision:   t.Revision,
+		Retries:    int(t.Retries),
+		MaxRetries: int(t.MaxRetries),
+		Error:      t.Error,
+		Metadata:   t.Metadata,
+	}
+
+	if t.StartedAt != nil {
+		ts := t.StartedAt.AsTime()
+		task.StartedAt = &ts
+	}
+	if t.CompletedAt != nil {
+		ts := t.CompletedAt.AsTime()
+		task.CompletedAt = &ts
+	}
+
+	return task, nil
+}
+
+// Enqueue enqueues a new task.
+func (c *Client) Enqueue(ctx context.Context, req EnqueueRequest) (string, time.Time, error) {
+	pbReq, err := req.toProto()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	resp, err := c.client.Enqueue(ctx, pbReq)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return resp.TaskId, resp.CreatedAt.AsTime(), nil
+}
+
+// BulkEnqueue streams reqs to the server over a single connection and
+// returns one result per request, in submitted order -- the streaming
+// counterpart of pkg/client.Client.BulkEnqueue's chunked HTTP calls.
+func (c *Client) BulkEnqueue(ctx context.Context, reqs []EnqueueRequest) ([]taskqueuepb.BulkItemResult, error) {
+	stream, err := c.client.BulkEnqueue(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, req := range reqs {
+		pbReq, err := req.toProto()
+		if err != nil {
+			return nil, err
+		}
+		if err := stream.Send(pbReq); err != nil {
+			// A Send error means the server already closed the stream;
+			// CloseAndRecv below returns the actual status.
+			break
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]taskqueuepb.BulkItemResult, len(resp.Results))
+	for i, r := range resp.Results {
+		results[i] = *r
+	}
+	return results, nil
+}
+
+// Get retrieves a task by ID.
+func (c *Client) Get(ctx context.Context, id string) (*Task, error) {
+	pbTask, err := c.client.Get(ctx, &taskqueuepb.GetRequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	return taskFromProto(pbTask)
+}
+
+// List lists tasks with optional filters.
+func (c *Client) List(ctx context.Context, opts ListOptions) ([]*Task, error) {
+	resp, err := c.client.List(ctx, &taskqueuepb.ListRequest{
+		State:    taskpb.State(taskpb.State_value["STATE_"+opts.State]),
+		Type:     opts.Type,
+		Priority: taskpb.Priority(opts.Priority),
+		Limit:    int32(opts.Limit),
+		Offset:   int32(opts.Offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*Task, len(resp.Tasks))
+	for i, t := range resp.Tasks {
+		task, err := taskFromProto(t)
+		if err != nil {
+			return nil, err
+		}
+		tasks[i] = task
+	}
+	return tasks, nil
+}
+
+// Cancel cancels a pending task.
+func (c *Client) Cancel(ctx context.Context, id string) (*Task, error) {
+	pbTask, err := c.client.Cancel(ctx, &taskqueuepb.TaskIDRequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	return taskFromProto(pbTask)
+}
+
+// Retry retries a failed task.
+func (c *Client) Retry(ctx context.Context, id string) (*Task, error) {
+	pbTask, err := c.client.Retry(ctx, &taskqueuepb.TaskIDRequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	return taskFromProto(pbTask)
+}
+
+// Delete deletes a task.
+func (c *Client) Delete(ctx context.Context, id string) error {
+	_, err := c.client.Delete(ctx, &taskqueuepb.TaskIDRequest{Id: id})
+	return err
+}
+
+// Stats returns queue statistics, JSON-decoded into v (a *worker.PoolMetrics
+// or *queue.Stats, matching whichever the server is actually backed by).
+func (c *Client) Stats(ctx context.Context, v interface{}) error {
+	resp, err := c.client.Stats(ctx, &taskqueuepb.StatsRequest{})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(resp.Stats, v)
+}
+
+// HealthStatus contains health check response.
+type HealthStatus struct {
+	Status    string
+	QueueSize int
+	Timestamp time.Time
+}
+
+// Health checks server health.
+func (c *Client) Health(ctx context.Context) (*HealthStatus, error) {
+	resp, err := c.client.Health(ctx, &taskqueuepb.HealthRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &HealthStatus{
+		Status:    resp.Status,
+		QueueSize: int(resp.QueueSize),
+		Timestamp: resp.Timestamp.AsTime(),
+	}, nil
+}
+
+// TaskEvent mirrors queue.TaskEvent, decoded from the wire taskqueuepb.TaskEvent.
+type TaskEvent struct {
+	TaskID    string
+	TaskType  string
+	State     string
+	Error     string
+	Revision  uint64
+	Timestamp time.Time
+}
+
+// Watch streams TaskEvents matching a single task id, closing the returned
+// channel when the task reaches a terminal state, the stream ends, or ctx
+// is done.
+func (c *Client) Watch(ctx context.Context, id string) (<-chan TaskEvent, <-chan error) {
+	events := make(chan TaskEvent)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(events)
+		defer close(errs)
+
+		stream, err := c.client.Watch(ctx, &taskqueuepb.WatchRequest{TaskId: id})
+		if err != nil {
+			errs <- err
+			return
+		}
+
+		for {
+			ev, err := stream.Recv()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			select {
+			case events <- TaskEvent{
+				TaskID:    ev.TaskId,
+				TaskType:  ev.TaskType,
+				State:     ev.State.String(),
+				Error:     ev.Error,
+				Revision:  ev.Revision,
+				Timestamp: ev.Timestamp.AsTime(),
+			}:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return events, errs
+}
+
+// waitPollTimeout is how long a single Wait RPC blocks server-side before
+// the client issues another one; Wait itself keeps polling -- each call
+// picking up from the last revision it saw -- until the task reaches a
+// terminal state or ctx is done.
+const waitPollTimeout = 30 * time.Second
+
+// Wait blocks until a task reaches a terminal state, calling the unary
+// Wait RPC in a loop rather than opening a Watch stream just to observe
+// it resolve.
+func (c *Client) Wait(ctx context.Context, id string) (*Task, error) {
+	task, err := c.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for !taskIsTerminal(task.State) {
+		pbTask, err := c.client.Wait(ctx, &taskqueuepb.WaitRequest{
+			Id:       id,
+			Revision: task.Revision,
+			Timeout:  durationpb.New(waitPollTimeout),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		task, err = taskFromProto(pbTask)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return task, nil
+}
+
+func taskIsTerminal(state string) bool {
+	switch state {
+	case "STATE_COMPLETED", "STATE_FAILED", "STATE_CANCELLED":
+		return true
+	default:
+		return false
+	}
+}